@@ -0,0 +1,100 @@
+package authority
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// AuditRecord defines the audit record of the signed mint
+type AuditRecord struct {
+	Time     time.Time `json:"time"`             // signing time
+	Symbol   string    `json:"symbol"`           // symbol
+	Owner    string    `json:"owner"`            // owner address
+	Metadata string    `json:"meta,omitempty"`   // metadata
+	AuthSig  string    `json:"authsig"`          // authority signature
+	PubKey   string    `json:"pubkey"`           // authority public key
+	ClientIP string    `json:"client,omitempty"` // client ip
+}
+
+// AuditLog appends the audit records to the JSON Lines file
+type AuditLog struct {
+	file *os.File // log file
+
+	mu sync.Mutex // lock
+}
+
+// OpenAuditLog opens the audit log at the given path for appending, creating it if not exists
+func OpenAuditLog(path string) (*AuditLog, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the audit log: %v", err)
+	}
+
+	return &AuditLog{file: file}, nil
+}
+
+// Append appends the given record and syncs it to the disk
+func (l *AuditLog) Append(record *AuditRecord) error {
+	bz, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, err := l.file.Write(append(bz, '\n')); err != nil {
+		return fmt.Errorf("failed to write the audit log: %v", err)
+	}
+
+	return l.file.Sync()
+}
+
+// Close closes the audit log
+func (l *AuditLog) Close() error {
+	return l.file.Close()
+}
+
+// LoadAuditRecords loads the audit records from the given path.
+// Returns no records if the audit log does not exist
+func LoadAuditRecords(path string) ([]*AuditRecord, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	records := make([]*AuditRecord, 0)
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, MAX_AUDIT_RECORD_SIZE)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("invalid audit record at line %d: %v", line, err)
+		}
+
+		records = append(records, &record)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the audit log: %v", err)
+	}
+
+	return records, nil
+}
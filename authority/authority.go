@@ -0,0 +1,90 @@
+package authority
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+
+	schnorrsig "btc-sbt/crypto/signature/schnorr"
+	"btc-sbt/protocol"
+)
+
+// Signer signs the mint operations on behalf of the issuing authority
+type Signer struct {
+	key *secp256k1.PrivateKey // authority private key
+
+	netParams *chaincfg.Params // net params
+}
+
+// NewSigner creates a new Signer instance
+func NewSigner(key *secp256k1.PrivateKey, netParams *chaincfg.Params) *Signer {
+	return &Signer{
+		key:       key,
+		netParams: netParams,
+	}
+}
+
+// PubKey returns the hex encoded x-only public key of the authority, i.e. the `auth pk` of the issue operation
+func (s *Signer) PubKey() string {
+	return hex.EncodeToString(schnorr.SerializePubKey(s.key.PubKey()))
+}
+
+// SignMint builds the mint operation from the given params and signs it.
+// The metadata is signed byte for byte as given, which must be the exact metadata of the mint
+func (s *Signer) SignMint(symbol string, owner string, metadata string) (*protocol.MintOperation, error) {
+	op := protocol.NewMintOperation(symbol, owner, "", metadata)
+
+	if err := op.Validate(s.netParams); err != nil {
+		return nil, err
+	}
+
+	sigHash, err := op.Hash()
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := schnorr.Sign(s.key, sigHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign the mint operation: %v", err)
+	}
+
+	op.AuthoritySignature = hex.EncodeToString(sig.Serialize())
+
+	if err := VerifyMint(op, s.PubKey()); err != nil {
+		return nil, err
+	}
+
+	return op, nil
+}
+
+// VerifyMint verifies the authority signature of the given mint operation against the authority public key,
+// in the same way as the state machine does
+func VerifyMint(op *protocol.MintOperation, pubKey string) error {
+	if len(op.AuthoritySignature) == 0 {
+		return fmt.Errorf("authority signature required")
+	}
+
+	sigBytes, err := hex.DecodeString(op.AuthoritySignature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %v", err)
+	}
+
+	pubKeyBytes, err := hex.DecodeString(pubKey)
+	if err != nil {
+		return fmt.Errorf("invalid public key: %v", err)
+	}
+
+	sigHash, err := op.Hash()
+	if err != nil {
+		return err
+	}
+
+	if !schnorrsig.VerifySignature(sigBytes, sigHash, pubKeyBytes) {
+		return fmt.Errorf("authority signature verification failed")
+	}
+
+	return nil
+}
@@ -0,0 +1,156 @@
+package authority
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/logger"
+	"btc-sbt/stacks/basics"
+	"btc-sbt/statemachine"
+	"btc-sbt/types"
+)
+
+func newTestSigner(t *testing.T) *Signer {
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate the key: %v", err)
+	}
+
+	return NewSigner(key, &chaincfg.RegressionNetParams)
+}
+
+func newTestOwner(t *testing.T) string {
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate the key: %v", err)
+	}
+
+	addr, err := basics.GetAddress(key, basics.Taproot, &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("failed to get the address: %v", err)
+	}
+
+	return addr.EncodeAddress()
+}
+
+func TestSignMint(t *testing.T) {
+	signer := newTestSigner(t)
+	owner := newTestOwner(t)
+
+	op, err := signer.SignMint("abc", owner, "")
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	if err := VerifyMint(op, signer.PubKey()); err != nil {
+		t.Fatalf("failed to verify: %v", err)
+	}
+
+	if err := VerifyMint(op, newTestSigner(t).PubKey()); err == nil {
+		t.Fatalf("another authority: got nil; want error")
+	}
+
+	tampered := *op
+	tampered.Owner = newTestOwner(t)
+
+	if err := VerifyMint(&tampered, signer.PubKey()); err == nil {
+		t.Fatalf("another owner: got nil; want error")
+	}
+
+	tampered = *op
+	tampered.AuthoritySignature = ""
+
+	if err := VerifyMint(&tampered, signer.PubKey()); err == nil {
+		t.Fatalf("no signature: got nil; want error")
+	}
+
+	if _, err := signer.SignMint("abc", "not an address", ""); err == nil {
+		t.Fatalf("invalid owner: got nil; want error")
+	}
+}
+
+func TestSignMintAcceptedByStateMachine(t *testing.T) {
+	sm, err := statemachine.Open(t.TempDir(), &chaincfg.RegressionNetParams, logger.Logger)
+	if err != nil {
+		t.Fatalf("failed to open the state machine: %v", err)
+	}
+
+	defer sm.Store.Close()
+
+	signer := newTestSigner(t)
+
+	if err := sm.SetSBTs(types.NewSBTs("abc", 1, 0, signer.PubKey(), 0, "", "issuer", 10, 0, "", 0)); err != nil {
+		t.Fatalf("failed to set the SBTs: %v", err)
+	}
+
+	op, err := signer.SignMint("abc", newTestOwner(t), "")
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	newContext := func() *statemachine.Context {
+		return statemachine.NewContext(11, chainhash.Hash{}, 0, wire.NewMsgTx(basics.TxVersion), "")
+	}
+
+	forged, err := newTestSigner(t).SignMint("abc", newTestOwner(t), "")
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	if err := sm.HandleMint(newContext(), forged); err == nil {
+		t.Fatalf("signed by another authority: got nil; want rejected")
+	}
+
+	if err := sm.HandleMint(newContext(), op); err != nil {
+		t.Fatalf("got %v; want the mint accepted", err)
+	}
+}
+
+func TestSignMintsFromCSV(t *testing.T) {
+	signer := newTestSigner(t)
+	owners := []string{newTestOwner(t), newTestOwner(t)}
+
+	for _, header := range []string{"", "owner,metadata\n"} {
+		in := header + owners[0] + "\n" + owners[1] + ",\n"
+
+		var out bytes.Buffer
+
+		count, err := signer.SignMintsFromCSV("abc", strings.NewReader(in), &out)
+		if err != nil || count != 2 {
+			t.Fatalf("header %q: got %d, %v; want 2 signed", header, count, err)
+		}
+
+		records, err := csv.NewReader(&out).ReadAll()
+		if err != nil {
+			t.Fatalf("failed to read the output: %v", err)
+		}
+
+		if len(records) != 3 || strings.Join(records[0], ",") != strings.Join(SIGNED_MINTS_CSV_HEADER, ",") {
+			t.Fatalf("header %q: got %v; want the header followed by 2 mints", header, records)
+		}
+
+		for idx, record := range records[1:] {
+			op, err := signer.SignMint("abc", record[0], record[1])
+			if err != nil || record[0] != owners[idx] {
+				t.Fatalf("line %d: got %v, %v; want owner %s", idx, record, err, owners[idx])
+			}
+
+			op.AuthoritySignature = record[2]
+
+			if err := VerifyMint(op, signer.PubKey()); err != nil {
+				t.Fatalf("line %d: failed to verify: %v", idx, err)
+			}
+		}
+	}
+
+	if _, err := signer.SignMintsFromCSV("abc", strings.NewReader(owners[0]+",,extra\n"), &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "line 1") {
+		t.Fatalf("too many fields: got %v; want the line error", err)
+	}
+}
@@ -0,0 +1,69 @@
+package authority
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CSV header of the signed mints
+var SIGNED_MINTS_CSV_HEADER = []string{"owner", "metadata", "authsig"}
+
+// SignMintsFromCSV signs the mints of the given symbol for the owners read from the CSV.
+// Each record consists of the owner and the optional metadata; the header is optional.
+// The signed mints are written to the output CSV in the form of `owner,metadata,authsig`.
+// Returns the number of the signed mints
+func (s *Signer) SignMintsFromCSV(symbol string, in io.Reader, out io.Writer) (int, error) {
+	reader := csv.NewReader(in)
+	reader.FieldsPerRecord = -1
+
+	writer := csv.NewWriter(out)
+
+	if err := writer.Write(SIGNED_MINTS_CSV_HEADER); err != nil {
+		return 0, err
+	}
+
+	count := 0
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return count, err
+		}
+
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), SIGNED_MINTS_CSV_HEADER[0]) {
+			continue
+		}
+
+		if len(record) > 2 {
+			return count, fmt.Errorf("line %d: expected owner and optional metadata, %d fields given", line, len(record))
+		}
+
+		owner := strings.TrimSpace(record[0])
+
+		metadata := ""
+		if len(record) == 2 {
+			metadata = record[1]
+		}
+
+		op, err := s.SignMint(symbol, owner, metadata)
+		if err != nil {
+			return count, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		if err := writer.Write([]string{op.Owner, op.Metadata, op.AuthoritySignature}); err != nil {
+			return count, err
+		}
+
+		count++
+	}
+
+	writer.Flush()
+
+	return count, writer.Error()
+}
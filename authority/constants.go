@@ -0,0 +1,6 @@
+package authority
+
+const (
+	// Maximum size of a single audit record in bytes
+	MAX_AUDIT_RECORD_SIZE = 1 << 20
+)
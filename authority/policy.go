@@ -0,0 +1,238 @@
+package authority
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SignRequest defines the request to sign the mint
+type SignRequest struct {
+	Symbol   string // symbol
+	Owner    string // owner address
+	Metadata string // metadata
+	ClientIP string // client ip
+}
+
+// Policy decides the eligibility of the sign requests
+type Policy interface {
+	// Name returns the policy name
+	Name() string
+
+	// Check returns an error if the request is not eligible
+	Check(req *SignRequest) error
+
+	// Record records the request which has been signed
+	Record(req *SignRequest)
+}
+
+// AllowlistPolicy only allows the owners in the allowlist.
+// The addresses are compared in lowercase as the state machine does
+type AllowlistPolicy struct {
+	owners map[string]bool // allowed owners in lowercase
+}
+
+// NewAllowlistPolicy creates an AllowlistPolicy instance from the given file,
+// which consists of one address per line; empty lines and lines starting with `#` are ignored
+func NewAllowlistPolicy(path string) (*AllowlistPolicy, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	owners := make(map[string]bool)
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		owners[strings.ToLower(line)] = true
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the allowlist: %v", err)
+	}
+
+	return &AllowlistPolicy{owners: owners}, nil
+}
+
+// Name implements Policy.Name
+func (p *AllowlistPolicy) Name() string {
+	return "allowlist"
+}
+
+// Check implements Policy.Check
+func (p *AllowlistPolicy) Check(req *SignRequest) error {
+	if !p.owners[strings.ToLower(req.Owner)] {
+		return fmt.Errorf("address not in the allowlist: %s", req.Owner)
+	}
+
+	return nil
+}
+
+// Record implements Policy.Record
+func (p *AllowlistPolicy) Record(req *SignRequest) {}
+
+// OnePerAddressPolicy allows only one signature per address for each symbol.
+// The addresses are compared in lowercase as the state machine does
+type OnePerAddressPolicy struct {
+	signed map[string]bool // signed symbol and owner pairs
+}
+
+// NewOnePerAddressPolicy creates a OnePerAddressPolicy instance seeded with the previously signed records
+func NewOnePerAddressPolicy(records []*AuditRecord) *OnePerAddressPolicy {
+	p := &OnePerAddressPolicy{signed: make(map[string]bool)}
+
+	for _, record := range records {
+		p.signed[signedKey(record.Symbol, record.Owner)] = true
+	}
+
+	return p
+}
+
+// Name implements Policy.Name
+func (p *OnePerAddressPolicy) Name() string {
+	return "one-per-address"
+}
+
+// Check implements Policy.Check
+func (p *OnePerAddressPolicy) Check(req *SignRequest) error {
+	if p.signed[signedKey(req.Symbol, req.Owner)] {
+		return fmt.Errorf("address has been signed for: %s", req.Owner)
+	}
+
+	return nil
+}
+
+// Record implements Policy.Record
+func (p *OnePerAddressPolicy) Record(req *SignRequest) {
+	p.signed[signedKey(req.Symbol, req.Owner)] = true
+}
+
+// signedKey returns the key of the signed symbol and owner pair
+func signedKey(symbol string, owner string) string {
+	return strings.ToLower(symbol) + "/" + strings.ToLower(owner)
+}
+
+// RateLimitPolicy limits the sign requests per client within the interval
+type RateLimitPolicy struct {
+	limit    int           // maximum requests per client within the interval
+	interval time.Duration // interval
+
+	requests  map[string][]time.Time // request times per client within the interval
+	lastEvict time.Time              // time when the clients without requests within the interval were last evicted
+
+	now func() time.Time // clock
+
+	mu sync.Mutex // lock
+}
+
+// NewRateLimitPolicy creates a RateLimitPolicy instance
+func NewRateLimitPolicy(limit int, interval time.Duration) *RateLimitPolicy {
+	return &RateLimitPolicy{
+		limit:    limit,
+		interval: interval,
+		requests: make(map[string][]time.Time),
+		now:      time.Now,
+	}
+}
+
+// Name implements Policy.Name
+func (p *RateLimitPolicy) Name() string {
+	return "rate-limit"
+}
+
+// Check implements Policy.Check
+func (p *RateLimitPolicy) Check(req *SignRequest) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+
+	p.evict(now)
+
+	requests := p.expire(p.requests[req.ClientIP], now)
+
+	if len(requests) >= p.limit {
+		p.requests[req.ClientIP] = requests
+		return fmt.Errorf("rate limit exceeded: %d requests per %s", p.limit, p.interval)
+	}
+
+	p.requests[req.ClientIP] = append(requests, now)
+
+	return nil
+}
+
+// Record implements Policy.Record
+func (p *RateLimitPolicy) Record(req *SignRequest) {}
+
+// expire drops the given request times out of the interval
+func (p *RateLimitPolicy) expire(requests []time.Time, now time.Time) []time.Time {
+	start := 0
+	for start < len(requests) && now.Sub(requests[start]) >= p.interval {
+		start++
+	}
+
+	return requests[start:]
+}
+
+// evict removes the clients without requests within the interval, at most once per interval
+func (p *RateLimitPolicy) evict(now time.Time) {
+	if now.Sub(p.lastEvict) < p.interval {
+		return
+	}
+
+	for client, requests := range p.requests {
+		if len(p.expire(requests, now)) == 0 {
+			delete(p.requests, client)
+		}
+	}
+
+	p.lastEvict = now
+}
+
+// ExpiryPolicy stops signing once the chain reaches the expiry height
+type ExpiryPolicy struct {
+	expiryHeight int64 // expiry height
+
+	getBlockHeight func() (int64, error) // function to get the latest block height
+}
+
+// NewExpiryPolicy creates an ExpiryPolicy instance
+func NewExpiryPolicy(expiryHeight int64, getBlockHeight func() (int64, error)) *ExpiryPolicy {
+	return &ExpiryPolicy{
+		expiryHeight:   expiryHeight,
+		getBlockHeight: getBlockHeight,
+	}
+}
+
+// Name implements Policy.Name
+func (p *ExpiryPolicy) Name() string {
+	return "expiry"
+}
+
+// Check implements Policy.Check.
+// The mint is included in the next block at the earliest, which must be before the expiry height
+func (p *ExpiryPolicy) Check(req *SignRequest) error {
+	height, err := p.getBlockHeight()
+	if err != nil {
+		return fmt.Errorf("failed to get the block height: %v", err)
+	}
+
+	if height+1 >= p.expiryHeight {
+		return fmt.Errorf("signing expired at block %d", p.expiryHeight)
+	}
+
+	return nil
+}
+
+// Record implements Policy.Record
+func (p *ExpiryPolicy) Record(req *SignRequest) {}
@@ -0,0 +1,109 @@
+package authority
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRateLimitPolicy(t *testing.T) {
+	p := NewRateLimitPolicy(2, time.Minute)
+
+	now := time.Unix(1700000000, 0)
+	p.now = func() time.Time { return now }
+
+	req := &SignRequest{ClientIP: "10.0.0.1"}
+
+	for i := 0; i < 2; i++ {
+		if err := p.Check(req); err != nil {
+			t.Fatalf("request %d: got %v; want allowed", i, err)
+		}
+	}
+
+	if err := p.Check(req); err == nil {
+		t.Fatalf("request 2: got allowed; want rate limited")
+	}
+
+	if err := p.Check(&SignRequest{ClientIP: "10.0.0.2"}); err != nil {
+		t.Fatalf("other client: got %v; want allowed", err)
+	}
+
+	now = now.Add(time.Minute)
+
+	if err := p.Check(req); err != nil {
+		t.Fatalf("after the interval: got %v; want allowed", err)
+	}
+}
+
+func TestRateLimitPolicyEvict(t *testing.T) {
+	p := NewRateLimitPolicy(1, time.Minute)
+
+	now := time.Unix(1700000000, 0)
+	p.now = func() time.Time { return now }
+
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2", "10.0.0.3"} {
+		if err := p.Check(&SignRequest{ClientIP: ip}); err != nil {
+			t.Fatalf("%s: got %v; want allowed", ip, err)
+		}
+	}
+
+	now = now.Add(2 * time.Minute)
+
+	if err := p.Check(&SignRequest{ClientIP: "10.0.0.4"}); err != nil {
+		t.Fatalf("got %v; want allowed", err)
+	}
+
+	if len(p.requests) != 1 {
+		t.Fatalf("got %d clients tracked; want 1", len(p.requests))
+	}
+}
+
+func TestAllowlistPolicyCaseInsensitive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "allowlist")
+
+	if err := os.WriteFile(path, []byte("# owners\nBC1QOWNER\n\nbc1qother\n"), 0600); err != nil {
+		t.Fatalf("failed to write the allowlist: %v", err)
+	}
+
+	p, err := NewAllowlistPolicy(path)
+	if err != nil {
+		t.Fatalf("failed to load the allowlist: %v", err)
+	}
+
+	tests := []struct {
+		owner   string
+		allowed bool
+	}{
+		{"bc1qowner", true},
+		{"BC1QOWNER", true},
+		{"BC1QOTHER", true},
+		{"bc1qunknown", false},
+	}
+
+	for _, test := range tests {
+		if err := p.Check(&SignRequest{Owner: test.owner}); (err == nil) != test.allowed {
+			t.Fatalf("%s: got %v; want allowed %v", test.owner, err, test.allowed)
+		}
+	}
+}
+
+func TestOnePerAddressPolicyCaseInsensitive(t *testing.T) {
+	p := NewOnePerAddressPolicy([]*AuditRecord{{Symbol: "ABC", Owner: "BC1QSIGNED"}})
+
+	if err := p.Check(&SignRequest{Symbol: "abc", Owner: "bc1qsigned"}); err == nil {
+		t.Fatalf("got allowed; want signed already")
+	}
+
+	req := &SignRequest{Symbol: "abc", Owner: "bc1qowner"}
+
+	if err := p.Check(req); err != nil {
+		t.Fatalf("got %v; want allowed", err)
+	}
+
+	p.Record(req)
+
+	if err := p.Check(&SignRequest{Symbol: "ABC", Owner: "BC1QOWNER"}); err == nil {
+		t.Fatalf("got allowed; want signed already")
+	}
+}
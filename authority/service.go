@@ -0,0 +1,149 @@
+package authority
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Service defines the HTTP service which signs the mints of the symbol on request
+type Service struct {
+	Signer   *Signer  // signer
+	Symbol   string   // symbol signed for
+	Policies []Policy // policies deciding the eligibility
+
+	TrustedProxies []string // proxies trusted to forward the client ip, none if empty
+
+	AuditLog *AuditLog // audit log
+
+	Router *gin.Engine
+
+	Logger *logrus.Logger
+
+	mu sync.Mutex // lock which serializes the policy checks and the signing
+}
+
+// SignMintRequest defines the body of the sign request
+type SignMintRequest struct {
+	Owner    string `json:"owner"`
+	Metadata string `json:"meta"`
+}
+
+// NewService creates a new Service instance
+func NewService(signer *Signer, symbol string, policies []Policy, trustedProxies []string, auditLog *AuditLog, logger *logrus.Logger) (*Service, error) {
+	srv := Service{
+		Signer:         signer,
+		Symbol:         symbol,
+		Policies:       policies,
+		TrustedProxies: trustedProxies,
+		AuditLog:       auditLog,
+		Logger:         logger,
+	}
+
+	if err := srv.createRouter(); err != nil {
+		return nil, err
+	}
+
+	return &srv, nil
+}
+
+// Start starts the service
+func (srv *Service) Start(listenerAddr string) error {
+	srv.Logger.Infof("starting the authority service for %s, public key: %s", srv.Symbol, srv.Signer.PubKey())
+
+	return srv.Router.Run(listenerAddr)
+}
+
+// createRouter creates the router.
+// The client ip is the remote address unless forwarded by the trusted proxies, so that the clients can not spoof it
+func (srv *Service) createRouter() error {
+	gin.SetMode(gin.ReleaseMode)
+
+	r := gin.Default()
+
+	if err := r.SetTrustedProxies(srv.TrustedProxies); err != nil {
+		return fmt.Errorf("invalid trusted proxies: %v", err)
+	}
+
+	r.GET("/api/authority", srv.GetInfo)
+	r.POST("/api/authority/sign", srv.SignMint)
+
+	srv.Router = r
+
+	return nil
+}
+
+// GetInfo responds the symbol, the authority public key and the enabled policies
+func (srv *Service) GetInfo(c *gin.Context) {
+	policies := make([]string, 0, len(srv.Policies))
+	for _, policy := range srv.Policies {
+		policies = append(policies, policy.Name())
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "result": gin.H{"symbol": srv.Symbol, "pubkey": srv.Signer.PubKey(), "policies": policies}})
+}
+
+// SignMint signs the mint for the requested owner and metadata if eligible
+func (srv *Service) SignMint(c *gin.Context) {
+	var body SignMintRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "error": fmt.Sprintf("invalid params: %v", err)})
+		return
+	}
+
+	req := &SignRequest{
+		Symbol:   srv.Symbol,
+		Owner:    strings.TrimSpace(body.Owner),
+		Metadata: body.Metadata,
+		ClientIP: c.ClientIP(),
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	for _, policy := range srv.Policies {
+		if err := policy.Check(req); err != nil {
+			srv.Logger.Infof("sign request rejected by %s policy, owner: %s, client: %s, err: %v", policy.Name(), req.Owner, req.ClientIP, err)
+
+			c.JSON(http.StatusForbidden, gin.H{"status": false, "error": err.Error()})
+			return
+		}
+	}
+
+	op, err := srv.Signer.SignMint(req.Symbol, req.Owner, req.Metadata)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "error": fmt.Sprintf("invalid params: %v", err)})
+		return
+	}
+
+	record := &AuditRecord{
+		Time:     time.Now().UTC(),
+		Symbol:   op.Symbol,
+		Owner:    op.Owner,
+		Metadata: op.Metadata,
+		AuthSig:  op.AuthoritySignature,
+		PubKey:   srv.Signer.PubKey(),
+		ClientIP: req.ClientIP,
+	}
+
+	// the signature is only released once audited
+	if err := srv.AuditLog.Append(record); err != nil {
+		srv.Logger.Errorf("%v", err)
+
+		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "error": "failed to audit the signature"})
+		return
+	}
+
+	for _, policy := range srv.Policies {
+		policy.Record(req)
+	}
+
+	srv.Logger.Infof("signed the mint of %s for %s, client: %s", op.Symbol, op.Owner, req.ClientIP)
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "result": op})
+}
@@ -0,0 +1,88 @@
+package authority
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+
+	"btc-sbt/logger"
+)
+
+func newTestService(t *testing.T, trustedProxies []string) (*Service, string) {
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate the key: %v", err)
+	}
+
+	owner, err := btcutil.NewAddressTaproot(txscript.ComputeTaprootKeyNoScript(key.PubKey()).SerializeCompressed()[1:], &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("failed to derive the address: %v", err)
+	}
+
+	auditLog, err := OpenAuditLog(filepath.Join(t.TempDir(), "audit.jsonl"))
+	if err != nil {
+		t.Fatalf("failed to open the audit log: %v", err)
+	}
+
+	t.Cleanup(func() { auditLog.Close() })
+
+	policies := []Policy{NewRateLimitPolicy(1, time.Hour)}
+
+	srv, err := NewService(NewSigner(key, &chaincfg.RegressionNetParams), "abc", policies, trustedProxies, auditLog, logger.Logger)
+	if err != nil {
+		t.Fatalf("failed to create the service: %v", err)
+	}
+
+	return srv, owner.EncodeAddress()
+}
+
+// sign posts the sign request from the given remote address with the given X-Forwarded-For header
+func sign(srv *Service, owner string, remoteAddr string, forwardedFor string) int {
+	req := httptest.NewRequest(http.MethodPost, "/api/authority/sign", strings.NewReader(`{"owner":"`+owner+`"}`))
+	req.RemoteAddr = remoteAddr
+
+	if len(forwardedFor) > 0 {
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+	}
+
+	w := httptest.NewRecorder()
+	srv.Router.ServeHTTP(w, req)
+
+	return w.Code
+}
+
+func TestServiceIgnoresForgedForwardedFor(t *testing.T) {
+	srv, owner := newTestService(t, nil)
+
+	if code := sign(srv, owner, "10.0.0.1:1000", "1.1.1.1"); code != http.StatusOK {
+		t.Fatalf("first request: got %d; want %d", code, http.StatusOK)
+	}
+
+	if code := sign(srv, owner, "10.0.0.1:1000", "2.2.2.2"); code != http.StatusForbidden {
+		t.Fatalf("forged X-Forwarded-For: got %d; want %d", code, http.StatusForbidden)
+	}
+}
+
+func TestServiceTrustedProxy(t *testing.T) {
+	srv, owner := newTestService(t, []string{"10.0.0.1"})
+
+	if code := sign(srv, owner, "10.0.0.1:1000", "1.1.1.1"); code != http.StatusOK {
+		t.Fatalf("first client: got %d; want %d", code, http.StatusOK)
+	}
+
+	if code := sign(srv, owner, "10.0.0.1:1000", "2.2.2.2"); code != http.StatusOK {
+		t.Fatalf("second client: got %d; want %d", code, http.StatusOK)
+	}
+
+	if code := sign(srv, owner, "10.0.0.1:1000", "1.1.1.1"); code != http.StatusForbidden {
+		t.Fatalf("first client again: got %d; want %d", code, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,131 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"btc-sbt/authority"
+	cfg "btc-sbt/config"
+	"btc-sbt/logger"
+	"btc-sbt/protocol"
+	"btc-sbt/stacks/client/rpcclient"
+)
+
+func GetAuthorityCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "authority",
+		Short: "Authority signing service",
+	}
+
+	cmd.AddCommand(getAuthorityServeCmd())
+
+	return cmd
+}
+
+func getAuthorityServeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "serve [config-file]",
+		Short:   "Start the HTTP service which signs the mints on request as the issuing authority",
+		Example: `btc-sbt authority serve`,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configFileName := ""
+
+			if len(args) == 0 {
+				configFileName = cfg.DefaultConfigFileName
+			} else {
+				configFileName = args[0]
+			}
+
+			v, err := cfg.LoadYAMLConfig(configFileName)
+			if err != nil {
+				return err
+			}
+
+			config, err := cfg.NewConfigFromViper(v)
+			if err != nil {
+				return err
+			}
+
+			authConfig := config.Authority
+
+			if err := protocol.ValidateSymbol(authConfig.Symbol); err != nil {
+				return fmt.Errorf("invalid authority symbol: %v", err)
+			}
+
+			logger.Logger.SetLevel(logrus.Level(config.LogLevel))
+
+			netParams := config.Network.NetParams
+
+			key, err := GetAuthorityKey(authConfig.KeyPath, authConfig.KeyName, authConfig.KeyAccount, netParams)
+			if err != nil {
+				return err
+			}
+
+			policies, err := getAuthorityPolicies(config)
+			if err != nil {
+				return err
+			}
+
+			auditLog, err := authority.OpenAuditLog(authConfig.AuditLogPath)
+			if err != nil {
+				return err
+			}
+
+			defer auditLog.Close()
+
+			signer := authority.NewSigner(key, netParams)
+
+			srv, err := authority.NewService(signer, authConfig.Symbol, policies, authConfig.TrustedProxies, auditLog, logger.Logger)
+			if err != nil {
+				return err
+			}
+
+			return srv.Start(authConfig.ListenerAddr)
+		},
+	}
+
+	return cmd
+}
+
+// getAuthorityPolicies creates the policies enabled in the config.
+// The rate limit is checked first so that every request counts towards it
+func getAuthorityPolicies(config *cfg.Config) ([]authority.Policy, error) {
+	authConfig := config.Authority
+	policies := make([]authority.Policy, 0)
+
+	if authConfig.RateLimit > 0 {
+		policies = append(policies, authority.NewRateLimitPolicy(authConfig.RateLimit, authConfig.RateInterval))
+	}
+
+	if authConfig.ExpiryHeight > 0 {
+		client, err := rpcclient.NewClient(config.NodeRPCUrl, config.NodeRPCUser, config.NodeRPCPass)
+		if err != nil {
+			return nil, err
+		}
+
+		policies = append(policies, authority.NewExpiryPolicy(authConfig.ExpiryHeight, client.GetLatestBlockHeight))
+	}
+
+	if len(authConfig.AllowlistPath) > 0 {
+		allowlist, err := authority.NewAllowlistPolicy(authConfig.AllowlistPath)
+		if err != nil {
+			return nil, err
+		}
+
+		policies = append(policies, allowlist)
+	}
+
+	if authConfig.OnePerAddress {
+		records, err := authority.LoadAuditRecords(authConfig.AuditLogPath)
+		if err != nil {
+			return nil, err
+		}
+
+		policies = append(policies, authority.NewOnePerAddressPolicy(records))
+	}
+
+	return policies, nil
+}
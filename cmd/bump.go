@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"btc-sbt/stacks/basics"
+)
+
+func GetBumpCmd() *cobra.Command {
+	var addrType uint8
+	var keyName string
+	var account uint32
+	var feeRate string
+
+	cmd := &cobra.Command{
+		Use:   "bump <commit txid> [flags] [config-file]",
+		Short: "Bump the fee of the stuck commit tx or reveal txs",
+		Long: "Bump the fee of the journaled commit tx and reveal txs to the fee rate, funded by the utxos of the key.\n" +
+			"The unconfirmed commit tx is replaced by RBF with the reveal txs signed against the new commit tx.\n" +
+			"Once the commit tx is confirmed, the unconfirmed reveal txs are bumped by the CPFP child spending the change if any, or replaced with the journaled tapscripts",
+		Example: `btc-sbt bump 5a3e... --fee-rate fastest`,
+		Args:    cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, initiator, err := loadInitiator(args[1:])
+			if err != nil {
+				return err
+			}
+
+			entry, err := initiator.Journal.Get(args[0])
+			if err != nil {
+				return err
+			}
+
+			rate, err := initiator.GetFeeRate(feeRate)
+			if err != nil {
+				return err
+			}
+
+			key, addr, err := GetPrivateKeyAndAddress(config.KeyStorePath, keyName, account, basics.AddressType(addrType), initiator.NetParams)
+			if err != nil {
+				return err
+			}
+
+			bumped, err := initiator.Bump(entry, key, addr, rate)
+			if err != nil {
+				return err
+			}
+
+			initiator.Logger.Infof("Commit tx %s bumped at %v sat/vB, commit tx in effect: %s", entry.CommitTxID, rate, bumped.CommitTxID)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().Uint8VarP(&addrType, "addr-type", "a", 0, ADDR_TYPE_USAGE)
+	cmd.Flags().StringVarP(&keyName, "key", "k", "", "name of the key in the key store; can be omitted if only one key stored")
+	cmd.Flags().Uint32Var(&account, "account", 0, "account index from which the key is derived if the key is HD")
+	cmd.Flags().StringVar(&feeRate, "fee-rate", "", "fee rate; auto, fastest, halfhour, economy or the fee rate in sat/vB; default to the configured one")
+
+	return cmd
+}
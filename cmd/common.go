@@ -1,31 +1,314 @@
 package cmd
 
 import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"time"
 
 	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
 	"github.com/decred/dcrd/dcrec/secp256k1/v4"
 
+	"btc-sbt/decoder"
+	"btc-sbt/initiator"
+	"btc-sbt/keystore"
 	"btc-sbt/stacks/basics"
+	"btc-sbt/stacks/taproot/inscriber"
 )
 
-// GetPrivateKeyAndAddress gets the private key from the given file path and generates the corresponding address by the given address type
-func GetPrivateKeyAndAddress(keyPath string, addrType basics.AddressType, netParam *chaincfg.Params) (*secp256k1.PrivateKey, btcutil.Address, error) {
-	keyWIFBytes, err := os.ReadFile(keyPath)
+// Suffix of the reveal file written along with the commit psbt
+const REVEAL_FILE_SUFFIX = ".reveal.json"
+
+// Usage of the --addr-type flag of the commands funding the txs
+const ADDR_TYPE_USAGE = "address type; 0: taproot, 1: p2wpkh, 3: p2pkh, 4: p2sh-p2wpkh; default to taproot"
+
+// Default of the --wait-timeout flag
+const DEFAULT_WAIT_TIMEOUT = 6 * time.Hour
+
+// GetPrivateKeyAndAddress gets the private key of the given name from the key store and generates the corresponding address by the given address type.
+// The account applies to the HD keys, from which the key is derived by the path for the address type
+func GetPrivateKeyAndAddress(keyStorePath string, name string, account uint32, addrType basics.AddressType, netParam *chaincfg.Params) (*secp256k1.PrivateKey, btcutil.Address, error) {
+	key, err := GetPrivateKey(keyStorePath, name, account, addrType, netParam)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	addr, err := basics.GetAddress(key, addrType, netParam)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	keyWIF, err := btcutil.DecodeWIF(string(keyWIFBytes))
+	return key, addr, nil
+}
+
+// GetPrivateKey gets the private key of the given name from the key store, prompting for the passphrase if required.
+// The name can be omitted if the key store holds only one key.
+// For the HD keys, the key is derived by the path for the account and the address type, i.e. BIP86 for taproot, BIP84 for p2wpkh, BIP49 for p2sh-p2wpkh and BIP44 for p2pkh.
+// The legacy key file in which the plain WIF is stored is supported without the name
+func GetPrivateKey(keyStorePath string, name string, account uint32, addrType basics.AddressType, netParams *chaincfg.Params) (*secp256k1.PrivateKey, error) {
+	return getKey(keyStorePath, name, account, netParams, func() (keystore.DerivationPath, error) {
+		return GetDerivationPath(addrType, account, netParams)
+	})
+}
+
+// GetAuthorityKey gets the authority key of the given name from the key store, prompting for the passphrase if required.
+// The HD keys are derived on the authority branch of the BIP86 account, apart from the funding keys.
+// The legacy WIF file and the non-HD keys are used as they are
+func GetAuthorityKey(keyStorePath string, name string, account uint32, netParams *chaincfg.Params) (*secp256k1.PrivateKey, error) {
+	return getKey(keyStorePath, name, account, netParams, func() (keystore.DerivationPath, error) {
+		return keystore.NewAuthorityDerivationPath(account, netParams), nil
+	})
+}
+
+// getKey gets the private key of the given name from the key store, deriving the HD keys by the path from getPath
+func getKey(keyStorePath string, name string, account uint32, netParams *chaincfg.Params, getPath func() (keystore.DerivationPath, error)) (*secp256k1.PrivateKey, error) {
+	contents, err := os.ReadFile(keyStorePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if !keystore.IsKeyStore(contents) {
+		if len(name) > 0 {
+			return nil, fmt.Errorf("%s is a legacy key file, import the key into a key store to use named keys", keyStorePath)
+		}
+
+		if account > 0 {
+			return nil, fmt.Errorf("account only applies to HD keys")
+		}
+
+		keyWIF, err := btcutil.DecodeWIF(strings.TrimSpace(string(contents)))
+		if err != nil {
+			return nil, err
+		}
+
+		return keyWIF.PrivKey, nil
+	}
+
+	ks, err := keystore.Open(keyStorePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(name) == 0 {
+		names := ks.Names()
+		if len(names) != 1 {
+			return nil, fmt.Errorf("key name required, available keys: %s", strings.Join(names, ", "))
+		}
+
+		name = names[0]
+	}
+
+	keyType, err := ks.Type(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !keyType.IsHD() && account > 0 {
+		return nil, fmt.Errorf("account only applies to HD keys")
+	}
+
+	passphrase, err := keystore.GetPassphrase(false)
+	if err != nil {
+		return nil, err
+	}
+
+	if !keyType.IsHD() {
+		return ks.Get(name, passphrase)
+	}
+
+	path, err := getPath()
+	if err != nil {
+		return nil, err
+	}
+
+	return ks.Derive(name, passphrase, path, netParams)
+}
+
+// GetDerivationPath gets the derivation path for the given address type and account
+func GetDerivationPath(addrType basics.AddressType, account uint32, netParams *chaincfg.Params) (keystore.DerivationPath, error) {
+	switch addrType {
+	case basics.Taproot:
+		return keystore.NewDerivationPath(keystore.PURPOSE_BIP86, account, netParams), nil
+
+	case basics.WitnessPubKeyHash:
+		return keystore.NewDerivationPath(keystore.PURPOSE_BIP84, account, netParams), nil
+
+	case basics.PubKeyHash:
+		return keystore.NewDerivationPath(keystore.PURPOSE_BIP44, account, netParams), nil
+
+	case basics.ScriptHash:
+		return keystore.NewDerivationPath(keystore.PURPOSE_BIP49, account, netParams), nil
+	}
+
+	return nil, fmt.Errorf("unsupported address type for HD keys: %s", addrType)
+}
+
+// GetPsbtAddress decodes the address of the external signer in PSBT mode.
+// The public key is required for taproot and nested segwit addresses
+func GetPsbtAddress(address string, pubKey string, netParams *chaincfg.Params) (btcutil.Address, error) {
+	if len(address) == 0 {
+		return nil, fmt.Errorf("address required in psbt mode")
+	}
+
+	addr, err := btcutil.DecodeAddress(address, netParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if !addr.IsForNet(netParams) {
+		return nil, fmt.Errorf("address %s is not for %s", address, netParams.Name)
+	}
+
+	if len(pubKey) == 0 && (basics.IsTaprootAddress(addr) || basics.IsP2SHAddress(addr)) {
+		return nil, fmt.Errorf("public key required for %s in psbt mode", address)
+	}
+
+	return addr, nil
+}
+
+// WritePsbt writes the base64 encoded psbt to the given file path and the reveal tx to `<path>.reveal.json`.
+// The reveal file contains the ephemeral key and is only readable by the owner
+func WritePsbt(path string, p *psbt.Packet, reveal *inscriber.Reveal) (string, error) {
+	psbtB64, err := p.B64Encode()
+	if err != nil {
+		return "", err
+	}
+
+	revealBz, err := json.MarshalIndent(reveal, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	revealPath := path + REVEAL_FILE_SUFFIX
+
+	if err := os.WriteFile(path, []byte(psbtB64), 0644); err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(revealPath, revealBz, 0600); err != nil {
+		return "", err
+	}
+
+	return revealPath, nil
+}
+
+// ReadPsbt reads the signed psbt in base64, hex or binary and the reveal tx from the given file paths
+func ReadPsbt(psbtPath string, revealPath string) (*psbt.Packet, *inscriber.Reveal, error) {
+	psbtBz, err := os.ReadFile(psbtPath)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	addr, err := basics.GetAddress(keyWIF.PrivKey, addrType, netParam)
+	p, err := decodePsbt(psbtBz)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid psbt: %v", err)
+	}
+
+	revealBz, err := os.ReadFile(revealPath)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	return keyWIF.PrivKey, addr, nil
+	reveal := new(inscriber.Reveal)
+	if err := json.Unmarshal(revealBz, reveal); err != nil {
+		return nil, nil, err
+	}
+
+	return p, reveal, nil
+}
+
+// decodePsbt decodes the psbt from the binary, hex or base64 format
+func decodePsbt(data []byte) (*psbt.Packet, error) {
+	if bytes.HasPrefix(data, decoder.PSBT_MAGIC) {
+		return psbt.NewFromRawBytes(bytes.NewReader(data), false)
+	}
+
+	str := strings.TrimSpace(string(data))
+
+	if bz, err := hex.DecodeString(str); err == nil {
+		return psbt.NewFromRawBytes(bytes.NewReader(bz), false)
+	}
+
+	return psbt.NewFromRawBytes(strings.NewReader(str), true)
+}
+
+// PrintFees prints the projected commit and reveal fees of the inscription at the fee rate
+func PrintFees(inscription *inscriber.Inscription, feeRate float64) {
+	commitFee := inscription.CommitFee()
+	revealFee := inscription.RevealFee()
+
+	fmt.Printf("Fee rate:   %v sat/vB\n", feeRate)
+	fmt.Printf("Commit fee: %d sats\n", commitFee)
+	fmt.Printf("Reveal fee: %d sats\n", revealFee)
+	fmt.Printf("Total fee:  %d sats\n", commitFee+revealFee)
+}
+
+// ConfirmFees prints the projected fees of the inscription and asks for the confirmation unless skipped
+func ConfirmFees(inscription *inscriber.Inscription, feeRate float64, skip bool) error {
+	PrintFees(inscription, feeRate)
+
+	if skip {
+		return nil
+	}
+
+	fmt.Print("Proceed? [y/N]: ")
+
+	var answer string
+	fmt.Scanln(&answer)
+
+	if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+		return fmt.Errorf("aborted")
+	}
+
+	return nil
+}
+
+// WaitForOutcome waits for the reveal tx to reach the confirmations and be indexed, and prints the indexed record.
+// Stops waiting after the given timeout if positive, or on interrupt.
+// Returns an error with the rejection reasons if any op is rejected by the indexer
+func WaitForOutcome(i *initiator.Initiator, revealTx *wire.MsgTx, confirmations int64, timeout time.Duration) error {
+	i.Logger.Infof("Waiting for reveal tx %s to reach %d confirmations and be indexed", revealTx.TxHash(), confirmations)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if timeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	record, err := i.WaitForOutcome(ctx, revealTx, confirmations)
+	if err != nil {
+		return err
+	}
+
+	bz, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(bz))
+
+	reasons := make([]string, 0)
+	for _, result := range record.Results {
+		if !result.Valid {
+			reasons = append(reasons, fmt.Sprintf("%s %s rejected: %s", result.Type, result.Symbol, result.Error))
+		}
+	}
+
+	if len(reasons) > 0 {
+		return fmt.Errorf("%s", strings.Join(reasons, "; "))
+	}
+
+	return nil
 }
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/btcsuite/btcd/wire"
+
+	cfg "btc-sbt/config"
+	"btc-sbt/decoder"
+	"btc-sbt/initiator"
+	"btc-sbt/logger"
+	"btc-sbt/stacks/client/rpcclient"
+)
+
+func GetDecodeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "decode <raw tx|psbt|txid> [config-file]",
+		Short:   "Decode the BTC-SBT protocol operations from the tx and simulate them against the current state",
+		Long:    "Decode the BTC-SBT protocol operations from the tx and simulate them against the current state.\nThe state is read through preflight.api, or the local db opened read-only, falling back to the api of the running node at server.listener_address. Nothing is persisted",
+		Example: `btc-sbt decode 70736274ff01007d0200000001...`,
+		Args:    cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configFileName := ""
+
+			if len(args) == 1 {
+				configFileName = cfg.DefaultConfigFileName
+			} else {
+				configFileName = args[1]
+			}
+
+			v, err := cfg.LoadYAMLConfig(configFileName)
+			if err != nil {
+				return err
+			}
+
+			config, err := cfg.NewConfigFromViper(v)
+			if err != nil {
+				return err
+			}
+
+			netParams := config.Network.NetParams
+
+			var tx *wire.MsgTx
+
+			if txHash, ok := decoder.ParseTxID(args[0]); ok {
+				client, err := rpcclient.NewClient(config.NodeRPCUrl, config.NodeRPCUser, config.NodeRPCPass)
+				if err != nil {
+					return err
+				}
+
+				tx, err = client.GetRawTransaction(txHash)
+				if err != nil {
+					return fmt.Errorf("failed to get the tx %s: %v", txHash, err)
+				}
+			} else {
+				tx, err = decoder.DecodeTxData(args[0])
+				if err != nil {
+					return err
+				}
+			}
+
+			reader, closer, err := initiator.NewStateReader(config, netParams, logger.Logger)
+			if err != nil {
+				return err
+			}
+
+			defer closer()
+
+			result, err := reader.Decode(tx)
+			if err != nil {
+				return err
+			}
+
+			bz, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(string(bz))
+
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	cfg "btc-sbt/config"
+	"btc-sbt/devnet"
+	"btc-sbt/logger"
+	"btc-sbt/network"
+)
+
+func GetDevnetCmd() *cobra.Command {
+	var (
+		listenerAddr  string
+		fundAddrs     []string
+		miningAddr    string
+		blockInterval time.Duration
+		feeRate       float64
+		txIndex       bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "devnet [config-file]",
+		Short: "Start the in-process regtest node serving the bitcoind rpc and the esplora api in memory",
+		Long: `Start the in-process regtest node serving the bitcoind rpc and the esplora api in memory.
+The node listens on node.rpc_url and authenticates by node.rpc_user and node.rpc_pass of the config, which must select regtest.
+The txs are mined on demand by generatetoaddress, or every block interval if given. The chain can be reorganized by invalidateblock,
+or by the devnet method reorg: depth, nblocks, address and optionally the txids dropped from the new blocks`,
+		Example: `btc-sbt devnet --fund bcrt1p... --block-interval 10s`,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configFileName := ""
+
+			if len(args) == 0 {
+				configFileName = cfg.DefaultConfigFileName
+			} else {
+				configFileName = args[0]
+			}
+
+			v, err := cfg.LoadYAMLConfig(configFileName)
+			if err != nil {
+				return err
+			}
+
+			config, err := cfg.NewConfigFromViper(v)
+			if err != nil {
+				return err
+			}
+
+			if config.Network.Name != network.REGTEST {
+				return fmt.Errorf("the devnet runs on %s, %s configured", network.REGTEST, config.Network.Name)
+			}
+
+			if len(listenerAddr) == 0 {
+				listenerAddr = config.NodeRPCUrl
+			}
+
+			logger.Logger.SetLevel(logrus.Level(config.LogLevel))
+
+			node := devnet.NewNode(config.NodeRPCUser, config.NodeRPCPass, feeRate, logger.Logger)
+			node.TxIndex = txIndex
+
+			if len(miningAddr) == 0 && len(fundAddrs) > 0 {
+				miningAddr = fundAddrs[0]
+			}
+
+			if err := fundDevnet(node, fundAddrs, miningAddr); err != nil {
+				return err
+			}
+
+			if blockInterval > 0 {
+				if len(miningAddr) == 0 {
+					return fmt.Errorf("mining address required by the block interval")
+				}
+
+				addr, err := decodeDevnetAddress(node, miningAddr)
+				if err != nil {
+					return err
+				}
+
+				go mineDevnet(node, addr, blockInterval)
+			}
+
+			return node.Start(listenerAddr)
+		},
+	}
+
+	cmd.Flags().StringVar(&listenerAddr, "listen", "", "listener address; default to node.rpc_url of the config")
+	cmd.Flags().StringSliceVar(&fundAddrs, "fund", nil, "addresses each funded by a coinbase output spendable on start")
+	cmd.Flags().StringVar(&miningAddr, "mining-address", "", "address to which the blocks mined by interval pay; default to the first funded address")
+	cmd.Flags().DurationVar(&blockInterval, "block-interval", 0, "interval at which a block is mined; 0 to mine on demand only")
+	cmd.Flags().Float64Var(&feeRate, "fee-rate", 1, "fee rate in sat/vB returned by the fee estimates")
+	cmd.Flags().BoolVar(&txIndex, "txindex", true, "find the confirmed txs by getrawtransaction as with txindex; --txindex=false to emulate the node without it")
+
+	return cmd
+}
+
+// fundDevnet mines a block paying to each of the given addresses, followed by the blocks paying to the mining address until the coinbase outputs mature
+func fundDevnet(node *devnet.Node, fundAddrs []string, miningAddr string) error {
+	if len(fundAddrs) == 0 {
+		return nil
+	}
+
+	for _, fundAddr := range fundAddrs {
+		addr, err := decodeDevnetAddress(node, fundAddr)
+		if err != nil {
+			return err
+		}
+
+		if _, err := node.Mine(1, addr); err != nil {
+			return err
+		}
+	}
+
+	addr, err := decodeDevnetAddress(node, miningAddr)
+	if err != nil {
+		return err
+	}
+
+	_, err = node.Mine(int(node.NetParams.CoinbaseMaturity), addr)
+
+	return err
+}
+
+// mineDevnet mines a block paying to the given address every interval
+func mineDevnet(node *devnet.Node, addr btcutil.Address, interval time.Duration) {
+	for range time.Tick(interval) {
+		if _, err := node.Mine(1, addr); err != nil {
+			node.Logger.Errorf("failed to mine the block: %v", err)
+		}
+	}
+}
+
+// decodeDevnetAddress decodes the given address of the devnet
+func decodeDevnetAddress(node *devnet.Node, address string) (btcutil.Address, error) {
+	addr, err := btcutil.DecodeAddress(address, node.NetParams)
+	if err != nil || !addr.IsForNet(node.NetParams) {
+		return nil, fmt.Errorf("invalid address: %s, %s address expected", address, network.REGTEST)
+	}
+
+	return addr, nil
+}
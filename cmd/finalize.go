@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	cfg "btc-sbt/config"
+	"btc-sbt/initiator"
+)
+
+func GetFinalizeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "finalize <signed psbt file> <reveal file> [config-file]",
+		Short:   "Finalize the signed commit psbt and broadcast the commit and reveal txs",
+		Example: `btc-sbt finalize commit.psbt commit.psbt.reveal.json`,
+		Args:    cobra.RangeArgs(2, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configFileName := ""
+
+			if len(args) == 2 {
+				configFileName = cfg.DefaultConfigFileName
+			} else {
+				configFileName = args[2]
+			}
+
+			v, err := cfg.LoadYAMLConfig(configFileName)
+			if err != nil {
+				return err
+			}
+
+			config, err := cfg.NewConfigFromViper(v)
+			if err != nil {
+				return err
+			}
+
+			p, reveal, err := ReadPsbt(args[0], args[1])
+			if err != nil {
+				return err
+			}
+
+			initiator, err := initiator.NewInitiator(config)
+			if err != nil {
+				return err
+			}
+
+			commitTxHash, revealTxHash, err := initiator.Finalize(p, reveal)
+			if err != nil {
+				return err
+			}
+
+			initiator.Logger.Infof("Finalizing completed, commit tx: %s, reveal tx: %s", commitTxHash, revealTxHash)
+
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -2,7 +2,9 @@ package cmd
 
 import (
 	"encoding/hex"
+	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -16,7 +18,18 @@ import (
 
 func GetIssueCmd() *cobra.Command {
 	var addrType uint8
+	var keyName string
+	var account uint32
+	var authAccount int
 	var selfPK bool
+	var psbtPath string
+	var address string
+	var pubKey string
+	var feeRate string
+	var yes bool
+	var force bool
+	var wait int64
+	var waitTimeout time.Duration
 
 	cmd := &cobra.Command{
 		Use:     "issue <symbol> <max supply> <auth pk> <end block> <metadata> [flags] [config-file]",
@@ -57,31 +70,115 @@ func GetIssueCmd() *cobra.Command {
 				return err
 			}
 
-			key, addr, err := GetPrivateKeyAndAddress(config.KeyStorePath, basics.AddressType(addrType), initiator.NetParams)
+			initiator.Force = force
+
+			if selfPK && authAccount >= 0 {
+				return fmt.Errorf("--self-pk and --auth-account are mutually exclusive")
+			}
+
+			authPK := args[2]
+			if authAccount >= 0 {
+				authKey, err := GetAuthorityKey(config.KeyStorePath, keyName, uint32(authAccount), initiator.NetParams)
+				if err != nil {
+					return err
+				}
+
+				authPK = hex.EncodeToString(schnorr.SerializePubKey(authKey.PubKey()))
+			}
+
+			rate, err := initiator.GetFeeRate(feeRate)
+			if err != nil {
+				return err
+			}
+
+			if len(psbtPath) > 0 {
+				addr, err := GetPsbtAddress(address, pubKey, initiator.NetParams)
+				if err != nil {
+					return err
+				}
+
+				if selfPK {
+					xOnlyPubKey, err := basics.GetXOnlyPubKey(pubKey)
+					if err != nil {
+						return err
+					}
+
+					authPK = hex.EncodeToString(xOnlyPubKey)
+				}
+
+				op := protocol.NewIssueOperation(args[0], uint64(maxSupply), authPK, endBlockHeight, args[4])
+
+				p, inscription, err := initiator.InitiatePsbt(addr, pubKey, op, rate)
+				if err != nil {
+					return err
+				}
+
+				PrintFees(inscription, rate)
+
+				revealPath, err := WritePsbt(psbtPath, p, inscription.Reveals[0])
+				if err != nil {
+					return err
+				}
+
+				initiator.Logger.Infof("Commit psbt written to %s, reveal tx written to %s; sign the psbt and run finalize", psbtPath, revealPath)
+
+				return nil
+			}
+
+			key, addr, err := GetPrivateKeyAndAddress(config.KeyStorePath, keyName, account, basics.AddressType(addrType), initiator.NetParams)
 			if err != nil {
 				return err
 			}
 
-			authPK := args[2]
 			if selfPK {
-				authPK = hex.EncodeToString(schnorr.SerializePubKey(key.PubKey()))
+				authKey, err := GetAuthorityKey(config.KeyStorePath, keyName, account, initiator.NetParams)
+				if err != nil {
+					return err
+				}
+
+				authPK = hex.EncodeToString(schnorr.SerializePubKey(authKey.PubKey()))
 			}
 
 			op := protocol.NewIssueOperation(args[0], uint64(maxSupply), authPK, endBlockHeight, args[4])
 
-			commitTxHash, revealTxHash, err := initiator.Initiate(key, addr, op)
+			inscription, err := initiator.Build(addr, op, rate)
+			if err != nil {
+				return err
+			}
+
+			if err := ConfirmFees(inscription, rate, yes); err != nil {
+				return err
+			}
+
+			commitTxHash, revealTxHash, err := initiator.Inscribe(key, addr, inscription)
 			if err != nil {
 				return err
 			}
 
 			initiator.Logger.Infof("Issuing SBT completed, commit tx: %s, reveal tx: %s", commitTxHash, revealTxHash)
 
+			if wait > 0 {
+				return WaitForOutcome(initiator, inscription.Reveals[0].Tx, wait, waitTimeout)
+			}
+
 			return nil
 		},
 	}
 
-	cmd.Flags().Uint8VarP(&addrType, "addr-type", "a", 0, "address type; 0: taproot, 1: p2wpkh; default to taproot")
-	cmd.Flags().BoolVarP(&selfPK, "self-pk", "p", false, "indicates if the current public key is used for verification")
+	cmd.Flags().Uint8VarP(&addrType, "addr-type", "a", 0, ADDR_TYPE_USAGE)
+	cmd.Flags().StringVarP(&keyName, "key", "k", "", "name of the key in the key store; can be omitted if only one key stored")
+	cmd.Flags().Uint32Var(&account, "account", 0, "account index from which the key is derived if the key is HD")
+	cmd.Flags().BoolVarP(&selfPK, "self-pk", "p", false, "indicates if the own public key is used for verification; for HD keys, the authority key of --account")
+	cmd.Flags().IntVar(&authAccount, "auth-account", -1, "account index whose authority key (m/86'/coin'/account'/2/0) is used in place of --self-pk; disabled if negative")
+	cmd.Flags().StringVar(&psbtPath, "psbt", "", "write the unsigned commit tx as a psbt to the given file instead of signing and broadcasting")
+	cmd.Flags().StringVar(&address, "address", "", "address funding the commit tx in psbt mode")
+	cmd.Flags().StringVar(&pubKey, "pubkey", "", "hex encoded public key of the address in psbt mode, required for taproot and nested segwit")
+	cmd.Flags().StringVar(&feeRate, "fee-rate", "", "fee rate; auto, fastest, halfhour, economy or the fee rate in sat/vB; default to the configured one")
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "skip the confirmation of the projected fees")
+	cmd.Flags().Int64Var(&wait, "wait", 0, "wait for the reveal tx to reach the confirmations given by --wait=<n> and print the indexed outcome; 1 if given without the value")
+	cmd.Flags().Lookup("wait").NoOptDefVal = "1"
+	cmd.Flags().DurationVar(&waitTimeout, "wait-timeout", DEFAULT_WAIT_TIMEOUT, "maximum time to wait for the outcome with --wait; 0 to wait indefinitely")
+	cmd.Flags().BoolVar(&force, "force", false, "proceed even if the preflight checks against the protocol state fail or are unavailable")
 
 	return cmd
 }
@@ -0,0 +1,432 @@
+package cmd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+
+	cfg "btc-sbt/config"
+	"btc-sbt/keystore"
+	"btc-sbt/stacks/basics"
+)
+
+func GetKeysCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "keys",
+		Short: "Manage the keys in the encrypted key store",
+		Long:  fmt.Sprintf("Manage the keys in the encrypted key store.\nThe passphrase is read from %s if set, otherwise prompted", keystore.PASSPHRASE_ENV),
+	}
+
+	cmd.AddCommand(getKeysNewCmd())
+	cmd.AddCommand(getKeysImportCmd())
+	cmd.AddCommand(getKeysListCmd())
+	cmd.AddCommand(getKeysExportCmd())
+	cmd.AddCommand(getKeysShowAddressCmd())
+
+	return cmd
+}
+
+func getKeysNewCmd() *cobra.Command {
+	var mnemonic bool
+
+	cmd := &cobra.Command{
+		Use:     "new <name> [flags] [config-file]",
+		Short:   "Generate a new key, or a BIP39 mnemonic from which the keys are derived",
+		Example: `btc-sbt keys new issuer --mnemonic`,
+		Args:    cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, ks, err := loadKeyStore(args[1:])
+			if err != nil {
+				return err
+			}
+
+			netParams := config.Network.NetParams
+
+			if mnemonic {
+				words, err := keystore.NewMnemonic()
+				if err != nil {
+					return err
+				}
+
+				if err := addKey(ks, args[0], func(passphrase []byte) error {
+					return ks.AddHD(args[0], keystore.KEY_TYPE_MNEMONIC, words, passphrase, netParams)
+				}); err != nil {
+					return err
+				}
+
+				fmt.Fprintf(os.Stderr, "Write down the mnemonic and keep it safe, it is the only backup of the keys:\n%s\n\n", words)
+
+				return showAddresses(ks, args[0], 0, netParams)
+			}
+
+			key, err := btcec.NewPrivateKey()
+			if err != nil {
+				return err
+			}
+
+			if err := addKey(ks, args[0], func(passphrase []byte) error {
+				return ks.Add(args[0], key, passphrase)
+			}); err != nil {
+				return err
+			}
+
+			return showAddresses(ks, args[0], 0, netParams)
+		},
+	}
+
+	cmd.Flags().BoolVar(&mnemonic, "mnemonic", false, "generate a 24-word BIP39 mnemonic instead of a single key")
+
+	return cmd
+}
+
+func getKeysImportCmd() *cobra.Command {
+	var keyType string
+	var secretPath string
+
+	cmd := &cobra.Command{
+		Use:     "import <name> [flags] [config-file]",
+		Short:   "Import the key in WIF, the BIP39 mnemonic or the extended private key",
+		Long:    "Import the key in WIF, the BIP39 mnemonic or the extended private key, which is prompted for unless read from the given file, e.g. the legacy key file",
+		Example: `btc-sbt keys import issuer --file keystore.txt`,
+		Args:    cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, ks, err := loadKeyStore(args[1:])
+			if err != nil {
+				return err
+			}
+
+			netParams := config.Network.NetParams
+
+			var secretBytes []byte
+
+			if len(secretPath) > 0 {
+				secretBytes, err = os.ReadFile(secretPath)
+			} else {
+				secretBytes, err = keystore.ReadSecret(fmt.Sprintf("Enter the %s: ", keyType))
+			}
+
+			if err != nil {
+				return err
+			}
+
+			secret := strings.Join(strings.Fields(string(secretBytes)), " ")
+
+			switch keystore.KeyType(keyType) {
+			case keystore.KEY_TYPE_MNEMONIC, keystore.KEY_TYPE_XPRV:
+				if err := keystore.ValidateHDSecret(keystore.KeyType(keyType), secret, netParams); err != nil {
+					return err
+				}
+
+				err = addKey(ks, args[0], func(passphrase []byte) error {
+					return ks.AddHD(args[0], keystore.KeyType(keyType), secret, passphrase, netParams)
+				})
+
+			case "wif":
+				keyWIF, decodeErr := btcutil.DecodeWIF(secret)
+				if decodeErr != nil {
+					return decodeErr
+				}
+
+				err = addKey(ks, args[0], func(passphrase []byte) error {
+					return ks.Add(args[0], keyWIF.PrivKey, passphrase)
+				})
+
+			default:
+				return fmt.Errorf("unsupported key type: %s", keyType)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			return showAddresses(ks, args[0], 0, netParams)
+		},
+	}
+
+	cmd.Flags().StringVarP(&keyType, "type", "t", "wif", "key type; wif, mnemonic or xprv")
+	cmd.Flags().StringVarP(&secretPath, "file", "f", "", "file from which the key is read")
+
+	return cmd
+}
+
+func getKeysListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list [config-file]",
+		Short:   "List the stored keys along with the taproot addresses of the single keys",
+		Example: `btc-sbt keys list`,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, ks, err := loadKeyStore(args)
+			if err != nil {
+				return err
+			}
+
+			netParams := config.Network.NetParams
+
+			for _, name := range ks.Names() {
+				keyType, err := ks.Type(name)
+				if err != nil {
+					return err
+				}
+
+				if keyType.IsHD() {
+					fmt.Printf("%s\t%s\n", name, keyType)
+					continue
+				}
+
+				pubKey, err := ks.PubKey(name)
+				if err != nil {
+					return err
+				}
+
+				addr, err := getTaprootAddress(pubKey, netParams)
+				if err != nil {
+					return err
+				}
+
+				fmt.Printf("%s\t%s\t%s\n", name, keyType, addr)
+			}
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func getKeysExportCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "export <name> [config-file]",
+		Short:   "Export the key in WIF, or the mnemonic or the extended private key for the HD keys",
+		Example: `btc-sbt keys export issuer`,
+		Args:    cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, ks, err := loadKeyStore(args[1:])
+			if err != nil {
+				return err
+			}
+
+			keyType, err := ks.Type(args[0])
+			if err != nil {
+				return err
+			}
+
+			passphrase, err := keystore.GetPassphrase(false)
+			if err != nil {
+				return err
+			}
+
+			if keyType.IsHD() {
+				secret, err := ks.GetHDSecret(args[0], passphrase)
+				if err != nil {
+					return err
+				}
+
+				fmt.Println(secret)
+
+				return nil
+			}
+
+			key, err := ks.Get(args[0], passphrase)
+			if err != nil {
+				return err
+			}
+
+			keyWIF, err := btcutil.NewWIF(key, config.Network.NetParams, true)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(keyWIF.String())
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func getKeysShowAddressCmd() *cobra.Command {
+	var account uint32
+
+	cmd := &cobra.Command{
+		Use:     "show-address <name> [flags] [config-file]",
+		Short:   "Show the taproot, p2wpkh, p2sh-p2wpkh and p2pkh addresses and the x-only public key of the key",
+		Long:    "Show the taproot, p2wpkh, p2sh-p2wpkh and p2pkh addresses, the x-only public key and the authority public key of the key.\nFor the HD keys, the addresses are derived by BIP86, BIP84, BIP49 and BIP44 for the account and the authority key on its own branch of the BIP86 account, which requires the passphrase",
+		Example: `btc-sbt keys show-address issuer --account 1`,
+		Args:    cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, ks, err := loadKeyStore(args[1:])
+			if err != nil {
+				return err
+			}
+
+			return showAddresses(ks, args[0], account, config.Network.NetParams)
+		},
+	}
+
+	cmd.Flags().Uint32Var(&account, "account", 0, "account index from which the key is derived if the key is HD")
+
+	return cmd
+}
+
+// loadKeyStore loads the config from the optional config file argument and opens the key store
+func loadKeyStore(args []string) (*cfg.Config, *keystore.KeyStore, error) {
+	configFileName := cfg.DefaultConfigFileName
+	if len(args) > 0 {
+		configFileName = args[0]
+	}
+
+	v, err := cfg.LoadYAMLConfig(configFileName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config, err := cfg.NewConfigFromViper(v)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ks, err := keystore.Open(config.KeyStorePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return config, ks, nil
+}
+
+// addKey gets the passphrase shared by the stored keys and adds the key by the given function
+func addKey(ks *keystore.KeyStore, name string, add func(passphrase []byte) error) error {
+	if ks.Has(name) {
+		return fmt.Errorf("key already exists: %s", name)
+	}
+
+	passphrase, err := keystore.GetPassphrase(len(ks.Names()) == 0)
+	if err != nil {
+		return err
+	}
+
+	if err := ks.VerifyPassphrase(passphrase); err != nil {
+		return err
+	}
+
+	return add(passphrase)
+}
+
+// showAddresses prints the addresses of the supported types and the x-only public key of the given key.
+// The HD keys are derived for the account
+func showAddresses(ks *keystore.KeyStore, name string, account uint32, netParams *chaincfg.Params) error {
+	keyType, err := ks.Type(name)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("name:    %s\n", name)
+
+	pubKeys := make([]*secp256k1.PublicKey, 0, len(showAddressTypes))
+
+	if !keyType.IsHD() {
+		if account > 0 {
+			return fmt.Errorf("account only applies to HD keys")
+		}
+
+		pubKey, err := ks.PubKey(name)
+		if err != nil {
+			return err
+		}
+
+		for range showAddressTypes {
+			pubKeys = append(pubKeys, pubKey)
+		}
+
+		return printAddresses(pubKeys, pubKey, netParams)
+	}
+
+	passphrase, err := keystore.GetPassphrase(false)
+	if err != nil {
+		return err
+	}
+
+	for _, addrType := range showAddressTypes {
+		path, err := GetDerivationPath(addrType, account, netParams)
+		if err != nil {
+			return err
+		}
+
+		key, err := ks.Derive(name, passphrase, path, netParams)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s path: %s\n", addrType, path)
+
+		pubKeys = append(pubKeys, key.PubKey())
+	}
+
+	authPath := keystore.NewAuthorityDerivationPath(account, netParams)
+
+	authKey, err := ks.Derive(name, passphrase, authPath, netParams)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("authority path: %s\n", authPath)
+
+	return printAddresses(pubKeys, authKey.PubKey(), netParams)
+}
+
+// showAddressTypes are the address types shown by show-address, in order
+var showAddressTypes = []basics.AddressType{basics.Taproot, basics.WitnessPubKeyHash, basics.ScriptHash, basics.PubKeyHash}
+
+// printAddresses prints the address of each type in showAddressTypes for the public key at the same index,
+// the x-only public key of the taproot key and the x-only authority public key
+func printAddresses(pubKeys []*secp256k1.PublicKey, authPubKey *secp256k1.PublicKey, netParams *chaincfg.Params) error {
+	taprootAddr, err := getTaprootAddress(pubKeys[0], netParams)
+	if err != nil {
+		return err
+	}
+
+	witnessAddr, err := btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pubKeys[1].SerializeCompressed()), netParams)
+	if err != nil {
+		return err
+	}
+
+	redeemScript, err := basics.GetRedeemScriptForNestedSegWit(hex.EncodeToString(pubKeys[2].SerializeCompressed()), netParams)
+	if err != nil {
+		return err
+	}
+
+	nestedAddr, err := btcutil.NewAddressScriptHash(redeemScript, netParams)
+	if err != nil {
+		return err
+	}
+
+	legacyAddr, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(pubKeys[3].SerializeCompressed()), netParams)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("p2tr:    %s\n", taprootAddr)
+	fmt.Printf("p2wpkh:  %s\n", witnessAddr)
+	fmt.Printf("p2sh:    %s\n", nestedAddr)
+	fmt.Printf("p2pkh:   %s\n", legacyAddr)
+	fmt.Printf("x-only:  %s\n", hex.EncodeToString(schnorr.SerializePubKey(pubKeys[0])))
+	fmt.Printf("auth-pk: %s\n", hex.EncodeToString(schnorr.SerializePubKey(authPubKey)))
+
+	return nil
+}
+
+// getTaprootAddress gets the taproot address from the public key
+func getTaprootAddress(pubKey *secp256k1.PublicKey, netParams *chaincfg.Params) (btcutil.Address, error) {
+	return btcutil.NewAddressTaproot(schnorr.SerializePubKey(txscript.ComputeTaprootKeyNoScript(pubKey)), netParams)
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	cfg "btc-sbt/config"
+	"btc-sbt/initiator"
+	"btc-sbt/stacks/basics"
+)
+
+const (
+	// Suffix of the batch progress file which is placed alongside the CSV file by default
+	BATCH_PROGRESS_FILE_SUFFIX = ".progress.json"
+)
+
+func GetMintBatchCmd() *cobra.Command {
+	var symbol string
+	var addrType uint8
+	var keyName string
+	var account uint32
+	var progressPath string
+	var feeRate string
+
+	cmd := &cobra.Command{
+		Use:   "mint-batch <file.csv> [flags] [config-file]",
+		Short: "Mint BTC SBT in batches from the CSV file",
+		Long: "Mint BTC SBT in batches from the CSV file of `owner,metadata,authsig` rows, e.g. produced by sign-mint --csv.\n" +
+			"Each reveal tx carries up to 10 mints and one commit tx funds many reveal txs.\n" +
+			"The progress is recorded to the progress file before broadcasting; rerun the same command to resume",
+		Example: `btc-sbt mint-batch signed.csv --symbol sbt`,
+		Args:    cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			configFileName := cfg.DefaultConfigFileName
+			if len(args) == 2 {
+				configFileName = args[1]
+			}
+
+			if len(progressPath) == 0 {
+				progressPath = args[0] + BATCH_PROGRESS_FILE_SUFFIX
+			}
+
+			v, err := cfg.LoadYAMLConfig(configFileName)
+			if err != nil {
+				return err
+			}
+
+			config, err := cfg.NewConfigFromViper(v)
+			if err != nil {
+				return err
+			}
+
+			initiator, err := initiator.NewInitiator(config)
+			if err != nil {
+				return err
+			}
+
+			contents, err := os.ReadFile(args[0])
+			if err != nil {
+				return err
+			}
+
+			mints, err := initiator.ReadMintsFromCSV(symbol, bytes.NewReader(contents))
+			if err != nil {
+				return err
+			}
+
+			progress, err := openBatchProgress(progressPath, symbol, contents, len(mints))
+			if err != nil {
+				return err
+			}
+
+			rate, err := initiator.GetFeeRate(feeRate)
+			if err != nil {
+				return err
+			}
+
+			key, addr, err := GetPrivateKeyAndAddress(config.KeyStorePath, keyName, account, basics.AddressType(addrType), initiator.NetParams)
+			if err != nil {
+				return err
+			}
+
+			initiator.Logger.Infof("Minting %d SBTs from %s at %v sat/vB, %d already batched, progress recorded to %s", len(mints), args[0], rate, progress.Next(), progressPath)
+
+			err = initiator.MintBatch(key, addr, mints, progress, rate)
+
+			printBatchReport(progress)
+
+			if err != nil {
+				return err
+			}
+
+			initiator.Logger.Infof("Batch minting completed")
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&symbol, "symbol", "s", "", "symbol of the SBT to be minted")
+	cmd.Flags().Uint8VarP(&addrType, "addr-type", "a", 0, ADDR_TYPE_USAGE)
+	cmd.Flags().StringVarP(&keyName, "key", "k", "", "name of the key in the key store; can be omitted if only one key stored")
+	cmd.Flags().Uint32Var(&account, "account", 0, "account index from which the key is derived if the key is HD")
+	cmd.Flags().StringVar(&feeRate, "fee-rate", "", "fee rate; auto, fastest, halfhour, economy or the fee rate in sat/vB; default to the configured one")
+	cmd.Flags().StringVar(&progressPath, "progress", "", "progress file; default to the CSV file path with the suffix "+BATCH_PROGRESS_FILE_SUFFIX)
+
+	cmd.MarkFlagRequired("symbol")
+
+	return cmd
+}
+
+// openBatchProgress opens the batch progress bound to the symbol and the CSV contents
+func openBatchProgress(path string, symbol string, contents []byte, total int) (*initiator.BatchProgress, error) {
+	checksum := sha256.Sum256(contents)
+
+	return initiator.OpenBatchProgress(path, symbol, hex.EncodeToString(checksum[:]), total)
+}
+
+// printBatchReport prints the commit and reveal txs along with the mints carried by each reveal tx
+func printBatchReport(progress *initiator.BatchProgress) {
+	fmt.Printf("%-8s\t%-10s\t%-64s\t%s\n", "mints", "status", "commit tx", "reveal tx")
+
+	for _, commit := range progress.Commits {
+		for _, reveal := range commit.Reveals {
+			fmt.Printf("%-8s\t%-10s\t%s\t%s\n", fmt.Sprintf("%d-%d", reveal.From+1, reveal.To), commit.Status, commit.TxID, reveal.TxID)
+		}
+	}
+
+	fmt.Printf("%d of %d mints batched\n", progress.Next(), progress.Total)
+}
@@ -0,0 +1,401 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/btcsuite/btcd/chaincfg"
+
+	cfg "btc-sbt/config"
+	"btc-sbt/logger"
+	"btc-sbt/protocol"
+	"btc-sbt/server/params"
+	"btc-sbt/stacks/client/base"
+	"btc-sbt/stacks/client/sbtnode"
+	"btc-sbt/statemachine"
+	"btc-sbt/types"
+)
+
+const (
+	// Output formats of the query commands
+	OUTPUT_JSON  = "json"
+	OUTPUT_TABLE = "table"
+)
+
+// queryBackend defines the state queried by the query commands,
+// which is either the local db or a remote node
+type queryBackend interface {
+	QuerySBTs(query *types.SBTsQuery) ([]*types.SBTs, string, error)
+	GetSBTsAt(symbol string, blockHeight int64) (*types.SBTs, error)
+	GetSBTAt(symbol string, id uint64, blockHeight int64) (*types.SBT, error)
+	QueryOwnedSBTs(query *types.OwnedSBTsQuery) ([]*types.CompactSBT, string, error)
+	GetLastBlockHeight() (int64, error)
+}
+
+// queryOptions defines the options shared by the query commands
+type queryOptions struct {
+	node   string
+	output string
+	height int64
+}
+
+// queryPage defines the json output of the paginated queries
+type queryPage struct {
+	Result any    `json:"result"`
+	Next   string `json:"next,omitempty"`
+}
+
+// queryStatus defines the json output of the status query
+type queryStatus struct {
+	LastBlockHeight int64 `json:"last_block_height"`
+}
+
+func GetQueryCmd() *cobra.Command {
+	opts := &queryOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "query",
+		Short: "Query the BTC-SBT protocol state from the local db or a remote node",
+		Long: "Query the BTC-SBT protocol state from the local db or a remote node.\n" +
+			"The local db is opened read-only, so the node must not be running; use --node to query a running node instead",
+	}
+
+	cmd.PersistentFlags().StringVar(&opts.node, "node", "", "api url of the btc-sbt node to query, the local db is queried if not given")
+	cmd.PersistentFlags().StringVarP(&opts.output, "output", "o", OUTPUT_JSON, "output format, json or table")
+	cmd.PersistentFlags().Int64Var(&opts.height, "height", 0, "block height at which the state is queried, default to the last indexed block")
+
+	cmd.AddCommand(getQueryCollectionsCmd(opts))
+	cmd.AddCommand(getQueryCollectionCmd(opts))
+	cmd.AddCommand(getQueryTokenCmd(opts))
+	cmd.AddCommand(getQueryOwnedCmd(opts))
+	cmd.AddCommand(getQueryStatusCmd(opts))
+
+	return cmd
+}
+
+func getQueryCollectionsCmd(opts *queryOptions) *cobra.Command {
+	p := &params.GetAllSBTsParams{}
+
+	cmd := &cobra.Command{
+		Use:     "collections [config-file]",
+		Short:   "List the SBTs collections page by page with sorting and filtering",
+		Example: `btc-sbt query collections --sort supply --order desc --mint open -o table`,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			backend, netParams, closer, err := openQueryBackend(args, opts)
+			if err != nil {
+				return err
+			}
+
+			defer closer()
+
+			p.Height = opts.height
+
+			// validated the same way as the api
+			if err := p.Validate(nil, netParams); err != nil {
+				return err
+			}
+
+			collections, next, err := backend.QuerySBTs(p.GetQuery())
+			if err != nil {
+				return err
+			}
+
+			if opts.output == OUTPUT_JSON {
+				return printJSON(&queryPage{Result: collections, Next: next})
+			}
+
+			w := newTableWriter()
+			fmt.Fprintln(w, "SYMBOL\tSEQ\tSUPPLY\tMAX SUPPLY\tEND BLOCK\tAUTH\tISSUER\tBLOCK HEIGHT")
+
+			for _, sbts := range collections {
+				fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t%t\t%s\t%d\n", sbts.Symbol, sbts.Sequence, sbts.TotalSupply, sbts.MaxSupply, sbts.EndBlockHeight, sbts.RequireSignatureOnMint(), sbts.Issuer, sbts.BlockHeight)
+			}
+
+			return flushTable(w, next)
+		},
+	}
+
+	addPaginationFlags(cmd, &p.PaginationParams)
+
+	cmd.Flags().StringVar(&p.Sort, "sort", "", "sort key: symbol, seq, height or supply, default to symbol")
+	cmd.Flags().StringVar(&p.Issuer, "issuer", "", "issuer address filter")
+	cmd.Flags().StringVar(&p.Auth, "auth", "", "authority signature requirement filter: true or false")
+	cmd.Flags().StringVar(&p.Mint, "mint", "", "mint status filter: open or closed")
+	cmd.Flags().StringVar(&p.Prefix, "prefix", "", "symbol prefix filter")
+
+	return cmd
+}
+
+func getQueryCollectionCmd(opts *queryOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "collection <symbol> [config-file]",
+		Short:   "Show the SBTs collection of the symbol",
+		Example: `btc-sbt query collection abc --height 830000`,
+		Args:    cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			symbol := args[0]
+			if err := protocol.ValidateSymbol(symbol); err != nil {
+				return err
+			}
+
+			backend, _, closer, err := openQueryBackend(args[1:], opts)
+			if err != nil {
+				return err
+			}
+
+			defer closer()
+
+			sbts, err := backend.GetSBTsAt(symbol, opts.height)
+			if err != nil {
+				return err
+			}
+
+			if sbts == nil {
+				return fmt.Errorf("SBTs does not exist: %s", symbol)
+			}
+
+			if opts.output == OUTPUT_JSON {
+				return printJSON(sbts)
+			}
+
+			w := newTableWriter()
+			fmt.Fprintf(w, "symbol\t%s\n", sbts.Symbol)
+			fmt.Fprintf(w, "seq\t%d\n", sbts.Sequence)
+			fmt.Fprintf(w, "supply\t%d\n", sbts.TotalSupply)
+			fmt.Fprintf(w, "max supply\t%d\n", sbts.MaxSupply)
+			fmt.Fprintf(w, "end block\t%d\n", sbts.EndBlockHeight)
+			fmt.Fprintf(w, "authority\t%s\n", sbts.AuthorityPubKey)
+			fmt.Fprintf(w, "metadata\t%s\n", sbts.Metadata)
+			fmt.Fprintf(w, "issuer\t%s\n", sbts.Issuer)
+			fmt.Fprintf(w, "block height\t%d\n", sbts.BlockHeight)
+			fmt.Fprintf(w, "issue tx\t%s\n", sbts.IssueTransactionHash)
+
+			return flushTable(w, "")
+		},
+	}
+
+	return cmd
+}
+
+func getQueryTokenCmd(opts *queryOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "token <symbol> <id> [config-file]",
+		Short:   "Show the SBT token of the symbol and token id",
+		Example: `btc-sbt query token abc 0`,
+		Args:    cobra.RangeArgs(2, 3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			symbol := args[0]
+			if err := protocol.ValidateSymbol(symbol); err != nil {
+				return err
+			}
+
+			id, err := strconv.ParseUint(args[1], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid token id: %s", args[1])
+			}
+
+			backend, _, closer, err := openQueryBackend(args[2:], opts)
+			if err != nil {
+				return err
+			}
+
+			defer closer()
+
+			sbt, err := backend.GetSBTAt(symbol, id, opts.height)
+			if err != nil {
+				return err
+			}
+
+			if sbt == nil {
+				return fmt.Errorf("SBT does not exist, symbol: %s, id: %d", symbol, id)
+			}
+
+			if opts.output == OUTPUT_JSON {
+				return printJSON(sbt)
+			}
+
+			w := newTableWriter()
+			fmt.Fprintf(w, "symbol\t%s\n", sbt.Symbol)
+			fmt.Fprintf(w, "token id\t%d\n", sbt.Id)
+			fmt.Fprintf(w, "owner\t%s\n", sbt.Owner)
+			fmt.Fprintf(w, "metadata\t%s\n", sbt.Metadata)
+			fmt.Fprintf(w, "block height\t%d\n", sbt.BlockHeight)
+			fmt.Fprintf(w, "mint tx\t%s\n", sbt.MintTransactionHash)
+
+			return flushTable(w, "")
+		},
+	}
+
+	return cmd
+}
+
+func getQueryOwnedCmd(opts *queryOptions) *cobra.Command {
+	p := &params.GetOwnedSBTsParams{}
+
+	cmd := &cobra.Command{
+		Use:     "owned <address> [config-file]",
+		Short:   "List the SBT tokens owned by the address page by page",
+		Example: `btc-sbt query owned tb1p... --prefix ab -o table`,
+		Args:    cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			backend, netParams, closer, err := openQueryBackend(args[1:], opts)
+			if err != nil {
+				return err
+			}
+
+			defer closer()
+
+			p.Address = args[0]
+			p.Height = opts.height
+
+			// validated the same way as the api
+			if err := p.Validate(nil, netParams); err != nil {
+				return err
+			}
+
+			sbts, next, err := backend.QueryOwnedSBTs(p.GetQuery())
+			if err != nil {
+				return err
+			}
+
+			if opts.output == OUTPUT_JSON {
+				return printJSON(&queryPage{Result: sbts, Next: next})
+			}
+
+			w := newTableWriter()
+			fmt.Fprintln(w, "SYMBOL\tTOKEN ID\tMINT TX")
+
+			for _, sbt := range sbts {
+				fmt.Fprintf(w, "%s\t%d\t%s\n", sbt.Symbol, sbt.Id, sbt.MintTransactionHash)
+			}
+
+			return flushTable(w, next)
+		},
+	}
+
+	addPaginationFlags(cmd, &p.PaginationParams)
+
+	cmd.Flags().StringVar(&p.Prefix, "prefix", "", "symbol prefix filter")
+
+	return cmd
+}
+
+func getQueryStatusCmd(opts *queryOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "status [config-file]",
+		Short:   "Show the last block indexed",
+		Example: `btc-sbt query status --node http://localhost:8080`,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.height != 0 {
+				return fmt.Errorf("--height is not supported by status")
+			}
+
+			backend, _, closer, err := openQueryBackend(args, opts)
+			if err != nil {
+				return err
+			}
+
+			defer closer()
+
+			blockHeight, err := backend.GetLastBlockHeight()
+			if err != nil {
+				return err
+			}
+
+			if opts.output == OUTPUT_JSON {
+				return printJSON(&queryStatus{LastBlockHeight: blockHeight})
+			}
+
+			w := newTableWriter()
+			fmt.Fprintf(w, "last block height\t%d\n", blockHeight)
+
+			return flushTable(w, "")
+		},
+	}
+
+	return cmd
+}
+
+// addPaginationFlags adds the flags of the given pagination params to the paginated query command
+func addPaginationFlags(cmd *cobra.Command, p *params.PaginationParams) {
+	cmd.Flags().StringVar(&p.Cursor, "cursor", "", "cursor of the page, returned as next along with the previous page")
+	cmd.Flags().IntVar(&p.Limit, "limit", types.DEFAULT_PAGE_LIMIT, fmt.Sprintf("maximum number of items per page, at most %d", types.MAX_PAGE_LIMIT))
+	cmd.Flags().StringVar(&p.Order, "order", params.ORDER_ASC, "order of the items, asc or desc")
+}
+
+// openQueryBackend loads the config from the optional config file argument and opens the queried state.
+// The returned function closes the state
+func openQueryBackend(args []string, opts *queryOptions) (queryBackend, *chaincfg.Params, func(), error) {
+	if opts.output != OUTPUT_JSON && opts.output != OUTPUT_TABLE {
+		return nil, nil, nil, fmt.Errorf("invalid output format, only %s or %s allowed: %s", OUTPUT_JSON, OUTPUT_TABLE, opts.output)
+	}
+
+	if err := params.ValidateHeight(opts.height); err != nil {
+		return nil, nil, nil, err
+	}
+
+	configFileName := cfg.DefaultConfigFileName
+	if len(args) > 0 {
+		configFileName = args[0]
+	}
+
+	v, err := cfg.LoadYAMLConfig(configFileName)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	config, err := cfg.NewConfigFromViper(v)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	netParams := config.Network.NetParams
+
+	if len(opts.node) > 0 {
+		return sbtnode.NewClient(opts.node, base.NewClient(config.Retries+1, config.Interval)), netParams, func() {}, nil
+	}
+
+	sm, err := statemachine.OpenReadOnly(config.DBPath, netParams, logger.Logger)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to open the db, make sure that the node is not running or query it by --node: %v", err)
+	}
+
+	return sm, netParams, func() { sm.Store.Close() }, nil
+}
+
+// printJSON prints the given value as indented json
+func printJSON(v any) error {
+	bz, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(bz))
+
+	return nil
+}
+
+// newTableWriter creates a writer aligning the tab separated columns
+func newTableWriter() *tabwriter.Writer {
+	return tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+}
+
+// flushTable flushes the table and prints the cursor for the next page if any
+func flushTable(w *tabwriter.Writer, next string) error {
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(next) > 0 {
+		fmt.Printf("\nnext: %s\n", next)
+	}
+
+	return nil
+}
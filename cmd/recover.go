@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/btcsuite/btcd/btcutil"
+
+	cfg "btc-sbt/config"
+	"btc-sbt/initiator"
+	"btc-sbt/stacks/basics"
+)
+
+func GetRecoverCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "recover",
+		Short: "Recover the stuck or failed reveal txs from the journal",
+		Long: "Recover the stuck or failed reveal txs from the journal, which is written before the commit and reveal txs are broadcast.\n" +
+			"The reveal txs can be rebroadcast, rebuilt with a higher fee, or the commit outputs swept back without executing the operations",
+	}
+
+	cmd.AddCommand(getRecoverListCmd())
+	cmd.AddCommand(getRecoverRebroadcastCmd())
+	cmd.AddCommand(getRecoverRebuildCmd())
+	cmd.AddCommand(getRecoverSweepCmd())
+
+	return cmd
+}
+
+func getRecoverListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list [config-file]",
+		Short:   "List the journal entries along with the status of the txs known by the node",
+		Example: `btc-sbt recover list`,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, initiator, err := loadInitiator(args)
+			if err != nil {
+				return err
+			}
+
+			entries, err := initiator.Journal.List()
+			if err != nil {
+				return err
+			}
+
+			for _, entry := range entries {
+				commitStatus, err := initiator.GetTxStatus(entry.CommitTxID, entry.CreatedAt)
+				if err != nil {
+					return err
+				}
+
+				fmt.Printf("commit %s\t%s\t%s\t%s\n", entry.CommitTxID, commitStatus, entry.Status, entry.CreatedAt.Format("2006-01-02 15:04:05"))
+
+				for _, reveal := range entry.Reveals {
+					revealStatus, err := initiator.GetTxStatus(reveal.TxID, entry.CreatedAt)
+					if err != nil {
+						return err
+					}
+
+					fmt.Printf("  reveal %s\t%s\t%d sats\n", reveal.TxID, revealStatus, reveal.CommitOutValue)
+				}
+
+				if len(entry.SweepTxID) > 0 {
+					fmt.Printf("  sweep %s\n", entry.SweepTxID)
+				}
+			}
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func getRecoverRebroadcastCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "rebroadcast <commit txid> [config-file]",
+		Short:   "Rebroadcast the journaled commit tx and reveal txs",
+		Example: `btc-sbt recover rebroadcast 5a3e...`,
+		Args:    cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, initiator, err := loadInitiator(args[1:])
+			if err != nil {
+				return err
+			}
+
+			entry, err := initiator.Journal.Get(args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := initiator.Rebroadcast(entry); err != nil {
+				return err
+			}
+
+			initiator.Logger.Infof("Commit tx %s and %d reveal txs rebroadcast", entry.CommitTxID, len(entry.Reveals))
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func getRecoverRebuildCmd() *cobra.Command {
+	var addrType uint8
+	var keyName string
+	var account uint32
+	var feeRate string
+
+	cmd := &cobra.Command{
+		Use:   "rebuild <commit txid> [flags] [config-file]",
+		Short: "Rebuild the unconfirmed reveal txs with a higher fee funded by the key",
+		Long: "Rebuild the unconfirmed reveal txs at the fee rate, with the utxos of the key added to pay the fee and the change returned to the key.\n" +
+			"The rebuilt reveal txs spend the same commit outputs and replace the previous ones",
+		Example: `btc-sbt recover rebuild 5a3e... --fee-rate 20`,
+		Args:    cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, initiator, err := loadInitiator(args[1:])
+			if err != nil {
+				return err
+			}
+
+			entry, err := initiator.Journal.Get(args[0])
+			if err != nil {
+				return err
+			}
+
+			rate, err := initiator.GetFeeRate(feeRate)
+			if err != nil {
+				return err
+			}
+
+			key, addr, err := GetPrivateKeyAndAddress(config.KeyStorePath, keyName, account, basics.AddressType(addrType), initiator.NetParams)
+			if err != nil {
+				return err
+			}
+
+			if err := initiator.RebuildReveals(entry, key, addr, rate); err != nil {
+				return err
+			}
+
+			initiator.Logger.Infof("Reveal txs of commit tx %s rebuilt at %v sat/vB", entry.CommitTxID, rate)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().Uint8VarP(&addrType, "addr-type", "a", 0, ADDR_TYPE_USAGE)
+	cmd.Flags().StringVarP(&keyName, "key", "k", "", "name of the key in the key store; can be omitted if only one key stored")
+	cmd.Flags().Uint32Var(&account, "account", 0, "account index from which the key is derived if the key is HD")
+	cmd.Flags().StringVar(&feeRate, "fee-rate", "", "fee rate; auto, fastest, halfhour, economy or the fee rate in sat/vB; default to the configured one")
+
+	return cmd
+}
+
+func getRecoverSweepCmd() *cobra.Command {
+	var to string
+	var feeRate string
+
+	cmd := &cobra.Command{
+		Use:   "sweep <commit txid> --to <address> [flags] [config-file]",
+		Short: "Sweep the unspent commit outputs back to the address through the key path",
+		Long: "Sweep the unspent commit outputs back to the address through the key path with the journaled ephemeral keys.\n" +
+			"The envelopes are neither revealed nor executed, so no protocol operations take effect",
+		Example: `btc-sbt recover sweep 5a3e... --to tb1p...`,
+		Args:    cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, initiator, err := loadInitiator(args[1:])
+			if err != nil {
+				return err
+			}
+
+			entry, err := initiator.Journal.Get(args[0])
+			if err != nil {
+				return err
+			}
+
+			toAddress, err := btcutil.DecodeAddress(to, initiator.NetParams)
+			if err != nil {
+				return err
+			}
+
+			rate, err := initiator.GetFeeRate(feeRate)
+			if err != nil {
+				return err
+			}
+
+			txHash, err := initiator.Sweep(entry, toAddress, rate)
+			if err != nil {
+				return err
+			}
+
+			initiator.Logger.Infof("Commit outputs of %s swept, tx: %s", entry.CommitTxID, txHash)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&to, "to", "", "address to which the commit outputs are swept")
+	cmd.Flags().StringVar(&feeRate, "fee-rate", "", "fee rate; auto, fastest, halfhour, economy or the fee rate in sat/vB; default to the configured one")
+
+	cmd.MarkFlagRequired("to")
+
+	return cmd
+}
+
+// loadInitiator loads the config from the optional config file argument and creates the initiator
+func loadInitiator(args []string) (*cfg.Config, *initiator.Initiator, error) {
+	configFileName := cfg.DefaultConfigFileName
+	if len(args) > 0 {
+		configFileName = args[0]
+	}
+
+	v, err := cfg.LoadYAMLConfig(configFileName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config, err := cfg.NewConfigFromViper(v)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	i, err := initiator.NewInitiator(config)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return config, i, nil
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"btc-sbt/authority"
+	cfg "btc-sbt/config"
+	"btc-sbt/logger"
+)
+
+func GetSignMintCmd() *cobra.Command {
+	var keyName string
+	var account uint32
+	var csvPath string
+	var outPath string
+
+	cmd := &cobra.Command{
+		Use:   "sign-mint <symbol> <owner> <metadata> [flags] [config-file]",
+		Short: "Sign the mint operation as the issuing authority",
+		Long: "Sign the mint operation as the issuing authority, producing the authsig required by the collections issued with an authority public key.\n" +
+			"In batch mode, i.e. --csv, only the symbol is given and the owners along with the optional metadata are read from the CSV",
+		Example: `btc-sbt sign-mint sbt tb1p... '{"attributes":[{"trait_type":"Level","value":"1"}]}'
+btc-sbt sign-mint sbt --csv owners.csv --out signed.csv`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(csvPath) > 0 {
+				return cobra.RangeArgs(1, 2)(cmd, args)
+			}
+
+			return cobra.RangeArgs(3, 4)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			argCount := 3
+			if len(csvPath) > 0 {
+				argCount = 1
+			}
+
+			configFileName := ""
+
+			if len(args) == argCount {
+				configFileName = cfg.DefaultConfigFileName
+			} else {
+				configFileName = args[argCount]
+			}
+
+			v, err := cfg.LoadYAMLConfig(configFileName)
+			if err != nil {
+				return err
+			}
+
+			config, err := cfg.NewConfigFromViper(v)
+			if err != nil {
+				return err
+			}
+
+			netParams := config.Network.NetParams
+
+			key, err := GetAuthorityKey(config.KeyStorePath, keyName, account, netParams)
+			if err != nil {
+				return err
+			}
+
+			signer := authority.NewSigner(key, netParams)
+
+			if len(csvPath) == 0 {
+				op, err := signer.SignMint(args[0], args[1], args[2])
+				if err != nil {
+					return err
+				}
+
+				fmt.Println(op.AuthoritySignature)
+
+				return nil
+			}
+
+			in, err := os.Open(csvPath)
+			if err != nil {
+				return err
+			}
+
+			defer in.Close()
+
+			var out io.Writer = os.Stdout
+
+			if len(outPath) > 0 {
+				file, err := os.Create(outPath)
+				if err != nil {
+					return err
+				}
+
+				defer file.Close()
+
+				out = file
+			}
+
+			count, err := signer.SignMintsFromCSV(args[0], in, out)
+			if err != nil {
+				return err
+			}
+
+			if len(outPath) > 0 {
+				logger.Logger.Infof("Signed %d mints with authority public key %s, written to %s", count, signer.PubKey(), outPath)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&keyName, "key", "k", "", "name of the authority key in the key store; can be omitted if only one key stored")
+	cmd.Flags().Uint32Var(&account, "account", 0, "account index whose authority key (m/86'/coin'/account'/2/0) signs if the key is HD")
+	cmd.Flags().StringVar(&csvPath, "csv", "", "CSV file of the owners and the optional metadata to sign in batch")
+	cmd.Flags().StringVarP(&outPath, "out", "o", "", "output CSV file of the signed mints in batch mode; default to stdout")
+
+	return cmd
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// AuthorityConfig defines the config for the authority signing service
+type AuthorityConfig struct {
+	ListenerAddr string // listener address for the authority service
+
+	KeyPath    string // key store of the authority key, default to the key store path
+	KeyName    string // name of the authority key in the key store
+	KeyAccount uint32 // account index whose authority key (m/86'/coin'/account'/2/0) signs if the key is HD
+
+	Symbol string // symbol of the SBTs signed for
+
+	AllowlistPath string // file of the allowed owner addresses, one per line; empty to disable
+
+	OnePerAddress bool // indicates if only one signature is issued per address
+
+	RateLimit    int           // maximum sign requests per client within the rate interval; 0 to disable
+	RateInterval time.Duration // rate interval
+
+	TrustedProxies []string // proxies trusted to forward the client ip, e.g. by X-Forwarded-For; none by default
+
+	ExpiryHeight int64 // block height at which the signing stops; 0 to disable
+
+	AuditLogPath string // audit log file
+}
+
+// NewAuthorityConfigFromViper creates a new AuthorityConfig instance from viper
+func NewAuthorityConfigFromViper(v *viper.Viper, keyStorePath string) *AuthorityConfig {
+	listenerAddr := v.GetString("authority.listener_address")
+	if len(listenerAddr) == 0 {
+		listenerAddr = DefaultAuthorityListenerAddr
+	}
+
+	keyPath := v.GetString("authority.key_path")
+	if len(keyPath) == 0 {
+		keyPath = keyStorePath
+	}
+
+	rateInterval := v.GetDuration("authority.rate_interval")
+	if rateInterval <= 0 {
+		rateInterval = DefaultAuthorityRateInterval
+	}
+
+	auditLogPath := v.GetString("authority.audit_log")
+	if len(auditLogPath) == 0 {
+		auditLogPath = DefaultAuthorityAuditLogPath
+	}
+
+	return &AuthorityConfig{
+		ListenerAddr:   listenerAddr,
+		KeyPath:        keyPath,
+		KeyName:        v.GetString("authority.key_name"),
+		KeyAccount:     v.GetUint32("authority.key_account"),
+		Symbol:         v.GetString("authority.symbol"),
+		AllowlistPath:  v.GetString("authority.allowlist"),
+		OnePerAddress:  v.GetBool("authority.one_per_address"),
+		RateLimit:      v.GetInt("authority.rate_limit"),
+		RateInterval:   rateInterval,
+		TrustedProxies: v.GetStringSlice("authority.trusted_proxies"),
+		ExpiryHeight:   v.GetInt64("authority.expiry_height"),
+		AuditLogPath:   auditLogPath,
+	}
+}
@@ -5,6 +5,14 @@ import (
 	"time"
 
 	"github.com/spf13/viper"
+
+	"btc-sbt/network"
+)
+
+const (
+	// Fee estimation sources
+	FEE_SOURCE_NODE    = "node"    // estimatesmartfee of the node
+	FEE_SOURCE_MEMPOOL = "mempool" // recommended fees of mempool.space
 )
 
 // Config defines the config
@@ -13,23 +21,34 @@ type Config struct {
 	NodeRPCUser string // node rpc username
 	NodeRPCPass string // node rpc passphrase
 
-	NetVersion uint8 // net version: 0(mainnet), 1(testnet), 2(signet)
+	Network *network.Network // network on which the protocol runs
 
 	UnisatAPI string // unisat api
 
+	UTXO *UTXOConfig // utxo provider config
+
 	IndexerInterval time.Duration // indexer interval
 
 	DBPath string // db path
 
 	KeyStorePath string // key store path
 
-	FeeRate int64 // fee rate
+	JournalPath string // directory of the commit and reveal journal
+
+	FeeRate    string  // fee rate: auto, fastest, halfhour, economy or the fee rate in sat/vB
+	FeeSource  string  // fee estimation source: node or mempool
+	MaxFeeRate float64 // cap of the fee rate in sat/vB
+	RBF        bool    // whether the commit and reveal txs signal replaceability
+
+	PreflightAPI string // btc-sbt node api against which the ops are checked before paying the fees; empty to open the local db read-only, falling back to the node at ListenerAddr
 
 	Retries  int           // retry count
 	Interval time.Duration // retry interval
 
 	ListenerAddr string // listener address for web server
 
+	Authority *AuthorityConfig // authority service config
+
 	LogLevel uint32 // logging level
 }
 
@@ -38,30 +57,44 @@ func NewConfig(
 	nodeRPCUrl,
 	nodeRPCUser,
 	nodeRPCPass string,
-	netVersion uint8,
+	network *network.Network,
 	unisatAPI string,
+	utxo *UTXOConfig,
 	indexerInterval time.Duration,
 	dbPath,
-	keyStorePath string,
-	feeRate int64,
+	keyStorePath,
+	journalPath,
+	feeRate,
+	feeSource string,
+	maxFeeRate float64,
+	rbf bool,
+	preflightAPI string,
 	retries int,
 	interval time.Duration,
 	listenerAddr string,
+	authority *AuthorityConfig,
 	logLevel uint32,
 ) *Config {
 	return &Config{
 		NodeRPCUrl:      nodeRPCUrl,
 		NodeRPCUser:     nodeRPCUser,
 		NodeRPCPass:     nodeRPCPass,
-		NetVersion:      netVersion,
+		Network:         network,
 		UnisatAPI:       unisatAPI,
+		UTXO:            utxo,
 		IndexerInterval: indexerInterval,
 		DBPath:          dbPath,
 		KeyStorePath:    keyStorePath,
+		JournalPath:     journalPath,
 		FeeRate:         feeRate,
+		FeeSource:       feeSource,
+		MaxFeeRate:      maxFeeRate,
+		RBF:             rbf,
+		PreflightAPI:    preflightAPI,
 		Retries:         retries,
 		Interval:        interval,
 		ListenerAddr:    listenerAddr,
+		Authority:       authority,
 		LogLevel:        logLevel,
 	}
 }
@@ -72,13 +105,22 @@ func NewConfigFromViper(v *viper.Viper) (*Config, error) {
 	nodeRPCUser := v.GetString("node.rpc_user")
 	nodeRPCPass := v.GetString("node.rpc_pass")
 
-	netVersion := uint8(v.GetUint("node.net_version"))
-	if netVersion > 2 {
-		return nil, fmt.Errorf("invalid net version: only 0, 1 or 2 allowed, %d given", netVersion)
+	network, err := NewNetworkFromViper(v)
+	if err != nil {
+		return nil, err
 	}
 
 	unisatAPI := v.GetString("unisat.api")
 
+	utxo, err := NewUTXOConfigFromViper(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(utxo.EsploraAPI) == 0 {
+		utxo.EsploraAPI = network.EsploraAPI
+	}
+
 	indexerInterval := v.GetDuration("indexer.interval")
 
 	dbPath := v.GetString("db.path")
@@ -91,7 +133,34 @@ func NewConfigFromViper(v *viper.Viper) (*Config, error) {
 		keyStorePath = DefaultKeyStorePath
 	}
 
-	feeRate := v.GetInt64("fee_rate")
+	journalPath := v.GetString("journal.path")
+	if len(journalPath) == 0 {
+		journalPath = DefaultJournalPath
+	}
+
+	feeRate := v.GetString("fee_rate")
+	if len(feeRate) == 0 {
+		feeRate = DefaultFeeRate
+	}
+
+	feeSource := v.GetString("fee_source")
+	if len(feeSource) == 0 {
+		feeSource = FEE_SOURCE_NODE
+	}
+
+	if feeSource != FEE_SOURCE_NODE && feeSource != FEE_SOURCE_MEMPOOL {
+		return nil, fmt.Errorf("invalid fee source: only %s or %s allowed, %s given", FEE_SOURCE_NODE, FEE_SOURCE_MEMPOOL, feeSource)
+	}
+
+	maxFeeRate := v.GetFloat64("max_fee_rate")
+	if maxFeeRate <= 0 {
+		maxFeeRate = DefaultMaxFeeRate
+	}
+
+	// replaceability is signaled unless disabled explicitly
+	rbf := !v.IsSet("rbf") || v.GetBool("rbf")
+
+	preflightAPI := v.GetString("preflight.api")
 
 	retries := v.GetInt("general.retries")
 	interval := v.GetDuration("general.interval")
@@ -101,21 +170,30 @@ func NewConfigFromViper(v *viper.Viper) (*Config, error) {
 		listenerAddr = DefaultListenerAddr
 	}
 
+	authority := NewAuthorityConfigFromViper(v, keyStorePath)
+
 	logLevel := v.GetUint32("log.level")
 
 	return NewConfig(
 		nodeRPCUrl,
 		nodeRPCUser,
 		nodeRPCPass,
-		netVersion,
+		network,
 		unisatAPI,
+		utxo,
 		indexerInterval,
 		dbPath,
 		keyStorePath,
+		journalPath,
 		feeRate,
+		feeSource,
+		maxFeeRate,
+		rbf,
+		preflightAPI,
 		retries,
 		interval,
 		listenerAddr,
+		authority,
 		logLevel,
 	), nil
 }
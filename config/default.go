@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"time"
 )
 
 var (
@@ -16,8 +17,32 @@ var (
 	// Key store defaults to the current directory
 	DefaultKeyStorePath = path.Join(".", "keystore.txt")
 
+	// Journal defaults to the current directory
+	DefaultJournalPath = path.Join(".", "journal")
+
+	// Fee rate defaults to the estimated fee rate
+	DefaultFeeRate = "auto"
+
+	// Cap of the fee rate in sat/vB
+	DefaultMaxFeeRate = float64(200)
+
+	// Coin selection defaults to largest first
+	DefaultCoinSelection = "largest-first"
+
+	// Long term fee rate in sat/vB for the waste metric of the coin selection
+	DefaultLongTermFeeRate = float64(10)
+
 	// Listener address default value
 	DefaultListenerAddr = "0.0.0.0:80"
+
+	// Listener address default value for the authority service
+	DefaultAuthorityListenerAddr = "127.0.0.1:8080"
+
+	// Rate interval default value for the authority service
+	DefaultAuthorityRateInterval = time.Minute
+
+	// Audit log of the authority service defaults to the current directory
+	DefaultAuthorityAuditLogPath = path.Join(".", "authority_audit.jsonl")
 )
 
 func init() {
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/spf13/viper"
+
+	"btc-sbt/network"
+)
+
+// CustomNetworkConfig defines the config for the custom signet network
+type CustomNetworkConfig struct {
+	Name             string   `mapstructure:"name"`              // network name
+	SignetChallenge  string   `mapstructure:"signet_challenge"`  // hex encoded block signing challenge
+	DNSSeeds         []string `mapstructure:"dns_seeds"`         // DNS seeds
+	ActivationHeight int64    `mapstructure:"activation_height"` // activation height of the protocol
+	EsploraAPI       string   `mapstructure:"esplora_api"`       // default esplora api; empty if none
+}
+
+// NewNetworkFromViper resolves the network selected by `node.network`, or the legacy `node.net_version`,
+// among the built-in networks and the custom ones defined by `networks`. Default to mainnet
+func NewNetworkFromViper(v *viper.Viper) (*network.Network, error) {
+	registry := network.NewRegistry()
+
+	var customs []CustomNetworkConfig
+	if err := v.UnmarshalKey("networks", &customs); err != nil {
+		return nil, fmt.Errorf("invalid custom networks: %v", err)
+	}
+
+	for _, custom := range customs {
+		challenge, err := hex.DecodeString(custom.SignetChallenge)
+		if err != nil || len(challenge) == 0 {
+			return nil, fmt.Errorf("invalid signet challenge of the network %s: %s", custom.Name, custom.SignetChallenge)
+		}
+
+		if custom.ActivationHeight < 0 {
+			return nil, fmt.Errorf("invalid activation height of the network %s: %d", custom.Name, custom.ActivationHeight)
+		}
+
+		if err := registry.Register(network.NewCustomSignet(custom.Name, challenge, custom.DNSSeeds, custom.ActivationHeight, custom.EsploraAPI)); err != nil {
+			return nil, err
+		}
+	}
+
+	name := v.GetString("node.network")
+
+	if len(v.GetString("node.net_version")) > 0 {
+		legacyName, err := network.GetNameByNetVersion(uint8(v.GetUint("node.net_version")))
+		if err != nil {
+			return nil, err
+		}
+
+		if len(name) > 0 {
+			if n, err := registry.Get(name); err != nil || n.Name != legacyName {
+				return nil, fmt.Errorf("conflicting node.network and node.net_version: %s, %s", name, legacyName)
+			}
+		}
+
+		name = legacyName
+	}
+
+	if len(name) == 0 {
+		name = network.MAINNET
+	}
+
+	net, err := registry.Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(v.GetString("indexer.activation_height")) > 0 {
+		return net.WithActivationHeight(v.GetInt64("indexer.activation_height"))
+	}
+
+	return net, nil
+}
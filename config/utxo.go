@@ -0,0 +1,65 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// UTXO providers
+	UTXO_PROVIDER_UNISAT   = "unisat"   // btc utxo api of unisat
+	UTXO_PROVIDER_ESPLORA  = "esplora"  // esplora api, e.g. mempool.space
+	UTXO_PROVIDER_BITCOIND = "bitcoind" // listunspent or scantxoutset of the node
+)
+
+// UTXOConfig defines the config for the utxos by which the txs are funded
+type UTXOConfig struct {
+	Provider string // utxo provider: unisat, esplora or bitcoind
+
+	EsploraAPI string // esplora api; default to the one of the network
+
+	Scan bool // indicates if scantxoutset is used instead of listunspent of the node wallet for bitcoind
+
+	OrdinalsAPI string // ord server api by which the utxos carrying inscriptions are excluded; empty to disable
+
+	CoinSelection      string   // coin selection strategy: largest-first, bnb, knapsack, smallest-first or least-waste
+	LongTermFeeRate    float64  // fee rate in sat/vB at which the utxos are expected to be spent in the long term, for the waste metric
+	ExcludeUnconfirmed bool     // indicates if the unconfirmed utxos are excluded
+	Locked             []string // outpoints never spent, in the form of txid:vout
+}
+
+// NewUTXOConfigFromViper creates a new UTXOConfig instance from viper
+func NewUTXOConfigFromViper(v *viper.Viper) (*UTXOConfig, error) {
+	provider := v.GetString("utxo.provider")
+	if len(provider) == 0 {
+		provider = UTXO_PROVIDER_UNISAT
+	}
+
+	switch provider {
+	case UTXO_PROVIDER_UNISAT, UTXO_PROVIDER_ESPLORA, UTXO_PROVIDER_BITCOIND:
+	default:
+		return nil, fmt.Errorf("invalid utxo provider: only %s, %s or %s allowed, %s given", UTXO_PROVIDER_UNISAT, UTXO_PROVIDER_ESPLORA, UTXO_PROVIDER_BITCOIND, provider)
+	}
+
+	coinSelection := v.GetString("utxo.coin_selection")
+	if len(coinSelection) == 0 {
+		coinSelection = DefaultCoinSelection
+	}
+
+	longTermFeeRate := v.GetFloat64("utxo.long_term_fee_rate")
+	if longTermFeeRate <= 0 {
+		longTermFeeRate = DefaultLongTermFeeRate
+	}
+
+	return &UTXOConfig{
+		Provider:           provider,
+		EsploraAPI:         v.GetString("utxo.esplora_api"),
+		Scan:               v.GetBool("utxo.scan"),
+		OrdinalsAPI:        v.GetString("utxo.ordinals_api"),
+		CoinSelection:      coinSelection,
+		LongTermFeeRate:    longTermFeeRate,
+		ExcludeUnconfirmed: v.GetBool("utxo.exclude_unconfirmed"),
+		Locked:             v.GetStringSlice("utxo.locked"),
+	}, nil
+}
@@ -0,0 +1,70 @@
+package decoder
+
+import (
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/protocol"
+	"btc-sbt/statemachine"
+	"btc-sbt/types"
+)
+
+// Decoder decodes the BTC-SBT protocol operations from txs and simulates them against the current state
+type Decoder struct {
+	Parser       *protocol.Parser           // protocol parser
+	StateMachine *statemachine.StateMachine // state machine
+}
+
+// NewDecoder creates a new Decoder instance
+func NewDecoder(parser *protocol.Parser, sm *statemachine.StateMachine) *Decoder {
+	return &Decoder{
+		Parser:       parser,
+		StateMachine: sm,
+	}
+}
+
+// Decode decodes the protocol envelopes and operations from the given tx,
+// and simulates the operations as if the tx was included in the next block.
+// Nothing is persisted
+func (d *Decoder) Decode(tx *wire.MsgTx) (*types.DecodeResult, error) {
+	ops, inputs := d.Parser.ParseTx(tx)
+
+	lastBlockHeight, err := d.StateMachine.GetLastBlockHeight()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &types.DecodeResult{
+		TxHash:      tx.TxHash().String(),
+		Envelopes:   make([]*types.DecodedEnvelope, 0),
+		BlockHeight: lastBlockHeight + 1,
+		Results:     make([]*types.OpResult, 0),
+	}
+
+	for _, in := range inputs {
+		result.Envelopes = append(result.Envelopes, &types.DecodedEnvelope{
+			InputIndex:   in.Index,
+			CommitTxHash: in.CommitTxHash.String(),
+			Payload:      string(in.Envelope.Payload),
+			OpCount:      len(in.Ops),
+		})
+	}
+
+	if len(ops) == 0 {
+		return result, nil
+	}
+
+	if ops.ContainIssue() {
+		result.Issuer = d.Parser.ParseIssuerAddress(tx)
+	}
+
+	ctx := statemachine.NewContext(result.BlockHeight, chainhash.Hash{}, 0, tx, result.Issuer)
+
+	if err := d.StateMachine.Simulate(ctx, ops); err != nil {
+		return nil, err
+	}
+
+	result.Results = ctx.Results
+
+	return result, nil
+}
@@ -0,0 +1,180 @@
+package decoder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/logger"
+	"btc-sbt/protocol"
+	"btc-sbt/stacks/basics"
+	"btc-sbt/stacks/taproot/inscriber"
+	"btc-sbt/statemachine"
+)
+
+// newTestRevealTx builds the prepared reveal tx carrying the given op, funded by a fake utxo
+func newTestRevealTx(t *testing.T, op protocol.Operation, txOut *wire.TxOut) *wire.MsgTx {
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate the key: %v", err)
+	}
+
+	addr, err := basics.GetAddress(key, basics.Taproot, &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("failed to get the address: %v", err)
+	}
+
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("failed to get the script: %v", err)
+	}
+
+	payload, err := op.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal the op: %v", err)
+	}
+
+	envelope, err := protocol.NewEnvelope(payload).Script()
+	if err != nil {
+		t.Fatalf("failed to get the envelope: %v", err)
+	}
+
+	fundingTxHash := chainhash.HashH([]byte("funding"))
+	utxos := []*basics.UTXO{basics.NewUTXO(&fundingTxHash, 0, 1000000, pkScript)}
+
+	inscription, err := inscriber.NewInscriber(nil, &chaincfg.RegressionNetParams).Build(addr, utxos, envelope, []*wire.TxOut{txOut}, 1)
+	if err != nil {
+		t.Fatalf("failed to build the inscription: %v", err)
+	}
+
+	return inscription.Reveals[0].Tx
+}
+
+func serializeTx(t *testing.T, tx *wire.MsgTx) []byte {
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		t.Fatalf("failed to serialize: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestParseTxID(t *testing.T) {
+	txID := chainhash.HashH([]byte("tx")).String()
+
+	if txHash, ok := ParseTxID(" " + txID + "\n"); !ok || txHash.String() != txID {
+		t.Fatalf("got %v, %v; want %s", txHash, ok, txID)
+	}
+
+	rawTx := hex.EncodeToString(serializeTx(t, newTestRevealTx(t, protocol.NewIssueOperation("abc", 10, "", 0, ""), wire.NewTxOut(0, basics.PADDED_NULL_OUTPUT_SCRIPT))))
+
+	for _, data := range []string{txID[1:], strings.Repeat("g", 64), txID + "00", rawTx} {
+		if _, ok := ParseTxID(data); ok {
+			t.Fatalf("%s: got a txid; want not", data)
+		}
+	}
+}
+
+func TestDecodeTxData(t *testing.T) {
+	tx := newTestRevealTx(t, protocol.NewIssueOperation("abc", 10, "", 0, ""), wire.NewTxOut(0, basics.PADDED_NULL_OUTPUT_SCRIPT))
+	bz := serializeTx(t, tx)
+
+	for name, data := range map[string]string{"hex": hex.EncodeToString(bz), "base64": base64.StdEncoding.EncodeToString(bz)} {
+		decoded, err := DecodeTxData(data)
+		if err != nil || decoded.TxHash() != tx.TxHash() {
+			t.Fatalf("%s: got %v, %v; want tx %s", name, decoded, err, tx.TxHash())
+		}
+	}
+
+	// the unsigned reveal psbt carrying the tapscript leaf
+	unsigned := tx.Copy()
+	unsigned.TxIn[0].Witness = nil
+
+	packet, err := psbt.NewFromUnsignedTx(unsigned)
+	if err != nil {
+		t.Fatalf("failed to create the psbt: %v", err)
+	}
+
+	witness := tx.TxIn[0].Witness
+	packet.Inputs[0].TaprootLeafScript = []*psbt.TaprootTapLeafScript{{Script: witness[1], ControlBlock: witness[2], LeafVersion: txscript.BaseLeafVersion}}
+
+	encoded, err := packet.B64Encode()
+	if err != nil {
+		t.Fatalf("failed to encode the psbt: %v", err)
+	}
+
+	decoded, err := DecodeTxData(encoded)
+	if err != nil {
+		t.Fatalf("psbt: failed to decode: %v", err)
+	}
+
+	got := decoded.TxIn[0].Witness
+	if len(got) != 3 || len(got[0]) != 64 || !bytes.Equal(got[1], witness[1]) || !bytes.Equal(got[2], witness[2]) {
+		t.Fatalf("psbt: got witness %x; want the dummy signature followed by the leaf", got)
+	}
+
+	for _, data := range []string{"not tx data", hex.EncodeToString([]byte{0x01, 0x02})} {
+		if _, err := DecodeTxData(data); err == nil {
+			t.Fatalf("%s: got nil; want error", data)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	sm, err := statemachine.Open(t.TempDir(), &chaincfg.RegressionNetParams, logger.Logger)
+	if err != nil {
+		t.Fatalf("failed to open the state machine: %v", err)
+	}
+
+	defer sm.Store.Close()
+
+	if err := sm.SetLastBlockHeight(10); err != nil {
+		t.Fatalf("failed to set the last block height: %v", err)
+	}
+
+	d := NewDecoder(protocol.NewParser(&chaincfg.RegressionNetParams), sm)
+
+	issueScript, err := txscript.NullDataScript([]byte("issuer"))
+	if err != nil {
+		t.Fatalf("failed to get the script: %v", err)
+	}
+
+	result, err := d.Decode(newTestRevealTx(t, protocol.NewIssueOperation("abc", 10, "", 0, ""), wire.NewTxOut(0, issueScript)))
+	if err != nil {
+		t.Fatalf("issue: failed to decode: %v", err)
+	}
+
+	if result.BlockHeight != 11 || len(result.Envelopes) != 1 || len(result.Results) != 1 || !result.Results[0].Valid {
+		t.Fatalf("issue: got %+v; want the valid op simulated at block 11", result)
+	}
+
+	result, err = d.Decode(newTestRevealTx(t, protocol.NewMintOperation("xyz", "owner", "", ""), wire.NewTxOut(0, basics.PADDED_NULL_OUTPUT_SCRIPT)))
+	if err != nil {
+		t.Fatalf("mint: failed to decode: %v", err)
+	}
+
+	if len(result.Results) != 1 || result.Results[0].Valid || len(result.Results[0].Error) == 0 {
+		t.Fatalf("mint: got %+v; want the rejected op", result.Results)
+	}
+
+	// nothing is persisted by the simulation
+	if sbts, err := sm.GetSBTs("abc"); err != nil || sbts != nil {
+		t.Fatalf("got %+v, %v; want the SBTs not persisted", sbts, err)
+	}
+
+	plain := wire.NewMsgTx(basics.TxVersion)
+	plain.AddTxOut(wire.NewTxOut(1000, []byte{txscript.OP_TRUE}))
+
+	if result, err := d.Decode(plain); err != nil || len(result.Envelopes) != 0 || len(result.Results) != 0 {
+		t.Fatalf("no envelope: got %+v, %v; want nothing decoded", result, err)
+	}
+}
@@ -0,0 +1,115 @@
+package decoder
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/stacks/basics"
+)
+
+// magic bytes of the PSBT
+var PSBT_MAGIC = []byte{0x70, 0x73, 0x62, 0x74, 0xff}
+
+// ParseTxID parses the given data as the txid of 64 hex characters, returning false if not a txid
+func ParseTxID(data string) (*chainhash.Hash, bool) {
+	data = strings.TrimSpace(data)
+
+	if len(data) != chainhash.MaxHashStringSize {
+		return nil, false
+	}
+
+	if _, err := hex.DecodeString(data); err != nil {
+		return nil, false
+	}
+
+	txHash, err := chainhash.NewHashFromStr(data)
+	if err != nil {
+		return nil, false
+	}
+
+	return txHash, true
+}
+
+// DecodeTxData decodes the tx from the given raw tx or PSBT, encoded in hex or base64
+func DecodeTxData(data string) (*wire.MsgTx, error) {
+	data = strings.TrimSpace(data)
+
+	bz, err := hex.DecodeString(data)
+	if err != nil {
+		bz, err = base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tx data: neither hex nor base64 encoded")
+		}
+	}
+
+	if bytes.HasPrefix(bz, PSBT_MAGIC) {
+		return decodePsbt(bz)
+	}
+
+	tx := wire.NewMsgTx(basics.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(bz)); err != nil {
+		return nil, fmt.Errorf("invalid raw tx: %v", err)
+	}
+
+	return tx, nil
+}
+
+// decodePsbt decodes the tx from the given PSBT.
+// The witness is taken from the finalized inputs, or built from the tapscript leaf with a dummy signature otherwise
+func decodePsbt(bz []byte) (*wire.MsgTx, error) {
+	packet, err := psbt.NewFromRawBytes(bytes.NewReader(bz), false)
+	if err != nil {
+		return nil, fmt.Errorf("invalid psbt: %v", err)
+	}
+
+	tx := packet.UnsignedTx.Copy()
+
+	for i, in := range packet.Inputs {
+		switch {
+		case len(in.FinalScriptWitness) > 0:
+			witness, err := readWitness(in.FinalScriptWitness)
+			if err != nil {
+				return nil, fmt.Errorf("invalid psbt: input %d: %v", i, err)
+			}
+
+			tx.TxIn[i].Witness = witness
+
+		case len(in.TaprootLeafScript) > 0:
+			leaf := in.TaprootLeafScript[0]
+			tx.TxIn[i].Witness = wire.TxWitness{make([]byte, schnorr.SignatureSize), leaf.Script, leaf.ControlBlock}
+		}
+	}
+
+	return tx, nil
+}
+
+// readWitness reads the witness stack from the serialized witness
+func readWitness(bz []byte) (wire.TxWitness, error) {
+	r := bytes.NewReader(bz)
+
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	witness := make(wire.TxWitness, 0)
+
+	for i := uint64(0); i < count; i++ {
+		item, err := wire.ReadVarBytes(r, 0, wire.MaxBlockPayload, "witness item")
+		if err != nil {
+			return nil, err
+		}
+
+		witness = append(witness, item)
+	}
+
+	return witness, nil
+}
@@ -0,0 +1,231 @@
+package devnet
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// UTXOEntry defines the unspent output of the chain
+type UTXOEntry struct {
+	TxOut    *wire.TxOut // output
+	Height   int64       // height of the block including the tx
+	Coinbase bool        // indicates if the output is of the coinbase tx
+}
+
+// TxLocation defines the location of the confirmed tx
+type TxLocation struct {
+	Height int64 // height of the block including the tx
+	Index  int   // index of the tx in the block
+}
+
+// spentOutput defines the output spent in the block, restored when the block is disconnected
+type spentOutput struct {
+	OutPoint wire.OutPoint
+	Entry    *UTXOEntry
+}
+
+// Chain defines the active chain of the devnet, starting from the genesis block.
+// No proof of work is required and the blocks are connected as given
+type Chain struct {
+	NetParams *chaincfg.Params // net params
+
+	blocks  []*wire.MsgBlock              // blocks by height
+	heights map[chainhash.Hash]int64      // heights by block hash
+	txs     map[chainhash.Hash]TxLocation // confirmed txs
+	utxos   map[wire.OutPoint]*UTXOEntry  // unspent outputs
+	undo    [][]*spentOutput              // spent outputs by height
+}
+
+// NewChain creates a chain with the genesis block of the given net params.
+// The genesis coinbase tx is neither indexed nor spendable as on bitcoin
+func NewChain(netParams *chaincfg.Params) *Chain {
+	genesis := netParams.GenesisBlock
+
+	return &Chain{
+		NetParams: netParams,
+		blocks:    []*wire.MsgBlock{genesis},
+		heights:   map[chainhash.Hash]int64{genesis.BlockHash(): 0},
+		txs:       make(map[chainhash.Hash]TxLocation),
+		utxos:     make(map[wire.OutPoint]*UTXOEntry),
+		undo:      [][]*spentOutput{nil},
+	}
+}
+
+// Height returns the height of the tip
+func (c *Chain) Height() int64 {
+	return int64(len(c.blocks) - 1)
+}
+
+// Tip returns the tip block
+func (c *Chain) Tip() *wire.MsgBlock {
+	return c.blocks[len(c.blocks)-1]
+}
+
+// GetBlock gets the block by the given height, nil if not found
+func (c *Chain) GetBlock(height int64) *wire.MsgBlock {
+	if height < 0 || height > c.Height() {
+		return nil
+	}
+
+	return c.blocks[height]
+}
+
+// GetBlockHeight gets the height of the given block hash on the active chain
+func (c *Chain) GetBlockHeight(hash *chainhash.Hash) (int64, bool) {
+	height, ok := c.heights[*hash]
+	return height, ok
+}
+
+// GetTx gets the confirmed tx by the given hash along with its location
+func (c *Chain) GetTx(hash *chainhash.Hash) (*wire.MsgTx, *TxLocation) {
+	location, ok := c.txs[*hash]
+	if !ok {
+		return nil, nil
+	}
+
+	return c.blocks[location.Height].Transactions[location.Index], &location
+}
+
+// GetUTXO gets the unspent output by the given outpoint, nil if not found
+func (c *Chain) GetUTXO(outPoint wire.OutPoint) *UTXOEntry {
+	return c.utxos[outPoint]
+}
+
+// GetUTXOsByScript gets the unspent outputs locked by the given pk script
+func (c *Chain) GetUTXOsByScript(pkScript []byte) map[wire.OutPoint]*UTXOEntry {
+	utxos := make(map[wire.OutPoint]*UTXOEntry)
+
+	for outPoint, entry := range c.utxos {
+		if string(entry.TxOut.PkScript) == string(pkScript) {
+			utxos[outPoint] = entry
+		}
+	}
+
+	return utxos
+}
+
+// IsMature returns true if the given output can be spent in the next block, false otherwise
+func (c *Chain) IsMature(entry *UTXOEntry) bool {
+	return !entry.Coinbase || c.Height()+1-entry.Height >= int64(c.NetParams.CoinbaseMaturity)
+}
+
+// ConnectBlock connects the given block to the tip, of which the inputs must be unspent on the chain or created earlier in the block
+func (c *Chain) ConnectBlock(block *wire.MsgBlock) error {
+	if !block.Header.PrevBlock.IsEqual(c.hashAt(c.Height())) {
+		return fmt.Errorf("block %s does not extend the tip %s", block.BlockHash(), c.hashAt(c.Height()))
+	}
+
+	if err := c.checkInputs(block); err != nil {
+		return err
+	}
+
+	height := c.Height() + 1
+	spent := make([]*spentOutput, 0)
+
+	for idx, tx := range block.Transactions {
+		coinbase := blockchain.IsCoinBaseTx(tx)
+
+		if !coinbase {
+			for _, txIn := range tx.TxIn {
+				spent = append(spent, &spentOutput{OutPoint: txIn.PreviousOutPoint, Entry: c.utxos[txIn.PreviousOutPoint]})
+				delete(c.utxos, txIn.PreviousOutPoint)
+			}
+		}
+
+		txHash := tx.TxHash()
+
+		for i, txOut := range tx.TxOut {
+			if txscript.IsUnspendable(txOut.PkScript) {
+				continue
+			}
+
+			c.utxos[wire.OutPoint{Hash: txHash, Index: uint32(i)}] = &UTXOEntry{TxOut: txOut, Height: height, Coinbase: coinbase}
+		}
+
+		c.txs[txHash] = TxLocation{Height: height, Index: idx}
+	}
+
+	c.blocks = append(c.blocks, block)
+	c.heights[block.BlockHash()] = height
+	c.undo = append(c.undo, spent)
+
+	return nil
+}
+
+// checkInputs checks that each input of the block spends an unspent output exactly once
+func (c *Chain) checkInputs(block *wire.MsgBlock) error {
+	created := make(map[wire.OutPoint]bool)
+	spent := make(map[wire.OutPoint]bool)
+
+	for _, tx := range block.Transactions {
+		if !blockchain.IsCoinBaseTx(tx) {
+			for _, txIn := range tx.TxIn {
+				outPoint := txIn.PreviousOutPoint
+
+				_, unspent := c.utxos[outPoint]
+				if spent[outPoint] || (!unspent && !created[outPoint]) {
+					return fmt.Errorf("input %s of tx %s missing or spent", outPoint, tx.TxHash())
+				}
+
+				spent[outPoint] = true
+			}
+		}
+
+		txHash := tx.TxHash()
+
+		for i := range tx.TxOut {
+			created[wire.OutPoint{Hash: txHash, Index: uint32(i)}] = true
+		}
+	}
+
+	return nil
+}
+
+// DisconnectTip disconnects the tip block, restoring the outputs spent by it.
+// The genesis block can not be disconnected
+func (c *Chain) DisconnectTip() (*wire.MsgBlock, error) {
+	height := c.Height()
+	if height == 0 {
+		return nil, fmt.Errorf("the genesis block can not be disconnected")
+	}
+
+	block := c.blocks[height]
+
+	for _, spent := range c.undo[height] {
+		c.utxos[spent.OutPoint] = spent.Entry
+	}
+
+	// outputs created and spent within the block are removed after restored
+	for _, tx := range block.Transactions {
+		c.removeTx(tx)
+	}
+
+	delete(c.heights, block.BlockHash())
+
+	c.blocks = c.blocks[:height]
+	c.undo = c.undo[:height]
+
+	return block, nil
+}
+
+// removeTx removes the outputs and the location of the given tx
+func (c *Chain) removeTx(tx *wire.MsgTx) {
+	txHash := tx.TxHash()
+
+	for i := range tx.TxOut {
+		delete(c.utxos, wire.OutPoint{Hash: txHash, Index: uint32(i)})
+	}
+
+	delete(c.txs, txHash)
+}
+
+// hashAt returns the hash of the block at the given height
+func (c *Chain) hashAt(height int64) *chainhash.Hash {
+	hash := c.blocks[height].BlockHash()
+	return &hash
+}
@@ -0,0 +1,152 @@
+package devnet
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/stacks/client/btcapi/mempool"
+)
+
+// GetAddressUTXOs responds the utxos of the address including the unconfirmed ones, by the esplora format
+func (n *Node) GetAddressUTXOs(c *gin.Context) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	pkScript, err := n.getAddressScript(c.Param("address"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid Bitcoin address")
+		return
+	}
+
+	utxos := make(mempool.UTXOs, 0)
+
+	for _, utxo := range n.getUTXOsByScript(pkScript, true) {
+		u := mempool.UTXO{
+			Txid:  utxo.OutPoint.Hash.String(),
+			Vout:  int(utxo.OutPoint.Index),
+			Value: utxo.TxOut.Value,
+		}
+
+		if utxo.Confirmations > 0 {
+			block := n.Chain.GetBlock(utxo.Height)
+
+			u.Status.Confirmed = true
+			u.Status.BlockHeight = int(utxo.Height)
+			u.Status.BlockHash = block.BlockHash().String()
+			u.Status.BlockTime = block.Header.Timestamp.Unix()
+		}
+
+		utxos = append(utxos, u)
+	}
+
+	c.JSON(http.StatusOK, utxos)
+}
+
+// GetTxHex responds the hex encoded tx
+func (n *Node) GetTxHex(c *gin.Context) {
+	tx, ok := n.getTxParam(c)
+	if !ok {
+		return
+	}
+
+	txHex, err := serializeHex(tx.Serialize)
+	if err != nil {
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.String(http.StatusOK, txHex)
+}
+
+// GetTxRaw responds the serialized tx
+func (n *Node) GetTxRaw(c *gin.Context) {
+	tx, ok := n.getTxParam(c)
+	if !ok {
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		c.String(http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	c.Data(http.StatusOK, "application/octet-stream", buf.Bytes())
+}
+
+// PostTx broadcasts the hex encoded tx of the body, responding the tx id
+func (n *Node) PostTx(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tx, err := decodeTx(strings.TrimSpace(string(body)))
+	if err != nil {
+		c.String(http.StatusBadRequest, err.Error())
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	txHash, err := n.sendTx(tx)
+	if err != nil {
+		c.String(http.StatusBadRequest, "sendrawtransaction RPC error: %v", toRPCError(err))
+		return
+	}
+
+	c.String(http.StatusOK, txHash.String())
+}
+
+// GetTipHeight responds the height of the tip
+func (n *Node) GetTipHeight(c *gin.Context) {
+	c.JSON(http.StatusOK, n.GetBlockCount())
+}
+
+// GetTipHash responds the hash of the tip
+func (n *Node) GetTipHash(c *gin.Context) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	c.String(http.StatusOK, n.Chain.Tip().BlockHash().String())
+}
+
+// GetRecommendedFees responds the configured fee rate for all the levels
+func (n *Node) GetRecommendedFees(c *gin.Context) {
+	c.JSON(http.StatusOK, &mempool.Fees{
+		FastestFee:  n.FeeRate,
+		HalfHourFee: n.FeeRate,
+		HourFee:     n.FeeRate,
+		EconomyFee:  n.FeeRate,
+		MinimumFee:  n.FeeRate,
+	})
+}
+
+// getTxParam gets the tx by the tx id of the path, responding 404 if not found
+func (n *Node) getTxParam(c *gin.Context) (*wire.MsgTx, bool) {
+	txHash, err := chainhash.NewHashFromStr(c.Param("txid"))
+	if err != nil {
+		c.String(http.StatusBadRequest, "Invalid hex string")
+		return nil, false
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	tx, _ := n.getTx(txHash)
+	if tx == nil {
+		c.String(http.StatusNotFound, "Transaction not found")
+		return nil, false
+	}
+
+	return tx, true
+}
@@ -0,0 +1,361 @@
+package devnet
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/stacks/basics"
+)
+
+// BIP125: the tx signals replaceability if any input has a sequence below
+const MAX_RBF_SEQUENCE = wire.MaxTxInSequenceNum - 1
+
+// MempoolEntry defines the tx accepted into the mempool
+type MempoolEntry struct {
+	Tx     *wire.MsgTx // tx
+	Fee    int64       // fee in sats
+	VSize  int64       // virtual size in vB
+	Time   time.Time   // time when accepted
+	Height int64       // chain height when accepted
+}
+
+// Mempool defines the mempool of the devnet holding the txs accepted on top of the chain.
+// The txs are kept in the order of acceptance so that the parents always precede their children
+type Mempool struct {
+	chain *Chain
+
+	entries map[chainhash.Hash]*MempoolEntry // entries by tx hash
+	order   []chainhash.Hash                 // tx hashes in the order of acceptance
+	spends  map[wire.OutPoint]chainhash.Hash // spending txs by outpoint
+}
+
+// NewMempool creates an empty mempool on top of the given chain
+func NewMempool(chain *Chain) *Mempool {
+	return &Mempool{
+		chain:   chain,
+		entries: make(map[chainhash.Hash]*MempoolEntry),
+		spends:  make(map[wire.OutPoint]chainhash.Hash),
+	}
+}
+
+// Clone returns a copy of the mempool, on which the txs can be tried without affecting the mempool
+func (m *Mempool) Clone() *Mempool {
+	clone := &Mempool{
+		chain:   m.chain,
+		entries: make(map[chainhash.Hash]*MempoolEntry, len(m.entries)),
+		order:   append([]chainhash.Hash{}, m.order...),
+		spends:  make(map[wire.OutPoint]chainhash.Hash, len(m.spends)),
+	}
+
+	for hash, entry := range m.entries {
+		clone.entries[hash] = entry
+	}
+
+	for outPoint, hash := range m.spends {
+		clone.spends[outPoint] = hash
+	}
+
+	return clone
+}
+
+// Entries returns the entries in the order of acceptance
+func (m *Mempool) Entries() []*MempoolEntry {
+	entries := make([]*MempoolEntry, 0, len(m.order))
+	for _, hash := range m.order {
+		entries = append(entries, m.entries[hash])
+	}
+
+	return entries
+}
+
+// Size returns the number of the txs in the mempool
+func (m *Mempool) Size() int {
+	return len(m.order)
+}
+
+// Get gets the entry by the given tx hash, nil if not found
+func (m *Mempool) Get(hash *chainhash.Hash) *MempoolEntry {
+	return m.entries[*hash]
+}
+
+// IsSpent returns true if the given outpoint is spent by any tx in the mempool, false otherwise
+func (m *Mempool) IsSpent(outPoint wire.OutPoint) bool {
+	_, ok := m.spends[outPoint]
+	return ok
+}
+
+// GetOutput gets the output created by the tx in the mempool, nil if not found
+func (m *Mempool) GetOutput(outPoint wire.OutPoint) *wire.TxOut {
+	entry, ok := m.entries[outPoint.Hash]
+	if !ok || outPoint.Index >= uint32(len(entry.Tx.TxOut)) {
+		return nil
+	}
+
+	return entry.Tx.TxOut[outPoint.Index]
+}
+
+// Accept validates the given tx against the chain and the mempool and adds it into the mempool.
+// The conflicting txs signaling replaceability are replaced along with their descendants if the tx pays for them by BIP125.
+// Returns the hashes of the replaced txs, or *RejectError
+func (m *Mempool) Accept(tx *wire.MsgTx) (*MempoolEntry, []chainhash.Hash, error) {
+	txHash := tx.TxHash()
+
+	if _, ok := m.entries[txHash]; ok {
+		return nil, nil, newRejectError(RPC_VERIFY_ALREADY_IN_MEMPOOL, REJECT_ALREADY_IN_MEMPOOL)
+	}
+
+	if _, ok := m.chain.txs[txHash]; ok {
+		return nil, nil, newRejectError(RPC_VERIFY_ALREADY_IN_CHAIN, REJECT_ALREADY_KNOWN)
+	}
+
+	if err := blockchain.CheckTransactionSanity(btcutil.NewTx(tx)); err != nil {
+		return nil, nil, newRejectError(RPC_VERIFY_REJECTED, err.Error())
+	}
+
+	if blockchain.IsCoinBaseTx(tx) {
+		return nil, nil, newRejectError(RPC_VERIFY_REJECTED, "coinbase")
+	}
+
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut, len(tx.TxIn))
+	conflicts := make(map[chainhash.Hash]bool)
+
+	for _, txIn := range tx.TxIn {
+		outPoint := txIn.PreviousOutPoint
+
+		if spender, ok := m.spends[outPoint]; ok {
+			conflicts[spender] = true
+		}
+
+		if txOut := m.GetOutput(outPoint); txOut != nil {
+			prevOuts[outPoint] = txOut
+			continue
+		}
+
+		entry := m.chain.GetUTXO(outPoint)
+		if entry == nil {
+			return nil, nil, newRejectError(RPC_VERIFY_ERROR, REJECT_MISSING_INPUTS)
+		}
+
+		if !m.chain.IsMature(entry) {
+			return nil, nil, newRejectError(RPC_VERIFY_REJECTED, "bad-txns-premature-spend-of-coinbase")
+		}
+
+		prevOuts[outPoint] = entry.TxOut
+	}
+
+	var inValue int64
+	for _, txOut := range prevOuts {
+		inValue += txOut.Value
+	}
+
+	fee := inValue - basics.GetTotalOutputValue(tx.TxOut)
+	if fee < 0 {
+		return nil, nil, newRejectError(RPC_VERIFY_REJECTED, "bad-txns-in-belowout")
+	}
+
+	vsize := basics.GetTxVirtualSize(tx, nil, true)
+	if minFee := basics.MIN_RELAY_TX_FEE * vsize / 1000; fee < minFee {
+		return nil, nil, newRejectError(RPC_VERIFY_REJECTED, fmt.Sprintf("min relay fee not met, %d < %d", fee, minFee))
+	}
+
+	replaced, err := m.checkReplacement(tx, conflicts, fee, vsize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := verifyScripts(tx, prevOuts); err != nil {
+		return nil, nil, err
+	}
+
+	for _, hash := range replaced {
+		m.remove(hash)
+	}
+
+	entry := &MempoolEntry{
+		Tx:     tx,
+		Fee:    fee,
+		VSize:  vsize,
+		Time:   time.Now(),
+		Height: m.chain.Height(),
+	}
+
+	m.entries[txHash] = entry
+	m.order = append(m.order, txHash)
+
+	for _, txIn := range tx.TxIn {
+		m.spends[txIn.PreviousOutPoint] = txHash
+	}
+
+	return entry, replaced, nil
+}
+
+// checkReplacement checks if the tx replaces the conflicting txs by BIP125, returning the txs replaced along with their descendants
+func (m *Mempool) checkReplacement(tx *wire.MsgTx, conflicts map[chainhash.Hash]bool, fee int64, vsize int64) ([]chainhash.Hash, error) {
+	if len(conflicts) == 0 {
+		return nil, nil
+	}
+
+	replaced := make([]chainhash.Hash, 0)
+	replacedSet := make(map[chainhash.Hash]bool)
+	var replacedFee int64
+
+	for hash := range conflicts {
+		if !signalsReplacement(m.entries[hash].Tx) {
+			return nil, newRejectError(RPC_VERIFY_REJECTED, "txn-mempool-conflict")
+		}
+
+		for _, descendant := range m.Descendants(&hash) {
+			if replacedSet[descendant] {
+				continue
+			}
+
+			replacedSet[descendant] = true
+			replaced = append(replaced, descendant)
+			replacedFee += m.entries[descendant].Fee
+		}
+	}
+
+	for _, txIn := range tx.TxIn {
+		if replacedSet[txIn.PreviousOutPoint.Hash] {
+			return nil, newRejectError(RPC_VERIFY_REJECTED, "bad-txns-spends-conflicting-tx")
+		}
+	}
+
+	if minFee := replacedFee + basics.MIN_RELAY_TX_FEE*vsize/1000; fee < minFee {
+		return nil, newRejectError(RPC_VERIFY_REJECTED, fmt.Sprintf("insufficient fee, rejecting replacement %s, not enough additional fees to relay; %d < %d", tx.TxHash(), fee, minFee))
+	}
+
+	return replaced, nil
+}
+
+// Descendants returns the given tx followed by its descendants in the mempool
+func (m *Mempool) Descendants(hash *chainhash.Hash) []chainhash.Hash {
+	descendants := []chainhash.Hash{*hash}
+	visited := map[chainhash.Hash]bool{*hash: true}
+
+	for i := 0; i < len(descendants); i++ {
+		entry := m.entries[descendants[i]]
+
+		for idx := range entry.Tx.TxOut {
+			child, ok := m.spends[wire.OutPoint{Hash: descendants[i], Index: uint32(idx)}]
+			if ok && !visited[child] {
+				visited[child] = true
+				descendants = append(descendants, child)
+			}
+		}
+	}
+
+	return descendants
+}
+
+// Ancestors returns the given tx followed by its ancestors in the mempool
+func (m *Mempool) Ancestors(hash *chainhash.Hash) []chainhash.Hash {
+	ancestors := []chainhash.Hash{*hash}
+	visited := map[chainhash.Hash]bool{*hash: true}
+
+	for i := 0; i < len(ancestors); i++ {
+		entry := m.entries[ancestors[i]]
+
+		for _, txIn := range entry.Tx.TxIn {
+			parent := txIn.PreviousOutPoint.Hash
+			if _, ok := m.entries[parent]; ok && !visited[parent] {
+				visited[parent] = true
+				ancestors = append(ancestors, parent)
+			}
+		}
+	}
+
+	return ancestors
+}
+
+// Evict removes the given tx along with its descendants, returning the hashes of the removed txs
+func (m *Mempool) Evict(hash *chainhash.Hash) []chainhash.Hash {
+	if _, ok := m.entries[*hash]; !ok {
+		return nil
+	}
+
+	evicted := m.Descendants(hash)
+	for _, h := range evicted {
+		m.remove(h)
+	}
+
+	return evicted
+}
+
+// RemoveForBlock removes the txs included in the given block, and evicts the txs conflicting with the block along with their descendants
+func (m *Mempool) RemoveForBlock(block *wire.MsgBlock) {
+	for _, tx := range block.Transactions {
+		txHash := tx.TxHash()
+		if _, ok := m.entries[txHash]; ok {
+			m.remove(txHash)
+			continue
+		}
+
+		for _, txIn := range tx.TxIn {
+			if spender, ok := m.spends[txIn.PreviousOutPoint]; ok {
+				m.Evict(&spender)
+			}
+		}
+	}
+}
+
+// remove removes the given tx only
+func (m *Mempool) remove(hash chainhash.Hash) {
+	entry, ok := m.entries[hash]
+	if !ok {
+		return
+	}
+
+	for _, txIn := range entry.Tx.TxIn {
+		if m.spends[txIn.PreviousOutPoint] == hash {
+			delete(m.spends, txIn.PreviousOutPoint)
+		}
+	}
+
+	delete(m.entries, hash)
+
+	for i, h := range m.order {
+		if h == hash {
+			m.order = append(m.order[:i], m.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// signalsReplacement returns true if the given tx signals replaceability by BIP125, false otherwise
+func signalsReplacement(tx *wire.MsgTx) bool {
+	for _, txIn := range tx.TxIn {
+		if txIn.Sequence < MAX_RBF_SEQUENCE {
+			return true
+		}
+	}
+
+	return false
+}
+
+// verifyScripts verifies the input scripts of the given tx against the spent outputs by the standard flags
+func verifyScripts(tx *wire.MsgTx, prevOuts map[wire.OutPoint]*wire.TxOut) error {
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(prevOuts)
+	sigHashes := txscript.NewTxSigHashes(tx, prevOutFetcher)
+
+	for idx, txIn := range tx.TxIn {
+		prevOut := prevOuts[txIn.PreviousOutPoint]
+
+		engine, err := txscript.NewEngine(prevOut.PkScript, tx, idx, txscript.StandardVerifyFlags, nil, sigHashes, prevOut.Value, prevOutFetcher)
+		if err == nil {
+			err = engine.Execute()
+		}
+
+		if err != nil {
+			return newRejectError(RPC_VERIFY_REJECTED, fmt.Sprintf("mandatory-script-verify-flag-failed (input %d: %v)", idx, err))
+		}
+	}
+
+	return nil
+}
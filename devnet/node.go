@@ -0,0 +1,322 @@
+package devnet
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/mining"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// block version signaling no deployments by BIP9
+const BLOCK_VERSION = 0x20000000
+
+// Node defines the in-process bitcoin node of the devnet on regtest, which serves the subset of the bitcoind JSON-RPC
+// used by the indexer and the initiator, along with the esplora endpoints of the utxos and the fees.
+// The accepted txs stay in the mempool until mined on demand, and the blocks can be replaced to script reorgs.
+// The state is kept in memory only
+type Node struct {
+	NetParams *chaincfg.Params // net params
+
+	Chain   *Chain   // active chain
+	Mempool *Mempool // mempool
+
+	FeeRate float64 // fee rate in sat/vB returned by the fee estimates
+
+	TxIndex bool // indicates if getrawtransaction finds the confirmed txs as with txindex, otherwise the mempool txs only
+
+	RPCUser string // rpc username, no authentication if empty
+	RPCPass string // rpc passphrase
+
+	Router *gin.Engine
+
+	Logger *logrus.Logger
+
+	mu         sync.Mutex // lock
+	extraNonce uint64     // extra nonce in the coinbase making the blocks unique
+}
+
+// NewNode creates a new Node instance with the regtest genesis block
+func NewNode(rpcUser, rpcPass string, feeRate float64, logger *logrus.Logger) *Node {
+	netParams := &chaincfg.RegressionNetParams
+	chain := NewChain(netParams)
+
+	n := Node{
+		NetParams: netParams,
+		Chain:     chain,
+		Mempool:   NewMempool(chain),
+		FeeRate:   feeRate,
+		TxIndex:   true,
+		RPCUser:   rpcUser,
+		RPCPass:   rpcPass,
+		Logger:    logger,
+	}
+
+	n.createRouter()
+
+	return &n
+}
+
+// Start starts serving the rpc and the esplora api on the listener address
+func (n *Node) Start(listenerAddr string) error {
+	n.Logger.Infof("starting the devnet node on %s, height: %d", listenerAddr, n.GetBlockCount())
+
+	return n.Router.Run(listenerAddr)
+}
+
+// GetBlockCount returns the height of the tip
+func (n *Node) GetBlockCount() int64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.Chain.Height()
+}
+
+// SendTx accepts the given tx into the mempool
+func (n *Node) SendTx(tx *wire.MsgTx) (*chainhash.Hash, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.sendTx(tx)
+}
+
+// Mine mines the given number of blocks paying to the given address, including the txs of the mempool into the first one.
+// Returns the hashes of the mined blocks
+func (n *Node) Mine(blocks int, address btcutil.Address) ([]chainhash.Hash, error) {
+	pkScript, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.mine(blocks, pkScript)
+}
+
+// InvalidateBlock disconnects the given block along with its descendants, returning their txs into the mempool
+func (n *Node) InvalidateBlock(hash *chainhash.Hash) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.invalidateBlock(hash)
+}
+
+// Reorg replaces the last depth blocks by the given number of new blocks paying to the given address.
+// The txs of the disconnected blocks are mined again into the new blocks, except the dropped ones which are evicted along with their descendants.
+// Returns the hashes of the new blocks
+func (n *Node) Reorg(depth int, blocks int, address btcutil.Address, dropped ...chainhash.Hash) ([]chainhash.Hash, error) {
+	pkScript, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		return nil, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.reorg(depth, blocks, pkScript, dropped)
+}
+
+// sendTx accepts the given tx into the mempool
+func (n *Node) sendTx(tx *wire.MsgTx) (*chainhash.Hash, error) {
+	_, replaced, err := n.Mempool.Accept(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	txHash := tx.TxHash()
+
+	for _, hash := range replaced {
+		n.Logger.Infof("tx replaced: %s by %s", hash, txHash)
+	}
+
+	n.Logger.Infof("tx accepted: %s", txHash)
+
+	return &txHash, nil
+}
+
+// invalidateBlock disconnects the given block along with its descendants
+func (n *Node) invalidateBlock(hash *chainhash.Hash) error {
+	height, ok := n.Chain.GetBlockHeight(hash)
+	if !ok {
+		return fmt.Errorf("block not found: %s", hash)
+	}
+
+	return n.disconnect(int(n.Chain.Height() - height + 1))
+}
+
+// reorg replaces the last depth blocks by the given number of new blocks paying to the given pk script, dropping the given txs
+func (n *Node) reorg(depth int, blocks int, pkScript []byte, dropped []chainhash.Hash) ([]chainhash.Hash, error) {
+	if err := n.disconnect(depth); err != nil {
+		return nil, err
+	}
+
+	for _, hash := range dropped {
+		for _, evicted := range n.Mempool.Evict(&hash) {
+			n.Logger.Infof("tx dropped: %s", evicted)
+		}
+	}
+
+	hashes, err := n.mine(blocks, pkScript)
+	if err != nil {
+		return nil, err
+	}
+
+	n.Logger.Infof("chain reorganized: %d blocks replaced by %d blocks, height: %d", depth, blocks, n.Chain.Height())
+
+	return hashes, nil
+}
+
+// mine mines the given number of blocks paying to the given pk script
+func (n *Node) mine(blocks int, pkScript []byte) ([]chainhash.Hash, error) {
+	if blocks < 0 {
+		return nil, fmt.Errorf("invalid number of blocks: %d", blocks)
+	}
+
+	hashes := make([]chainhash.Hash, 0, blocks)
+
+	for i := 0; i < blocks; i++ {
+		block, err := n.createBlock(pkScript)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := n.Chain.ConnectBlock(block); err != nil {
+			return nil, err
+		}
+
+		n.Mempool.RemoveForBlock(block)
+
+		hash := block.BlockHash()
+		hashes = append(hashes, hash)
+
+		n.Logger.Infof("block mined: %d, hash: %s, txs: %d", n.Chain.Height(), hash, len(block.Transactions))
+	}
+
+	return hashes, nil
+}
+
+// createBlock creates the block on top of the tip including all the txs of the mempool, with the coinbase paying to the given pk script
+func (n *Node) createBlock(pkScript []byte) (*wire.MsgBlock, error) {
+	height := n.Chain.Height() + 1
+	entries := n.Mempool.Entries()
+
+	n.extraNonce++
+
+	coinbaseScript, err := txscript.NewScriptBuilder().AddInt64(height).AddInt64(int64(n.extraNonce)).Script()
+	if err != nil {
+		return nil, err
+	}
+
+	reward := blockchain.CalcBlockSubsidy(int32(height), n.NetParams)
+	for _, entry := range entries {
+		reward += entry.Fee
+	}
+
+	coinbaseTx := wire.NewMsgTx(wire.TxVersion)
+	coinbaseTx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: *wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex),
+		SignatureScript:  coinbaseScript,
+		Sequence:         wire.MaxTxInSequenceNum,
+	})
+	coinbaseTx.AddTxOut(wire.NewTxOut(reward, pkScript))
+
+	txs := []*btcutil.Tx{btcutil.NewTx(coinbaseTx)}
+	witness := false
+
+	for _, entry := range entries {
+		txs = append(txs, btcutil.NewTx(entry.Tx))
+		witness = witness || entry.Tx.HasWitness()
+	}
+
+	if witness {
+		mining.AddWitnessCommitment(txs[0], txs)
+		txs[0] = btcutil.NewTx(coinbaseTx)
+	}
+
+	merkles := blockchain.BuildMerkleTreeStore(txs, false)
+
+	// the timestamp increases strictly so that the blocks are unique and ordered even if mined at once
+	timestamp := time.Unix(time.Now().Unix(), 0)
+	if prevTimestamp := n.Chain.Tip().Header.Timestamp; !timestamp.After(prevTimestamp) {
+		timestamp = prevTimestamp.Add(time.Second)
+	}
+
+	block := wire.NewMsgBlock(&wire.BlockHeader{
+		Version:    BLOCK_VERSION,
+		PrevBlock:  n.Chain.Tip().BlockHash(),
+		MerkleRoot: *merkles[len(merkles)-1],
+		Timestamp:  timestamp,
+		Bits:       n.NetParams.PowLimitBits,
+	})
+
+	for _, tx := range txs {
+		if err := block.AddTransaction(tx.MsgTx()); err != nil {
+			return nil, err
+		}
+	}
+
+	// the regtest target is met in a few tries
+	target := blockchain.CompactToBig(block.Header.Bits)
+	for {
+		hash := block.Header.BlockHash()
+		if blockchain.HashToBig(&hash).Cmp(target) <= 0 {
+			break
+		}
+
+		block.Header.Nonce++
+	}
+
+	return block, nil
+}
+
+// disconnect disconnects the last depth blocks, and then rebuilds the mempool with the txs of the disconnected blocks followed by the previous mempool txs.
+// The txs no longer valid, e.g. spending the disconnected coinbase outputs, are dropped
+func (n *Node) disconnect(depth int) error {
+	if depth <= 0 || int64(depth) > n.Chain.Height() {
+		return fmt.Errorf("invalid depth: %d, only 1 to %d allowed", depth, n.Chain.Height())
+	}
+
+	disconnected := make([]*wire.MsgBlock, 0, depth)
+
+	for i := 0; i < depth; i++ {
+		block, err := n.Chain.DisconnectTip()
+		if err != nil {
+			return err
+		}
+
+		n.Logger.Infof("block disconnected: %d, hash: %s", n.Chain.Height()+1, block.BlockHash())
+
+		disconnected = append(disconnected, block)
+	}
+
+	previous := n.Mempool.Entries()
+	n.Mempool = NewMempool(n.Chain)
+
+	txs := make([]*wire.MsgTx, 0)
+
+	for i := len(disconnected) - 1; i >= 0; i-- {
+		txs = append(txs, disconnected[i].Transactions[1:]...)
+	}
+
+	for _, entry := range previous {
+		txs = append(txs, entry.Tx)
+	}
+
+	for _, tx := range txs {
+		if _, _, err := n.Mempool.Accept(tx); err != nil {
+			n.Logger.Infof("tx dropped: %s, err: %v", tx.TxHash(), err)
+		}
+	}
+
+	return nil
+}
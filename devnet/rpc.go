@@ -0,0 +1,1123 @@
+package devnet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/stacks/basics"
+	"btc-sbt/stacks/client/broadcaster"
+	"btc-sbt/stacks/client/btcapi/bitcoind"
+)
+
+const (
+	// bitcoind version reported by getnetworkinfo, by which rpcclient chooses the request formats
+	BITCOIND_VERSION    = 250000
+	BITCOIND_SUBVERSION = "/Satoshi:25.0.0/"
+)
+
+// rpcHandler handles the rpc method with the given params
+type rpcHandler func(n *Node, params []json.RawMessage) (interface{}, error)
+
+// rpcHandlers maps the supported rpc methods to the handlers
+var rpcHandlers = map[string]rpcHandler{
+	"getblockchaininfo":  (*Node).handleGetBlockChainInfo,
+	"getindexinfo":       (*Node).handleGetIndexInfo,
+	"getnetworkinfo":     (*Node).handleGetNetworkInfo,
+	"getblockcount":      (*Node).handleGetBlockCount,
+	"getbestblockhash":   (*Node).handleGetBestBlockHash,
+	"getblockhash":       (*Node).handleGetBlockHash,
+	"getblock":           (*Node).handleGetBlock,
+	"getblockheader":     (*Node).handleGetBlockHeader,
+	"getrawtransaction":  (*Node).handleGetRawTransaction,
+	"sendrawtransaction": (*Node).handleSendRawTransaction,
+	"testmempoolaccept":  (*Node).handleTestMempoolAccept,
+	"submitpackage":      (*Node).handleSubmitPackage,
+	"getmempoolentry":    (*Node).handleGetMempoolEntry,
+	"getrawmempool":      (*Node).handleGetRawMempool,
+	"gettxout":           (*Node).handleGetTxOut,
+	"listunspent":        (*Node).handleListUnspent,
+	"scantxoutset":       (*Node).handleScanTxOutSet,
+	"estimatesmartfee":   (*Node).handleEstimateSmartFee,
+	"generatetoaddress":  (*Node).handleGenerateToAddress,
+	"invalidateblock":    (*Node).handleInvalidateBlock,
+	"reorg":              (*Node).handleReorg,
+}
+
+// createRouter creates the router serving the rpc and the esplora api
+func (n *Node) createRouter() {
+	gin.SetMode(gin.ReleaseMode)
+
+	r := gin.New()
+	r.Use(gin.Recovery())
+
+	r.POST("/", n.HandleRPC)
+
+	r.GET("/address/:address/utxo", n.GetAddressUTXOs)
+	r.GET("/tx/:txid/hex", n.GetTxHex)
+	r.GET("/tx/:txid/raw", n.GetTxRaw)
+	r.POST("/tx", n.PostTx)
+	r.GET("/blocks/tip/height", n.GetTipHeight)
+	r.GET("/blocks/tip/hash", n.GetTipHash)
+	r.GET("/v1/fees/recommended", n.GetRecommendedFees)
+
+	n.Router = r
+}
+
+// HandleRPC handles the JSON-RPC request, responding with the status codes of bitcoind
+func (n *Node) HandleRPC(c *gin.Context) {
+	if len(n.RPCUser) > 0 {
+		user, pass, ok := c.Request.BasicAuth()
+		if !ok || user != n.RPCUser || pass != n.RPCPass {
+			c.Status(http.StatusUnauthorized)
+			return
+		}
+	}
+
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, &RPCResponse{Error: newRPCError(RPC_PARSE_ERROR, "%v", err)})
+		return
+	}
+
+	var req RPCRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		c.JSON(http.StatusInternalServerError, &RPCResponse{Error: newRPCError(RPC_PARSE_ERROR, "Parse error")})
+		return
+	}
+
+	handler, ok := rpcHandlers[req.Method]
+	if !ok {
+		c.JSON(http.StatusNotFound, &RPCResponse{Error: newRPCError(RPC_METHOD_NOT_FOUND, "Method not found"), ID: req.ID})
+		return
+	}
+
+	n.mu.Lock()
+	result, err := handler(n, req.Params)
+	n.mu.Unlock()
+
+	if err != nil {
+		n.Logger.Debugf("rpc %s failed: %v", req.Method, err)
+		c.JSON(http.StatusInternalServerError, &RPCResponse{Error: toRPCError(err), ID: req.ID})
+		return
+	}
+
+	c.JSON(http.StatusOK, &RPCResponse{Result: result, ID: req.ID})
+}
+
+// handleGetBlockChainInfo handles getblockchaininfo
+func (n *Node) handleGetBlockChainInfo(params []json.RawMessage) (interface{}, error) {
+	tip := n.Chain.Tip()
+
+	return &btcjson.GetBlockChainInfoResult{
+		Chain:                n.NetParams.Name,
+		Blocks:               int32(n.Chain.Height()),
+		Headers:              int32(n.Chain.Height()),
+		BestBlockHash:        tip.BlockHash().String(),
+		Difficulty:           getDifficulty(tip.Header.Bits),
+		MedianTime:           n.getMedianTime(),
+		VerificationProgress: 1,
+		ChainWork:            fmt.Sprintf("%064x", new(big.Int).Mul(blockchain.CalcWork(tip.Header.Bits), big.NewInt(n.Chain.Height()+1))),
+	}, nil
+}
+
+// handleGetIndexInfo handles getindexinfo, reporting txindex synced if enabled
+func (n *Node) handleGetIndexInfo(params []json.RawMessage) (interface{}, error) {
+	indexes := make(map[string]*IndexInfo)
+
+	if n.TxIndex {
+		indexes["txindex"] = &IndexInfo{Synced: true, BestBlockHeight: n.Chain.Height()}
+	}
+
+	return indexes, nil
+}
+
+// handleGetNetworkInfo handles getnetworkinfo
+func (n *Node) handleGetNetworkInfo(params []json.RawMessage) (interface{}, error) {
+	return &btcjson.GetNetworkInfoResult{
+		Version:         BITCOIND_VERSION,
+		SubVersion:      BITCOIND_SUBVERSION,
+		ProtocolVersion: int32(wire.ProtocolVersion),
+		LocalRelay:      true,
+		NetworkActive:   true,
+		Networks:        []btcjson.NetworksResult{},
+		RelayFee:        btcutil.Amount(basics.MIN_RELAY_TX_FEE).ToBTC(),
+		IncrementalFee:  btcutil.Amount(basics.MIN_RELAY_TX_FEE).ToBTC(),
+		LocalAddresses:  []btcjson.LocalAddressesResult{},
+	}, nil
+}
+
+// handleGetBlockCount handles getblockcount
+func (n *Node) handleGetBlockCount(params []json.RawMessage) (interface{}, error) {
+	return n.Chain.Height(), nil
+}
+
+// handleGetBestBlockHash handles getbestblockhash
+func (n *Node) handleGetBestBlockHash(params []json.RawMessage) (interface{}, error) {
+	return n.Chain.Tip().BlockHash().String(), nil
+}
+
+// handleGetBlockHash handles getblockhash
+func (n *Node) handleGetBlockHash(params []json.RawMessage) (interface{}, error) {
+	var height int64
+	if err := requireParam(params, 0, &height); err != nil {
+		return nil, err
+	}
+
+	block := n.Chain.GetBlock(height)
+	if block == nil {
+		return nil, newRPCError(RPC_INVALID_PARAMETER, "Block height out of range")
+	}
+
+	return block.BlockHash().String(), nil
+}
+
+// handleGetBlock handles getblock of the verbosity 0, 1 or 2
+func (n *Node) handleGetBlock(params []json.RawMessage) (interface{}, error) {
+	block, height, err := n.getBlockParam(params)
+	if err != nil {
+		return nil, err
+	}
+
+	verbosity, err := parseVerbosity(params, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	if verbosity == 0 {
+		return serializeHex(block.Serialize)
+	}
+
+	hash := block.BlockHash()
+	utilBlock := btcutil.NewBlock(block)
+
+	result := &btcjson.GetBlockVerboseResult{
+		Hash:          hash.String(),
+		Confirmations: n.Chain.Height() - height + 1,
+		StrippedSize:  int32(block.SerializeSizeStripped()),
+		Size:          int32(block.SerializeSize()),
+		Weight:        int32(blockchain.GetBlockWeight(utilBlock)),
+		Height:        height,
+		Version:       block.Header.Version,
+		VersionHex:    fmt.Sprintf("%08x", block.Header.Version),
+		MerkleRoot:    block.Header.MerkleRoot.String(),
+		Time:          block.Header.Timestamp.Unix(),
+		Nonce:         block.Header.Nonce,
+		Bits:          fmt.Sprintf("%08x", block.Header.Bits),
+		Difficulty:    getDifficulty(block.Header.Bits),
+		PreviousHash:  block.Header.PrevBlock.String(),
+	}
+
+	if next := n.Chain.GetBlock(height + 1); next != nil {
+		result.NextHash = next.BlockHash().String()
+	}
+
+	for _, tx := range block.Transactions {
+		if verbosity == 1 {
+			result.Tx = append(result.Tx, tx.TxHash().String())
+			continue
+		}
+
+		rawTx, err := n.newTxRawResult(tx, height)
+		if err != nil {
+			return nil, err
+		}
+
+		result.RawTx = append(result.RawTx, *rawTx)
+	}
+
+	return result, nil
+}
+
+// handleGetBlockHeader handles getblockheader
+func (n *Node) handleGetBlockHeader(params []json.RawMessage) (interface{}, error) {
+	block, height, err := n.getBlockParam(params)
+	if err != nil {
+		return nil, err
+	}
+
+	verbose := true
+	if _, err := parseParam(params, 1, &verbose); err != nil {
+		return nil, err
+	}
+
+	if !verbose {
+		return serializeHex(block.Header.Serialize)
+	}
+
+	result := &btcjson.GetBlockHeaderVerboseResult{
+		Hash:          block.BlockHash().String(),
+		Confirmations: n.Chain.Height() - height + 1,
+		Height:        int32(height),
+		Version:       block.Header.Version,
+		VersionHex:    fmt.Sprintf("%08x", block.Header.Version),
+		MerkleRoot:    block.Header.MerkleRoot.String(),
+		Time:          block.Header.Timestamp.Unix(),
+		Nonce:         uint64(block.Header.Nonce),
+		Bits:          fmt.Sprintf("%08x", block.Header.Bits),
+		Difficulty:    getDifficulty(block.Header.Bits),
+	}
+
+	if height > 0 {
+		result.PreviousHash = block.Header.PrevBlock.String()
+	}
+
+	if next := n.Chain.GetBlock(height + 1); next != nil {
+		result.NextHash = next.BlockHash().String()
+	}
+
+	return result, nil
+}
+
+// handleGetRawTransaction handles getrawtransaction, looking up the mempool and then the chain if TxIndex is enabled
+func (n *Node) handleGetRawTransaction(params []json.RawMessage) (interface{}, error) {
+	txHash, err := parseHashParam(params, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	verbosity, err := parseVerbosity(params, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, height := n.getTx(txHash)
+	if tx == nil {
+		return nil, newRPCError(RPC_INVALID_ADDRESS_OR_KEY, "No such mempool or blockchain transaction")
+	}
+
+	if height >= 0 && !n.TxIndex {
+		return nil, newRPCError(RPC_INVALID_ADDRESS_OR_KEY, "No such mempool transaction. Use -txindex or provide a block hash to enable blockchain transaction queries")
+	}
+
+	if verbosity == 0 {
+		return serializeHex(tx.Serialize)
+	}
+
+	return n.newTxRawResult(tx, height)
+}
+
+// handleSendRawTransaction handles sendrawtransaction, succeeding if the tx is already in the mempool as bitcoind does
+func (n *Node) handleSendRawTransaction(params []json.RawMessage) (interface{}, error) {
+	var txHex string
+	if err := requireParam(params, 0, &txHex); err != nil {
+		return nil, err
+	}
+
+	tx, err := decodeTx(txHex)
+	if err != nil {
+		return nil, err
+	}
+
+	txHash, err := n.sendTx(tx)
+	if rejectErr, ok := err.(*RejectError); ok && rejectErr.Reason == REJECT_ALREADY_IN_MEMPOOL {
+		return tx.TxHash().String(), nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return txHash.String(), nil
+}
+
+// handleTestMempoolAccept handles testmempoolaccept, trying the txs in order on a copy of the mempool
+func (n *Node) handleTestMempoolAccept(params []json.RawMessage) (interface{}, error) {
+	txs, err := parseRawTxsParam(params, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	mempool := n.Mempool.Clone()
+	results := make([]*broadcaster.TestMempoolAcceptResult, 0, len(txs))
+
+	for _, tx := range txs {
+		result := &broadcaster.TestMempoolAcceptResult{
+			TxID:  tx.TxHash().String(),
+			WTxID: tx.WitnessHash().String(),
+		}
+
+		entry, _, err := mempool.Accept(tx)
+		allowed := err == nil
+		result.Allowed = &allowed
+
+		if err != nil {
+			result.RejectReason = err.Error()
+		} else {
+			result.VSize = entry.VSize
+			result.Fees = &broadcaster.TestMempoolAcceptFees{Base: btcutil.Amount(entry.Fee).ToBTC()}
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// handleSubmitPackage handles submitpackage, accepting the txs as a whole or none of them
+func (n *Node) handleSubmitPackage(params []json.RawMessage) (interface{}, error) {
+	txs, err := parseRawTxsParam(params, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	mempool := n.Mempool.Clone()
+
+	result := &broadcaster.SubmitPackageResult{
+		PackageMsg:           "success",
+		TxResults:            make(map[string]broadcaster.SubmitPackageTxResult),
+		ReplacedTransactions: []string{},
+	}
+
+	for _, tx := range txs {
+		txResult := broadcaster.SubmitPackageTxResult{TxID: tx.TxHash().String()}
+
+		entry, replaced, err := mempool.Accept(tx)
+		if err == nil {
+			txResult.VSize = entry.VSize
+
+			for _, hash := range replaced {
+				result.ReplacedTransactions = append(result.ReplacedTransactions, hash.String())
+			}
+		} else if rejectErr, ok := err.(*RejectError); !ok || rejectErr.Reason != REJECT_ALREADY_IN_MEMPOOL {
+			txResult.Error = err.Error()
+			result.PackageMsg = "transaction failed"
+		}
+
+		result.TxResults[tx.WitnessHash().String()] = txResult
+	}
+
+	if result.PackageMsg == "success" {
+		n.Mempool = mempool
+
+		for _, tx := range txs {
+			n.Logger.Infof("tx accepted: %s", tx.TxHash())
+		}
+	}
+
+	return result, nil
+}
+
+// handleGetMempoolEntry handles getmempoolentry
+func (n *Node) handleGetMempoolEntry(params []json.RawMessage) (interface{}, error) {
+	txHash, err := parseHashParam(params, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := n.Mempool.Get(txHash)
+	if entry == nil {
+		return nil, newRPCError(RPC_INVALID_ADDRESS_OR_KEY, "Transaction not in mempool")
+	}
+
+	result := &btcjson.GetMempoolEntryResult{
+		VSize:  int32(entry.VSize),
+		Size:   int32(entry.Tx.SerializeSize()),
+		Weight: entry.VSize * 4,
+		Fee:    btcutil.Amount(entry.Fee).ToBTC(),
+		Time:   entry.Time.Unix(),
+		Height: entry.Height,
+		WTxId:  entry.Tx.WitnessHash().String(),
+	}
+
+	result.ModifiedFee = result.Fee
+	result.Fees.Base = result.Fee
+	result.Fees.Modified = result.Fee
+
+	var descendantFee, ancestorFee int64
+
+	for _, hash := range n.Mempool.Descendants(txHash) {
+		descendant := n.Mempool.Get(&hash)
+
+		result.DescendantCount++
+		result.DescendantSize += descendant.VSize
+		descendantFee += descendant.Fee
+	}
+
+	for _, hash := range n.Mempool.Ancestors(txHash) {
+		ancestor := n.Mempool.Get(&hash)
+
+		result.AncestorCount++
+		result.AncestorSize += ancestor.VSize
+		ancestorFee += ancestor.Fee
+	}
+
+	result.Fees.Descendant = btcutil.Amount(descendantFee).ToBTC()
+	result.Fees.Ancestor = btcutil.Amount(ancestorFee).ToBTC()
+	result.DescendantFees = float64(descendantFee)
+	result.AncestorFees = float64(ancestorFee)
+
+	result.Depends = []string{}
+	for _, txIn := range entry.Tx.TxIn {
+		if n.Mempool.Get(&txIn.PreviousOutPoint.Hash) != nil {
+			result.Depends = append(result.Depends, txIn.PreviousOutPoint.Hash.String())
+		}
+	}
+
+	return result, nil
+}
+
+// handleGetRawMempool handles getrawmempool, returning the tx ids in the order of acceptance
+func (n *Node) handleGetRawMempool(params []json.RawMessage) (interface{}, error) {
+	txIDs := make([]string, 0, n.Mempool.Size())
+	for _, entry := range n.Mempool.Entries() {
+		txIDs = append(txIDs, entry.Tx.TxHash().String())
+	}
+
+	return txIDs, nil
+}
+
+// handleGetTxOut handles gettxout, responding null if the output is spent or not found
+func (n *Node) handleGetTxOut(params []json.RawMessage) (interface{}, error) {
+	txHash, err := parseHashParam(params, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var index uint32
+	if err := requireParam(params, 1, &index); err != nil {
+		return nil, err
+	}
+
+	includeMempool := true
+	if _, err := parseParam(params, 2, &includeMempool); err != nil {
+		return nil, err
+	}
+
+	outPoint := wire.OutPoint{Hash: *txHash, Index: index}
+
+	if includeMempool {
+		if n.Mempool.IsSpent(outPoint) {
+			return nil, nil
+		}
+
+		if txOut := n.Mempool.GetOutput(outPoint); txOut != nil {
+			return n.newTxOutResult(txOut, 0, false), nil
+		}
+	}
+
+	entry := n.Chain.GetUTXO(outPoint)
+	if entry == nil {
+		return nil, nil
+	}
+
+	return n.newTxOutResult(entry.TxOut, n.Chain.Height()-entry.Height+1, entry.Coinbase), nil
+}
+
+// handleListUnspent handles listunspent of the given addresses, as if the addresses were watched by the node wallet.
+// The immature coinbase outputs and the outputs spent in the mempool are excluded as bitcoind does
+func (n *Node) handleListUnspent(params []json.RawMessage) (interface{}, error) {
+	minConf, maxConf := int64(1), int64(bitcoind.MAX_CONFIRMATIONS)
+
+	if _, err := parseParam(params, 0, &minConf); err != nil {
+		return nil, err
+	}
+
+	if _, err := parseParam(params, 1, &maxConf); err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	if _, err := parseParam(params, 2, &addresses); err != nil {
+		return nil, err
+	}
+
+	if len(addresses) == 0 {
+		return nil, newRPCError(RPC_INVALID_PARAMETER, "addresses required, no wallet on the devnet")
+	}
+
+	results := make([]*btcjson.ListUnspentResult, 0)
+
+	for _, address := range addresses {
+		pkScript, err := n.getAddressScript(address)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, utxo := range n.getUTXOsByScript(pkScript, true) {
+			if utxo.Confirmations < minConf || utxo.Confirmations > maxConf || !n.Chain.IsMature(&utxo.UTXOEntry) {
+				continue
+			}
+
+			results = append(results, &btcjson.ListUnspentResult{
+				TxID:          utxo.OutPoint.Hash.String(),
+				Vout:          utxo.OutPoint.Index,
+				Address:       address,
+				ScriptPubKey:  hex.EncodeToString(pkScript),
+				Amount:        btcutil.Amount(utxo.TxOut.Value).ToBTC(),
+				Confirmations: utxo.Confirmations,
+				Spendable:     true,
+			})
+		}
+	}
+
+	return results, nil
+}
+
+// handleScanTxOutSet handles scantxoutset of the addr() descriptors, including the confirmed outputs only
+func (n *Node) handleScanTxOutSet(params []json.RawMessage) (interface{}, error) {
+	var action string
+	if err := requireParam(params, 0, &action); err != nil {
+		return nil, err
+	}
+
+	switch action {
+	case "start":
+	case "abort":
+		return false, nil
+	case "status":
+		return nil, nil
+	default:
+		return nil, newRPCError(RPC_INVALID_PARAMETER, "Invalid action '%s'", action)
+	}
+
+	var scanObjects []json.RawMessage
+	if err := requireParam(params, 1, &scanObjects); err != nil {
+		return nil, err
+	}
+
+	hash := n.Chain.Tip().BlockHash()
+
+	result := &bitcoind.ScanTxOutSetResult{
+		Success:   true,
+		Height:    n.Chain.Height(),
+		BestBlock: hash.String(),
+		Unspents:  []bitcoind.ScanTxOutSetUnspent{},
+	}
+
+	var total int64
+
+	for _, scanObject := range scanObjects {
+		descriptor, err := parseDescriptor(scanObject)
+		if err != nil {
+			return nil, err
+		}
+
+		address, ok := parseAddrDescriptor(descriptor)
+		if !ok {
+			return nil, newRPCError(RPC_INVALID_PARAMETER, "only addr() descriptors supported by the devnet: %s", descriptor)
+		}
+
+		pkScript, err := n.getAddressScript(address)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, utxo := range n.getUTXOsByScript(pkScript, false) {
+			result.Unspents = append(result.Unspents, bitcoind.ScanTxOutSetUnspent{
+				TxID:         utxo.OutPoint.Hash.String(),
+				Vout:         utxo.OutPoint.Index,
+				ScriptPubKey: hex.EncodeToString(pkScript),
+				Descriptor:   descriptor,
+				Amount:       btcutil.Amount(utxo.TxOut.Value).ToBTC(),
+				Height:       utxo.Height,
+			})
+
+			total += utxo.TxOut.Value
+		}
+	}
+
+	result.TotalAmount = btcutil.Amount(total).ToBTC()
+
+	return result, nil
+}
+
+// handleEstimateSmartFee handles estimatesmartfee, estimating the configured fee rate for any target
+func (n *Node) handleEstimateSmartFee(params []json.RawMessage) (interface{}, error) {
+	var confTarget int64
+	if err := requireParam(params, 0, &confTarget); err != nil {
+		return nil, err
+	}
+
+	if confTarget < 1 || confTarget > 1008 {
+		return nil, newRPCError(RPC_INVALID_PARAMETER, "Invalid conf_target, must be between 1 and 1008")
+	}
+
+	// sat/vB to BTC/kvB
+	feeRate := n.FeeRate / 1e5
+
+	return &btcjson.EstimateSmartFeeResult{FeeRate: &feeRate, Blocks: confTarget}, nil
+}
+
+// handleGenerateToAddress handles generatetoaddress
+func (n *Node) handleGenerateToAddress(params []json.RawMessage) (interface{}, error) {
+	var blocks int
+	if err := requireParam(params, 0, &blocks); err != nil {
+		return nil, err
+	}
+
+	var address string
+	if err := requireParam(params, 1, &address); err != nil {
+		return nil, err
+	}
+
+	pkScript, err := n.getAddressScript(address)
+	if err != nil {
+		return nil, err
+	}
+
+	hashes, err := n.mine(blocks, pkScript)
+	if err != nil {
+		return nil, err
+	}
+
+	return hashStrings(hashes), nil
+}
+
+// handleInvalidateBlock handles invalidateblock
+func (n *Node) handleInvalidateBlock(params []json.RawMessage) (interface{}, error) {
+	hash, err := parseHashParam(params, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := n.invalidateBlock(hash); err != nil {
+		return nil, newRPCError(RPC_INVALID_ADDRESS_OR_KEY, "%v", err)
+	}
+
+	return nil, nil
+}
+
+// handleReorg handles reorg, the devnet method which replaces the last blocks:
+// reorg depth nblocks address [txids dropped from the new blocks]
+func (n *Node) handleReorg(params []json.RawMessage) (interface{}, error) {
+	var depth, blocks int
+	if err := requireParam(params, 0, &depth); err != nil {
+		return nil, err
+	}
+
+	if err := requireParam(params, 1, &blocks); err != nil {
+		return nil, err
+	}
+
+	var address string
+	if err := requireParam(params, 2, &address); err != nil {
+		return nil, err
+	}
+
+	pkScript, err := n.getAddressScript(address)
+	if err != nil {
+		return nil, err
+	}
+
+	var txIDs []string
+	if _, err := parseParam(params, 3, &txIDs); err != nil {
+		return nil, err
+	}
+
+	dropped := make([]chainhash.Hash, 0, len(txIDs))
+	for _, txID := range txIDs {
+		hash, err := parseHash(txID)
+		if err != nil {
+			return nil, err
+		}
+
+		dropped = append(dropped, *hash)
+	}
+
+	hashes, err := n.reorg(depth, blocks, pkScript, dropped)
+	if err != nil {
+		return nil, newRPCError(RPC_INVALID_PARAMETER, "%v", err)
+	}
+
+	return hashStrings(hashes), nil
+}
+
+// addressUTXO defines the unspent output of the address
+type addressUTXO struct {
+	UTXOEntry
+
+	OutPoint      wire.OutPoint
+	Confirmations int64
+}
+
+// getUTXOsByScript gets the unspent outputs locked by the given pk script, sorted by height and outpoint.
+// If the mempool applies, the outputs spent in the mempool are excluded while the ones created in the mempool are appended with 0 confirmations
+func (n *Node) getUTXOsByScript(pkScript []byte, mempool bool) []*addressUTXO {
+	utxos := make([]*addressUTXO, 0)
+
+	for outPoint, entry := range n.Chain.GetUTXOsByScript(pkScript) {
+		if mempool && n.Mempool.IsSpent(outPoint) {
+			continue
+		}
+
+		utxos = append(utxos, &addressUTXO{UTXOEntry: *entry, OutPoint: outPoint, Confirmations: n.Chain.Height() - entry.Height + 1})
+	}
+
+	sort.Slice(utxos, func(i, j int) bool {
+		if utxos[i].Height != utxos[j].Height {
+			return utxos[i].Height < utxos[j].Height
+		}
+
+		return utxos[i].OutPoint.String() < utxos[j].OutPoint.String()
+	})
+
+	if !mempool {
+		return utxos
+	}
+
+	for _, entry := range n.Mempool.Entries() {
+		txHash := entry.Tx.TxHash()
+
+		for idx, txOut := range entry.Tx.TxOut {
+			outPoint := wire.OutPoint{Hash: txHash, Index: uint32(idx)}
+
+			if bytes.Equal(txOut.PkScript, pkScript) && !n.Mempool.IsSpent(outPoint) {
+				utxos = append(utxos, &addressUTXO{UTXOEntry: UTXOEntry{TxOut: txOut, Height: -1}, OutPoint: outPoint})
+			}
+		}
+	}
+
+	return utxos
+}
+
+// getTx gets the tx from the mempool or the chain, along with the height of the block including the tx, -1 if unconfirmed
+func (n *Node) getTx(txHash *chainhash.Hash) (*wire.MsgTx, int64) {
+	if entry := n.Mempool.Get(txHash); entry != nil {
+		return entry.Tx, -1
+	}
+
+	tx, location := n.Chain.GetTx(txHash)
+	if tx == nil {
+		return nil, 0
+	}
+
+	return tx, location.Height
+}
+
+// getBlockParam gets the block by the hash of the first param, along with its height
+func (n *Node) getBlockParam(params []json.RawMessage) (*wire.MsgBlock, int64, error) {
+	hash, err := parseHashParam(params, 0)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	height, ok := n.Chain.GetBlockHeight(hash)
+	if !ok {
+		return nil, 0, newRPCError(RPC_INVALID_ADDRESS_OR_KEY, "Block not found")
+	}
+
+	return n.Chain.GetBlock(height), height, nil
+}
+
+// getAddressScript decodes the address of the network into the pk script
+func (n *Node) getAddressScript(address string) ([]byte, error) {
+	addr, err := btcutil.DecodeAddress(address, n.NetParams)
+	if err != nil || !addr.IsForNet(n.NetParams) {
+		return nil, newRPCError(RPC_INVALID_ADDRESS_OR_KEY, "Invalid Bitcoin address: %s", address)
+	}
+
+	return txscript.PayToAddrScript(addr)
+}
+
+// getMedianTime gets the median time of the last 11 blocks
+func (n *Node) getMedianTime() int64 {
+	timestamps := make([]int64, 0, 11)
+
+	for h := n.Chain.Height(); h >= 0 && len(timestamps) < 11; h-- {
+		timestamps = append(timestamps, n.Chain.GetBlock(h).Header.Timestamp.Unix())
+	}
+
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+
+	return timestamps[len(timestamps)/2]
+}
+
+// newTxRawResult creates the verbose result of the given tx included in the block at the given height, -1 if unconfirmed
+func (n *Node) newTxRawResult(tx *wire.MsgTx, height int64) (*btcjson.TxRawResult, error) {
+	txHex, err := serializeHex(tx.Serialize)
+	if err != nil {
+		return nil, err
+	}
+
+	utilTx := btcutil.NewTx(tx)
+
+	result := &btcjson.TxRawResult{
+		Hex:      txHex,
+		Txid:     tx.TxHash().String(),
+		Hash:     tx.WitnessHash().String(),
+		Size:     int32(tx.SerializeSize()),
+		Vsize:    int32((blockchain.GetTransactionWeight(utilTx) + blockchain.WitnessScaleFactor - 1) / blockchain.WitnessScaleFactor),
+		Weight:   int32(blockchain.GetTransactionWeight(utilTx)),
+		Version:  uint32(tx.Version),
+		LockTime: tx.LockTime,
+		Vin:      make([]btcjson.Vin, 0, len(tx.TxIn)),
+		Vout:     make([]btcjson.Vout, 0, len(tx.TxOut)),
+	}
+
+	for _, txIn := range tx.TxIn {
+		vin := btcjson.Vin{Sequence: txIn.Sequence}
+
+		if blockchain.IsCoinBaseTx(tx) {
+			vin.Coinbase = hex.EncodeToString(txIn.SignatureScript)
+		} else {
+			asm, _ := txscript.DisasmString(txIn.SignatureScript)
+
+			vin.Txid = txIn.PreviousOutPoint.Hash.String()
+			vin.Vout = txIn.PreviousOutPoint.Index
+			vin.ScriptSig = &btcjson.ScriptSig{Asm: asm, Hex: hex.EncodeToString(txIn.SignatureScript)}
+		}
+
+		for _, item := range txIn.Witness {
+			vin.Witness = append(vin.Witness, hex.EncodeToString(item))
+		}
+
+		result.Vin = append(result.Vin, vin)
+	}
+
+	for idx, txOut := range tx.TxOut {
+		result.Vout = append(result.Vout, btcjson.Vout{
+			Value:        btcutil.Amount(txOut.Value).ToBTC(),
+			N:            uint32(idx),
+			ScriptPubKey: n.newScriptPubKeyResult(txOut.PkScript),
+		})
+	}
+
+	if height >= 0 {
+		block := n.Chain.GetBlock(height)
+
+		result.BlockHash = block.BlockHash().String()
+		result.Confirmations = uint64(n.Chain.Height() - height + 1)
+		result.Time = block.Header.Timestamp.Unix()
+		result.Blocktime = result.Time
+	}
+
+	return result, nil
+}
+
+// newTxOutResult creates the result of gettxout
+func (n *Node) newTxOutResult(txOut *wire.TxOut, confirmations int64, coinbase bool) *btcjson.GetTxOutResult {
+	return &btcjson.GetTxOutResult{
+		BestBlock:     n.Chain.Tip().BlockHash().String(),
+		Confirmations: confirmations,
+		Value:         btcutil.Amount(txOut.Value).ToBTC(),
+		ScriptPubKey:  n.newScriptPubKeyResult(txOut.PkScript),
+		Coinbase:      coinbase,
+	}
+}
+
+// newScriptPubKeyResult creates the decoded result of the given pk script
+func (n *Node) newScriptPubKeyResult(pkScript []byte) btcjson.ScriptPubKeyResult {
+	asm, _ := txscript.DisasmString(pkScript)
+	class, addrs, reqSigs, _ := txscript.ExtractPkScriptAddrs(pkScript, n.NetParams)
+
+	result := btcjson.ScriptPubKeyResult{
+		Asm:     asm,
+		Hex:     hex.EncodeToString(pkScript),
+		ReqSigs: int32(reqSigs),
+		Type:    class.String(),
+	}
+
+	for _, addr := range addrs {
+		result.Addresses = append(result.Addresses, addr.EncodeAddress())
+	}
+
+	return result
+}
+
+// parseParam unmarshals the param at the given index into v, returning false if the param is absent or null
+func parseParam(params []json.RawMessage, idx int, v interface{}) (bool, error) {
+	if idx >= len(params) || string(params[idx]) == "null" {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(params[idx], v); err != nil {
+		return false, newRPCError(RPC_TYPE_ERROR, "invalid param %d: %v", idx, err)
+	}
+
+	return true, nil
+}
+
+// requireParam unmarshals the required param at the given index into v
+func requireParam(params []json.RawMessage, idx int, v interface{}) error {
+	ok, err := parseParam(params, idx, v)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return newRPCError(RPC_INVALID_PARAMETER, "param %d missing", idx)
+	}
+
+	return nil
+}
+
+// parseVerbosity parses the verbosity param given as either the number or the bool
+func parseVerbosity(params []json.RawMessage, idx int, defaultVerbosity int) (int, error) {
+	var verbosity interface{}
+	ok, err := parseParam(params, idx, &verbosity)
+	if err != nil || !ok {
+		return defaultVerbosity, err
+	}
+
+	switch v := verbosity.(type) {
+	case bool:
+		if v {
+			return 1, nil
+		}
+
+		return 0, nil
+
+	case float64:
+		if v < 0 || v > 2 {
+			return 0, newRPCError(RPC_INVALID_PARAMETER, "invalid verbosity: %v", v)
+		}
+
+		return int(v), nil
+
+	default:
+		return 0, newRPCError(RPC_TYPE_ERROR, "invalid verbosity: %v", v)
+	}
+}
+
+// parseHashParam parses the hash param at the given index
+func parseHashParam(params []json.RawMessage, idx int) (*chainhash.Hash, error) {
+	var s string
+	if err := requireParam(params, idx, &s); err != nil {
+		return nil, err
+	}
+
+	return parseHash(s)
+}
+
+// parseHash parses the hex encoded hash
+func parseHash(s string) (*chainhash.Hash, error) {
+	if len(s) != 2*chainhash.HashSize {
+		return nil, newRPCError(RPC_INVALID_PARAMETER, "hash must be of length 64 (not %d, for '%s')", len(s), s)
+	}
+
+	hash, err := chainhash.NewHashFromStr(s)
+	if err != nil {
+		return nil, newRPCError(RPC_INVALID_PARAMETER, "hash must be hexadecimal string (not '%s')", s)
+	}
+
+	return hash, nil
+}
+
+// parseRawTxsParam parses the param of the hex encoded txs at the given index
+func parseRawTxsParam(params []json.RawMessage, idx int) ([]*wire.MsgTx, error) {
+	var rawTxs []string
+	if err := requireParam(params, idx, &rawTxs); err != nil {
+		return nil, err
+	}
+
+	if len(rawTxs) == 0 {
+		return nil, newRPCError(RPC_INVALID_PARAMETER, "Array must contain at least one transaction")
+	}
+
+	txs := make([]*wire.MsgTx, 0, len(rawTxs))
+	for _, rawTx := range rawTxs {
+		tx, err := decodeTx(rawTx)
+		if err != nil {
+			return nil, err
+		}
+
+		txs = append(txs, tx)
+	}
+
+	return txs, nil
+}
+
+// parseDescriptor parses the scan object of scantxoutset, either the descriptor or the object holding it
+func parseDescriptor(scanObject json.RawMessage) (string, error) {
+	var descriptor string
+	if err := json.Unmarshal(scanObject, &descriptor); err == nil {
+		return descriptor, nil
+	}
+
+	var object struct {
+		Desc string `json:"desc"`
+	}
+
+	if err := json.Unmarshal(scanObject, &object); err != nil {
+		return "", newRPCError(RPC_INVALID_PARAMETER, "Scan object needs to be either a string or an object")
+	}
+
+	return object.Desc, nil
+}
+
+// parseAddrDescriptor parses the address of the addr() descriptor, with the checksum if any
+func parseAddrDescriptor(descriptor string) (string, bool) {
+	descriptor, _, _ = strings.Cut(descriptor, "#")
+
+	if !strings.HasPrefix(descriptor, "addr(") || !strings.HasSuffix(descriptor, ")") {
+		return "", false
+	}
+
+	return descriptor[len("addr(") : len(descriptor)-1], true
+}
+
+// decodeTx decodes the hex encoded tx
+func decodeTx(txHex string) (*wire.MsgTx, error) {
+	txBytes, err := hex.DecodeString(txHex)
+	if err != nil {
+		return nil, newRPCError(RPC_DESERIALIZATION_ERROR, "TX decode failed")
+	}
+
+	var tx wire.MsgTx
+	if err := tx.Deserialize(bytes.NewReader(txBytes)); err != nil {
+		return nil, newRPCError(RPC_DESERIALIZATION_ERROR, "TX decode failed")
+	}
+
+	return &tx, nil
+}
+
+// serializeHex serializes by the given function into the hex string
+func serializeHex(serialize func(w io.Writer) error) (string, error) {
+	var buf bytes.Buffer
+	if err := serialize(&buf); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf.Bytes()), nil
+}
+
+// hashStrings converts the hashes to the strings
+func hashStrings(hashes []chainhash.Hash) []string {
+	s := make([]string, 0, len(hashes))
+	for _, hash := range hashes {
+		s = append(s, hash.String())
+	}
+
+	return s
+}
+
+// toRPCError converts the given error to the rpc error
+func toRPCError(err error) *RPCError {
+	switch e := err.(type) {
+	case *RPCError:
+		return e
+
+	case *RejectError:
+		return &RPCError{Code: e.Code, Message: e.Reason}
+
+	default:
+		return &RPCError{Code: RPC_MISC_ERROR, Message: err.Error()}
+	}
+}
+
+// getDifficulty gets the difficulty of the given bits relative to the minimum difficulty of the main net
+func getDifficulty(bits uint32) float64 {
+	max := new(big.Float).SetInt(blockchain.CompactToBig(chaincfg.MainNetParams.PowLimitBits))
+	target := new(big.Float).SetInt(blockchain.CompactToBig(bits))
+
+	difficulty, _ := new(big.Float).Quo(max, target).Float64()
+
+	return difficulty
+}
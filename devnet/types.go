@@ -0,0 +1,82 @@
+package devnet
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// RPC error codes of bitcoind
+	RPC_MISC_ERROR                = -1
+	RPC_TYPE_ERROR                = -3
+	RPC_INVALID_ADDRESS_OR_KEY    = -5
+	RPC_INVALID_PARAMETER         = -8
+	RPC_DESERIALIZATION_ERROR     = -22
+	RPC_VERIFY_ERROR              = -25
+	RPC_VERIFY_REJECTED           = -26
+	RPC_VERIFY_ALREADY_IN_CHAIN   = -27
+	RPC_VERIFY_ALREADY_IN_MEMPOOL = -27
+	RPC_METHOD_NOT_FOUND          = -32601
+	RPC_INVALID_REQUEST           = -32600
+	RPC_PARSE_ERROR               = -32700
+)
+
+const (
+	// Reject reasons of bitcoind
+	REJECT_ALREADY_IN_MEMPOOL = "txn-already-in-mempool"
+	REJECT_ALREADY_KNOWN      = "txn-already-known"
+	REJECT_MISSING_INPUTS     = "bad-txns-inputs-missingorspent"
+)
+
+// IndexInfo defines the status of the index in the getindexinfo result
+type IndexInfo struct {
+	Synced          bool  `json:"synced"`
+	BestBlockHeight int64 `json:"best_block_height"`
+}
+
+// RPCRequest defines the JSON-RPC request
+type RPCRequest struct {
+	JSONRPC string            `json:"jsonrpc"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+	ID      json.RawMessage   `json:"id"`
+}
+
+// RPCResponse defines the JSON-RPC response
+type RPCResponse struct {
+	Result interface{}     `json:"result"`
+	Error  *RPCError       `json:"error"`
+	ID     json.RawMessage `json:"id"`
+}
+
+// RPCError defines the JSON-RPC error
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements error
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%d: %s", e.Code, e.Message)
+}
+
+// newRPCError creates an RPCError instance
+func newRPCError(code int, format string, args ...interface{}) *RPCError {
+	return &RPCError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// RejectError defines the error of the tx rejected by the mempool
+type RejectError struct {
+	Code   int    // rpc error code
+	Reason string // reject reason
+}
+
+// Error implements error
+func (e *RejectError) Error() string {
+	return e.Reason
+}
+
+// newRejectError creates a RejectError instance
+func newRejectError(code int, reason string) *RejectError {
+	return &RejectError{Code: code, Reason: reason}
+}
@@ -0,0 +1,107 @@
+package events
+
+import (
+	"sync"
+
+	"btc-sbt/types"
+)
+
+// Default buffer size of the subscription channel
+const DEFAULT_SUBSCRIPTION_BUFFER_SIZE = 1024
+
+// Bus dispatches the events published by the indexer to the subscribers
+type Bus struct {
+	subscriptions map[uint64]*Subscription // subscriptions
+	nextId        uint64                   // id for the next subscription
+
+	mu sync.Mutex // lock
+}
+
+// NewBus creates a new Bus instance
+func NewBus() *Bus {
+	return &Bus{
+		subscriptions: make(map[uint64]*Subscription),
+	}
+}
+
+// Subscribe subscribes to the events matching the given filter
+func (b *Bus) Subscribe(filter *types.EventFilter) *Subscription {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sub := &Subscription{
+		id:     b.nextId,
+		filter: filter,
+		events: make(chan *types.Event, DEFAULT_SUBSCRIPTION_BUFFER_SIZE),
+		bus:    b,
+	}
+
+	b.subscriptions[sub.id] = sub
+	b.nextId++
+
+	return sub
+}
+
+// Publish dispatches the given events to the matched subscribers.
+// The subscription is closed if the subscriber can not keep up with the events,
+// which is expected to catch up from the store and subscribe again
+func (b *Bus) Publish(events []*types.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for id, sub := range b.subscriptions {
+		if !sub.dispatch(events) {
+			delete(b.subscriptions, id)
+			close(sub.events)
+		}
+	}
+}
+
+// unsubscribe removes the given subscription
+func (b *Bus) unsubscribe(sub *Subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscriptions[sub.id]; ok {
+		delete(b.subscriptions, sub.id)
+		close(sub.events)
+	}
+}
+
+// Subscription represents the subscription to the events
+type Subscription struct {
+	id     uint64             // subscription id
+	filter *types.EventFilter // event filter
+	events chan *types.Event  // event channel
+	bus    *Bus               // bus
+}
+
+// Events returns the channel from which the events are received.
+// The channel is closed when unsubscribed or the subscriber falls behind
+func (s *Subscription) Events() <-chan *types.Event {
+	return s.events
+}
+
+// dispatch sends the matched events to the subscription channel without blocking.
+// Returns false if the channel is full, true otherwise
+func (s *Subscription) dispatch(events []*types.Event) bool {
+	for _, event := range events {
+		if !s.filter.Match(event) {
+			continue
+		}
+
+		select {
+		case s.events <- event:
+
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// Unsubscribe cancels the subscription
+func (s *Subscription) Unsubscribe() {
+	s.bus.unsubscribe(s)
+}
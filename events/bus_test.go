@@ -0,0 +1,52 @@
+package events
+
+import (
+	"testing"
+
+	"btc-sbt/types"
+)
+
+func TestPublishFilter(t *testing.T) {
+	bus := NewBus()
+
+	sub := bus.Subscribe(&types.EventFilter{Symbol: "abc"})
+	defer sub.Unsubscribe()
+
+	bus.Publish([]*types.Event{
+		{Type: types.EVENT_ISSUE, Symbol: "abc"},
+		{Type: types.EVENT_ISSUE, Symbol: "xyz"},
+	})
+
+	if event := <-sub.Events(); event.Symbol != "abc" {
+		t.Fatalf("got symbol %s; want abc", event.Symbol)
+	}
+
+	if len(sub.Events()) != 0 {
+		t.Fatalf("got %d pending events; want 0", len(sub.Events()))
+	}
+}
+
+func TestPublishFallenBehind(t *testing.T) {
+	bus := NewBus()
+
+	sub := bus.Subscribe(&types.EventFilter{})
+
+	events := make([]*types.Event, DEFAULT_SUBSCRIPTION_BUFFER_SIZE+1)
+	for i := range events {
+		events[i] = types.NewBlockEvent(int64(i), "")
+	}
+
+	bus.Publish(events)
+
+	received := 0
+	for range sub.Events() {
+		received++
+	}
+
+	if received != DEFAULT_SUBSCRIPTION_BUFFER_SIZE {
+		t.Fatalf("got %d events before closed; want %d", received, DEFAULT_SUBSCRIPTION_BUFFER_SIZE)
+	}
+
+	// no-op once closed by the bus
+	sub.Unsubscribe()
+}
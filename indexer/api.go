@@ -2,13 +2,17 @@ package indexer
 
 import (
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
 
+	"btc-sbt/decoder"
+	"btc-sbt/events"
 	"btc-sbt/types"
 )
 
-// GetSBTs queries all the SBTs
-func (i *Indexer) GetAllSBTs() ([]*types.SBTs, error) {
-	return i.StateMachine.GetAllSBTs()
+// QuerySBTs queries the SBTs page by the given query
+func (i *Indexer) QuerySBTs(query *types.SBTsQuery) ([]*types.SBTs, string, error) {
+	return i.StateMachine.QuerySBTs(query)
 }
 
 // GetSBTs queries the SBTs by the given symbol
@@ -16,14 +20,29 @@ func (i *Indexer) GetSBTs(symbol string) (*types.SBTs, error) {
 	return i.StateMachine.GetSBTs(symbol)
 }
 
+// GetSBTsAt queries the SBTs by the given symbol at the given block height, 0 for the latest
+func (i *Indexer) GetSBTsAt(symbol string, blockHeight int64) (*types.SBTs, error) {
+	return i.StateMachine.GetSBTsAt(symbol, blockHeight)
+}
+
 // GetSBT queries the SBT token by the given symbol and token id
 func (i *Indexer) GetSBT(symbol string, id uint64) (*types.SBT, error) {
 	return i.StateMachine.GetSBT(symbol, id)
 }
 
-// GetOwnedSBTs queries the SBT tokens owned by the given owner
-func (i *Indexer) GetOwnedSBTs(owner string) ([]*types.CompactSBT, error) {
-	return i.StateMachine.GetOwnedSBTs(owner)
+// GetSBTAt queries the SBT token by the given symbol and token id at the given block height, 0 for the latest
+func (i *Indexer) GetSBTAt(symbol string, id uint64, blockHeight int64) (*types.SBT, error) {
+	return i.StateMachine.GetSBTAt(symbol, id, blockHeight)
+}
+
+// QueryOwnedSBTs queries the page of the SBT tokens owned by the given owner
+func (i *Indexer) QueryOwnedSBTs(query *types.OwnedSBTsQuery) ([]*types.CompactSBT, string, error) {
+	return i.StateMachine.QueryOwnedSBTs(query)
+}
+
+// QueryTokens queries the page of the SBT tokens of the given symbol
+func (i *Indexer) QueryTokens(query *types.TokensQuery) ([]*types.SBT, string, error) {
+	return i.StateMachine.QueryTokens(query)
 }
 
 // GetOwnedSBT queries the specified SBT token owned by the given owner
@@ -31,6 +50,26 @@ func (i *Indexer) GetOwnedSBT(owner string, symbol string) (*types.CompactSBT, e
 	return i.StateMachine.GetOwnedSBT(owner, symbol)
 }
 
+// Decode decodes the protocol operations from the given tx and simulates them against the current state
+func (i *Indexer) Decode(tx *wire.MsgTx) (*types.DecodeResult, error) {
+	return decoder.NewDecoder(i.Parser, i.StateMachine).Decode(tx)
+}
+
+// GetEvents queries at most `limit` events matching the given filter, starting from the given cursor
+func (i *Indexer) GetEvents(cursor types.EventCursor, filter *types.EventFilter, limit int) ([]*types.Event, error) {
+	return i.StateMachine.GetEvents(cursor, filter, limit)
+}
+
+// SubscribeEvents subscribes to the events matching the given filter
+func (i *Indexer) SubscribeEvents(filter *types.EventFilter) *events.Subscription {
+	return i.EventBus.Subscribe(filter)
+}
+
+// GetTx queries the protocol operations and results of the given reveal tx, or of all the reveal txs spending the given commit tx
+func (i *Indexer) GetTx(txHash *chainhash.Hash) ([]*types.TxRecord, error) {
+	return i.StateMachine.GetTx(txHash)
+}
+
 // GetStatus returns the current status of the indexer
 func (i *Indexer) GetStatus() (any, error) {
 	return i.GetLastBlockHeight()
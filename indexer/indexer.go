@@ -10,12 +10,12 @@ import (
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 
 	"btc-sbt/config"
+	"btc-sbt/events"
 	"btc-sbt/logger"
 	"btc-sbt/params"
 	"btc-sbt/protocol"
 	"btc-sbt/stacks/client/rpcclient"
 	"btc-sbt/statemachine"
-	"btc-sbt/store"
 )
 
 // Indexer defines the indexer struct
@@ -28,6 +28,8 @@ type Indexer struct {
 	Parser       *protocol.Parser           // protocol parser
 	StateMachine *statemachine.StateMachine // state machine
 
+	EventBus *events.Bus // event bus
+
 	Logger *logrus.Logger // logger
 
 	interval time.Duration // block scanning interval
@@ -46,38 +48,25 @@ func NewIndexer(config *config.Config) (*Indexer, error) {
 		return nil, err
 	}
 
-	netParams := new(chaincfg.Params)
-	protoParams := new(params.Params)
-
-	switch config.NetVersion {
-	case 1:
-		netParams = &chaincfg.TestNet3Params
-		protoParams = &params.TestNetParams
-	case 2:
-		netParams = &chaincfg.SigNetParams
-		protoParams = &params.SigNetParams
-	default:
-		netParams = &chaincfg.MainNetParams
-		protoParams = &params.MainNetParams
-	}
+	netParams := config.Network.NetParams
+	protoParams := config.Network.Params
 
 	logger.Logger.SetLevel(logrus.Level(config.LogLevel))
 
 	parser := protocol.NewParser(netParams)
 
-	store, err := store.NewStore(config.DBPath)
+	sm, err := statemachine.Open(config.DBPath, netParams, logger.Logger)
 	if err != nil {
 		return nil, err
 	}
 
-	sm := statemachine.NewStateMachine(store, netParams, logger.Logger)
-
 	indexer := &Indexer{
 		Client:       client,
 		NetParams:    netParams,
 		Params:       protoParams,
 		Parser:       parser,
 		StateMachine: sm,
+		EventBus:     events.NewBus(),
 		Logger:       logger.Logger,
 		interval:     config.IndexerInterval,
 		stopped:      make(chan struct{}),
@@ -5,63 +5,62 @@ import (
 	"github.com/btcsuite/btcd/wire"
 
 	"btc-sbt/protocol"
-	"btc-sbt/stacks/basics"
 	sm "btc-sbt/statemachine"
+	"btc-sbt/types"
 )
 
-// parseBTCSBTProtocol parses the potential BTC-SBT protocol data in the given block
-func (i *Indexer) parseBTCSBTProtocol(blockHeight int64, block *wire.MsgBlock) error {
+// parseBTCSBTProtocol parses the potential BTC-SBT protocol data in the given block, applying the state transition to the given state machine.
+// The events emitted by the state machine are returned
+func (i *Indexer) parseBTCSBTProtocol(state *sm.StateMachine, blockHeight int64, block *wire.MsgBlock) ([]*types.Event, error) {
+	events := make([]*types.Event, 0)
+
 	for idx, tx := range block.Transactions {
-		if err := i.parseBTCSBTProtocolPerTx(tx, blockHeight, block.BlockHash(), idx); err != nil {
-			return err
+		txEvents, err := i.parseBTCSBTProtocolPerTx(state, tx, blockHeight, block.BlockHash(), idx)
+		if err != nil {
+			return nil, err
 		}
+
+		events = append(events, txEvents...)
 	}
 
-	return nil
+	return events, nil
 }
 
 // parseBTCSBTProtocolPerTx parses the potential BTC-SBT protocol data in the given tx
-func (i *Indexer) parseBTCSBTProtocolPerTx(tx *wire.MsgTx, blockHeight int64, blockHash chainhash.Hash, txIndex int) error {
-	parsedOps := make([]protocol.Operation, 0)
+func (i *Indexer) parseBTCSBTProtocolPerTx(state *sm.StateMachine, tx *wire.MsgTx, blockHeight int64, blockHash chainhash.Hash, txIndex int) ([]*types.Event, error) {
+	parsedOps, inputs := i.Parser.ParseTx(tx)
 
-	for _, in := range tx.TxIn {
-		ops := i.parseBTCSBTProtocolFromWitness(in.Witness)
-		if len(ops) > 0 {
-			parsedOps = append(parsedOps, ops...)
-			if len(parsedOps) >= protocol.BULK_OPERATION_COUNT_PER_TX {
-				parsedOps = parsedOps[0:protocol.BULK_OPERATION_COUNT_PER_TX]
+	if len(parsedOps) > 0 {
+		commitTxHashes := make([]string, 0)
 
-				break
+		for _, in := range inputs {
+			if len(in.Ops) > 0 {
+				commitTxHashes = append(commitTxHashes, in.CommitTxHash.String())
 			}
 		}
-	}
-
-	if len(parsedOps) > 0 {
-		return i.onBTCSBTProtocol(parsedOps, tx, blockHeight, blockHash, txIndex)
-	}
 
-	return nil
-}
-
-// parseBTCSBTProtocolFromWitness parses the potential BTC-SBT protocol data from the given witness
-func (i *Indexer) parseBTCSBTProtocolFromWitness(witness wire.TxWitness) []protocol.Operation {
-	if basics.IsTapscriptWitness(witness) {
-		envelope := i.Parser.GetEnvelope(witness[1])
-		if envelope != nil {
-			return i.Parser.GetOps(envelope.Payload)
-		}
+		return i.onBTCSBTProtocol(state, parsedOps, commitTxHashes, tx, blockHeight, blockHash, txIndex)
 	}
 
-	return nil
+	return nil, nil
 }
 
 // onBTCSBTProtocol performs the corresponding handling for the given protocol operations
-func (i *Indexer) onBTCSBTProtocol(ops protocol.Operations, tx *wire.MsgTx, blockHeight int64, blockHash chainhash.Hash, txIndex int) error {
+func (i *Indexer) onBTCSBTProtocol(state *sm.StateMachine, ops protocol.Operations, commitTxHashes []string, tx *wire.MsgTx, blockHeight int64, blockHash chainhash.Hash, txIndex int) ([]*types.Event, error) {
 	i.Logger.Infof("protocol ops found, block: %d, tx: %s", blockHeight, tx.TxHash())
 
 	context := i.buildSMContext(blockHeight, blockHash, txIndex, tx, ops.ContainIssue())
 
-	return i.StateMachine.HandleOps(context, ops)
+	if err := state.HandleOps(context, ops); err != nil {
+		return nil, err
+	}
+
+	record := types.NewTxRecord(tx.TxHash().String(), commitTxHashes, blockHeight, blockHash.String(), txIndex, context.Results)
+	if err := state.SetTxRecord(record); err != nil {
+		return nil, err
+	}
+
+	return context.Events, nil
 }
 
 // buildSMContext builds the execution context for the state machine
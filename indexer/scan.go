@@ -5,6 +5,9 @@ import (
 
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
+
+	sm "btc-sbt/statemachine"
+	"btc-sbt/types"
 )
 
 // startScanner starts to scan blocks
@@ -54,41 +57,63 @@ func (i *Indexer) scanBlocksByRange(startHeight int64, endHeight int64) {
 	}
 }
 
-// onBlock handles the given block
+// onBlock handles the given block.
+// The state transition, the events and the indexing status of the block are persisted atomically before the events are published
 func (i *Indexer) onBlock(height int64, block *wire.MsgBlock) {
 	if i.isReorged(block) {
 		i.Logger.Fatalf("chain reorg detected, please reindex. The previous block hash of the indexing block: %s, last indexed block hash: %s", block.Header.PrevBlock, i.lastBlockHash)
 	}
 
-	if err := i.parseBTCSBTProtocol(height, block); err != nil {
-		i.Logger.Fatalf("parsing failed, block height: %d, block hash: %s, err: %v", height, block.BlockHash(), err)
-	}
+	blockHash := block.BlockHash()
 
-	if err := i.saveStatus(height, block.BlockHash()); err != nil {
-		i.Logger.Fatalf("failed to save the indexing status: %v", err)
+	batch := i.StateMachine.NewBatch()
+	defer batch.Store.Close()
+
+	events, err := i.parseBTCSBTProtocol(batch, height, block)
+	if err != nil {
+		i.Logger.Fatalf("parsing failed, block height: %d, block hash: %s, err: %v", height, blockHash, err)
 	}
 
-	i.Logger.Infof("block indexed: %d", height)
-}
+	events = append(events, types.NewBlockEvent(height, blockHash.String()))
 
-// saveStatus saves the current indexing status
-func (i *Indexer) saveStatus(blockHeight int64, blockHash chainhash.Hash) error {
-	// store status
+	if err := i.saveEvents(batch, events); err != nil {
+		i.Logger.Fatalf("failed to save the events: %v", err)
+	}
 
-	if err := i.StateMachine.SetLastBlockHeight(blockHeight); err != nil {
-		return err
+	if err := i.saveStatus(batch, height, blockHash); err != nil {
+		i.Logger.Fatalf("failed to save the indexing status: %v", err)
 	}
 
-	if err := i.StateMachine.SetLastBlockHash(blockHash); err != nil {
-		return err
+	if err := batch.Store.Commit(); err != nil {
+		i.Logger.Fatalf("failed to commit the block, height: %d, block hash: %s, err: %v", height, blockHash, err)
 	}
 
 	// in-memory status
 
-	i.lastBlockHeight = blockHeight
+	i.lastBlockHeight = height
 	i.lastBlockHash = &blockHash
 
-	return nil
+	i.EventBus.Publish(events)
+
+	i.Logger.Infof("block indexed: %d", height)
+}
+
+// saveEvents indexes and saves the events emitted in the block
+func (i *Indexer) saveEvents(state *sm.StateMachine, events []*types.Event) error {
+	for idx, event := range events {
+		event.Index = uint32(idx)
+	}
+
+	return state.SetEvents(events)
+}
+
+// saveStatus saves the current indexing status
+func (i *Indexer) saveStatus(state *sm.StateMachine, blockHeight int64, blockHash chainhash.Hash) error {
+	if err := state.SetLastBlockHeight(blockHeight); err != nil {
+		return err
+	}
+
+	return state.SetLastBlockHash(blockHash)
 }
 
 // isReorged returns true if the given block does not point to the last indexed block, false otherwise
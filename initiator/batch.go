@@ -0,0 +1,485 @@
+package initiator
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+
+	"btc-sbt/authority"
+	"btc-sbt/journal"
+	"btc-sbt/protocol"
+	"btc-sbt/stacks/basics"
+	"btc-sbt/stacks/taproot/inscriber"
+)
+
+// ReadMintsFromCSV reads the mints of the given symbol from the CSV in the form of `owner,metadata,authsig`, e.g. produced by sign-mint.
+// The header is optional. The mints are validated and each owner is allowed once as one SBT is owned per symbol
+func (i *Initiator) ReadMintsFromCSV(symbol string, in io.Reader) ([]*protocol.MintOperation, error) {
+	reader := csv.NewReader(in)
+	reader.FieldsPerRecord = -1
+
+	mints := make([]*protocol.MintOperation, 0)
+	owners := make(map[string]int)
+
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), authority.SIGNED_MINTS_CSV_HEADER[0]) {
+			continue
+		}
+
+		if len(record) != len(authority.SIGNED_MINTS_CSV_HEADER) {
+			return nil, fmt.Errorf("line %d: expected owner, metadata and authsig, %d fields given", line, len(record))
+		}
+
+		owner := strings.TrimSpace(record[0])
+
+		op := protocol.NewMintOperation(symbol, owner, strings.TrimSpace(record[2]), record[1])
+		if err := op.Validate(i.NetParams); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		// the owners are compared in lowercase as the bech32 addresses are case insensitive
+		if prevLine, ok := owners[strings.ToLower(owner)]; ok {
+			return nil, fmt.Errorf("line %d: duplicate owner %s on line %d", line, owner, prevLine)
+		}
+
+		owners[strings.ToLower(owner)] = line
+		mints = append(mints, op)
+	}
+
+	return mints, nil
+}
+
+// GroupMints groups the given mints into the reveal txs by the operation count and the payload size.
+// Returns the end indices of the groups in order
+func GroupMints(mints []*protocol.MintOperation) ([]int, error) {
+	ends := make([]int, 0)
+
+	start := 0
+	for idx := range mints {
+		size, err := getPayloadSize(mints[start : idx+1])
+		if err != nil {
+			return nil, err
+		}
+
+		if idx+1-start <= protocol.BULK_OPERATION_COUNT_PER_TX && size <= MAX_BATCH_PAYLOAD_SIZE {
+			continue
+		}
+
+		if idx > start {
+			ends = append(ends, idx)
+			start = idx
+
+			size, err = getPayloadSize(mints[idx : idx+1])
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if size > MAX_BATCH_PAYLOAD_SIZE {
+			return nil, fmt.Errorf("mint %d exceeds the max payload size: %d > %d", idx+1, size, MAX_BATCH_PAYLOAD_SIZE)
+		}
+	}
+
+	if len(mints) > 0 {
+		ends = append(ends, len(mints))
+	}
+
+	return ends, nil
+}
+
+// MintBatch mints the given mints in batches at the given fee rate in sat/vB, with up to MAX_REVEALS_PER_COMMIT reveal txs funded by one commit tx.
+// The signed txs are recorded to the progress before broadcasting, so that the batch minting can be resumed from the progress.
+// The txs signed but not broadcast in the previous run are rebroadcast first.
+// Once the other utxos run out, the commit tx is funded by the change of the previous one within MAX_CHAINED_TXS,
+// beyond which the last commit tx is waited to be confirmed before continuing
+func (i *Initiator) MintBatch(key *secp256k1.PrivateKey, addr btcutil.Address, mints []*protocol.MintOperation, progress *BatchProgress, feeRate float64) error {
+	if err := i.rebroadcast(progress); err != nil {
+		return err
+	}
+
+	next := progress.Next()
+	if next == len(mints) {
+		return nil
+	}
+
+	ends, err := GroupMints(mints[next:])
+	if err != nil {
+		return err
+	}
+
+	utxos, err := i.getBatchUtxos(addr, progress)
+	if err != nil {
+		return err
+	}
+
+	inscriber := i.newInscriber()
+
+	// change outputs of the last commit tx, and the number of the unconfirmed txs descending from the first commit tx of the chain
+	var change []*basics.UTXO
+	chainedTxs := 0
+
+	start := next
+
+	for len(ends) > 0 {
+		count := len(ends)
+		if count > MAX_REVEALS_PER_COMMIT {
+			count = MAX_REVEALS_PER_COMMIT
+		}
+
+		chained := false
+
+		inscription, reveals, err := i.buildMintBatch(addr, utxos, mints, start, next, ends[:count], feeRate)
+		if err != nil && len(change) > 0 {
+			if room := i.getChainRoom(chainedTxs); count > room {
+				count = room
+			}
+
+			if count == 0 {
+				lastCommit := progress.Commits[len(progress.Commits)-1]
+
+				i.Logger.Infof("Waiting for commit tx %s to be confirmed before chaining more txs", lastCommit.TxID)
+
+				if utxos, err = i.waitForBatchUtxos(addr, progress, lastCommit); err != nil {
+					return err
+				}
+
+				change, chainedTxs = nil, 0
+
+				continue
+			}
+
+			chained = true
+
+			inscription, reveals, err = i.buildMintBatch(addr, append(utxos, change...), mints, start, next, ends[:count], feeRate)
+		}
+
+		from := next + ends[count-1]
+		if err != nil {
+			return fmt.Errorf("failed to fund mints %d-%d: %v; wait for the broadcast txs to be confirmed and rerun to resume", start+1, from, err)
+		}
+
+		if !i.Config.RBF {
+			inscription.DisableRBF()
+		}
+
+		if err := inscriber.SignCommitTx(key, addr, inscription); err != nil {
+			return err
+		}
+
+		commit, err := newBatchCommit(inscription, reveals)
+		if err != nil {
+			return err
+		}
+
+		txs := []*wire.MsgTx{inscription.CommitTx}
+		for _, reveal := range inscription.Reveals {
+			txs = append(txs, reveal.Tx)
+		}
+
+		// nothing is recorded or sent if any tx is rejected
+		if err := i.Broadcaster.TestAccept(txs); err != nil {
+			return fmt.Errorf("mints %d-%d rejected: %v", start+1, from, err)
+		}
+
+		entry, err := journal.NewEntry(inscription.CommitTx, inscription.Reveals)
+		if err != nil {
+			return err
+		}
+
+		if err := i.Journal.Write(entry); err != nil {
+			return fmt.Errorf("failed to write journal: %v", err)
+		}
+
+		progress.Commits = append(progress.Commits, commit)
+		if err := progress.Save(); err != nil {
+			return err
+		}
+
+		if err := i.broadcastBatchCommit(commit); err != nil {
+			return err
+		}
+
+		if err := progress.Save(); err != nil {
+			return err
+		}
+
+		i.Logger.Infof("Mints %d-%d of %d broadcast, commit tx: %s, fees: %d sats", start+1, from, len(mints), commit.TxID, inscription.CommitFee()+inscription.RevealFee())
+
+		utxos = excludeSpentUtxos(utxos, inscription.CommitTx)
+
+		if !chained {
+			chainedTxs = 0
+		}
+
+		chainedTxs += len(txs)
+
+		if change, err = getChangeUtxos(inscription.CommitTx, len(reveals), addr); err != nil {
+			return err
+		}
+
+		ends = ends[count:]
+		start = from
+	}
+
+	return nil
+}
+
+// buildMintBatch builds the commit tx funding a reveal tx per group of the mints from start, where the groups end at the given ends relative to next.
+// Returns the inscription along with the mint range of each reveal tx
+func (i *Initiator) buildMintBatch(addr btcutil.Address, utxos []*basics.UTXO, mints []*protocol.MintOperation, start int, next int, ends []int, feeRate float64) (*inscriber.Inscription, []*BatchReveal, error) {
+	envelopes := make([][]byte, 0, len(ends))
+	revealTxOuts := make([][]*wire.TxOut, 0, len(ends))
+	reveals := make([]*BatchReveal, 0, len(ends))
+
+	from := start
+	for _, end := range ends {
+		to := next + end
+
+		ops := make([]protocol.Operation, 0, to-from)
+		for _, mint := range mints[from:to] {
+			ops = append(ops, mint)
+		}
+
+		envelope, err := GetEnvelopeFromOps(ops)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		envelopes = append(envelopes, envelope)
+		revealTxOuts = append(revealTxOuts, []*wire.TxOut{wire.NewTxOut(0, MINT_OUTPUT_SCRIPT_WITH_PROTOCOL)})
+		reveals = append(reveals, &BatchReveal{From: from, To: to})
+
+		from = to
+	}
+
+	inscription, err := i.newInscriber().BuildBatch(addr, utxos, envelopes, revealTxOuts, feeRate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return inscription, reveals, nil
+}
+
+// getChainRoom gets the number of the reveal txs which can be funded by the commit tx chained after the given number of the unconfirmed txs.
+// Nothing is chained if the unconfirmed utxos are excluded by the coin selection
+func (i *Initiator) getChainRoom(chainedTxs int) int {
+	if i.CoinSelection != nil && i.CoinSelection.ExcludeUnconfirmed {
+		return 0
+	}
+
+	room := MAX_CHAINED_TXS - chainedTxs - 1
+	if room < 0 {
+		return 0
+	}
+
+	return room
+}
+
+// waitForBatchUtxos waits until the given commit tx is confirmed, and then gets the available utxos again
+func (i *Initiator) waitForBatchUtxos(addr btcutil.Address, progress *BatchProgress, commit *BatchCommit) ([]*basics.UTXO, error) {
+	commitTx, err := basics.DecodeTx(commit.Tx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := i.WaitForConfirmations(context.Background(), commitTx, 1); err != nil {
+		return nil, err
+	}
+
+	return i.getBatchUtxos(addr, progress)
+}
+
+// rebroadcast broadcasts the txs signed but not broadcast in the previous run
+func (i *Initiator) rebroadcast(progress *BatchProgress) error {
+	for _, commit := range progress.Commits {
+		if commit.Status == BATCH_STATUS_BROADCAST {
+			continue
+		}
+
+		if err := i.broadcastBatchCommit(commit); err != nil {
+			return err
+		}
+
+		if err := progress.Save(); err != nil {
+			return err
+		}
+
+		i.Logger.Infof("Pending commit tx %s rebroadcast", commit.TxID)
+	}
+
+	return nil
+}
+
+// broadcastBatchCommit broadcasts the commit tx and then the reveal txs, skipping the txs already known by the node
+func (i *Initiator) broadcastBatchCommit(commit *BatchCommit) error {
+	txHexes := []string{commit.Tx}
+	for _, reveal := range commit.Reveals {
+		txHexes = append(txHexes, reveal.Tx)
+	}
+
+	txs := make([]*wire.MsgTx, 0, len(txHexes))
+	for _, txHex := range txHexes {
+		tx, err := basics.DecodeTx(txHex)
+		if err != nil {
+			return err
+		}
+
+		txs = append(txs, tx)
+	}
+
+	mode, err := i.Broadcaster.Submit(txs)
+	if err != nil {
+		return err
+	}
+
+	i.Logger.Debugf("Commit tx %s and %d reveal txs broadcast in %s mode", commit.TxID, len(commit.Reveals), mode)
+
+	commit.Status = BATCH_STATUS_BROADCAST
+
+	i.markJournalBroadcast(commit.TxID)
+
+	return nil
+}
+
+// markJournalBroadcast marks the journal entry of the given commit tx as broadcast
+func (i *Initiator) markJournalBroadcast(commitTxID string) {
+	entry, err := i.Journal.Get(commitTxID)
+	if err == nil {
+		entry.Status = journal.STATUS_BROADCAST
+		err = i.Journal.Write(entry)
+	}
+
+	if err != nil {
+		i.Logger.Warnf("Failed to update journal of commit tx %s: %v", commitTxID, err)
+	}
+}
+
+// getBatchUtxos gets the available utxos of the given address excluding the ones spent by the recorded commit txs,
+// which may not be reflected by the utxo provider yet
+func (i *Initiator) getBatchUtxos(addr btcutil.Address, progress *BatchProgress) ([]*basics.UTXO, error) {
+	utxos, err := i.UTXOProvider.GetUTXOs(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, commit := range progress.Commits {
+		tx, err := basics.DecodeTx(commit.Tx)
+		if err != nil {
+			return nil, err
+		}
+
+		utxos = excludeSpentUtxos(utxos, tx)
+	}
+
+	if len(utxos) == 0 {
+		return nil, fmt.Errorf("no utxos available for address: %s", addr)
+	}
+
+	return utxos, nil
+}
+
+// newBatchCommit creates the BatchCommit from the inscription whose commit tx is signed.
+// The reveal txs are signed against the commit tx
+func newBatchCommit(inscription *inscriber.Inscription, reveals []*BatchReveal) (*BatchCommit, error) {
+	commitTxHash := inscription.CommitTx.TxHash()
+
+	commitTxHex, err := basics.EncodeTx(inscription.CommitTx)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := inscriber.SignReveals(inscription.CommitTx, inscription.Reveals); err != nil {
+		return nil, err
+	}
+
+	for idx, reveal := range inscription.Reveals {
+		revealTxHex, err := basics.EncodeTx(reveal.Tx)
+		if err != nil {
+			return nil, err
+		}
+
+		reveals[idx].Tx = revealTxHex
+		reveals[idx].TxID = reveal.Tx.TxHash().String()
+	}
+
+	return &BatchCommit{
+		Status:  BATCH_STATUS_SIGNED,
+		Tx:      commitTxHex,
+		TxID:    commitTxHash.String(),
+		Reveals: reveals,
+	}, nil
+}
+
+// getChangeUtxos gets the unconfirmed change outputs of the given commit tx, which pay to the address following the outputs funding the reveal txs
+func getChangeUtxos(commitTx *wire.MsgTx, revealCount int, addr btcutil.Address) ([]*basics.UTXO, error) {
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	commitTxHash := commitTx.TxHash()
+
+	utxos := make([]*basics.UTXO, 0)
+	for idx := revealCount; idx < len(commitTx.TxOut); idx++ {
+		txOut := commitTx.TxOut[idx]
+		if !bytes.Equal(txOut.PkScript, pkScript) {
+			continue
+		}
+
+		utxo := basics.NewUTXO(&commitTxHash, uint32(idx), txOut.Value, txOut.PkScript)
+		utxo.Unconfirmed = true
+
+		utxos = append(utxos, utxo)
+	}
+
+	return utxos, nil
+}
+
+// excludeSpentUtxos excludes the utxos spent by the given tx
+func excludeSpentUtxos(utxos []*basics.UTXO, tx *wire.MsgTx) []*basics.UTXO {
+	spent := make(map[wire.OutPoint]bool)
+	for _, in := range tx.TxIn {
+		spent[in.PreviousOutPoint] = true
+	}
+
+	remaining := make([]*basics.UTXO, 0, len(utxos))
+	for _, utxo := range utxos {
+		if !spent[*utxo.GetOutPoint()] {
+			remaining = append(remaining, utxo)
+		}
+	}
+
+	return remaining
+}
+
+// getPayloadSize gets the payload size of the given mints
+func getPayloadSize(mints []*protocol.MintOperation) (int, error) {
+	ops := make([]protocol.Operation, 0, len(mints))
+	for _, mint := range mints {
+		ops = append(ops, mint)
+	}
+
+	payload, err := MarshalOps(ops)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(payload), nil
+}
@@ -0,0 +1,101 @@
+package initiator
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/config"
+	"btc-sbt/stacks/basics"
+)
+
+func newTestAddress(t *testing.T) string {
+	return newTestTaprootAddress(t).EncodeAddress()
+}
+
+func newTestTaprootAddress(t *testing.T) btcutil.Address {
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate the key: %v", err)
+	}
+
+	addr, err := basics.GetAddress(key, basics.Taproot, &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("failed to get the address: %v", err)
+	}
+
+	return addr
+}
+
+func TestReadMintsFromCSVDuplicateOwner(t *testing.T) {
+	i := &Initiator{NetParams: &chaincfg.RegressionNetParams}
+
+	owner := newTestAddress(t)
+	csv := fmt.Sprintf("owner,metadata,authsig\n%s,,\n%s,,\n", owner, strings.ToUpper(owner))
+
+	if _, err := i.ReadMintsFromCSV("abc", strings.NewReader(csv)); err == nil || !strings.Contains(err.Error(), "duplicate owner") {
+		t.Fatalf("got %v; want the duplicate owner error", err)
+	}
+
+	csv = fmt.Sprintf("%s,,\n%s,,\n", owner, newTestAddress(t))
+
+	mints, err := i.ReadMintsFromCSV("abc", strings.NewReader(csv))
+	if err != nil || len(mints) != 2 {
+		t.Fatalf("got %d mints, %v; want 2", len(mints), err)
+	}
+}
+
+func TestGetChangeUtxos(t *testing.T) {
+	addr := newTestTaprootAddress(t)
+
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("failed to get the script: %v", err)
+	}
+
+	tx := wire.NewMsgTx(basics.TxVersion)
+	tx.AddTxOut(wire.NewTxOut(1000, pkScript)) // funding the reveal tx, to the same script by chance
+	tx.AddTxOut(wire.NewTxOut(1000, []byte{txscript.OP_TRUE}))
+	tx.AddTxOut(wire.NewTxOut(5000, pkScript))
+
+	utxos, err := getChangeUtxos(tx, 2, addr)
+	if err != nil {
+		t.Fatalf("failed to get the change: %v", err)
+	}
+
+	if len(utxos) != 1 || utxos[0].Index != 2 || utxos[0].Value != 5000 || !utxos[0].Unconfirmed || utxos[0].Hash != tx.TxHash() {
+		t.Fatalf("got %+v; want the unconfirmed output 2", utxos)
+	}
+}
+
+func TestGetChainRoom(t *testing.T) {
+	i := &Initiator{Config: &config.Config{}}
+
+	tests := []struct {
+		chainedTxs int
+		want       int
+	}{
+		{0, MAX_CHAINED_TXS - 1},
+		{MAX_REVEALS_PER_COMMIT + 1, 0},
+		{10, MAX_CHAINED_TXS - 11},
+		{MAX_CHAINED_TXS + 5, 0},
+	}
+
+	for _, test := range tests {
+		if got := i.getChainRoom(test.chainedTxs); got != test.want {
+			t.Errorf("chained %d: got %d; want %d", test.chainedTxs, got, test.want)
+		}
+	}
+
+	i.CoinSelection = &basics.CoinSelection{ExcludeUnconfirmed: true}
+
+	if got := i.getChainRoom(0); got != 0 {
+		t.Errorf("excluding unconfirmed: got %d; want 0", got)
+	}
+}
@@ -0,0 +1,387 @@
+package initiator
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+
+	"btc-sbt/journal"
+	"btc-sbt/stacks/basics"
+	"btc-sbt/stacks/taproot/inscriber"
+)
+
+// Bump bumps the fee of the journaled commit tx and reveal txs to the given fee rate.
+// The commit tx is replaced by RBF if unconfirmed, otherwise the unconfirmed reveal txs are bumped.
+// Returns the journal entry of the commit tx in effect
+func (i *Initiator) Bump(entry *journal.Entry, key *secp256k1.PrivateKey, addr btcutil.Address, feeRate float64) (*journal.Entry, error) {
+	if entry.Status == journal.STATUS_REPLACED {
+		return nil, fmt.Errorf("commit tx %s already replaced by %s", entry.CommitTxID, entry.ReplacedBy)
+	}
+
+	status, err := i.GetTxStatus(entry.CommitTxID, entry.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	switch status {
+	case TX_STATUS_UNKNOWN:
+		return nil, fmt.Errorf("commit tx %s unknown by the node; run recover rebroadcast first", entry.CommitTxID)
+
+	case TX_STATUS_MEMPOOL:
+		return i.BumpCommit(entry, key, addr, feeRate)
+	}
+
+	if err := i.BumpReveals(entry, key, addr, feeRate); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// BumpCommit replaces the unconfirmed commit tx by RBF at the given fee rate, funded by the utxos of the given address.
+// The commit outputs commit to the same tapscripts, so the reveal txs are signed against the new commit tx and the ops parse the same way.
+// The new commit tx is journaled as a new entry before broadcasting, and the replaced entry is marked as such
+func (i *Initiator) BumpCommit(entry *journal.Entry, key *secp256k1.PrivateKey, addr btcutil.Address, feeRate float64) (*journal.Entry, error) {
+	commitTx, err := entry.GetCommitTx()
+	if err != nil {
+		return nil, err
+	}
+
+	if !signalsRBF(commitTx) {
+		return nil, fmt.Errorf("commit tx %s does not signal RBF and cannot be replaced", entry.CommitTxID)
+	}
+
+	commitUtxos, err := i.getSpentUtxos(commitTx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	reveals := make([]*inscriber.Reveal, 0, len(entry.Reveals))
+	replacedTxIDs := []string{entry.CommitTxID}
+
+	for _, revealEntry := range entry.Reveals {
+		reveal, err := revealEntry.GetReveal()
+		if err != nil {
+			return nil, err
+		}
+
+		reveals = append(reveals, reveal)
+		replacedTxIDs = append(replacedTxIDs, revealEntry.TxID, revealEntry.ChildTxID)
+	}
+
+	fundingUtxos, err := i.UTXOProvider.GetUTXOs(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	fundingUtxos = excludeTxOutputs(excludeSpentUtxos(fundingUtxos, commitTx), replacedTxIDs)
+
+	inscriber := i.newInscriber()
+
+	inscription, err := inscriber.RebuildCommit(addr, commitUtxos, reveals, fundingUtxos, feeRate)
+	if err != nil {
+		return nil, err
+	}
+
+	// BIP125: the replacement pays for the replaced txs along with its own relay
+	mempoolEntry, err := i.RPCClient.GetMempoolEntry(entry.CommitTxID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mempool entry of commit tx %s: %v", entry.CommitTxID, err)
+	}
+
+	replacedFee, err := btcutil.NewAmount(mempoolEntry.Fees.Descendant)
+	if err != nil {
+		return nil, err
+	}
+
+	commitVSize := basics.GetTxVirtualSize(inscription.CommitTx, inscription.CommitUtxos, false)
+	if minFee := getReplacementMinFee(int64(replacedFee), commitVSize); inscription.CommitFee() < minFee {
+		return nil, fmt.Errorf("commit fee of %d sats at %v sat/vB insufficient to replace the txs paying %d sats, at least %d sats required; raise the fee rate",
+			inscription.CommitFee(), feeRate, int64(replacedFee), minFee)
+	}
+
+	if err := inscriber.SignCommitTx(key, addr, inscription); err != nil {
+		return nil, err
+	}
+
+	commitTxHash := inscription.CommitTx.TxHash()
+
+	for _, reveal := range inscription.Reveals {
+		if err := reveal.Sign(commitTxHash); err != nil {
+			return nil, err
+		}
+	}
+
+	newEntry, err := journal.NewEntry(inscription.CommitTx, inscription.Reveals)
+	if err != nil {
+		return nil, err
+	}
+
+	newEntry.Replaces = entry.CommitTxID
+
+	if err := i.Journal.Write(newEntry); err != nil {
+		return nil, fmt.Errorf("failed to write journal: %v", err)
+	}
+
+	if _, err := i.RPCClient.SendRawTransaction(inscription.CommitTx, false); err != nil {
+		return nil, fmt.Errorf("failed to broadcast replacement commit tx: %v", err)
+	}
+
+	entry.Status = journal.STATUS_REPLACED
+	entry.ReplacedBy = newEntry.CommitTxID
+
+	if err := i.Journal.Write(entry); err != nil {
+		i.Logger.Warnf("Failed to update journal of commit tx %s: %v", entry.CommitTxID, err)
+	}
+
+	i.Logger.Infof("Commit tx %s replaced by %s, fee: %d sats", entry.CommitTxID, newEntry.CommitTxID, inscription.CommitFee())
+
+	for idx, reveal := range inscription.Reveals {
+		if _, err := i.RPCClient.SendRawTransaction(reveal.Tx, false); err != nil {
+			return nil, fmt.Errorf("failed to broadcast reveal tx %d: %v; run recover for commit tx %s", idx, err, newEntry.CommitTxID)
+		}
+	}
+
+	newEntry.Status = journal.STATUS_BROADCAST
+
+	if err := i.Journal.Write(newEntry); err != nil {
+		i.Logger.Warnf("Failed to update journal of commit tx %s: %v", newEntry.CommitTxID, err)
+	}
+
+	return newEntry, nil
+}
+
+// BumpReveals bumps the unconfirmed reveal txs of the journal entry at the given fee rate, funded by the utxos of the given address.
+// A reveal tx paying the change to the address is bumped by the CPFP child spending the change, otherwise replaced by RBF
+func (i *Initiator) BumpReveals(entry *journal.Entry, key *secp256k1.PrivateKey, addr btcutil.Address, feeRate float64) error {
+	commitTx, err := entry.GetCommitTx()
+	if err != nil {
+		return err
+	}
+
+	changePkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return err
+	}
+
+	utxos, err := i.UTXOProvider.GetUTXOs(addr)
+	if err != nil {
+		return err
+	}
+
+	utxos = excludeSpentUtxos(utxos, commitTx)
+
+	replacedTxIDs := make([]string, 0, len(entry.Reveals)*2)
+	for _, revealEntry := range entry.Reveals {
+		replacedTxIDs = append(replacedTxIDs, revealEntry.TxID, revealEntry.ChildTxID)
+	}
+
+	utxos = excludeTxOutputs(utxos, replacedTxIDs)
+
+	inscriber := i.newInscriber()
+
+	bumped := 0
+
+	for idx, revealEntry := range entry.Reveals {
+		status, err := i.GetTxStatus(revealEntry.TxID, entry.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		if status == TX_STATUS_CONFIRMED {
+			continue
+		}
+
+		reveal, err := revealEntry.GetReveal()
+		if err != nil {
+			return err
+		}
+
+		var tx *wire.MsgTx
+
+		changeIndex := getOutputIndex(reveal.Tx, changePkScript)
+		if status == TX_STATUS_MEMPOOL && changeIndex >= 0 {
+			tx, err = i.bumpRevealByCPFP(inscriber, entry, revealEntry, reveal.Tx, uint32(changeIndex), key, addr, utxos, feeRate)
+		} else {
+			tx, err = i.replaceReveal(inscriber, entry, revealEntry, reveal, key, addr, utxos, feeRate)
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to bump reveal tx %d: %v", idx, err)
+		}
+
+		utxos = excludeSpentUtxos(utxos, tx)
+		bumped++
+	}
+
+	if bumped == 0 {
+		return fmt.Errorf("commit tx and reveal txs of %s already confirmed", entry.CommitTxID)
+	}
+
+	entry.Status = journal.STATUS_BROADCAST
+
+	return i.Journal.Write(entry)
+}
+
+// bumpRevealByCPFP builds the CPFP child spending the change of the reveal tx in the mempool,
+// which replaces the previous child if any, and broadcasts it after journaling
+func (i *Initiator) bumpRevealByCPFP(inscriber *inscriber.Inscriber, entry *journal.Entry, revealEntry *journal.RevealEntry, revealTx *wire.MsgTx, changeIndex uint32, key *secp256k1.PrivateKey, addr btcutil.Address, utxos []*basics.UTXO, feeRate float64) (*wire.MsgTx, error) {
+	mempoolEntry, err := i.RPCClient.GetMempoolEntry(revealEntry.TxID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get mempool entry of reveal tx %s: %v", revealEntry.TxID, err)
+	}
+
+	parentFee, err := btcutil.NewAmount(mempoolEntry.Fees.Base)
+	if err != nil {
+		return nil, err
+	}
+
+	descendantFee, err := btcutil.NewAmount(mempoolEntry.Fees.Descendant)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, txUtxos, err := inscriber.BuildCPFPTx(revealTx, int64(parentFee), changeIndex, addr, utxos, feeRate)
+	if err != nil {
+		return nil, err
+	}
+
+	// the child replaces the previous child if any, for which BIP125 applies
+	childFee := basics.UTXOs(txUtxos).TotalValue() - basics.GetTotalOutputValue(tx.TxOut)
+	if minFee := getReplacementMinFee(int64(descendantFee-parentFee), basics.GetTxVirtualSize(tx, txUtxos, false)); len(revealEntry.ChildTxID) > 0 && childFee < minFee {
+		return nil, fmt.Errorf("child fee of %d sats insufficient to replace the previous child %s, at least %d sats required; raise the fee rate", childFee, revealEntry.ChildTxID, minFee)
+	}
+
+	if err := inscriber.SignFundingTx(key, addr, tx, txUtxos); err != nil {
+		return nil, err
+	}
+
+	if err := revealEntry.SetChildTx(tx); err != nil {
+		return nil, err
+	}
+
+	if err := i.Journal.Write(entry); err != nil {
+		return nil, fmt.Errorf("failed to write journal: %v", err)
+	}
+
+	if _, err := i.RPCClient.SendRawTransaction(tx, false); err != nil {
+		return nil, fmt.Errorf("failed to broadcast child tx %s: %v", revealEntry.ChildTxID, err)
+	}
+
+	i.Logger.Infof("Reveal tx %s bumped by child tx %s, fee: %d sats", revealEntry.TxID, revealEntry.ChildTxID, childFee)
+
+	return tx, nil
+}
+
+// replaceReveal rebuilds the reveal tx from the journaled tapscript with the funding utxos appended to pay the given fee rate,
+// and broadcasts it after journaling, which replaces the previous reveal tx by spending the same commit output
+func (i *Initiator) replaceReveal(inscriber *inscriber.Inscriber, entry *journal.Entry, revealEntry *journal.RevealEntry, reveal *inscriber.Reveal, key *secp256k1.PrivateKey, addr btcutil.Address, utxos []*basics.UTXO, feeRate float64) (*wire.MsgTx, error) {
+	tx, txUtxos, err := inscriber.FundReveal(reveal, addr, utxos, feeRate)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := inscriber.SignFundedReveal(reveal, tx, txUtxos, key, addr); err != nil {
+		return nil, err
+	}
+
+	if err := revealEntry.SetTx(tx); err != nil {
+		return nil, err
+	}
+
+	if err := i.Journal.Write(entry); err != nil {
+		return nil, fmt.Errorf("failed to write journal: %v", err)
+	}
+
+	if _, err := i.RPCClient.SendRawTransaction(tx, false); err != nil {
+		return nil, fmt.Errorf("failed to broadcast rebuilt reveal tx %s: %v", revealEntry.TxID, err)
+	}
+
+	i.Logger.Infof("Reveal tx rebuilt, tx: %s, fee: %d sats", revealEntry.TxID, basics.UTXOs(txUtxos).TotalValue()-basics.GetTotalOutputValue(tx.TxOut))
+
+	return tx, nil
+}
+
+// getSpentUtxos gets the utxos spent by the given tx from the node, all of which are expected to be owned by the given address
+func (i *Initiator) getSpentUtxos(tx *wire.MsgTx, addr btcutil.Address) ([]*basics.UTXO, error) {
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos := make([]*basics.UTXO, 0, len(tx.TxIn))
+
+	for _, txIn := range tx.TxIn {
+		prevOut := txIn.PreviousOutPoint
+
+		prevTx, err := i.RPCClient.GetRawTransaction(&prevOut.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get tx %s: %v", prevOut.Hash, err)
+		}
+
+		txOut := prevTx.MsgTx().TxOut[prevOut.Index]
+		if !bytes.Equal(txOut.PkScript, pkScript) {
+			return nil, fmt.Errorf("input %s not owned by address %s", prevOut, addr)
+		}
+
+		utxos = append(utxos, basics.NewUTXO(&prevOut.Hash, prevOut.Index, txOut.Value, txOut.PkScript))
+	}
+
+	return utxos, nil
+}
+
+// getReplacementMinFee gets the minimum fee of the replacement tx of the given virtual size by BIP125,
+// which pays for the replaced txs along with its own relay at the incremental relay fee rate of 1 sat/vB
+func getReplacementMinFee(replacedFee int64, vsize int64) int64 {
+	return replacedFee + basics.GetFee(vsize, INCREMENTAL_RELAY_FEE_RATE)
+}
+
+// signalsRBF checks if the given tx signals replaceability by BIP125
+func signalsRBF(tx *wire.MsgTx) bool {
+	for _, txIn := range tx.TxIn {
+		if txIn.Sequence < wire.MaxTxInSequenceNum-1 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getOutputIndex gets the index of the first output of the tx paying to the given pk script, -1 if not found
+func getOutputIndex(tx *wire.MsgTx, pkScript []byte) int {
+	for idx, txOut := range tx.TxOut {
+		if bytes.Equal(txOut.PkScript, pkScript) {
+			return idx
+		}
+	}
+
+	return -1
+}
+
+// excludeTxOutputs excludes the utxos created by the given txs, which are evicted once replaced
+func excludeTxOutputs(utxos []*basics.UTXO, txIDs []string) []*basics.UTXO {
+	excluded := make(map[chainhash.Hash]bool)
+	for _, txID := range txIDs {
+		if len(txID) == 0 {
+			continue
+		}
+
+		if txHash, err := chainhash.NewHashFromStr(txID); err == nil {
+			excluded[*txHash] = true
+		}
+	}
+
+	remaining := make([]*basics.UTXO, 0, len(utxos))
+	for _, utxo := range utxos {
+		if !excluded[utxo.Hash] {
+			remaining = append(remaining, utxo)
+		}
+	}
+
+	return remaining
+}
@@ -0,0 +1,67 @@
+package initiator
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/stacks/basics"
+)
+
+func TestGetReplacementMinFee(t *testing.T) {
+	tests := []struct {
+		replacedFee int64
+		vsize       int64
+		want        int64
+	}{
+		{0, 150, 150},
+		{1000, 150, 1150},
+		{12345, 1, 12346},
+	}
+
+	for _, test := range tests {
+		if got := getReplacementMinFee(test.replacedFee, test.vsize); got != test.want {
+			t.Errorf("replaced %d, vsize %d: got %d; want %d", test.replacedFee, test.vsize, got, test.want)
+		}
+	}
+}
+
+func TestSignalsRBF(t *testing.T) {
+	tests := []struct {
+		sequences []uint32
+		want      bool
+	}{
+		{[]uint32{wire.MaxTxInSequenceNum}, false},
+		{[]uint32{wire.MaxTxInSequenceNum - 1}, false},
+		{[]uint32{basics.RBFSequence}, true},
+		{[]uint32{wire.MaxTxInSequenceNum, wire.MaxTxInSequenceNum - 2}, true},
+	}
+
+	for _, test := range tests {
+		tx := wire.NewMsgTx(basics.TxVersion)
+		for _, sequence := range test.sequences {
+			tx.AddTxIn(&wire.TxIn{Sequence: sequence})
+		}
+
+		if got := signalsRBF(tx); got != test.want {
+			t.Errorf("sequences %v: got %v; want %v", test.sequences, got, test.want)
+		}
+	}
+}
+
+func TestExcludeTxOutputs(t *testing.T) {
+	replaced := chainhash.HashH([]byte("replaced"))
+	kept := chainhash.HashH([]byte("kept"))
+
+	utxos := []*basics.UTXO{
+		basics.NewUTXO(&replaced, 0, 1000, nil),
+		basics.NewUTXO(&kept, 0, 2000, nil),
+		basics.NewUTXO(&replaced, 1, 3000, nil),
+	}
+
+	remaining := excludeTxOutputs(utxos, []string{replaced.String(), "", "invalid"})
+	if len(remaining) != 1 || remaining[0].Hash != kept {
+		t.Fatalf("got %d utxos; want the output of %s only", len(remaining), kept)
+	}
+}
@@ -1,10 +1,13 @@
 package initiator
 
 import (
+	"time"
+
 	"github.com/btcsuite/btcd/txscript"
 
 	"btc-sbt/protocol"
 	"btc-sbt/stacks/basics"
+	"btc-sbt/stacks/taproot/inscriber"
 )
 
 const (
@@ -19,3 +22,43 @@ var (
 	// Output script for mint operation with the protocol name attached
 	MINT_OUTPUT_SCRIPT_WITH_PROTOCOL, _ = txscript.NullDataScript([]byte(protocol.PROTOCOL_NAME))
 )
+
+const (
+	// Maximum number of the reveal txs funded by one commit tx in the batch minting
+	MAX_REVEALS_PER_COMMIT = inscriber.MAX_BATCH_REVEALS
+
+	// Maximum number of the unconfirmed txs chained from the commit tx in the batch minting including itself,
+	// i.e. the default mempool descendant limit
+	MAX_CHAINED_TXS = 25
+
+	// Maximum payload size in bytes of the reveal tx in the batch minting
+	MAX_BATCH_PAYLOAD_SIZE = 100000
+)
+
+const (
+	// Fee levels which the fee rate is estimated for
+	FEE_LEVEL_AUTO      = "auto"     // same as halfhour
+	FEE_LEVEL_FASTEST   = "fastest"  // next block
+	FEE_LEVEL_HALF_HOUR = "halfhour" // within 3 blocks
+	FEE_LEVEL_ECONOMY   = "economy"  // within a day
+
+	// Confirmation targets in blocks of the fee levels for estimatesmartfee
+	FEE_CONF_TARGET_FASTEST   = 1
+	FEE_CONF_TARGET_HALF_HOUR = 3
+	FEE_CONF_TARGET_ECONOMY   = 144
+)
+
+const (
+	// Incremental relay fee rate in sat/vB by which the replacement pays for its own relay, the default of bitcoind
+	INCREMENTAL_RELAY_FEE_RATE = 1
+)
+
+const (
+	// Tolerance of the block time earlier than the tx broadcast, within which the blocks are scanned for the tx without txindex
+	BLOCK_TIME_TOLERANCE = 2 * time.Hour
+)
+
+const (
+	// Interval at which the confirmations and the indexed state are polled when waiting for the outcome
+	WAIT_POLL_INTERVAL = 10 * time.Second
+)
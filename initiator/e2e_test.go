@@ -0,0 +1,291 @@
+package initiator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+
+	"btc-sbt/config"
+	"btc-sbt/devnet"
+	"btc-sbt/indexer"
+	"btc-sbt/logger"
+	"btc-sbt/network"
+	"btc-sbt/protocol"
+	"btc-sbt/server"
+	"btc-sbt/stacks/basics"
+	"btc-sbt/stacks/client/base"
+	"btc-sbt/stacks/client/sbtnode"
+	"btc-sbt/types"
+)
+
+// e2eTest drives the devnet node, the indexer serving the node api and the initiator checking the ops against it
+type e2eTest struct {
+	node      *devnet.Node
+	initiator *Initiator
+	client    *sbtnode.Client
+
+	exited chan struct{} // closed once the indexer exits on the fatal error
+	fatal  string        // fatal error of the indexer
+}
+
+// newE2ETest starts the devnet node and the indexer on top of it, returning the initiator connected to both
+func newE2ETest(t *testing.T) *e2eTest {
+	node := devnet.NewNode("user", "pass", 1, logger.Logger)
+
+	rpcServer := httptest.NewServer(node.Router)
+	t.Cleanup(rpcServer.Close)
+
+	regtest, err := network.NewRegistry().Get(network.REGTEST)
+	if err != nil {
+		t.Fatalf("failed to get the network: %v", err)
+	}
+
+	c := &config.Config{
+		NodeRPCUrl:      strings.TrimPrefix(rpcServer.URL, "http://"),
+		NodeRPCUser:     "user",
+		NodeRPCPass:     "pass",
+		Network:         regtest,
+		UTXO:            &config.UTXOConfig{Provider: config.UTXO_PROVIDER_BITCOIND, Scan: true, CoinSelection: config.DefaultCoinSelection},
+		IndexerInterval: 10 * time.Millisecond,
+		DBPath:          t.TempDir(),
+		JournalPath:     filepath.Join(t.TempDir(), "journal"),
+		LogLevel:        uint32(logger.Logger.GetLevel()),
+	}
+
+	idx, err := indexer.NewIndexer(c)
+	if err != nil {
+		t.Fatalf("failed to create the indexer: %v", err)
+	}
+
+	e := &e2eTest{node: node, exited: make(chan struct{})}
+
+	// the fatal error of the indexer stops the scanner rather than exiting the test binary
+	idxLogger := logrus.New()
+	idxLogger.SetLevel(logger.Logger.GetLevel())
+
+	hook := test.NewLocal(idxLogger)
+
+	idxLogger.ExitFunc = func(int) {
+		e.fatal = hook.LastEntry().Message
+		close(e.exited)
+
+		runtime.Goexit()
+	}
+
+	idx.Logger = idxLogger
+
+	apiServer := httptest.NewServer(server.NewAPIService(idx, logger.Logger).Router)
+	t.Cleanup(apiServer.Close)
+
+	if err := idx.Start(); err != nil {
+		t.Fatalf("failed to start the indexer: %v", err)
+	}
+
+	t.Cleanup(func() {
+		// no one waits for the stop signal once exited
+		select {
+		case <-e.exited:
+		default:
+			idx.Stop()
+		}
+
+		idx.StateMachine.Store.Close()
+	})
+
+	// the db is held by the indexer
+	c.PreflightAPI = apiServer.URL
+
+	i, err := NewInitiator(c)
+	if err != nil {
+		t.Fatalf("failed to create the initiator: %v", err)
+	}
+
+	t.Cleanup(i.RPCClient.Shutdown)
+
+	e.initiator = i
+	e.client = sbtnode.NewClient(apiServer.URL, base.NewClient(1, 0))
+
+	return e
+}
+
+// initiate builds and inscribes the given op funded by the key, mines the txs and waits for the outcome through the node api
+func (e *e2eTest) initiate(t *testing.T, wallet *testWallet, op protocol.Operation) *types.TxRecord {
+	addr, err := basics.GetAddress(wallet.key, basics.Taproot, e.node.NetParams)
+	if err != nil {
+		t.Fatalf("failed to get the address: %v", err)
+	}
+
+	inscription, err := e.initiator.Build(addr, op, 1)
+	if err != nil {
+		t.Fatalf("failed to build: %v", err)
+	}
+
+	if _, _, err := e.initiator.Inscribe(wallet.key, addr, inscription); err != nil {
+		t.Fatalf("failed to inscribe: %v", err)
+	}
+
+	e.mine(t, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	record, err := e.initiator.WaitForOutcome(ctx, inscription.Reveals[0].Tx, 1)
+	if err != nil {
+		t.Fatalf("failed to wait for the outcome: %v", err)
+	}
+
+	return record
+}
+
+// mine mines the given number of blocks and waits until the indexer handles them, sooner than the poll interval of the initiator
+func (e *e2eTest) mine(t *testing.T, blocks int) {
+	if _, err := e.node.Mine(blocks, newTestTaprootAddress(t)); err != nil {
+		t.Fatalf("failed to mine: %v", err)
+	}
+
+	deadline := time.Now().Add(10 * time.Second)
+
+	for {
+		height, err := e.client.GetLastBlockHeight()
+		if err == nil && height == e.node.GetBlockCount() {
+			return
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("indexer behind the chain: got %d, %v; want %d", height, err, e.node.GetBlockCount())
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// waitForFatal waits until the indexer exits on the fatal error, returning the error
+func (e *e2eTest) waitForFatal(t *testing.T) string {
+	select {
+	case <-e.exited:
+		return e.fatal
+
+	case <-time.After(10 * time.Second):
+		t.Fatalf("indexer still running; want exited on the fatal error")
+		return ""
+	}
+}
+
+func TestIssueMintIndexed(t *testing.T) {
+	e := newE2ETest(t)
+	wallet := newTestWallet(t, e.node, 2)
+
+	issuer, err := basics.GetAddress(wallet.key, basics.Taproot, e.node.NetParams)
+	if err != nil {
+		t.Fatalf("failed to get the address: %v", err)
+	}
+
+	record := e.initiate(t, wallet, protocol.NewIssueOperation("abc", 10, "", 0, ""))
+	assertApplied(t, record, protocol.OP_ISSUE)
+
+	issueHeight := record.BlockHeight
+
+	owner := newTestAddress(t)
+
+	record = e.initiate(t, wallet, protocol.NewMintOperation("abc", owner, "", ""))
+	assertApplied(t, record, protocol.OP_MINT)
+
+	// minting again to the same owner is indexed as rejected once forced through the preflight checks
+	mintTxHash := record.TxHash
+	e.initiator.Force = true
+
+	record = e.initiate(t, wallet, protocol.NewMintOperation("abc", owner, "", ""))
+	if len(record.Results) != 1 || record.Results[0].Valid || !strings.Contains(record.Results[0].Error, "owned") {
+		t.Fatalf("duplicate mint: got %+v; want rejected", record.Results)
+	}
+
+	sbts, err := e.client.GetSBTsAt("abc", 0)
+	if err != nil || sbts == nil {
+		t.Fatalf("got %+v, %v; want the SBTs", sbts, err)
+	}
+
+	if sbts.Issuer != issuer.EncodeAddress() || sbts.TotalSupply != 1 || sbts.MaxSupply != 10 {
+		t.Fatalf("got %+v; want issued by %s with the supply of 1 in 10", sbts, issuer)
+	}
+
+	if sbts, err := e.client.GetSBTsAt("abc", issueHeight); err != nil || sbts == nil || sbts.TotalSupply != 0 {
+		t.Fatalf("at the issue block: got %+v, %v; want no supply", sbts, err)
+	}
+
+	owned, _, err := e.client.QueryOwnedSBTs(&types.OwnedSBTsQuery{Owner: owner})
+	if err != nil || len(owned) != 1 {
+		t.Fatalf("got %+v, %v; want the token owned", owned, err)
+	}
+
+	if owned[0].Symbol != "abc" || owned[0].Id != 0 || owned[0].MintTransactionHash != mintTxHash {
+		t.Fatalf("got %+v; want token 0 minted by %s", owned[0], mintTxHash)
+	}
+}
+
+func TestReorgDetected(t *testing.T) {
+	e := newE2ETest(t)
+	wallet := newTestWallet(t, e.node, 2)
+
+	record := e.initiate(t, wallet, protocol.NewIssueOperation("abc", 10, "", 0, ""))
+	assertApplied(t, record, protocol.OP_ISSUE)
+
+	indexedHeight, err := e.client.GetLastBlockHeight()
+	if err != nil || indexedHeight != record.BlockHeight {
+		t.Fatalf("got indexed height %d, %v; want %d", indexedHeight, err, record.BlockHeight)
+	}
+
+	// the block of the issue is replaced by two blocks without the reveal tx, scripted through the devnet rpc
+	params := make([]json.RawMessage, 0, 4)
+	for _, param := range []interface{}{1, 2, newTestTaprootAddress(t).EncodeAddress(), []string{record.TxHash}} {
+		bz, err := json.Marshal(param)
+		if err != nil {
+			t.Fatalf("failed to marshal the param: %v", err)
+		}
+
+		params = append(params, bz)
+	}
+
+	if _, err := e.initiator.RPCClient.RawRequest("reorg", params); err != nil {
+		t.Fatalf("failed to reorg: %v", err)
+	}
+
+	revealTxHash, err := chainhash.NewHashFromStr(record.TxHash)
+	if err != nil {
+		t.Fatalf("invalid tx hash: %v", err)
+	}
+
+	if _, err := e.initiator.RPCClient.GetRawTransaction(revealTxHash); err == nil {
+		t.Fatalf("got the reveal tx known by the node; want dropped by the reorg")
+	}
+
+	// the indexer does not follow the reorg but stops, keeping the stale state until reindexed
+	if fatal := e.waitForFatal(t); !strings.Contains(fatal, "chain reorg detected") {
+		t.Fatalf("got the fatal error %q; want the reorg detected", fatal)
+	}
+
+	if height, err := e.client.GetLastBlockHeight(); err != nil || height != indexedHeight {
+		t.Fatalf("got indexed height %d, %v; want %d", height, err, indexedHeight)
+	}
+
+	if sbts, err := e.client.GetSBTsAt("abc", 0); err != nil || sbts == nil {
+		t.Fatalf("got %+v, %v; want the orphaned issue still indexed", sbts, err)
+	}
+}
+
+// assertApplied asserts that the record holds the single applied op of the given type
+func assertApplied(t *testing.T, record *types.TxRecord, opType protocol.OpType) {
+	t.Helper()
+
+	if len(record.Results) != 1 || !record.Results[0].Valid || record.Results[0].Type != opType {
+		t.Fatalf("got %+v; want the applied %s", record.Results, opType)
+	}
+}
@@ -0,0 +1,114 @@
+package initiator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcjson"
+
+	"btc-sbt/config"
+	"btc-sbt/network"
+	"btc-sbt/stacks/basics"
+	"btc-sbt/stacks/client/base"
+	"btc-sbt/stacks/client/btcapi/mempool"
+)
+
+// GetFeeRate gets the fee rate in sat/vB from the given fee level or the explicit fee rate, default to the configured one.
+// The fee levels are estimated by the configured source. The explicit fee rate must be within the minimum relay fee rate and the cap,
+// while the estimated fee rate is bounded by them
+func (i *Initiator) GetFeeRate(feeRate string) (float64, error) {
+	if len(feeRate) == 0 {
+		feeRate = i.Config.FeeRate
+	}
+
+	feeRate = strings.ToLower(strings.TrimSpace(feeRate))
+	minFeeRate := float64(basics.MIN_RELAY_TX_FEE) / 1000
+
+	switch feeRate {
+	case FEE_LEVEL_AUTO, FEE_LEVEL_FASTEST, FEE_LEVEL_HALF_HOUR, FEE_LEVEL_ECONOMY:
+		estimated, err := i.estimateFeeRate(feeRate)
+		if err != nil {
+			return 0, fmt.Errorf("failed to estimate the fee rate: %v; specify the fee rate in sat/vB", err)
+		}
+
+		if estimated > i.Config.MaxFeeRate {
+			i.Logger.Warnf("Estimated fee rate %.2f sat/vB exceeds the cap, %.2f sat/vB used", estimated, i.Config.MaxFeeRate)
+			return i.Config.MaxFeeRate, nil
+		}
+
+		if estimated < minFeeRate {
+			return minFeeRate, nil
+		}
+
+		return estimated, nil
+
+	default:
+		rate, err := strconv.ParseFloat(feeRate, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid fee rate: %s, %s, %s, %s, %s or the fee rate in sat/vB expected", feeRate, FEE_LEVEL_AUTO, FEE_LEVEL_FASTEST, FEE_LEVEL_HALF_HOUR, FEE_LEVEL_ECONOMY)
+		}
+
+		if rate < minFeeRate || rate > i.Config.MaxFeeRate {
+			return 0, fmt.Errorf("fee rate %v sat/vB out of range [%v, %v]", rate, minFeeRate, i.Config.MaxFeeRate)
+		}
+
+		return rate, nil
+	}
+}
+
+// estimateFeeRate estimates the fee rate in sat/vB for the given fee level by the configured source
+func (i *Initiator) estimateFeeRate(level string) (float64, error) {
+	if i.Config.Network.Name == network.REGTEST {
+		// no fee estimates on regtest, where the minimum relay fee rate always confirms
+		return float64(basics.MIN_RELAY_TX_FEE) / 1000, nil
+	}
+
+	if i.Config.FeeSource == config.FEE_SOURCE_MEMPOOL {
+		if len(i.Config.Network.EsploraAPI) == 0 {
+			return 0, fmt.Errorf("no mempool api on %s", i.Config.Network.Name)
+		}
+
+		client := mempool.NewClient(i.Config.Network.EsploraAPI, base.NewClient(i.Config.Retries+1, i.Config.Interval))
+
+		fees, err := client.GetFees()
+		if err != nil {
+			return 0, err
+		}
+
+		switch level {
+		case FEE_LEVEL_FASTEST:
+			return fees.FastestFee, nil
+
+		case FEE_LEVEL_ECONOMY:
+			return fees.EconomyFee, nil
+
+		default:
+			return fees.HalfHourFee, nil
+		}
+	}
+
+	confTarget := int64(FEE_CONF_TARGET_HALF_HOUR)
+	var mode *btcjson.EstimateSmartFeeMode
+
+	switch level {
+	case FEE_LEVEL_FASTEST:
+		confTarget = FEE_CONF_TARGET_FASTEST
+
+	case FEE_LEVEL_ECONOMY:
+		confTarget = FEE_CONF_TARGET_ECONOMY
+		mode = &btcjson.EstimateModeEconomical
+	}
+
+	result, err := i.RPCClient.EstimateSmartFee(confTarget, mode)
+	if err != nil {
+		return 0, err
+	}
+
+	if result.FeeRate == nil {
+		return 0, fmt.Errorf("no estimate from the node: %s", strings.Join(result.Errors, "; "))
+	}
+
+	// BTC/kvB to sat/vB
+	return *result.FeeRate * 1e5, nil
+}
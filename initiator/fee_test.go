@@ -0,0 +1,112 @@
+package initiator
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"btc-sbt/config"
+	"btc-sbt/logger"
+	"btc-sbt/network"
+)
+
+// newTestFeeNetwork returns the copy of testnet4 using the given esplora api
+func newTestFeeNetwork(t *testing.T, esploraAPI string) *network.Network {
+	testnet, err := network.NewRegistry().Get(network.TESTNET4)
+	if err != nil {
+		t.Fatalf("failed to get the network: %v", err)
+	}
+
+	copied := *testnet
+	copied.EsploraAPI = esploraAPI
+
+	return &copied
+}
+
+func TestGetFeeRate(t *testing.T) {
+	node, i := newTestDevnet(t, 0)
+	i.Config = &config.Config{Network: newTestFeeNetwork(t, ""), FeeSource: config.FEE_SOURCE_NODE, FeeRate: FEE_LEVEL_AUTO, MaxFeeRate: 20}
+
+	tests := []struct {
+		nodeFeeRate float64
+		feeRate     string
+		want        float64 // 0 if error expected
+	}{
+		{5, "", 5},
+		{5, " FASTEST ", 5},
+		{5, FEE_LEVEL_ECONOMY, 5},
+		{5, "7.5", 7.5},
+		{5, "1", 1},
+		{5, "20", 20},
+		{5, "0.5", 0},
+		{5, "25", 0},
+		{5, "cheap", 0},
+		{50, FEE_LEVEL_HALF_HOUR, 20}, // capped
+		{0.2, FEE_LEVEL_HALF_HOUR, 1}, // raised to the minimum relay fee rate
+	}
+
+	for _, test := range tests {
+		node.FeeRate = test.nodeFeeRate
+
+		got, err := i.GetFeeRate(test.feeRate)
+		if test.want == 0 {
+			if err == nil {
+				t.Errorf("%q: got %v; want error", test.feeRate, got)
+			}
+
+			continue
+		}
+
+		if err != nil || got != test.want {
+			t.Errorf("%q at %v sat/vB: got %v, %v; want %v", test.feeRate, test.nodeFeeRate, got, err, test.want)
+		}
+	}
+}
+
+func TestGetFeeRateMempool(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/fees/recommended" {
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Write([]byte(`{"fastestFee":30,"halfHourFee":12,"hourFee":8,"economyFee":3,"minimumFee":1}`))
+	}))
+	t.Cleanup(server.Close)
+
+	i := &Initiator{Config: &config.Config{Network: newTestFeeNetwork(t, server.URL), FeeSource: config.FEE_SOURCE_MEMPOOL, MaxFeeRate: 20}, Logger: logger.Logger}
+
+	tests := []struct {
+		feeRate string
+		want    float64
+	}{
+		{FEE_LEVEL_AUTO, 12},
+		{FEE_LEVEL_HALF_HOUR, 12},
+		{FEE_LEVEL_ECONOMY, 3},
+		{FEE_LEVEL_FASTEST, 20}, // capped
+	}
+
+	for _, test := range tests {
+		if got, err := i.GetFeeRate(test.feeRate); err != nil || got != test.want {
+			t.Errorf("%s: got %v, %v; want %v", test.feeRate, got, err, test.want)
+		}
+	}
+
+	i.Config.Network = newTestFeeNetwork(t, "")
+
+	if _, err := i.GetFeeRate(FEE_LEVEL_AUTO); err == nil {
+		t.Fatalf("no mempool api: got nil; want error")
+	}
+
+	// no estimates needed on regtest
+	regtest, err := network.NewRegistry().Get(network.REGTEST)
+	if err != nil {
+		t.Fatalf("failed to get the network: %v", err)
+	}
+
+	i.Config.Network = regtest
+
+	if got, err := i.GetFeeRate(FEE_LEVEL_FASTEST); err != nil || got != 1 {
+		t.Fatalf("regtest: got %v, %v; want 1", got, err)
+	}
+}
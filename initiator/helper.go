@@ -1,6 +1,7 @@
 package initiator
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/btcsuite/btcd/btcutil"
@@ -38,3 +39,29 @@ func GetTxOutFromOp(op protocol.Operation, addr btcutil.Address) (*wire.TxOut, e
 		return nil, fmt.Errorf("unsupported operation type: %d", op.Type())
 	}
 }
+
+// GetEnvelopeFromOps gets the envelope carrying the given operations as a JSON array
+func GetEnvelopeFromOps(ops []protocol.Operation) ([]byte, error) {
+	payload, err := MarshalOps(ops)
+	if err != nil {
+		return nil, err
+	}
+
+	return protocol.NewEnvelope(payload).Script()
+}
+
+// MarshalOps marshals the given operations to a JSON array
+func MarshalOps(ops []protocol.Operation) ([]byte, error) {
+	rawOps := make([]json.RawMessage, 0, len(ops))
+
+	for _, op := range ops {
+		bz, err := op.Marshal()
+		if err != nil {
+			return nil, err
+		}
+
+		rawOps = append(rawOps, bz)
+	}
+
+	return json.Marshal(rawOps)
+}
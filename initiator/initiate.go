@@ -4,32 +4,166 @@ import (
 	"fmt"
 
 	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/decred/dcrd/dcrec/secp256k1/v4"
 
+	"btc-sbt/journal"
 	"btc-sbt/protocol"
+	"btc-sbt/stacks/basics"
 	"btc-sbt/stacks/taproot/inscriber"
 )
 
-// Initiate executes the given protocol operation
-func (i *Initiator) Initiate(key *secp256k1.PrivateKey, addr btcutil.Address, op protocol.Operation) (*chainhash.Hash, *chainhash.Hash, error) {
-	if err := op.Validate(i.NetParams); err != nil {
+// Initiate executes the given protocol operation at the given fee rate in sat/vB
+func (i *Initiator) Initiate(key *secp256k1.PrivateKey, addr btcutil.Address, op protocol.Operation, feeRate float64) (*chainhash.Hash, *chainhash.Hash, error) {
+	inscription, err := i.Build(addr, op, feeRate)
+	if err != nil {
 		return nil, nil, err
 	}
 
-	envelope, err := GetEnvelopeFromOp(op)
+	return i.Inscribe(key, addr, inscription)
+}
+
+// Build builds the unsigned commit tx and the prepared reveal tx for the given protocol operation at the given fee rate in sat/vB,
+// which allows the fees to be reviewed before Inscribe. The op is checked against the current protocol state unless forced
+func (i *Initiator) Build(addr btcutil.Address, op protocol.Operation, feeRate float64) (*inscriber.Inscription, error) {
+	envelope, utxos, txOut, err := i.prepare(addr, op)
 	if err != nil {
+		return nil, err
+	}
+
+	inscription, err := i.newInscriber().Build(addr, utxos, envelope, []*wire.TxOut{txOut}, feeRate)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := i.preflight(inscription); err != nil {
+		return nil, err
+	}
+
+	if !i.Config.RBF {
+		inscription.DisableRBF()
+	}
+
+	return inscription, nil
+}
+
+// Inscribe signs the commit tx of the given inscription, signs the reveal tx and broadcasts both after journaling
+func (i *Initiator) Inscribe(key *secp256k1.PrivateKey, addr btcutil.Address, inscription *inscriber.Inscription) (*chainhash.Hash, *chainhash.Hash, error) {
+	if err := i.newInscriber().SignCommitTx(key, addr, inscription); err != nil {
 		return nil, nil, err
 	}
 
-	utxos, err := i.UnisatClient.GetBTCUtxos(addr.EncodeAddress())
+	return i.broadcast(inscription.CommitTx, inscription.Reveals[0])
+}
+
+// InitiatePsbt builds the unsigned commit psbt and the prepared reveal tx for the given protocol operation at the given fee rate in sat/vB.
+// The commit psbt is expected to be signed externally and then passed to Finalize along with the reveal tx of the inscription
+func (i *Initiator) InitiatePsbt(addr btcutil.Address, pubKey string, op protocol.Operation, feeRate float64) (*psbt.Packet, *inscriber.Inscription, error) {
+	inscription, err := i.Build(addr, op, feeRate)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	if len(utxos) == 0 {
-		return nil, nil, fmt.Errorf("no utxos available for address: %s", addr)
+	p, err := i.newInscriber().BuildCommitPsbt(inscription, addr, pubKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return p, inscription, nil
+}
+
+// Finalize finalizes the signed commit psbt, signs the reveal tx and broadcasts both after journaling
+func (i *Initiator) Finalize(p *psbt.Packet, reveal *inscriber.Reveal) (*chainhash.Hash, *chainhash.Hash, error) {
+	commitTx, err := inscriber.FinalizeCommitPsbt(p, reveal)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return i.broadcast(commitTx, reveal)
+}
+
+// broadcast signs the reveal tx against the signed commit tx, writes the journal entry and broadcasts both.
+// The commit output can be recovered from the journal if the reveal tx fails
+func (i *Initiator) broadcast(commitTx *wire.MsgTx, reveal *inscriber.Reveal) (*chainhash.Hash, *chainhash.Hash, error) {
+	results, err := i.broadcastBatch(commitTx, []*inscriber.Reveal{reveal})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &results[0].CommitTxHash, &results[0].RevealTxHash, nil
+}
+
+// broadcastBatch signs the reveal txs against the signed commit tx, checks the txs together by the node, writes the journal entry
+// and broadcasts the commit tx followed by the reveal txs in order, as a package if possible.
+// The commit outputs can be recovered from the journal if any reveal tx fails
+func (i *Initiator) broadcastBatch(commitTx *wire.MsgTx, reveals []*inscriber.Reveal) ([]*inscriber.InscribeResult, error) {
+	results, err := inscriber.SignReveals(commitTx, reveals)
+	if err != nil {
+		return nil, err
+	}
+
+	commitTxHash := commitTx.TxHash()
+
+	txs := []*wire.MsgTx{commitTx}
+	for _, reveal := range reveals {
+		txs = append(txs, reveal.Tx)
+	}
+
+	// nothing is journaled or sent if any tx is rejected
+	if err := i.Broadcaster.TestAccept(txs); err != nil {
+		return nil, err
+	}
+
+	entry, err := journal.NewEntry(commitTx, reveals)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := i.Journal.Write(entry); err != nil {
+		return nil, fmt.Errorf("failed to write journal: %v", err)
+	}
+
+	mode, err := i.Broadcaster.Submit(txs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to broadcast: %v; run recover for commit tx %s", err, commitTxHash)
+	}
+
+	i.Logger.Debugf("Commit tx %s and %d reveal txs broadcast in %s mode", commitTxHash, len(reveals), mode)
+
+	entry.Status = journal.STATUS_BROADCAST
+	if err := i.Journal.Write(entry); err != nil {
+		i.Logger.Warnf("Failed to update journal of commit tx %s: %v", commitTxHash, err)
+	}
+
+	return results, nil
+}
+
+// prepare validates the given protocol operation and gets the envelope, the available utxos and the reveal tx out
+func (i *Initiator) prepare(addr btcutil.Address, op protocol.Operation) ([]byte, []*basics.UTXO, *wire.TxOut, error) {
+	envelope, txOut, err := i.prepareOp(addr, op)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	utxos, err := i.getUtxos(addr)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return envelope, utxos, txOut, nil
+}
+
+// prepareOp validates the given protocol operation and gets the envelope and the reveal tx out
+func (i *Initiator) prepareOp(addr btcutil.Address, op protocol.Operation) ([]byte, *wire.TxOut, error) {
+	if err := op.Validate(i.NetParams); err != nil {
+		return nil, nil, err
+	}
+
+	envelope, err := GetEnvelopeFromOp(op)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	txOut, err := GetTxOutFromOp(op, addr)
@@ -37,7 +171,19 @@ func (i *Initiator) Initiate(key *secp256k1.PrivateKey, addr btcutil.Address, op
 		return nil, nil, err
 	}
 
-	inscriber := inscriber.NewInscriber(i.RPCClient, i.NetParams)
+	return envelope, txOut, nil
+}
+
+// getUtxos gets the available utxos of the given address
+func (i *Initiator) getUtxos(addr btcutil.Address) ([]*basics.UTXO, error) {
+	utxos, err := i.UTXOProvider.GetUTXOs(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(utxos) == 0 {
+		return nil, fmt.Errorf("no utxos available for address: %s", addr)
+	}
 
-	return inscriber.Inscribe(key, addr, utxos, envelope, []*wire.TxOut{txOut}, i.Config.FeeRate)
+	return utxos, nil
 }
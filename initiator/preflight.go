@@ -0,0 +1,150 @@
+package initiator
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/config"
+	"btc-sbt/decoder"
+	"btc-sbt/protocol"
+	"btc-sbt/stacks/client/base"
+	"btc-sbt/stacks/client/sbtnode"
+	"btc-sbt/stacks/taproot/inscriber"
+	"btc-sbt/statemachine"
+	"btc-sbt/types"
+)
+
+// StateReader reads the indexed protocol state, through the btc-sbt node api or the local db
+type StateReader interface {
+	// Decode decodes the protocol operations from the tx and simulates them against the current state
+	Decode(tx *wire.MsgTx) (*types.DecodeResult, error)
+
+	// GetTx gets the indexed protocol operations and results of the given reveal tx, or of all the reveal txs spending the given commit tx
+	GetTx(txHash *chainhash.Hash) ([]*types.TxRecord, error)
+
+	// GetLastBlockHeight gets the height of the last indexed block
+	GetLastBlockHeight() (int64, error)
+}
+
+// localState reads the protocol state from the local db
+type localState struct {
+	*decoder.Decoder
+	*statemachine.StateMachine
+}
+
+// Preflight simulates the operations of the prepared reveal txs against the current protocol state, as the indexer would handle them in the next block,
+// so that the ops to be rejected, e.g. minting a nonexistent symbol or with a bad authority signature, are caught before paying the fees.
+// The state is queried through the decode api of the configured btc-sbt node, or the local db opened read-only, falling back to the api of the running node.
+// Returns the exact reason if any op would be rejected.
+//
+// The reveal txs of a batch are simulated one by one against the same indexed state rather than in order on top of each other,
+// as the decode api takes a single tx. Thus the ops depending on the earlier reveals of the batch are not checked against them,
+// e.g. the mints exceeding the max supply only together pass, while the duplicate owners are refused by ReadMintsFromCSV beforehand
+func (i *Initiator) Preflight(inscription *inscriber.Inscription) error {
+	reader, closer, err := i.newStateReader()
+	if err != nil {
+		return fmt.Errorf("preflight check unavailable: %v", err)
+	}
+
+	defer closer()
+
+	for idx, reveal := range inscription.Reveals {
+		result, err := reader.Decode(reveal.Tx)
+		if err != nil {
+			return fmt.Errorf("preflight check unavailable: %v", err)
+		}
+
+		if idx == 0 {
+			i.warnIfIndexerBehind(result.BlockHeight - 1)
+		}
+
+		if len(result.Results) == 0 {
+			return fmt.Errorf("preflight check failed: no protocol operations decoded from reveal tx %d", idx)
+		}
+
+		for _, opResult := range result.Results {
+			if !opResult.Valid {
+				return fmt.Errorf("preflight check failed: %s %s rejected: %s", opResult.Type, opResult.Symbol, opResult.Error)
+			}
+		}
+	}
+
+	return nil
+}
+
+// preflight runs the preflight checks on the given inscription, which are bypassed with a warning if forced
+func (i *Initiator) preflight(inscription *inscriber.Inscription) error {
+	err := i.Preflight(inscription)
+	if err == nil || !i.Force {
+		return err
+	}
+
+	i.Logger.Warnf("Proceeding by force: %v", err)
+
+	return nil
+}
+
+// newStateReader creates the state reader of the initiator by NewStateReader.
+// The returned function releases the resources
+func (i *Initiator) newStateReader() (StateReader, func(), error) {
+	return NewStateReader(i.Config, i.NetParams, i.Logger)
+}
+
+// NewStateReader creates the state reader through the configured btc-sbt node api, or on top of the local db opened read-only.
+// The db is locked while the node is running, in which case the api of the node at the configured listener address is queried.
+// The returned function releases the resources
+func NewStateReader(c *config.Config, netParams *chaincfg.Params, logger *logrus.Logger) (StateReader, func(), error) {
+	if len(c.PreflightAPI) > 0 {
+		return newNodeClient(c, c.PreflightAPI), func() {}, nil
+	}
+
+	sm, err := statemachine.OpenReadOnly(c.DBPath, netParams, logger)
+	if err != nil {
+		api := getLocalAPI(c.ListenerAddr)
+		if len(api) == 0 {
+			return nil, nil, fmt.Errorf("failed to open the db %s, configure preflight.api if the node is running: %v", c.DBPath, err)
+		}
+
+		logger.Debugf("Failed to open the db %s, querying the node api %s instead: %v", c.DBPath, api, err)
+
+		return newNodeClient(c, api), func() {}, nil
+	}
+
+	return &localState{decoder.NewDecoder(protocol.NewParser(netParams), sm), sm}, func() { sm.Store.Close() }, nil
+}
+
+// newNodeClient creates the client of the given btc-sbt node api
+func newNodeClient(c *config.Config, api string) *sbtnode.Client {
+	return sbtnode.NewClient(api, base.NewClient(c.Retries+1, c.Interval))
+}
+
+// getLocalAPI gets the url of the node api served at the given listener address, reached through the loopback if listening on all interfaces.
+// Returns empty if the address is invalid
+func getLocalAPI(listenerAddr string) string {
+	host, port, err := net.SplitHostPort(listenerAddr)
+	if err != nil || len(port) == 0 {
+		return ""
+	}
+
+	if ip := net.ParseIP(host); len(host) == 0 || ip != nil && ip.IsUnspecified() {
+		host = "127.0.0.1"
+	}
+
+	return fmt.Sprintf("http://%s", net.JoinHostPort(host, port))
+}
+
+// warnIfIndexerBehind warns if the indexed state is behind the chain, in which case the preflight checks may be inaccurate
+func (i *Initiator) warnIfIndexerBehind(indexedHeight int64) {
+	height, err := i.RPCClient.GetBlockCount()
+	if err != nil || height <= indexedHeight {
+		return
+	}
+
+	i.Logger.Warnf("Indexed state at block %d is %d blocks behind the chain, the preflight checks may be inaccurate", indexedHeight, height-indexedHeight)
+}
@@ -0,0 +1,221 @@
+package initiator
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/config"
+	"btc-sbt/events"
+	"btc-sbt/indexer"
+	"btc-sbt/logger"
+	"btc-sbt/protocol"
+	"btc-sbt/server"
+	"btc-sbt/stacks/basics"
+	"btc-sbt/stacks/client/sbtnode"
+	"btc-sbt/stacks/taproot/inscriber"
+	"btc-sbt/statemachine"
+)
+
+// newTestInscription builds the inscription with a reveal tx per given op, funded by a fake utxo
+func newTestInscription(t *testing.T, ops ...protocol.Operation) *inscriber.Inscription {
+	addr := newTestTaprootAddress(t)
+
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("failed to get the script: %v", err)
+	}
+
+	envelopes := make([][]byte, 0, len(ops))
+	revealTxOuts := make([][]*wire.TxOut, 0, len(ops))
+
+	for _, op := range ops {
+		envelope, err := GetEnvelopeFromOp(op)
+		if err != nil {
+			t.Fatalf("failed to get the envelope: %v", err)
+		}
+
+		txOut, err := GetTxOutFromOp(op, addr)
+		if err != nil {
+			t.Fatalf("failed to get the tx out: %v", err)
+		}
+
+		envelopes = append(envelopes, envelope)
+		revealTxOuts = append(revealTxOuts, []*wire.TxOut{txOut})
+	}
+
+	fundingTxHash := chainhash.HashH([]byte("funding"))
+	utxos := []*basics.UTXO{basics.NewUTXO(&fundingTxHash, 0, 1000000, pkScript)}
+
+	inscription, err := inscriber.NewInscriber(nil, &chaincfg.RegressionNetParams).BuildBatch(addr, utxos, envelopes, revealTxOuts, 1)
+	if err != nil {
+		t.Fatalf("failed to build the inscription: %v", err)
+	}
+
+	return inscription
+}
+
+// newTestNodeAPI serves the node api on top of the db at the given path, which is held until the test ends
+func newTestNodeAPI(t *testing.T, dbPath string) string {
+	netParams := &chaincfg.RegressionNetParams
+
+	sm, err := statemachine.Open(dbPath, netParams, logger.Logger)
+	if err != nil {
+		t.Fatalf("failed to open the state machine: %v", err)
+	}
+
+	t.Cleanup(func() { sm.Store.Close() })
+
+	idx := &indexer.Indexer{
+		NetParams:    netParams,
+		Parser:       protocol.NewParser(netParams),
+		StateMachine: sm,
+		EventBus:     events.NewBus(),
+		Logger:       logger.Logger,
+	}
+
+	srv := httptest.NewServer(server.NewAPIService(idx, logger.Logger).Router)
+	t.Cleanup(srv.Close)
+
+	return srv.URL
+}
+
+func TestPreflightParity(t *testing.T) {
+	dbPath := t.TempDir()
+
+	// migrated before being opened read-only
+	sm, err := statemachine.Open(dbPath, &chaincfg.RegressionNetParams, logger.Logger)
+	if err != nil {
+		t.Fatalf("failed to open the state machine: %v", err)
+	}
+
+	sm.Store.Close()
+
+	inscriptions := []*inscriber.Inscription{
+		newTestInscription(t, protocol.NewIssueOperation("abc", 10, "", 0, "")),
+		newTestInscription(t, protocol.NewMintOperation("xyz", newTestAddress(t), "", "")),
+	}
+
+	_, i := newTestDevnet(t, 0)
+	i.Config = &config.Config{DBPath: dbPath}
+
+	local := make([]string, 0, len(inscriptions))
+	localErrs := make([]error, 0, len(inscriptions))
+
+	for _, inscription := range inscriptions {
+		result, err := decodeForTest(t, i, inscription)
+		if err != nil {
+			t.Fatalf("local: failed to decode: %v", err)
+		}
+
+		local = append(local, result)
+		localErrs = append(localErrs, i.Preflight(inscription))
+	}
+
+	if localErrs[0] != nil {
+		t.Fatalf("local issue: got %v; want nil", localErrs[0])
+	}
+
+	if localErrs[1] == nil || !strings.Contains(localErrs[1].Error(), "rejected") {
+		t.Fatalf("local mint of the nonexistent symbol: got %v; want rejected", localErrs[1])
+	}
+
+	// the db is held by the node from now on
+	api := newTestNodeAPI(t, dbPath)
+
+	configs := map[string]*config.Config{
+		"preflight.api": {DBPath: dbPath, PreflightAPI: api},
+		"listener":      {DBPath: dbPath, ListenerAddr: strings.TrimPrefix(api, "http://")},
+	}
+
+	for name, c := range configs {
+		i.Config = c
+
+		for idx, inscription := range inscriptions {
+			result, err := decodeForTest(t, i, inscription)
+			if err != nil {
+				t.Fatalf("%s: failed to decode: %v", name, err)
+			}
+
+			if result != local[idx] {
+				t.Fatalf("%s: got %s; want %s as the local db", name, result, local[idx])
+			}
+
+			if err := i.Preflight(inscription); (err == nil) != (localErrs[idx] == nil) || err != nil && err.Error() != localErrs[idx].Error() {
+				t.Fatalf("%s: got %v; want %v as the local db", name, err, localErrs[idx])
+			}
+		}
+	}
+}
+
+func TestNewStateReaderUnavailable(t *testing.T) {
+	dbPath := t.TempDir()
+	newTestNodeAPI(t, dbPath)
+
+	i := &Initiator{NetParams: &chaincfg.RegressionNetParams, Logger: logger.Logger, Config: &config.Config{DBPath: dbPath}}
+
+	if _, _, err := i.newStateReader(); err == nil || !strings.Contains(err.Error(), "preflight.api") {
+		t.Fatalf("got %v; want the error suggesting preflight.api", err)
+	}
+
+	i.Config.ListenerAddr = "0.0.0.0:8080"
+
+	reader, _, err := i.newStateReader()
+	if err != nil {
+		t.Fatalf("failed to create the state reader: %v", err)
+	}
+
+	if client, ok := reader.(*sbtnode.Client); !ok || client.API != "http://127.0.0.1:8080" {
+		t.Fatalf("got %+v; want the client of the local node api", reader)
+	}
+}
+
+func TestGetLocalAPI(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{"0.0.0.0:80", "http://127.0.0.1:80"},
+		{":8080", "http://127.0.0.1:8080"},
+		{"[::]:8080", "http://127.0.0.1:8080"},
+		{"192.168.1.2:8080", "http://192.168.1.2:8080"},
+		{"localhost:8080", "http://localhost:8080"},
+		{"[::1]:8080", "http://[::1]:8080"},
+		{"", ""},
+		{"127.0.0.1", ""},
+	}
+
+	for _, tt := range tests {
+		if got := getLocalAPI(tt.addr); got != tt.want {
+			t.Errorf("%q: got %q; want %q", tt.addr, got, tt.want)
+		}
+	}
+}
+
+// decodeForTest decodes the first reveal tx of the inscription through the state reader of the initiator, marshaled for comparison
+func decodeForTest(t *testing.T, i *Initiator, inscription *inscriber.Inscription) (string, error) {
+	reader, closer, err := i.newStateReader()
+	if err != nil {
+		return "", err
+	}
+
+	defer closer()
+
+	result, err := reader.Decode(inscription.Reveals[0].Tx)
+	if err != nil {
+		return "", err
+	}
+
+	bz, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("failed to marshal: %v", err)
+	}
+
+	return string(bz), nil
+}
@@ -0,0 +1,130 @@
+package initiator
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// BatchStatus represents the status of the commit tx and the reveal txs in the batch minting
+type BatchStatus string
+
+const (
+	BATCH_STATUS_SIGNED    BatchStatus = "signed"    // signed but not broadcast yet
+	BATCH_STATUS_BROADCAST BatchStatus = "broadcast" // broadcast
+)
+
+// BatchProgress tracks the progress of the batch minting, which is persisted before broadcasting to resume on failure
+type BatchProgress struct {
+	path string // progress file path
+
+	Symbol   string         `json:"symbol"`   // symbol to be minted
+	Checksum string         `json:"checksum"` // hex encoded sha256 hash of the source CSV
+	Total    int            `json:"total"`    // total number of the mints
+	Commits  []*BatchCommit `json:"commits"`  // commit txs in order
+}
+
+// BatchCommit defines the signed commit tx and the reveal txs funded by it
+type BatchCommit struct {
+	Status  BatchStatus    `json:"status"`  // broadcast status
+	Tx      string         `json:"tx"`      // hex encoded signed commit tx
+	TxID    string         `json:"txid"`    // commit tx id
+	Reveals []*BatchReveal `json:"reveals"` // reveal txs in the order of the commit tx outputs
+}
+
+// BatchReveal defines the signed reveal tx carrying the mints in [From, To)
+type BatchReveal struct {
+	From int    `json:"from"` // index of the first mint
+	To   int    `json:"to"`   // index after the last mint
+	Tx   string `json:"tx"`   // hex encoded signed reveal tx
+	TxID string `json:"txid"` // reveal tx id
+}
+
+// OpenBatchProgress opens the batch progress at the given path, or creates a new one if the file does not exist.
+// The existing progress must match the symbol and the checksum of the source CSV
+func OpenBatchProgress(path string, symbol string, checksum string, total int) (*BatchProgress, error) {
+	progress := &BatchProgress{
+		path:     path,
+		Symbol:   symbol,
+		Checksum: checksum,
+		Total:    total,
+		Commits:  make([]*BatchCommit, 0),
+	}
+
+	bz, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return progress, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var existing BatchProgress
+	if err := json.Unmarshal(bz, &existing); err != nil {
+		return nil, fmt.Errorf("invalid progress file %s: %v", path, err)
+	}
+
+	if existing.Symbol != symbol || existing.Checksum != checksum || existing.Total != total {
+		return nil, fmt.Errorf("progress file %s does not match the symbol or the CSV file", path)
+	}
+
+	existing.path = path
+
+	return &existing, nil
+}
+
+// Next returns the index of the next mint to be batched
+func (p *BatchProgress) Next() int {
+	if len(p.Commits) == 0 {
+		return 0
+	}
+
+	reveals := p.Commits[len(p.Commits)-1].Reveals
+
+	return reveals[len(reveals)-1].To
+}
+
+// Done returns true if all the mints are batched and broadcast, false otherwise
+func (p *BatchProgress) Done() bool {
+	for _, commit := range p.Commits {
+		if commit.Status != BATCH_STATUS_BROADCAST {
+			return false
+		}
+	}
+
+	return p.Next() == p.Total
+}
+
+// Save writes the progress to the file atomically
+func (p *BatchProgress) Save() error {
+	bz, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(p.path), filepath.Base(p.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(bz); err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), p.path)
+}
@@ -0,0 +1,314 @@
+package initiator
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+
+	"btc-sbt/journal"
+	"btc-sbt/stacks/basics"
+	"btc-sbt/stacks/taproot/inscriber"
+)
+
+// TxStatus represents the status of the tx known by the node
+type TxStatus string
+
+const (
+	TX_STATUS_UNKNOWN   TxStatus = "unknown"   // neither in the mempool nor in the chain
+	TX_STATUS_MEMPOOL   TxStatus = "mempool"   // in the mempool
+	TX_STATUS_CONFIRMED TxStatus = "confirmed" // confirmed
+)
+
+// GetTxStatus gets the status of the given tx broadcast since the given time from the node.
+// Without txindex, getrawtransaction only finds the txs in the mempool, thus the tx not found is looked up in the blocks since the given time
+func (i *Initiator) GetTxStatus(txID string, since time.Time) (TxStatus, error) {
+	txHash, err := chainhash.NewHashFromStr(txID)
+	if err != nil {
+		return "", err
+	}
+
+	tx, err := i.RPCClient.GetRawTransactionVerbose(txHash)
+	if err == nil {
+		if tx.Confirmations > 0 {
+			return TX_STATUS_CONFIRMED, nil
+		}
+
+		return TX_STATUS_MEMPOOL, nil
+	}
+
+	if !isTxNotFoundErr(err) {
+		return "", fmt.Errorf("failed to get tx %s: %v", txID, err)
+	}
+
+	blockHash, err := i.findTxBlock(txHash, since)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up tx %s in the blocks: %v", txID, err)
+	}
+
+	if blockHash != nil {
+		return TX_STATUS_CONFIRMED, nil
+	}
+
+	return TX_STATUS_UNKNOWN, nil
+}
+
+// blockScan caches the txs of the blocks scanned back from the tip, by which the confirmed txs are found without txindex
+type blockScan struct {
+	tip      chainhash.Hash                    // block from which the blocks are scanned back
+	next     *chainhash.Hash                   // block to be scanned next, nil if the genesis block scanned
+	earliest time.Time                         // time of the earliest block scanned
+	txBlocks map[chainhash.Hash]chainhash.Hash // block hash by the hash of the tx in the scanned blocks
+}
+
+// findTxBlock finds the block including the given tx in the blocks since the given time, scanning back from the tip.
+// Returns nil if the node has txindex or the tx is not found
+func (i *Initiator) findTxBlock(txHash *chainhash.Hash, since time.Time) (*chainhash.Hash, error) {
+	if i.hasTxIndex() {
+		return nil, nil
+	}
+
+	tip, err := i.RPCClient.GetBestBlockHash()
+	if err != nil {
+		return nil, err
+	}
+
+	if i.scan == nil || i.scan.tip != *tip {
+		i.scan = &blockScan{tip: *tip, next: tip, earliest: time.Now(), txBlocks: make(map[chainhash.Hash]chainhash.Hash)}
+	}
+
+	// the block time may be earlier than the time at which the tx is broadcast
+	since = since.Add(-BLOCK_TIME_TOLERANCE)
+
+	for {
+		if blockHash, ok := i.scan.txBlocks[*txHash]; ok {
+			return &blockHash, nil
+		}
+
+		if i.scan.next == nil || i.scan.earliest.Before(since) {
+			return nil, nil
+		}
+
+		block, err := i.RPCClient.GetBlockVerbose(i.scan.next)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, txID := range block.Tx {
+			blockTxHash, err := chainhash.NewHashFromStr(txID)
+			if err != nil {
+				return nil, err
+			}
+
+			i.scan.txBlocks[*blockTxHash] = *i.scan.next
+		}
+
+		i.scan.earliest = time.Unix(block.Time, 0)
+		i.scan.next = nil
+
+		if len(block.PreviousHash) > 0 {
+			if i.scan.next, err = chainhash.NewHashFromStr(block.PreviousHash); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// hasTxIndex checks if the node has the synced txindex by getindexinfo, false if unsupported by the node
+func (i *Initiator) hasTxIndex() bool {
+	if i.txIndex != nil {
+		return *i.txIndex
+	}
+
+	txIndex := false
+
+	resp, err := i.RPCClient.RawRequest("getindexinfo", []json.RawMessage{[]byte(`"txindex"`)})
+	if err == nil {
+		var indexes map[string]struct {
+			Synced bool `json:"synced"`
+		}
+
+		if json.Unmarshal(resp, &indexes) == nil {
+			txIndex = indexes["txindex"].Synced
+		}
+	}
+
+	i.txIndex = &txIndex
+
+	return txIndex
+}
+
+// isTxNotFoundErr checks if the given error indicates that the tx is neither in the mempool nor found in the chain by the node
+func isTxNotFoundErr(err error) bool {
+	rpcErr, ok := err.(*btcjson.RPCError)
+
+	return ok && rpcErr.Code == btcjson.ErrRPCNoTxInfo
+}
+
+// Rebroadcast broadcasts the journaled commit tx, reveal txs and CPFP child txs again, skipping the txs already known by the node
+func (i *Initiator) Rebroadcast(entry *journal.Entry) error {
+	if err := i.ensureCommitTx(entry); err != nil {
+		return err
+	}
+
+	txs := make([]*wire.MsgTx, 0, len(entry.Reveals))
+
+	for _, revealEntry := range entry.Reveals {
+		tx, err := basics.DecodeTx(revealEntry.Tx)
+		if err != nil {
+			return err
+		}
+
+		txs = append(txs, tx)
+
+		if len(revealEntry.ChildTx) == 0 {
+			continue
+		}
+
+		childTx, err := basics.DecodeTx(revealEntry.ChildTx)
+		if err != nil {
+			return err
+		}
+
+		txs = append(txs, childTx)
+	}
+
+	if len(txs) > 0 {
+		if _, err := i.Broadcaster.Submit(txs); err != nil {
+			return err
+		}
+	}
+
+	entry.Status = journal.STATUS_BROADCAST
+
+	return i.Journal.Write(entry)
+}
+
+// RebuildReveals rebuilds the unconfirmed reveal txs of the journal entry at the given fee rate, funded by the utxos of the given address.
+// The rebuilt reveal txs replace the previous ones by spending the same commit outputs, and are journaled before broadcasting
+func (i *Initiator) RebuildReveals(entry *journal.Entry, key *secp256k1.PrivateKey, addr btcutil.Address, feeRate float64) error {
+	if err := i.ensureCommitTx(entry); err != nil {
+		return err
+	}
+
+	commitTx, err := entry.GetCommitTx()
+	if err != nil {
+		return err
+	}
+
+	utxos, err := i.UTXOProvider.GetUTXOs(addr)
+	if err != nil {
+		return err
+	}
+
+	utxos = excludeSpentUtxos(utxos, commitTx)
+
+	inscriber := i.newInscriber()
+
+	for idx, revealEntry := range entry.Reveals {
+		status, err := i.GetTxStatus(revealEntry.TxID, entry.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		if status == TX_STATUS_CONFIRMED {
+			continue
+		}
+
+		reveal, err := revealEntry.GetReveal()
+		if err != nil {
+			return err
+		}
+
+		tx, err := i.replaceReveal(inscriber, entry, revealEntry, reveal, key, addr, utxos, feeRate)
+		if err != nil {
+			return fmt.Errorf("failed to rebuild reveal tx %d: %v", idx, err)
+		}
+
+		utxos = excludeSpentUtxos(utxos, tx)
+	}
+
+	entry.Status = journal.STATUS_BROADCAST
+
+	return i.Journal.Write(entry)
+}
+
+// Sweep sweeps the unspent commit outputs of the journal entry to the given address through the key path,
+// so that the ops in the envelopes are not executed
+func (i *Initiator) Sweep(entry *journal.Entry, toAddress btcutil.Address, feeRate float64) (*chainhash.Hash, error) {
+	status, err := i.GetTxStatus(entry.CommitTxID, entry.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if status == TX_STATUS_UNKNOWN {
+		return nil, fmt.Errorf("commit tx %s unknown by the node, the funds are not committed", entry.CommitTxID)
+	}
+
+	commitTxHash, err := chainhash.NewHashFromStr(entry.CommitTxID)
+	if err != nil {
+		return nil, err
+	}
+
+	reveals := make([]*inscriber.Reveal, 0, len(entry.Reveals))
+
+	for _, revealEntry := range entry.Reveals {
+		txOut, err := i.RPCClient.GetTxOut(commitTxHash, revealEntry.CommitOutIndex, true)
+		if err != nil {
+			return nil, err
+		}
+
+		if txOut == nil {
+			continue
+		}
+
+		reveal, err := revealEntry.GetReveal()
+		if err != nil {
+			return nil, err
+		}
+
+		reveals = append(reveals, reveal)
+	}
+
+	if len(reveals) == 0 {
+		return nil, fmt.Errorf("commit outputs of %s already spent", entry.CommitTxID)
+	}
+
+	tx, err := i.newInscriber().BuildSweepTx(reveals, toAddress, feeRate)
+	if err != nil {
+		return nil, err
+	}
+
+	txHash, err := i.RPCClient.SendRawTransaction(tx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Status = journal.STATUS_SWEPT
+	entry.SweepTxID = txHash.String()
+
+	if err := i.Journal.Write(entry); err != nil {
+		return nil, fmt.Errorf("failed to write journal: %v", err)
+	}
+
+	return txHash, nil
+}
+
+// ensureCommitTx broadcasts the journaled commit tx unless known by the node
+func (i *Initiator) ensureCommitTx(entry *journal.Entry) error {
+	commitTx, err := entry.GetCommitTx()
+	if err != nil {
+		return err
+	}
+
+	if _, err := i.Broadcaster.Submit([]*wire.MsgTx{commitTx}); err != nil {
+		return err
+	}
+
+	return nil
+}
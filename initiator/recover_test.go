@@ -0,0 +1,75 @@
+package initiator
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/rpcclient"
+
+	"btc-sbt/devnet"
+	"btc-sbt/logger"
+)
+
+// newTestDevnet starts the devnet node with the given blocks mined, returning the initiator connected to it
+func newTestDevnet(t *testing.T, blocks int) (*devnet.Node, *Initiator) {
+	node := devnet.NewNode("user", "pass", 1, logger.Logger)
+
+	server := httptest.NewServer(node.Router)
+	t.Cleanup(server.Close)
+
+	rpcClient, err := rpcclient.New(&rpcclient.ConnConfig{
+		Host:         strings.TrimPrefix(server.URL, "http://"),
+		User:         "user",
+		Pass:         "pass",
+		HTTPPostMode: true,
+		DisableTLS:   true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to create the rpc client: %v", err)
+	}
+
+	t.Cleanup(rpcClient.Shutdown)
+
+	if blocks > 0 {
+		if _, err := node.Mine(blocks, newTestTaprootAddress(t)); err != nil {
+			t.Fatalf("failed to mine: %v", err)
+		}
+	}
+
+	return node, &Initiator{RPCClient: rpcClient, NetParams: node.NetParams, Logger: logger.Logger}
+}
+
+func TestGetTxStatus(t *testing.T) {
+	node, i := newTestDevnet(t, 3)
+
+	coinbase := node.Chain.GetBlock(1).Transactions[0].TxHash().String()
+	unknown := chainhash.HashH([]byte("unknown")).String()
+	since := time.Now().Add(-time.Minute)
+
+	for _, txIndex := range []bool{true, false} {
+		node.TxIndex = txIndex
+		i.txIndex, i.scan = nil, nil
+
+		if status, err := i.GetTxStatus(coinbase, since); err != nil || status != TX_STATUS_CONFIRMED {
+			t.Fatalf("txindex %v: got %s, %v; want confirmed", txIndex, status, err)
+		}
+
+		if status, err := i.GetTxStatus(unknown, since); err != nil || status != TX_STATUS_UNKNOWN {
+			t.Fatalf("txindex %v: got %s, %v; want unknown", txIndex, status, err)
+		}
+	}
+
+	// the blocks before the given time are not scanned
+	i.scan = nil
+
+	if status, err := i.GetTxStatus(coinbase, time.Now().Add(BLOCK_TIME_TOLERANCE+time.Hour)); err != nil || status != TX_STATUS_UNKNOWN {
+		t.Fatalf("got %s, %v; want unknown beyond the scanned blocks", status, err)
+	}
+
+	if _, err := i.GetTxStatus("invalid", since); err == nil {
+		t.Fatalf("invalid txid: got nil; want error")
+	}
+}
@@ -9,54 +9,70 @@ import (
 	"github.com/btcsuite/btcd/rpcclient"
 
 	"btc-sbt/config"
+	"btc-sbt/journal"
 	"btc-sbt/logger"
+	"btc-sbt/stacks/basics"
 	"btc-sbt/stacks/client/base"
+	"btc-sbt/stacks/client/broadcaster"
+	"btc-sbt/stacks/client/btcapi"
+	"btc-sbt/stacks/client/btcapi/bitcoind"
+	"btc-sbt/stacks/client/btcapi/mempool"
+	"btc-sbt/stacks/client/ordinals"
 	"btc-sbt/stacks/client/unisat"
+	"btc-sbt/stacks/taproot/inscriber"
 )
 
 // Initiator defines the initiator struct which is intended to initiate the protocol operations
 type Initiator struct {
-	RPCClient *rpcclient.Client // btc rpc client
+	RPCClient   *rpcclient.Client        // btc rpc client
+	Broadcaster *broadcaster.Broadcaster // broadcaster checking and submitting the dependent txs together
 
-	UnisatClient *unisat.Client // unisat client
+	UTXOProvider  btcapi.UTXOProvider   // provider of the utxos funding the txs
+	CoinSelection *basics.CoinSelection // coin selection of the utxos funding the txs
+
+	Journal *journal.Journal // commit and reveal journal
 
 	NetParams *chaincfg.Params // net params
 	Config    *config.Config   // config
 
+	Force bool // indicates if the ops are initiated regardless of the failed preflight checks
+
 	Logger *logrus.Logger // logger
+
+	txIndex *bool      // indicates if the node has txindex, nil if not checked yet
+	scan    *blockScan // blocks scanned for the confirmed txs without txindex
 }
 
 // NewInitiator creates a new Initiator instance
 func NewInitiator(config *config.Config) (*Initiator, error) {
-	var netParams *chaincfg.Params
+	netParams := config.Network.NetParams
 
-	switch config.NetVersion {
-	case 0:
-		netParams = &chaincfg.MainNetParams
-	case 1:
-		netParams = &chaincfg.TestNet3Params
-	default:
-		netParams = &chaincfg.SigNetParams
+	rpcClient, err := createRPCClient(config, netParams)
+	if err != nil {
+		return nil, err
 	}
 
-	rpcClient, err := createRPCClient(config, netParams)
+	utxoProvider, err := createUTXOProvider(config, rpcClient)
 	if err != nil {
 		return nil, err
 	}
 
-	unisatClient := createUnisatClient(config)
-	if unisatClient == nil {
-		return nil, fmt.Errorf("unisat client not created")
+	coinSelection, err := createCoinSelection(config)
+	if err != nil {
+		return nil, err
 	}
 
 	logger.Logger.SetLevel(logrus.Level(config.LogLevel))
 
 	return &Initiator{
-		RPCClient:    rpcClient,
-		UnisatClient: unisatClient,
-		NetParams:    netParams,
-		Config:       config,
-		Logger:       logger.Logger,
+		RPCClient:     rpcClient,
+		Broadcaster:   broadcaster.NewBroadcaster(rpcClient),
+		UTXOProvider:  utxoProvider,
+		CoinSelection: coinSelection,
+		Journal:       journal.Open(config.JournalPath),
+		NetParams:     netParams,
+		Config:        config,
+		Logger:        logger.Logger,
 	}, nil
 }
 
@@ -83,14 +99,63 @@ func createRPCClient(config *config.Config, netParams *chaincfg.Params) (*rpccli
 	return rpcClient, nil
 }
 
-// createUnisatClient creates a unisat client
-func createUnisatClient(config *config.Config) *unisat.Client {
-	if len(config.UnisatAPI) > 0 {
-		return unisat.NewClient(
-			config.UnisatAPI,
-			base.NewClient(config.Retries+1, config.Interval),
-		)
+// createUTXOProvider creates the utxo provider chosen in the config, wrapped by the inscription filter if the ord server is configured
+func createUTXOProvider(c *config.Config, rpcClient *rpcclient.Client) (btcapi.UTXOProvider, error) {
+	var provider btcapi.UTXOProvider
+
+	switch c.UTXO.Provider {
+	case config.UTXO_PROVIDER_ESPLORA:
+		if len(c.UTXO.EsploraAPI) == 0 {
+			return nil, fmt.Errorf("esplora api required by the utxo provider %s on %s", c.UTXO.Provider, c.Network.Name)
+		}
+
+		provider = mempool.NewClient(c.UTXO.EsploraAPI, base.NewClient(c.Retries+1, c.Interval))
+
+	case config.UTXO_PROVIDER_BITCOIND:
+		provider = bitcoind.NewClient(rpcClient, c.UTXO.Scan)
+
+	default:
+		if len(c.UnisatAPI) == 0 {
+			return nil, fmt.Errorf("unisat api required by the utxo provider %s", c.UTXO.Provider)
+		}
+
+		provider = unisat.NewClient(c.UnisatAPI, base.NewClient(c.Retries+1, c.Interval))
+	}
+
+	if len(c.UTXO.OrdinalsAPI) > 0 {
+		provider = ordinals.NewUTXOFilter(provider, ordinals.NewClient(c.UTXO.OrdinalsAPI, base.NewClient(c.Retries+1, c.Interval)))
+	}
+
+	return provider, nil
+}
+
+// createCoinSelection creates the coin selection from the config, nil for largest first which is the default of the inscriber
+func createCoinSelection(c *config.Config) (*basics.CoinSelection, error) {
+	strategy, err := basics.ParseCoinSelectionStrategy(c.UTXO.CoinSelection)
+	if err != nil {
+		return nil, err
+	}
+
+	if strategy == basics.LargestFirst && !c.UTXO.ExcludeUnconfirmed && len(c.UTXO.Locked) == 0 {
+		return nil, nil
+	}
+
+	coinSelection := basics.NewCoinSelection(strategy, c.UTXO.LongTermFeeRate)
+	coinSelection.ExcludeUnconfirmed = c.UTXO.ExcludeUnconfirmed
+
+	for _, locked := range c.UTXO.Locked {
+		outPoint, err := basics.ParseOutPoint(locked)
+		if err != nil {
+			return nil, err
+		}
+
+		coinSelection.LockedOutPoints = append(coinSelection.LockedOutPoints, *outPoint)
 	}
 
-	return nil
+	return coinSelection, nil
+}
+
+// newInscriber creates the inscriber with the configured coin selection and the broadcaster of the initiator
+func (i *Initiator) newInscriber() *inscriber.Inscriber {
+	return inscriber.NewInscriber(i.RPCClient, i.NetParams).SetCoinSelection(i.CoinSelection).SetBroadcaster(i.Broadcaster)
 }
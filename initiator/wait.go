@@ -0,0 +1,232 @@
+package initiator
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/types"
+)
+
+// WaitForOutcome waits until the given reveal tx reaches the confirmations and is handled by the indexer,
+// and then returns the indexed record along with the resulting SBTs or SBT of each applied op.
+// The indexed state is queried through the configured btc-sbt node api, or the local db opened read-only.
+// Stops with the error once the context is done, or if the reveal tx is dropped or replaced
+func (i *Initiator) WaitForOutcome(ctx context.Context, revealTx *wire.MsgTx, confirmations int64) (*types.TxRecord, error) {
+	blockHeight, err := i.WaitForConfirmations(ctx, revealTx, confirmations)
+	if err != nil {
+		return nil, err
+	}
+
+	revealTxHash := revealTx.TxHash()
+
+	return i.WaitForIndexed(ctx, &revealTxHash, blockHeight)
+}
+
+// WaitForConfirmations waits until the given tx broadcast recently reaches the confirmations, returning the height of the block including the tx.
+// Without txindex, the tx no longer in the mempool is looked up in the blocks since the wait started.
+// Returns the error if the tx is neither in the mempool nor in the chain, i.e. dropped by the node or any input spent by another tx
+func (i *Initiator) WaitForConfirmations(ctx context.Context, tx *wire.MsgTx, confirmations int64) (int64, error) {
+	if confirmations <= 0 {
+		confirmations = 1
+	}
+
+	txHash := tx.TxHash()
+	since := time.Now()
+
+	lastConfirmations := int64(-1)
+
+	for {
+		current, blockHeight, err := i.getConfirmations(tx, since)
+		if err != nil {
+			if _, ok := err.(*TxMissingError); ok {
+				return 0, err
+			}
+
+			i.Logger.Warnf("Failed to get the confirmations of tx %s: %v", txHash, err)
+		} else if current != lastConfirmations {
+			i.Logger.Infof("Tx %s: %d/%d confirmations", txHash, current, confirmations)
+
+			lastConfirmations = current
+
+			if current >= confirmations {
+				return blockHeight, nil
+			}
+		}
+
+		if err := waitForNextPoll(ctx); err != nil {
+			return 0, fmt.Errorf("stopped waiting for tx %s at %d/%d confirmations: %v", txHash, lastConfirmations, confirmations, err)
+		}
+	}
+}
+
+// WaitForIndexed waits until the indexer handles the block at the given height, and then gets the record of the given tx.
+// The local db is reopened on each poll as the db can not be opened while the node is running
+func (i *Initiator) WaitForIndexed(ctx context.Context, txHash *chainhash.Hash, blockHeight int64) (*types.TxRecord, error) {
+	for {
+		record, indexed, err := i.getIndexedTx(txHash, blockHeight)
+		if err != nil {
+			i.Logger.Warnf("Failed to query the indexed state: %v", err)
+		} else if indexed {
+			if record == nil {
+				return nil, fmt.Errorf("no protocol operations indexed for tx %s at block %d", txHash, blockHeight)
+			}
+
+			return record, nil
+		}
+
+		if err := waitForNextPoll(ctx); err != nil {
+			return nil, fmt.Errorf("stopped waiting for block %d to be indexed: %v", blockHeight, err)
+		}
+	}
+}
+
+// TxMissingError defines the error of the tx neither in the mempool nor in the chain
+type TxMissingError struct {
+	TxHash  chainhash.Hash
+	SpentBy *wire.OutPoint // input spent by another tx, nil if the tx is dropped by the node with all inputs unspent
+}
+
+// Error implements error
+func (e *TxMissingError) Error() string {
+	if e.SpentBy != nil {
+		return fmt.Sprintf("tx %s replaced or double spent: input %s spent by another tx", e.TxHash, e.SpentBy)
+	}
+
+	return fmt.Sprintf("tx %s dropped by the node with the inputs unspent; rebroadcast it by recover", e.TxHash)
+}
+
+// getConfirmations gets the confirmations of the given tx broadcast since the given time along with the height of the including block,
+// 0 confirmations if in the mempool. Returns *TxMissingError if the tx is neither in the mempool nor in the chain
+func (i *Initiator) getConfirmations(tx *wire.MsgTx, since time.Time) (int64, int64, error) {
+	txHash := tx.TxHash()
+
+	blockHash, found, err := i.getTxBlock(&txHash, since)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if !found {
+		spent, err := i.getSpentInput(tx)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		// the tx may be confirmed in the meantime, spending the inputs
+		if blockHash, found, err = i.getTxBlock(&txHash, since); err != nil {
+			return 0, 0, err
+		}
+
+		if !found {
+			return 0, 0, &TxMissingError{TxHash: txHash, SpentBy: spent}
+		}
+	}
+
+	if blockHash == nil {
+		return 0, 0, nil
+	}
+
+	header, err := i.RPCClient.GetBlockHeaderVerbose(blockHash)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return int64(header.Confirmations), int64(header.Height), nil
+}
+
+// getTxBlock gets the hash of the block including the given tx broadcast since the given time, nil if in the mempool.
+// Returns false if the tx is neither in the mempool nor in the chain
+func (i *Initiator) getTxBlock(txHash *chainhash.Hash, since time.Time) (*chainhash.Hash, bool, error) {
+	tx, err := i.RPCClient.GetRawTransactionVerbose(txHash)
+	if err == nil {
+		if len(tx.BlockHash) == 0 {
+			return nil, true, nil
+		}
+
+		blockHash, err := chainhash.NewHashFromStr(tx.BlockHash)
+		if err != nil {
+			return nil, false, err
+		}
+
+		return blockHash, true, nil
+	}
+
+	if !isTxNotFoundErr(err) {
+		return nil, false, err
+	}
+
+	blockHash, err := i.findTxBlock(txHash, since)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return blockHash, blockHash != nil, nil
+}
+
+// getSpentInput gets the input of the given tx spent by another tx in the mempool or the chain, nil if all unspent
+func (i *Initiator) getSpentInput(tx *wire.MsgTx) (*wire.OutPoint, error) {
+	for _, txIn := range tx.TxIn {
+		outPoint := txIn.PreviousOutPoint
+
+		txOut, err := i.RPCClient.GetTxOut(&outPoint.Hash, outPoint.Index, true)
+		if err != nil {
+			return nil, err
+		}
+
+		if txOut == nil {
+			return &outPoint, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// waitForNextPoll waits for the poll interval, returning the error if the context is done first
+func waitForNextPoll(ctx context.Context) error {
+	timer := time.NewTimer(WAIT_POLL_INTERVAL)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+
+	case <-timer.C:
+		return nil
+	}
+}
+
+// getIndexedTx gets the record of the given tx if the block at the given height has been indexed
+func (i *Initiator) getIndexedTx(txHash *chainhash.Hash, blockHeight int64) (*types.TxRecord, bool, error) {
+	reader, closer, err := i.newStateReader()
+	if err != nil {
+		return nil, false, err
+	}
+
+	defer closer()
+
+	lastBlockHeight, err := reader.GetLastBlockHeight()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if lastBlockHeight < blockHeight {
+		i.Logger.Debugf("Waiting for the indexer to handle block %d, last indexed: %d", blockHeight, lastBlockHeight)
+		return nil, false, nil
+	}
+
+	records, err := reader.GetTx(txHash)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, record := range records {
+		if record.TxHash == txHash.String() {
+			return record, true, nil
+		}
+	}
+
+	return nil, true, nil
+}
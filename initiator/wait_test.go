@@ -0,0 +1,134 @@
+package initiator
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/devnet"
+	"btc-sbt/stacks/basics"
+	"btc-sbt/stacks/taproot"
+)
+
+// testWallet spends the mature coinbase outputs of the devnet paying to its key
+type testWallet struct {
+	key      *btcec.PrivateKey
+	pkScript []byte
+	node     *devnet.Node
+}
+
+// newTestWallet mines the given number of mature coinbase outputs to a new key on the devnet
+func newTestWallet(t *testing.T, node *devnet.Node, coinbases int) *testWallet {
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate the key: %v", err)
+	}
+
+	addr, err := basics.GetAddress(key, basics.Taproot, node.NetParams)
+	if err != nil {
+		t.Fatalf("failed to get the address: %v", err)
+	}
+
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("failed to get the script: %v", err)
+	}
+
+	if _, err := node.Mine(coinbases+int(node.NetParams.CoinbaseMaturity), addr); err != nil {
+		t.Fatalf("failed to mine: %v", err)
+	}
+
+	return &testWallet{key: key, pkScript: pkScript, node: node}
+}
+
+// spend builds the tx spending the coinbase output of the block at the given height back to the key, paying the given fee
+func (w *testWallet) spend(t *testing.T, height int64, fee int64) *wire.MsgTx {
+	coinbase := w.node.Chain.GetBlock(height).Transactions[0]
+	coinbaseHash := coinbase.TxHash()
+
+	utxo := basics.NewUTXO(&coinbaseHash, 0, coinbase.TxOut[0].Value, w.pkScript)
+
+	tx := wire.NewMsgTx(basics.TxVersion)
+	tx.AddTxIn(&wire.TxIn{PreviousOutPoint: *utxo.GetOutPoint(), Sequence: basics.RBFSequence})
+	tx.AddTxOut(wire.NewTxOut(utxo.Value-fee, w.pkScript))
+
+	if err := taproot.SignTaprootTransaction(w.key, tx, []*basics.UTXO{utxo}, txscript.SigHashDefault); err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	return tx
+}
+
+// send builds and sends the tx spending the coinbase output of the block at the given height
+func (w *testWallet) send(t *testing.T, height int64, fee int64) *wire.MsgTx {
+	tx := w.spend(t, height, fee)
+
+	if _, err := w.node.SendTx(tx); err != nil {
+		t.Fatalf("failed to send: %v", err)
+	}
+
+	return tx
+}
+
+func TestWaitForConfirmations(t *testing.T) {
+	node, i := newTestDevnet(t, 0)
+	wallet := newTestWallet(t, node, 2)
+
+	for idx, txIndex := range []bool{true, false} {
+		node.TxIndex = txIndex
+		i.txIndex, i.scan = nil, nil
+
+		tx := wallet.send(t, int64(idx+1), 1000)
+
+		if _, err := node.Mine(2, newTestTaprootAddress(t)); err != nil {
+			t.Fatalf("failed to mine: %v", err)
+		}
+
+		blockHeight, err := i.WaitForConfirmations(context.Background(), tx, 2)
+		if err != nil {
+			t.Fatalf("txindex %v: failed to wait: %v", txIndex, err)
+		}
+
+		if want := node.Chain.Height() - 1; blockHeight != want {
+			t.Fatalf("txindex %v: got block %d; want %d", txIndex, blockHeight, want)
+		}
+	}
+}
+
+func TestWaitForConfirmationsStopped(t *testing.T) {
+	node, i := newTestDevnet(t, 0)
+	wallet := newTestWallet(t, node, 3)
+
+	// timed out in the mempool
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if _, err := i.WaitForConfirmations(ctx, wallet.send(t, 1, 1000), 1); err == nil || !strings.Contains(err.Error(), "deadline") {
+		t.Fatalf("timed out: got %v; want the deadline error", err)
+	}
+
+	// replaced by the tx spending the same input
+	replaced := wallet.send(t, 2, 1000)
+	wallet.send(t, 2, 5000)
+
+	_, err := i.WaitForConfirmations(context.Background(), replaced, 1)
+	if missing, ok := err.(*TxMissingError); !ok || missing.SpentBy == nil || *missing.SpentBy != replaced.TxIn[0].PreviousOutPoint {
+		t.Fatalf("replaced: got %v; want the input spent by another tx", err)
+	}
+
+	// dropped by the node
+	dropped := wallet.send(t, 3, 1000)
+
+	droppedHash := dropped.TxHash()
+	node.Mempool.Evict(&droppedHash)
+
+	_, err = i.WaitForConfirmations(context.Background(), dropped, 1)
+	if missing, ok := err.(*TxMissingError); !ok || missing.SpentBy != nil {
+		t.Fatalf("dropped: got %v; want the tx dropped with the inputs unspent", err)
+	}
+}
@@ -0,0 +1,6 @@
+package journal
+
+const (
+	// Suffix of the journal entry file named by the commit tx id
+	JOURNAL_FILE_SUFFIX = ".json"
+)
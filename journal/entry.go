@@ -0,0 +1,143 @@
+package journal
+
+import (
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/stacks/basics"
+	"btc-sbt/stacks/taproot/inscriber"
+)
+
+// Status represents the status of the journal entry
+type Status string
+
+const (
+	STATUS_PENDING   Status = "pending"   // written before broadcasting
+	STATUS_BROADCAST Status = "broadcast" // commit and reveal txs broadcast
+	STATUS_SWEPT     Status = "swept"     // commit outputs swept back
+	STATUS_REPLACED  Status = "replaced"  // commit tx replaced by a bumped one
+)
+
+// Entry defines the journal entry of the commit tx and the reveal txs funded by it
+type Entry struct {
+	CommitTxID string         `json:"commit_txid"`           // commit tx id
+	CommitTx   string         `json:"commit_tx"`             // hex encoded signed commit tx
+	Reveals    []*RevealEntry `json:"reveals"`               // reveal txs in the order of the commit tx outputs
+	Status     Status         `json:"status"`                // entry status
+	SweepTxID  string         `json:"sweep_txid,omitempty"`  // tx id sweeping the commit outputs if any
+	Replaces   string         `json:"replaces,omitempty"`    // commit tx id replaced by this one if bumped
+	ReplacedBy string         `json:"replaced_by,omitempty"` // commit tx id replacing this one if bumped
+	CreatedAt  time.Time      `json:"created_at"`            // creation time
+	UpdatedAt  time.Time      `json:"updated_at"`            // last update time
+}
+
+// RevealEntry defines the reveal tx along with the ephemeral key material to spend the commit output through the script path
+type RevealEntry struct {
+	Key               string `json:"key"`                  // ephemeral WIF which the tapscript commits to
+	Tapscript         string `json:"tapscript"`            // hex encoded tapscript carrying the envelope
+	ControlBlock      string `json:"control_block"`        // hex encoded control block of the tapscript
+	CommitOutIndex    uint32 `json:"commit_out_index"`     // index of the commit tx output
+	CommitOutValue    int64  `json:"commit_out_value"`     // value of the commit tx output
+	CommitOutPkScript string `json:"commit_out_pk_script"` // hex encoded pk script of the commit tx output
+	Tx                string `json:"tx"`                   // hex encoded signed reveal tx
+	TxID              string `json:"txid"`                 // reveal tx id
+	ChildTx           string `json:"child_tx,omitempty"`   // hex encoded signed CPFP child tx if bumped
+	ChildTxID         string `json:"child_txid,omitempty"` // CPFP child tx id if bumped
+}
+
+// NewEntry creates a pending entry from the signed commit tx and the reveal txs signed against it
+func NewEntry(commitTx *wire.MsgTx, reveals []*inscriber.Reveal) (*Entry, error) {
+	commitTxHex, err := basics.EncodeTx(commitTx)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &Entry{
+		CommitTxID: commitTx.TxHash().String(),
+		CommitTx:   commitTxHex,
+		Reveals:    make([]*RevealEntry, 0, len(reveals)),
+		Status:     STATUS_PENDING,
+	}
+
+	for _, reveal := range reveals {
+		revealEntry := &RevealEntry{
+			Key:               reveal.Key.String(),
+			Tapscript:         hex.EncodeToString(reveal.Tx.TxIn[0].Witness[1]),
+			ControlBlock:      hex.EncodeToString(reveal.Tx.TxIn[0].Witness[2]),
+			CommitOutIndex:    reveal.CommitOutIndex,
+			CommitOutValue:    reveal.CommitTxOut.Value,
+			CommitOutPkScript: hex.EncodeToString(reveal.CommitTxOut.PkScript),
+		}
+
+		if err := revealEntry.SetTx(reveal.Tx); err != nil {
+			return nil, err
+		}
+
+		entry.Reveals = append(entry.Reveals, revealEntry)
+	}
+
+	return entry, nil
+}
+
+// GetCommitTx gets the signed commit tx
+func (e *Entry) GetCommitTx() (*wire.MsgTx, error) {
+	return basics.DecodeTx(e.CommitTx)
+}
+
+// GetReveal restores the reveal whose tx is the latest signed one
+func (re *RevealEntry) GetReveal() (*inscriber.Reveal, error) {
+	tx, err := basics.DecodeTx(re.Tx)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reveal tx: %v", err)
+	}
+
+	key, err := btcutil.DecodeWIF(re.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid reveal key: %v", err)
+	}
+
+	pkScript, err := hex.DecodeString(re.CommitOutPkScript)
+	if err != nil {
+		return nil, fmt.Errorf("invalid commit out pk script: %v", err)
+	}
+
+	return &inscriber.Reveal{
+		Tx:             tx,
+		Key:            key,
+		CommitTxOut:    wire.NewTxOut(re.CommitOutValue, pkScript),
+		CommitOutIndex: re.CommitOutIndex,
+	}, nil
+}
+
+// SetTx sets the signed reveal tx, e.g. rebuilt with a higher fee.
+// The CPFP child tx spending the previous reveal tx is cleared
+func (re *RevealEntry) SetTx(tx *wire.MsgTx) error {
+	txHex, err := basics.EncodeTx(tx)
+	if err != nil {
+		return err
+	}
+
+	re.Tx = txHex
+	re.TxID = tx.TxHash().String()
+	re.ChildTx = ""
+	re.ChildTxID = ""
+
+	return nil
+}
+
+// SetChildTx sets the signed CPFP child tx spending the reveal tx
+func (re *RevealEntry) SetChildTx(tx *wire.MsgTx) error {
+	txHex, err := basics.EncodeTx(tx)
+	if err != nil {
+		return err
+	}
+
+	re.ChildTx = txHex
+	re.ChildTxID = tx.TxHash().String()
+
+	return nil
+}
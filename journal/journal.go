@@ -0,0 +1,124 @@
+package journal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Journal persists the commit and reveal state as one JSON file per commit tx in the directory,
+// so that the commit outputs can be recovered if the reveal txs fail
+type Journal struct {
+	dir string // journal directory
+}
+
+// Open opens the journal in the given directory, which is created on the first entry written
+func Open(dir string) *Journal {
+	return &Journal{dir: dir}
+}
+
+// Write writes the given entry atomically, replacing the existing one of the same commit tx
+func (j *Journal) Write(entry *Entry) error {
+	if err := os.MkdirAll(j.dir, 0700); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = now
+	}
+
+	entry.UpdatedAt = now
+
+	bz, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	path := j.getPath(entry.CommitTxID)
+
+	tmpFile, err := os.CreateTemp(j.dir, filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(bz); err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), path)
+}
+
+// Get gets the entry of the given commit tx id
+func (j *Journal) Get(commitTxID string) (*Entry, error) {
+	bz, err := os.ReadFile(j.getPath(commitTxID))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("journal entry not found: %s", commitTxID)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(bz, &entry); err != nil {
+		return nil, fmt.Errorf("invalid journal entry %s: %v", commitTxID, err)
+	}
+
+	return &entry, nil
+}
+
+// List lists the entries in the order of creation
+func (j *Journal) List() ([]*Entry, error) {
+	files, err := os.ReadDir(j.dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]*Entry, 0, len(files))
+
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), JOURNAL_FILE_SUFFIX) {
+			continue
+		}
+
+		entry, err := j.Get(strings.TrimSuffix(file.Name(), JOURNAL_FILE_SUFFIX))
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, k int) bool {
+		return entries[i].CreatedAt.Before(entries[k].CreatedAt)
+	})
+
+	return entries, nil
+}
+
+// getPath gets the file path of the entry of the given commit tx id
+func (j *Journal) getPath(commitTxID string) string {
+	return filepath.Join(j.dir, commitTxID+JOURNAL_FILE_SUFFIX)
+}
@@ -0,0 +1,58 @@
+package keystore
+
+const (
+	// Key store version
+	KEY_STORE_VERSION = 1
+
+	// Environment variable from which the passphrase is read if set
+	PASSPHRASE_ENV = "BTC_SBT_KEYSTORE_PASSPHRASE"
+)
+
+const (
+	// Cipher for the key encryption
+	CIPHER_AES_256_GCM = "aes-256-gcm"
+
+	// Key derivation function
+	KDF_SCRYPT = "scrypt"
+
+	// scrypt params
+	SCRYPT_N = 1 << 17
+	SCRYPT_R = 8
+	SCRYPT_P = 1
+
+	// Bounds of the scrypt params read from the key store, beyond which the derivation would stall or exhaust the memory
+	SCRYPT_MAX_N      = 1 << 20
+	SCRYPT_MAX_R      = 32
+	SCRYPT_MAX_P      = 4
+	SCRYPT_MAX_MEMORY = 1 << 30 // 128 * N * R bytes
+
+	// Salt size in bytes
+	SALT_SIZE = 32
+
+	// Derived key size in bytes for AES-256
+	DERIVED_KEY_SIZE = 32
+)
+
+const (
+	// Entropy bits of the generated mnemonic, i.e. 24 words
+	MNEMONIC_ENTROPY_BITS = 256
+)
+
+const (
+	// BIP44 purpose for p2pkh
+	PURPOSE_BIP44 = 44
+
+	// BIP49 purpose for p2sh-p2wpkh
+	PURPOSE_BIP49 = 49
+
+	// BIP84 purpose for p2wpkh
+	PURPOSE_BIP84 = 84
+
+	// BIP86 purpose for taproot
+	PURPOSE_BIP86 = 86
+)
+
+const (
+	// Branch of the BIP86 account from which the authority key is derived, apart from the receiving (0) and change (1) branches
+	AUTHORITY_BRANCH = 2
+)
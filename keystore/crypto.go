@@ -0,0 +1,135 @@
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// CryptoJSON defines the encrypted secret along with the key derivation params
+type CryptoJSON struct {
+	Cipher     string    `json:"cipher"`     // cipher
+	CipherText string    `json:"ciphertext"` // hex encoded cipher text
+	Nonce      string    `json:"nonce"`      // hex encoded nonce
+	KDF        string    `json:"kdf"`        // key derivation function
+	KDFParams  KDFParams `json:"kdfparams"`  // key derivation params
+}
+
+// KDFParams defines the scrypt params
+type KDFParams struct {
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+	Salt string `json:"salt"` // hex encoded salt
+}
+
+// Validate checks if the scrypt params are within the bounds
+func (p KDFParams) Validate() error {
+	if p.N < 2 || p.N > SCRYPT_MAX_N || p.N&(p.N-1) != 0 {
+		return fmt.Errorf("invalid scrypt param N: %d, must be a power of 2 up to %d", p.N, SCRYPT_MAX_N)
+	}
+
+	if p.R < 1 || p.R > SCRYPT_MAX_R {
+		return fmt.Errorf("invalid scrypt param r: %d, must be between 1 and %d", p.R, SCRYPT_MAX_R)
+	}
+
+	if p.P < 1 || p.P > SCRYPT_MAX_P {
+		return fmt.Errorf("invalid scrypt param p: %d, must be between 1 and %d", p.P, SCRYPT_MAX_P)
+	}
+
+	if 128*p.N*p.R > SCRYPT_MAX_MEMORY {
+		return fmt.Errorf("invalid scrypt params N: %d, r: %d, exceeding the memory of %d bytes", p.N, p.R, SCRYPT_MAX_MEMORY)
+	}
+
+	return nil
+}
+
+// encrypt encrypts the secret with the key derived from the passphrase.
+// `ad` is the additional data authenticated along with the secret
+func encrypt(secret []byte, passphrase []byte, ad []byte) (*CryptoJSON, error) {
+	salt := make([]byte, SALT_SIZE)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	params := KDFParams{N: SCRYPT_N, R: SCRYPT_R, P: SCRYPT_P, Salt: hex.EncodeToString(salt)}
+
+	aead, err := newAEAD(passphrase, params)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return &CryptoJSON{
+		Cipher:     CIPHER_AES_256_GCM,
+		CipherText: hex.EncodeToString(aead.Seal(nil, nonce, secret, ad)),
+		Nonce:      hex.EncodeToString(nonce),
+		KDF:        KDF_SCRYPT,
+		KDFParams:  params,
+	}, nil
+}
+
+// decrypt decrypts the secret with the key derived from the passphrase
+func decrypt(crypto *CryptoJSON, passphrase []byte, ad []byte) ([]byte, error) {
+	if crypto.Cipher != CIPHER_AES_256_GCM || crypto.KDF != KDF_SCRYPT {
+		return nil, fmt.Errorf("unsupported cipher or kdf: %s, %s", crypto.Cipher, crypto.KDF)
+	}
+
+	cipherText, err := hex.DecodeString(crypto.CipherText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cipher text: %v", err)
+	}
+
+	nonce, err := hex.DecodeString(crypto.Nonce)
+	if err != nil {
+		return nil, fmt.Errorf("invalid nonce: %v", err)
+	}
+
+	if err := crypto.KDFParams.Validate(); err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(passphrase, crypto.KDFParams)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid nonce size: %d", len(nonce))
+	}
+
+	secret, err := aead.Open(nil, nonce, cipherText, ad)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt the key with the given passphrase")
+	}
+
+	return secret, nil
+}
+
+// newAEAD creates the AES-256-GCM cipher with the key derived from the passphrase
+func newAEAD(passphrase []byte, params KDFParams) (cipher.AEAD, error) {
+	salt, err := hex.DecodeString(params.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid salt: %v", err)
+	}
+
+	derivedKey, err := scrypt.Key(passphrase, salt, params.N, params.R, params.P, DERIVED_KEY_SIZE)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive the key: %v", err)
+	}
+
+	block, err := aes.NewCipher(derivedKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
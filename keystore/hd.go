@@ -0,0 +1,131 @@
+package keystore
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/tyler-smith/go-bip39"
+)
+
+// DerivationPath represents the BIP32 derivation path
+type DerivationPath []uint32
+
+// NewDerivationPath creates the derivation path `m/purpose'/coin'/account'/0/0` for the given purpose and account.
+// The coin type is 0 for mainnet and 1 for the test networks
+func NewDerivationPath(purpose uint32, account uint32, netParams *chaincfg.Params) DerivationPath {
+	coinType := uint32(1)
+	if netParams.Net == chaincfg.MainNetParams.Net {
+		coinType = 0
+	}
+
+	return DerivationPath{
+		hdkeychain.HardenedKeyStart + purpose,
+		hdkeychain.HardenedKeyStart + coinType,
+		hdkeychain.HardenedKeyStart + account,
+		0,
+		0,
+	}
+}
+
+// NewAuthorityDerivationPath creates the derivation path `m/86'/coin'/account'/2/0` of the authority key for the given account,
+// so that the authority key never coincides with a funding key of any account
+func NewAuthorityDerivationPath(account uint32, netParams *chaincfg.Params) DerivationPath {
+	path := NewDerivationPath(PURPOSE_BIP86, account, netParams)
+	path[3] = AUTHORITY_BRANCH
+
+	return path
+}
+
+// String implements fmt.Stringer
+func (p DerivationPath) String() string {
+	elems := []string{"m"}
+
+	for _, index := range p {
+		if index >= hdkeychain.HardenedKeyStart {
+			elems = append(elems, fmt.Sprintf("%d'", index-hdkeychain.HardenedKeyStart))
+		} else {
+			elems = append(elems, fmt.Sprintf("%d", index))
+		}
+	}
+
+	return strings.Join(elems, "/")
+}
+
+// NewMnemonic generates a new BIP39 mnemonic of 24 words
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(MNEMONIC_ENTROPY_BITS)
+	if err != nil {
+		return "", err
+	}
+
+	return bip39.NewMnemonic(entropy)
+}
+
+// ValidateHDSecret validates the mnemonic or the extended private key according to the key type
+func ValidateHDSecret(keyType KeyType, secret string, netParams *chaincfg.Params) error {
+	switch keyType {
+	case KEY_TYPE_MNEMONIC:
+		if !bip39.IsMnemonicValid(secret) {
+			return fmt.Errorf("invalid mnemonic")
+		}
+
+	case KEY_TYPE_XPRV:
+		extendedKey, err := hdkeychain.NewKeyFromString(secret)
+		if err != nil {
+			return fmt.Errorf("invalid extended key: %v", err)
+		}
+
+		if !extendedKey.IsPrivate() {
+			return fmt.Errorf("extended private key required")
+		}
+
+		if !extendedKey.IsForNet(netParams) {
+			return fmt.Errorf("extended key is not for %s", netParams.Name)
+		}
+
+	default:
+		return fmt.Errorf("not an HD key type: %s", keyType)
+	}
+
+	return nil
+}
+
+// deriveKey derives the private key by the given path from the mnemonic or the extended private key
+func deriveKey(keyType KeyType, secret string, path DerivationPath, netParams *chaincfg.Params) (*secp256k1.PrivateKey, error) {
+	var extendedKey *hdkeychain.ExtendedKey
+	var err error
+
+	switch keyType {
+	case KEY_TYPE_MNEMONIC:
+		seed, err := bip39.NewSeedWithErrorChecking(secret, "")
+		if err != nil {
+			return nil, fmt.Errorf("invalid mnemonic: %v", err)
+		}
+
+		extendedKey, err = hdkeychain.NewMaster(seed, netParams)
+		if err != nil {
+			return nil, err
+		}
+
+	case KEY_TYPE_XPRV:
+		extendedKey, err = hdkeychain.NewKeyFromString(secret)
+		if err != nil {
+			return nil, fmt.Errorf("invalid extended key: %v", err)
+		}
+
+	default:
+		return nil, fmt.Errorf("not an HD key type: %s", keyType)
+	}
+
+	for _, index := range path {
+		extendedKey, err = extendedKey.Derive(index)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive %s: %v", path, err)
+		}
+	}
+
+	return extendedKey.ECPrivKey()
+}
@@ -0,0 +1,50 @@
+package keystore
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+func TestAuthorityDerivationPath(t *testing.T) {
+	netParams := &chaincfg.RegressionNetParams
+
+	if got := NewAuthorityDerivationPath(3, netParams).String(); got != "m/86'/1'/3'/2/0" {
+		t.Fatalf("got %s; want m/86'/1'/3'/2/0", got)
+	}
+
+	if got := NewAuthorityDerivationPath(0, &chaincfg.MainNetParams).String(); got != "m/86'/0'/0'/2/0" {
+		t.Fatalf("got %s; want m/86'/0'/0'/2/0", got)
+	}
+
+	// the authority key never coincides with the funding key of the same account
+	funding := NewDerivationPath(PURPOSE_BIP86, 0, netParams)
+	authority := NewAuthorityDerivationPath(0, netParams)
+
+	if funding.String() == authority.String() {
+		t.Fatalf("authority path %s equals the funding path", authority)
+	}
+}
+
+func TestDeriveAuthorityKey(t *testing.T) {
+	netParams := &chaincfg.RegressionNetParams
+
+	mnemonic, err := NewMnemonic()
+	if err != nil {
+		t.Fatalf("failed to generate the mnemonic: %v", err)
+	}
+
+	funding, err := deriveKey(KEY_TYPE_MNEMONIC, mnemonic, NewDerivationPath(PURPOSE_BIP86, 0, netParams), netParams)
+	if err != nil {
+		t.Fatalf("failed to derive the funding key: %v", err)
+	}
+
+	authority, err := deriveKey(KEY_TYPE_MNEMONIC, mnemonic, NewAuthorityDerivationPath(0, netParams), netParams)
+	if err != nil {
+		t.Fatalf("failed to derive the authority key: %v", err)
+	}
+
+	if funding.Key.Equals(&authority.Key) {
+		t.Fatalf("the authority key equals the funding key")
+	}
+}
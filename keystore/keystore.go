@@ -0,0 +1,308 @@
+package keystore
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+// Name pattern of the keys
+var keyNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// KeyStore holds several named keys encrypted by the passphrase
+type KeyStore struct {
+	path string // key store file path
+
+	entries map[string]*Entry // entries by name
+}
+
+// KeyType represents the type of the stored key
+type KeyType string
+
+const (
+	KEY_TYPE_KEY      KeyType = "key"      // single private key
+	KEY_TYPE_MNEMONIC KeyType = "mnemonic" // BIP39 mnemonic
+	KEY_TYPE_XPRV     KeyType = "xprv"     // BIP32 extended private key
+)
+
+// IsHD returns true if the keys are derived from the stored secret, false otherwise
+func (t KeyType) IsHD() bool {
+	return t == KEY_TYPE_MNEMONIC || t == KEY_TYPE_XPRV
+}
+
+// Entry defines the encrypted key entry.
+// The public key of the single private key is kept in plain text so that the key can be listed without the passphrase
+type Entry struct {
+	Name   string      `json:"name"`             // key name
+	Type   KeyType     `json:"type,omitempty"`   // key type, default to the single private key
+	PubKey string      `json:"pubkey,omitempty"` // hex encoded compressed public key of the single private key
+	Crypto *CryptoJSON `json:"crypto"`           // encrypted private key, mnemonic or extended private key
+}
+
+// GetType returns the key type
+func (e *Entry) GetType() KeyType {
+	if len(e.Type) == 0 {
+		return KEY_TYPE_KEY
+	}
+
+	return e.Type
+}
+
+// keyStoreJSON is the JSON representation of the KeyStore
+type keyStoreJSON struct {
+	Version int      `json:"version"`
+	Keys    []*Entry `json:"keys"`
+}
+
+// Open opens the key store at the given path.
+// An empty key store is returned if the file does not exist, which is created on the first key added
+func Open(path string) (*KeyStore, error) {
+	ks := &KeyStore{
+		path:    path,
+		entries: make(map[string]*Entry),
+	}
+
+	bz, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ks, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsKeyStore(bz) {
+		return nil, fmt.Errorf("%s is not a key store", path)
+	}
+
+	var raw keyStoreJSON
+	if err := json.Unmarshal(bz, &raw); err != nil {
+		return nil, fmt.Errorf("invalid key store: %v", err)
+	}
+
+	if raw.Version != KEY_STORE_VERSION {
+		return nil, fmt.Errorf("unsupported key store version: %d", raw.Version)
+	}
+
+	for _, entry := range raw.Keys {
+		ks.entries[entry.Name] = entry
+	}
+
+	return ks, nil
+}
+
+// IsKeyStore returns true if the given file contents are a key store rather than a legacy plain WIF, false otherwise
+func IsKeyStore(contents []byte) bool {
+	return json.Valid(contents)
+}
+
+// Names returns the sorted key names
+func (ks *KeyStore) Names() []string {
+	names := make([]string, 0, len(ks.entries))
+	for name := range ks.entries {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Has returns true if the key of the given name exists, false otherwise
+func (ks *KeyStore) Has(name string) bool {
+	_, ok := ks.entries[name]
+	return ok
+}
+
+// Type returns the type of the key of the given name
+func (ks *KeyStore) Type(name string) (KeyType, error) {
+	entry, err := ks.getEntry(name)
+	if err != nil {
+		return "", err
+	}
+
+	return entry.GetType(), nil
+}
+
+// PubKey returns the public key of the given name without decryption.
+// Only applicable to the single private key
+func (ks *KeyStore) PubKey(name string) (*secp256k1.PublicKey, error) {
+	entry, err := ks.getEntry(name, KEY_TYPE_KEY)
+	if err != nil {
+		return nil, err
+	}
+
+	pubKeyBytes, err := hex.DecodeString(entry.PubKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key of %s: %v", name, err)
+	}
+
+	return btcec.ParsePubKey(pubKeyBytes)
+}
+
+// Get decrypts the single private key of the given name with the passphrase
+func (ks *KeyStore) Get(name string, passphrase []byte) (*secp256k1.PrivateKey, error) {
+	entry, err := ks.getEntry(name, KEY_TYPE_KEY)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := decrypt(entry.Crypto, passphrase, []byte(name))
+	if err != nil {
+		return nil, err
+	}
+
+	key, _ := btcec.PrivKeyFromBytes(secret)
+
+	return key, nil
+}
+
+// Derive decrypts the mnemonic or the extended private key of the given name with the passphrase
+// and derives the private key by the given path
+func (ks *KeyStore) Derive(name string, passphrase []byte, path DerivationPath, netParams *chaincfg.Params) (*secp256k1.PrivateKey, error) {
+	secret, err := ks.GetHDSecret(name, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return deriveKey(ks.entries[name].GetType(), secret, path, netParams)
+}
+
+// GetHDSecret decrypts the mnemonic or the extended private key of the given name with the passphrase
+func (ks *KeyStore) GetHDSecret(name string, passphrase []byte) (string, error) {
+	entry, err := ks.getEntry(name, KEY_TYPE_MNEMONIC, KEY_TYPE_XPRV)
+	if err != nil {
+		return "", err
+	}
+
+	secret, err := decrypt(entry.Crypto, passphrase, []byte(name))
+	if err != nil {
+		return "", err
+	}
+
+	return string(secret), nil
+}
+
+// Add encrypts the given private key with the passphrase and saves it under the given name
+func (ks *KeyStore) Add(name string, key *secp256k1.PrivateKey, passphrase []byte) error {
+	return ks.add(&Entry{
+		Name:   name,
+		PubKey: hex.EncodeToString(key.PubKey().SerializeCompressed()),
+	}, key.Serialize(), passphrase)
+}
+
+// AddHD encrypts the given mnemonic or extended private key with the passphrase and saves it under the given name
+func (ks *KeyStore) AddHD(name string, keyType KeyType, secret string, passphrase []byte, netParams *chaincfg.Params) error {
+	if err := ValidateHDSecret(keyType, secret, netParams); err != nil {
+		return err
+	}
+
+	return ks.add(&Entry{Name: name, Type: keyType}, []byte(secret), passphrase)
+}
+
+// add encrypts the secret of the entry and saves the entry
+func (ks *KeyStore) add(entry *Entry, secret []byte, passphrase []byte) error {
+	name := entry.Name
+
+	if !keyNameRegex.MatchString(name) {
+		return fmt.Errorf("invalid key name: %s, only letters, digits, '_' and '-' allowed", name)
+	}
+
+	if ks.Has(name) {
+		return fmt.Errorf("key already exists: %s", name)
+	}
+
+	crypto, err := encrypt(secret, passphrase, []byte(name))
+	if err != nil {
+		return err
+	}
+
+	entry.Crypto = crypto
+	ks.entries[name] = entry
+
+	if err := ks.save(); err != nil {
+		delete(ks.entries, name)
+		return err
+	}
+
+	return nil
+}
+
+// getEntry gets the entry of the given name, which must be one of the given types if any
+func (ks *KeyStore) getEntry(name string, keyTypes ...KeyType) (*Entry, error) {
+	entry, ok := ks.entries[name]
+	if !ok {
+		return nil, fmt.Errorf("key not found: %s", name)
+	}
+
+	if len(keyTypes) == 0 {
+		return entry, nil
+	}
+
+	for _, keyType := range keyTypes {
+		if entry.GetType() == keyType {
+			return entry, nil
+		}
+	}
+
+	return nil, fmt.Errorf("unexpected type of key %s: %s", name, entry.GetType())
+}
+
+// save writes the key store to the file atomically
+func (ks *KeyStore) save() error {
+	raw := keyStoreJSON{Version: KEY_STORE_VERSION, Keys: make([]*Entry, 0, len(ks.entries))}
+
+	for _, name := range ks.Names() {
+		raw.Keys = append(raw.Keys, ks.entries[name])
+	}
+
+	bz, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmpFile, err := os.CreateTemp(filepath.Dir(ks.path), filepath.Base(ks.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(bz); err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	if err := tmpFile.Sync(); err != nil {
+		tmpFile.Close()
+		return err
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFile.Name(), ks.path)
+}
+
+// VerifyPassphrase checks if the passphrase decrypts the stored keys, which share the same passphrase.
+// Any passphrase is accepted if the key store is empty
+func (ks *KeyStore) VerifyPassphrase(passphrase []byte) error {
+	names := ks.Names()
+	if len(names) == 0 {
+		return nil
+	}
+
+	_, err := decrypt(ks.entries[names[0]].Crypto, passphrase, []byte(names[0]))
+
+	return err
+}
@@ -0,0 +1,191 @@
+package keystore
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// newTestKeyStore opens the empty key store under a temp dir
+func newTestKeyStore(t *testing.T) *KeyStore {
+	ks, err := Open(filepath.Join(t.TempDir(), "keystore.json"))
+	if err != nil {
+		t.Fatalf("failed to open the key store: %v", err)
+	}
+
+	return ks
+}
+
+func newTestKey(t *testing.T) *btcec.PrivateKey {
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate the key: %v", err)
+	}
+
+	return key
+}
+
+func TestKeyStoreRoundTrip(t *testing.T) {
+	ks := newTestKeyStore(t)
+	key := newTestKey(t)
+	passphrase := []byte("passphrase")
+
+	if err := ks.Add("alice", key, passphrase); err != nil {
+		t.Fatalf("failed to add the key: %v", err)
+	}
+
+	if err := ks.Add("alice", newTestKey(t), passphrase); err == nil {
+		t.Fatalf("duplicate name: got nil; want error")
+	}
+
+	if err := ks.Add("not a name", newTestKey(t), passphrase); err == nil {
+		t.Fatalf("invalid name: got nil; want error")
+	}
+
+	reopened, err := Open(ks.path)
+	if err != nil {
+		t.Fatalf("failed to reopen the key store: %v", err)
+	}
+
+	if names := reopened.Names(); len(names) != 1 || names[0] != "alice" {
+		t.Fatalf("got names %v; want [alice]", names)
+	}
+
+	pubKey, err := reopened.PubKey("alice")
+	if err != nil || !pubKey.IsEqual(key.PubKey()) {
+		t.Fatalf("got public key %v, %v; want %x", pubKey, err, key.PubKey().SerializeCompressed())
+	}
+
+	got, err := reopened.Get("alice", passphrase)
+	if err != nil || !got.Key.Equals(&key.Key) {
+		t.Fatalf("got %v; want the same key", err)
+	}
+
+	if _, err := reopened.Get("alice", []byte("wrong")); err == nil || !strings.Contains(err.Error(), "passphrase") {
+		t.Fatalf("wrong passphrase: got %v; want the passphrase error", err)
+	}
+
+	if err := reopened.VerifyPassphrase([]byte("wrong")); err == nil {
+		t.Fatalf("verify wrong passphrase: got nil; want error")
+	}
+
+	if _, err := reopened.Get("bob", passphrase); err == nil {
+		t.Fatalf("missing key: got nil; want error")
+	}
+}
+
+func TestKeyStoreNameBound(t *testing.T) {
+	ks := newTestKeyStore(t)
+	passphrase := []byte("passphrase")
+
+	for _, name := range []string{"alice", "bob"} {
+		if err := ks.Add(name, newTestKey(t), passphrase); err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+	}
+
+	// the encrypted key moved under another name fails to decrypt even with the right passphrase
+	ks.entries["bob"].Crypto = ks.entries["alice"].Crypto
+
+	if _, err := ks.Get("bob", passphrase); err == nil {
+		t.Fatalf("swapped entry: got nil; want error")
+	}
+
+	if _, err := ks.Get("alice", passphrase); err != nil {
+		t.Fatalf("original entry: got %v; want decrypted", err)
+	}
+}
+
+func TestKeyStoreAtomicSave(t *testing.T) {
+	ks := newTestKeyStore(t)
+	passphrase := []byte("passphrase")
+
+	for _, name := range []string{"alice", "bob"} {
+		if err := ks.Add(name, newTestKey(t), passphrase); err != nil {
+			t.Fatalf("failed to add %s: %v", name, err)
+		}
+	}
+
+	files, err := os.ReadDir(filepath.Dir(ks.path))
+	if err != nil {
+		t.Fatalf("failed to read the dir: %v", err)
+	}
+
+	if len(files) != 1 || files[0].Name() != filepath.Base(ks.path) {
+		t.Fatalf("got files %v; want the key store only", files)
+	}
+
+	reopened, err := Open(ks.path)
+	if err != nil || len(reopened.Names()) != 2 {
+		t.Fatalf("got %v; want both keys saved", err)
+	}
+
+	// the entry is not kept if the key store fails to be saved
+	broken := &KeyStore{path: filepath.Join(t.TempDir(), "missing", "keystore.json"), entries: make(map[string]*Entry)}
+
+	if err := broken.Add("alice", newTestKey(t), passphrase); err == nil {
+		t.Fatalf("unwritable path: got nil; want error")
+	}
+
+	if broken.Has("alice") {
+		t.Fatalf("got the key kept; want dropped on the failed save")
+	}
+}
+
+func TestOpenLegacyWIF(t *testing.T) {
+	wif, err := btcutil.NewWIF(newTestKey(t), &chaincfg.RegressionNetParams, true)
+	if err != nil {
+		t.Fatalf("failed to encode the wif: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "key")
+	if err := os.WriteFile(path, []byte(wif.String()+"\n"), 0600); err != nil {
+		t.Fatalf("failed to write the wif: %v", err)
+	}
+
+	if IsKeyStore([]byte(wif.String())) {
+		t.Fatalf("got the wif recognized as a key store")
+	}
+
+	if _, err := Open(path); err == nil || !strings.Contains(err.Error(), "is not a key store") {
+		t.Fatalf("got %v; want the legacy wif rejected", err)
+	}
+}
+
+func TestDecryptKDFParamsBounds(t *testing.T) {
+	crypto, err := encrypt([]byte("secret"), []byte("passphrase"), nil)
+	if err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(p *KDFParams)
+	}{
+		{"N too large", func(p *KDFParams) { p.N = 1 << 30 }},
+		{"N not a power of 2", func(p *KDFParams) { p.N = 3 << 10 }},
+		{"N too small", func(p *KDFParams) { p.N = 1 }},
+		{"r zero", func(p *KDFParams) { p.R = 0 }},
+		{"r too large", func(p *KDFParams) { p.R = 1 << 20 }},
+		{"p too large", func(p *KDFParams) { p.P = 1 << 20 }},
+		{"memory too large", func(p *KDFParams) { p.N, p.R = SCRYPT_MAX_N, SCRYPT_MAX_R }},
+	}
+
+	for _, test := range tests {
+		edited := *crypto
+		test.modify(&edited.KDFParams)
+
+		if _, err := decrypt(&edited, []byte("passphrase"), nil); err == nil || !strings.Contains(err.Error(), "invalid scrypt param") {
+			t.Fatalf("%s: got %v; want the params rejected", test.name, err)
+		}
+	}
+
+	if secret, err := decrypt(crypto, []byte("passphrase"), nil); err != nil || string(secret) != "secret" {
+		t.Fatalf("got %s, %v; want the secret", secret, err)
+	}
+}
@@ -0,0 +1,61 @@
+package keystore
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"golang.org/x/term"
+)
+
+// Passphrase prompted previously, which is reused within the process
+var promptedPassphrase []byte
+
+// GetPassphrase gets the passphrase from the environment variable, or prompts for it on the terminal.
+// The passphrase is asked twice if `confirm` is true
+func GetPassphrase(confirm bool) ([]byte, error) {
+	if passphrase, ok := os.LookupEnv(PASSPHRASE_ENV); ok {
+		return []byte(passphrase), nil
+	}
+
+	if !confirm && promptedPassphrase != nil {
+		return promptedPassphrase, nil
+	}
+
+	passphrase, err := ReadSecret("Enter the key store passphrase: ")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(passphrase) == 0 {
+		return nil, fmt.Errorf("empty passphrase")
+	}
+
+	if confirm {
+		confirmation, err := ReadSecret("Repeat the passphrase: ")
+		if err != nil {
+			return nil, err
+		}
+
+		if !bytes.Equal(passphrase, confirmation) {
+			return nil, fmt.Errorf("passphrases do not match")
+		}
+	}
+
+	promptedPassphrase = passphrase
+
+	return passphrase, nil
+}
+
+// ReadSecret prompts for the secret on the terminal without echoing
+func ReadSecret(prompt string) ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("no terminal to prompt for the secret, set %s instead", PASSPHRASE_ENV)
+	}
+
+	fmt.Fprint(os.Stderr, prompt)
+	defer fmt.Fprintln(os.Stderr)
+
+	return term.ReadPassword(fd)
+}
@@ -14,12 +14,37 @@ func main() {
 
 	issueCmd := cmd.GetIssueCmd()
 	mintCmd := cmd.GetMintCmd()
+	mintBatchCmd := cmd.GetMintBatchCmd()
+
+	finalizeCmd := cmd.GetFinalizeCmd()
+	recoverCmd := cmd.GetRecoverCmd()
+	bumpCmd := cmd.GetBumpCmd()
+
+	signMintCmd := cmd.GetSignMintCmd()
+	authorityCmd := cmd.GetAuthorityCmd()
+
+	keysCmd := cmd.GetKeysCmd()
+
+	decodeCmd := cmd.GetDecodeCmd()
+	queryCmd := cmd.GetQueryCmd()
+
+	devnetCmd := cmd.GetDevnetCmd()
 
 	versionCmd := cmd.GetVersionCmd()
 
 	rootCmd.AddCommand(nodeCmd)
 	rootCmd.AddCommand(issueCmd)
 	rootCmd.AddCommand(mintCmd)
+	rootCmd.AddCommand(mintBatchCmd)
+	rootCmd.AddCommand(finalizeCmd)
+	rootCmd.AddCommand(recoverCmd)
+	rootCmd.AddCommand(bumpCmd)
+	rootCmd.AddCommand(signMintCmd)
+	rootCmd.AddCommand(authorityCmd)
+	rootCmd.AddCommand(keysCmd)
+	rootCmd.AddCommand(decodeCmd)
+	rootCmd.AddCommand(queryCmd)
+	rootCmd.AddCommand(devnetCmd)
 	rootCmd.AddCommand(versionCmd)
 
 	if err := rootCmd.Execute(); err != nil {
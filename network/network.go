@@ -0,0 +1,127 @@
+package network
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/chaincfg"
+
+	"btc-sbt/params"
+)
+
+const (
+	// Built-in network names
+	MAINNET  = "mainnet"
+	TESTNET3 = "testnet3"
+	TESTNET4 = "testnet4"
+	SIGNET   = "signet"
+	REGTEST  = "regtest"
+
+	// Alias of testnet3
+	TESTNET = "testnet"
+)
+
+// Network defines the bitcoin network on which the protocol runs
+type Network struct {
+	Name string // network name
+
+	NetParams *chaincfg.Params // chain params
+	Params    *params.Params   // protocol params
+
+	EsploraAPI string // default esplora api, e.g. mempool.space of the network; empty if none
+
+	SignetChallenge []byte // block signing challenge of the signet, nil for the other networks
+
+	Configurable bool // indicates if the protocol params are configurable, i.e. no canonical deployment on the network
+}
+
+// NewCustomSignet creates a custom signet network by the given challenge and DNS seeds
+func NewCustomSignet(name string, challenge []byte, dnsSeeds []string, activationHeight int64, esploraAPI string) *Network {
+	seeds := make([]chaincfg.DNSSeed, len(dnsSeeds))
+	for i, seed := range dnsSeeds {
+		seeds[i] = chaincfg.DNSSeed{Host: seed}
+	}
+
+	netParams := chaincfg.CustomSignetParams(challenge, seeds)
+	netParams.Name = name
+
+	return &Network{
+		Name:            name,
+		NetParams:       &netParams,
+		Params:          params.NewParams(activationHeight),
+		EsploraAPI:      esploraAPI,
+		SignetChallenge: challenge,
+		Configurable:    true,
+	}
+}
+
+// WithActivationHeight returns a copy of the network with the activation height of the protocol replaced.
+// Only allowed on the configurable networks
+func (n *Network) WithActivationHeight(activationHeight int64) (*Network, error) {
+	if !n.Configurable {
+		return nil, fmt.Errorf("the activation height is not configurable on %s", n.Name)
+	}
+
+	if activationHeight < 0 {
+		return nil, fmt.Errorf("invalid activation height: %d", activationHeight)
+	}
+
+	network := *n
+	network.Params = params.NewParams(activationHeight)
+
+	return &network, nil
+}
+
+// IsSignet returns true if the network is a signet, false otherwise
+func (n *Network) IsSignet() bool {
+	return n.SignetChallenge != nil
+}
+
+// builtinNetworks returns the built-in networks
+func builtinNetworks() []*Network {
+	return []*Network{
+		{
+			Name:       MAINNET,
+			NetParams:  &chaincfg.MainNetParams,
+			Params:     &params.MainNetParams,
+			EsploraAPI: "https://mempool.space/api",
+		},
+		{
+			Name:       TESTNET3,
+			NetParams:  &chaincfg.TestNet3Params,
+			Params:     &params.TestNetParams,
+			EsploraAPI: "https://mempool.space/testnet/api",
+		},
+		{
+			Name:         TESTNET4,
+			NetParams:    &TestNet4Params,
+			Params:       &params.TestNet4Params,
+			EsploraAPI:   "https://mempool.space/testnet4/api",
+			Configurable: true,
+		},
+		{
+			Name:            SIGNET,
+			NetParams:       &chaincfg.SigNetParams,
+			Params:          &params.SigNetParams,
+			EsploraAPI:      "https://mempool.space/signet/api",
+			SignetChallenge: chaincfg.DefaultSignetChallenge,
+		},
+		{
+			Name:         REGTEST,
+			NetParams:    &chaincfg.RegressionNetParams,
+			Params:       &params.RegTestParams,
+			Configurable: true,
+		},
+	}
+}
+
+// netVersions maps the legacy net versions to the network names
+var netVersions = []string{MAINNET, TESTNET3, SIGNET, REGTEST, TESTNET4}
+
+// GetNameByNetVersion gets the network name by the given legacy net version
+func GetNameByNetVersion(netVersion uint8) (string, error) {
+	if int(netVersion) >= len(netVersions) {
+		return "", fmt.Errorf("invalid net version: only 0 to %d allowed, %d given", len(netVersions)-1, netVersion)
+	}
+
+	return netVersions[netVersion], nil
+}
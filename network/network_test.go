@@ -0,0 +1,110 @@
+package network
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+func TestTestNet4Params(t *testing.T) {
+	if got := TestNet4Params.GenesisHash.String(); got != "00000000da84f2bafbbc53dee25a72ae507ff4914b867c565be350b0da8bf043" {
+		t.Fatalf("got genesis hash %s; want 00000000da84f2bafbbc53dee25a72ae507ff4914b867c565be350b0da8bf043", got)
+	}
+
+	if got := TestNet4Params.GenesisBlock.Header.MerkleRoot.String(); got != "7aa0a7ae1e223414cb807e40cd57e667b718e42aaf9306db9102fe28912b7b4e" {
+		t.Fatalf("got merkle root %s; want 7aa0a7ae1e223414cb807e40cd57e667b718e42aaf9306db9102fe28912b7b4e", got)
+	}
+
+	if TestNet4Params.Net == chaincfg.TestNet3Params.Net {
+		t.Fatalf("got the message start of testnet3")
+	}
+
+	// the testnet3 params are left untouched
+	if chaincfg.TestNet3Params.Name != "testnet3" || *chaincfg.TestNet3Params.GenesisHash == *TestNet4Params.GenesisHash {
+		t.Fatalf("got the testnet3 params modified")
+	}
+
+	// the addresses are encoded as on testnet3
+	addr, err := btcutil.DecodeAddress("tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", &TestNet4Params)
+	if err != nil || !addr.IsForNet(&chaincfg.TestNet3Params) {
+		t.Fatalf("got %v, %v; want the testnet address", addr, err)
+	}
+}
+
+func TestRegistryGet(t *testing.T) {
+	r := NewRegistry()
+
+	tests := []struct {
+		name     string
+		want     string
+		wantNet  *chaincfg.Params
+		wantConf bool
+	}{
+		{"mainnet", MAINNET, &chaincfg.MainNetParams, false},
+		{" Testnet ", TESTNET3, &chaincfg.TestNet3Params, false},
+		{"testnet3", TESTNET3, &chaincfg.TestNet3Params, false},
+		{"TESTNET4", TESTNET4, &TestNet4Params, true},
+		{"signet", SIGNET, &chaincfg.SigNetParams, false},
+		{"regtest", REGTEST, &chaincfg.RegressionNetParams, true},
+	}
+
+	for _, test := range tests {
+		n, err := r.Get(test.name)
+		if err != nil {
+			t.Fatalf("%s: failed to get: %v", test.name, err)
+		}
+
+		if n.Name != test.want || n.NetParams != test.wantNet || n.Configurable != test.wantConf {
+			t.Fatalf("%s: got %s, %s, configurable %v; want %s, %s, %v", test.name, n.Name, n.NetParams.Name, n.Configurable, test.want, test.wantNet.Name, test.wantConf)
+		}
+	}
+
+	if _, err := r.Get("simnet"); err == nil {
+		t.Fatalf("unknown: got nil; want error")
+	}
+}
+
+func TestWithActivationHeight(t *testing.T) {
+	r := NewRegistry()
+
+	regtest, err := r.Get(REGTEST)
+	if err != nil {
+		t.Fatalf("failed to get: %v", err)
+	}
+
+	n, err := regtest.WithActivationHeight(100)
+	if err != nil || n.Params.ActivationBlockHeight != 100 {
+		t.Fatalf("got %v, %v; want activated at 100", n, err)
+	}
+
+	// the registered network is not modified
+	if regtest.Params.ActivationBlockHeight != 0 {
+		t.Fatalf("got the registered activation height %d; want 0", regtest.Params.ActivationBlockHeight)
+	}
+
+	if _, err := regtest.WithActivationHeight(-1); err == nil {
+		t.Fatalf("negative: got nil; want error")
+	}
+
+	mainnet, err := r.Get(MAINNET)
+	if err != nil {
+		t.Fatalf("failed to get: %v", err)
+	}
+
+	if _, err := mainnet.WithActivationHeight(100); err == nil {
+		t.Fatalf("mainnet: got nil; want error")
+	}
+}
+
+func TestGetNameByNetVersion(t *testing.T) {
+	for netVersion, want := range []string{MAINNET, TESTNET3, SIGNET, REGTEST, TESTNET4} {
+		if got, err := GetNameByNetVersion(uint8(netVersion)); err != nil || got != want {
+			t.Fatalf("%d: got %s, %v; want %s", netVersion, got, err, want)
+		}
+	}
+
+	if _, err := GetNameByNetVersion(5); err == nil {
+		t.Fatalf("5: got nil; want error")
+	}
+}
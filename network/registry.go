@@ -0,0 +1,78 @@
+package network
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Registry defines the registry of the networks by name, holding the built-in networks and the custom ones
+type Registry struct {
+	networks map[string]*Network
+}
+
+// NewRegistry creates a registry with the built-in networks
+func NewRegistry() *Registry {
+	r := &Registry{
+		networks: make(map[string]*Network),
+	}
+
+	for _, network := range builtinNetworks() {
+		r.networks[network.Name] = network
+	}
+
+	return r
+}
+
+// Register registers the given custom network under the normalized name
+func (r *Registry) Register(network *Network) error {
+	name := normalizeName(network.Name)
+	if len(name) == 0 {
+		return fmt.Errorf("network name missing")
+	}
+
+	if name == TESTNET {
+		return fmt.Errorf("network name reserved: %s", network.Name)
+	}
+
+	if _, ok := r.networks[name]; ok {
+		return fmt.Errorf("network already exists: %s", network.Name)
+	}
+
+	network.Name = name
+	r.networks[name] = network
+
+	return nil
+}
+
+// Get gets the network by the given name
+func (r *Registry) Get(name string) (*Network, error) {
+	name = normalizeName(name)
+	if name == TESTNET {
+		name = TESTNET3
+	}
+
+	network, ok := r.networks[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown network: %s, one of %s expected", name, strings.Join(r.Names(), ", "))
+	}
+
+	return network, nil
+}
+
+// Names returns the names of the registered networks in order
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.networks))
+	for name := range r.networks {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// normalizeName normalizes the network name to lower case
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
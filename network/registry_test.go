@@ -0,0 +1,28 @@
+package network
+
+import (
+	"testing"
+)
+
+func TestRegisterNormalizesName(t *testing.T) {
+	r := NewRegistry()
+
+	if err := r.Register(NewCustomSignet(" MutinyNet ", []byte{0x51}, nil, 0, "")); err != nil {
+		t.Fatalf("failed to register: %v", err)
+	}
+
+	n, err := r.Get("mutinynet")
+	if err != nil {
+		t.Fatalf("failed to get: %v", err)
+	}
+
+	if n.Name != "mutinynet" {
+		t.Fatalf("got name %q; want mutinynet", n.Name)
+	}
+
+	for _, name := range []string{"MUTINYNET", "Testnet", "regtest"} {
+		if err := r.Register(NewCustomSignet(name, []byte{0x51}, nil, 0, "")); err == nil {
+			t.Fatalf("%s: got nil; want the existing or reserved name error", name)
+		}
+	}
+}
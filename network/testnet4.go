@@ -0,0 +1,85 @@
+package network
+
+import (
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// TestNet4 is the message start of the test network (version 4), which is unknown to btcd
+const TestNet4 wire.BitcoinNet = 0x283f161c
+
+// testNet4GenesisCoinbaseTx is the coinbase tx of the genesis block for the test network (version 4)
+var testNet4GenesisCoinbaseTx = wire.MsgTx{
+	Version: 1,
+	TxIn: []*wire.TxIn{
+		{
+			PreviousOutPoint: wire.OutPoint{
+				Hash:  chainhash.Hash{},
+				Index: 0xffffffff,
+			},
+			// push of 0x1d00ffff, push of 4 and the message
+			SignatureScript: append(
+				[]byte{0x04, 0xff, 0xff, 0x00, 0x1d, 0x01, 0x04, 0x4c, 0x4c},
+				[]byte("03/May/2024 000000000000000000001ebd58c244970b3aa9d783bb001011fbe8ea8e98e00e")...,
+			),
+			Sequence: 0xffffffff,
+		},
+	},
+	TxOut: []*wire.TxOut{
+		{
+			Value: 0x12a05f200,
+			// push of the 33 zero bytes and OP_CHECKSIG
+			PkScript: append(append([]byte{0x21}, make([]byte, 33)...), 0xac),
+		},
+	},
+	LockTime: 0,
+}
+
+// testNet4GenesisBlock is the genesis block for the test network (version 4)
+var testNet4GenesisBlock = wire.MsgBlock{
+	Header: wire.BlockHeader{
+		Version:    1,
+		PrevBlock:  chainhash.Hash{},
+		MerkleRoot: testNet4GenesisCoinbaseTx.TxHash(),
+		Timestamp:  time.Unix(1714777860, 0), // 2024-05-03 23:11:00 +0000 UTC
+		Bits:       0x1d00ffff,
+		Nonce:      393743547,
+	},
+	Transactions: []*wire.MsgTx{&testNet4GenesisCoinbaseTx},
+}
+
+// testNet4GenesisHash is the hash of the genesis block for the test network (version 4),
+// i.e. 00000000da84f2bafbbc53dee25a72ae507ff4914b867c565be350b0da8bf043
+var testNet4GenesisHash = testNet4GenesisBlock.BlockHash()
+
+// TestNet4Params defines the network params for the test network (version 4).
+// The address encodings are shared with the test network (version 3)
+var TestNet4Params = newTestNet4Params()
+
+// newTestNet4Params creates the network params for the test network (version 4) from the ones of version 3
+func newTestNet4Params() chaincfg.Params {
+	params := chaincfg.TestNet3Params
+
+	params.Name = "testnet4"
+	params.Net = TestNet4
+	params.DefaultPort = "48333"
+	params.DNSSeeds = []chaincfg.DNSSeed{
+		{Host: "seed.testnet4.bitcoin.sprovoost.nl", HasFiltering: true},
+		{Host: "seed.testnet4.wiz.biz", HasFiltering: true},
+	}
+
+	params.GenesisBlock = &testNet4GenesisBlock
+	params.GenesisHash = &testNet4GenesisHash
+
+	// all active since genesis
+	params.BIP0034Height = 1
+	params.BIP0065Height = 1
+	params.BIP0066Height = 1
+
+	params.Checkpoints = nil
+
+	return params
+}
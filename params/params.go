@@ -27,4 +27,14 @@ var (
 	SigNetParams = Params{
 		ActivationBlockHeight: 176800,
 	}
+
+	// TestNet4Params is the params for the testnet4 network
+	TestNet4Params = Params{
+		ActivationBlockHeight: 0,
+	}
+
+	// RegTestParams is the params for the regtest network
+	RegTestParams = Params{
+		ActivationBlockHeight: 0,
+	}
 )
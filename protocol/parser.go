@@ -7,6 +7,7 @@ import (
 
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 
@@ -57,6 +58,53 @@ func (p *Parser) GetOps(payload []byte) []Operation {
 	return operations
 }
 
+// EnvelopeInput represents the tx input carrying the BTC-SBT protocol envelope
+type EnvelopeInput struct {
+	Index        int            // input index
+	CommitTxHash chainhash.Hash // hash of the commit tx spent by the input
+	Envelope     *Envelope      // protocol envelope
+	Ops          Operations     // operations parsed from the envelope
+}
+
+// ParseTx parses the BTC-SBT protocol operations from the given tx.
+// At most BULK_OPERATION_COUNT_PER_TX operations are accepted per tx, the remaining inputs are skipped.
+// Returns the accepted operations along with the inputs carrying the envelope
+func (p *Parser) ParseTx(tx *wire.MsgTx) (Operations, []*EnvelopeInput) {
+	parsedOps := make(Operations, 0)
+	inputs := make([]*EnvelopeInput, 0)
+
+	for idx, in := range tx.TxIn {
+		if !basics.IsTapscriptWitness(in.Witness) {
+			continue
+		}
+
+		envelope := p.GetEnvelope(in.Witness[1])
+		if envelope == nil {
+			continue
+		}
+
+		ops := p.GetOps(envelope.Payload)
+
+		inputs = append(inputs, &EnvelopeInput{
+			Index:        idx,
+			CommitTxHash: in.PreviousOutPoint.Hash,
+			Envelope:     envelope,
+			Ops:          ops,
+		})
+
+		if len(ops) > 0 {
+			parsedOps = append(parsedOps, ops...)
+			if len(parsedOps) >= BULK_OPERATION_COUNT_PER_TX {
+				parsedOps = parsedOps[0:BULK_OPERATION_COUNT_PER_TX]
+
+				break
+			}
+		}
+	}
+
+	return parsedOps, inputs
+}
+
 // ToIssueOp parses the given data to the issue operation
 func (p *Parser) ToIssueOp(data []byte) (*IssueOperation, error) {
 	var op IssueOperation
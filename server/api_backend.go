@@ -1,22 +1,35 @@
 package server
 
 import (
-	"btc-sbt/types"
-
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/events"
+	"btc-sbt/types"
 )
 
 // APIBackend defines the backend interface for the api server
 type APIBackend interface {
-	GetAllSBTs() ([]*types.SBTs, error)
+	QuerySBTs(query *types.SBTsQuery) ([]*types.SBTs, string, error)
 
 	GetSBTs(symbol string) (*types.SBTs, error)
+	GetSBTsAt(symbol string, blockHeight int64) (*types.SBTs, error)
 	GetSBT(symbol string, id uint64) (*types.SBT, error)
+	GetSBTAt(symbol string, id uint64, blockHeight int64) (*types.SBT, error)
+	QueryTokens(query *types.TokensQuery) ([]*types.SBT, string, error)
 
-	GetOwnedSBTs(owner string) ([]*types.CompactSBT, error)
+	QueryOwnedSBTs(query *types.OwnedSBTsQuery) ([]*types.CompactSBT, string, error)
 	GetOwnedSBT(owner string, symbol string) (*types.CompactSBT, error)
 
+	GetTx(txHash *chainhash.Hash) ([]*types.TxRecord, error)
+	Decode(tx *wire.MsgTx) (*types.DecodeResult, error)
+
+	GetEvents(cursor types.EventCursor, filter *types.EventFilter, limit int) ([]*types.Event, error)
+	SubscribeEvents(filter *types.EventFilter) *events.Subscription
+
 	GetStatus() (any, error)
+	GetLastBlockHeight() (int64, error)
 
 	GetNetParams() *chaincfg.Params
 }
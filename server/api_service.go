@@ -57,10 +57,17 @@ func (srv *APIService) createRouter() {
 	r.GET("/api/collections", srv.GetAllSBTs)
 
 	r.GET("/api/collections/:symbol", srv.GetSBTs)
+	r.GET("/api/collections/:symbol/tokens", srv.GetTokens)
+	r.GET("/api/collections/:symbol/holders", srv.GetHolders)
 	r.GET("api/sbts", srv.GetSBT)
 
 	r.GET("api/sbts/address/:address", srv.GetOwnedSBTsWrapper)
 
+	r.GET("api/tx/:txid", srv.GetTx)
+	r.POST("api/decode", srv.Decode)
+
+	r.GET("api/events", srv.SubscribeEvents)
+
 	r.GET("api/status", srv.Status)
 
 	srv.Router = r
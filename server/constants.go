@@ -0,0 +1,13 @@
+package server
+
+import (
+	"time"
+)
+
+const (
+	// Number of events loaded from the store per batch when replaying events
+	EVENT_REPLAY_BATCH_SIZE = 1000
+
+	// Interval for sending keep-alive comments on the event stream
+	EVENT_KEEPALIVE_INTERVAL = 15 * time.Second
+)
@@ -2,22 +2,37 @@ package server
 
 import (
 	"fmt"
+	"io"
 	"net/http"
+	"time"
 
+	"github.com/gin-contrib/sse"
 	"github.com/gin-gonic/gin"
 
 	"btc-sbt/server/params"
+	"btc-sbt/types"
 )
 
-// GetAllSBTs queries all the SBTs
+// GetAllSBTs queries the SBTs page by page with sorting and filtering
 func (srv *APIService) GetAllSBTs(c *gin.Context) {
-	collections, err := srv.APIBackend.GetAllSBTs()
+	var p params.GetAllSBTsParams
+	if err := c.ShouldBindQuery(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "error": fmt.Sprintf("invalid params: %v", err)})
+		return
+	}
+
+	if err := p.Validate(c, srv.GetNetParams()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "error": fmt.Sprintf("invalid params: %v", err)})
+		return
+	}
+
+	collections, next, err := srv.APIBackend.QuerySBTs(p.GetQuery())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "error": fmt.Sprintf("%v", err)})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": true, "result": collections})
+	c.JSON(http.StatusOK, gin.H{"status": true, "result": collections, "next": next})
 }
 
 // GetSBTs queries the SBTs by the given symbol
@@ -28,12 +43,17 @@ func (srv *APIService) GetSBTs(c *gin.Context) {
 		return
 	}
 
+	if err := c.ShouldBindQuery(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "error": fmt.Sprintf("invalid params: %v", err)})
+		return
+	}
+
 	if err := p.Validate(c, srv.GetNetParams()); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"status": false, "error": fmt.Sprintf("invalid params: %v", err)})
 		return
 	}
 
-	sbts, err := srv.APIBackend.GetSBTs(p.Symbol)
+	sbts, err := srv.APIBackend.GetSBTsAt(p.Symbol, p.Height)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "error": fmt.Sprintf("%v", err)})
 		return
@@ -60,7 +80,7 @@ func (srv *APIService) GetSBT(c *gin.Context) {
 		return
 	}
 
-	sbt, err := srv.APIBackend.GetSBT(p.Symbol, p.Id)
+	sbt, err := srv.APIBackend.GetSBTAt(p.Symbol, p.Id, p.Height)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "error": fmt.Sprintf("%v", err)})
 		return
@@ -74,6 +94,70 @@ func (srv *APIService) GetSBT(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": true, "result": sbt})
 }
 
+// GetTokens queries the SBT tokens of the given symbol page by page, ordered by the token id
+func (srv *APIService) GetTokens(c *gin.Context) {
+	sbts, next, ok := srv.queryTokens(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "result": sbts, "next": next})
+}
+
+// GetHolders queries the holders of the given symbol page by page, ordered by the token id
+func (srv *APIService) GetHolders(c *gin.Context) {
+	sbts, next, ok := srv.queryTokens(c)
+	if !ok {
+		return
+	}
+
+	holders := make([]*types.Holder, len(sbts))
+	for i, sbt := range sbts {
+		holders[i] = sbt.Holder()
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "result": holders, "next": next})
+}
+
+// queryTokens queries the SBT tokens page for the GetTokens and GetHolders handlers.
+// Returns false if the request failed, in which case the response has been written
+func (srv *APIService) queryTokens(c *gin.Context) ([]*types.SBT, string, bool) {
+	var p params.GetTokensParams
+	if err := c.ShouldBindUri(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "error": fmt.Sprintf("invalid params: %v", err)})
+		return nil, "", false
+	}
+
+	if err := c.ShouldBindQuery(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "error": fmt.Sprintf("invalid params: %v", err)})
+		return nil, "", false
+	}
+
+	if err := p.Validate(c, srv.GetNetParams()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "error": fmt.Sprintf("invalid params: %v", err)})
+		return nil, "", false
+	}
+
+	sbts, err := srv.APIBackend.GetSBTs(p.Symbol)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "error": fmt.Sprintf("%v", err)})
+		return nil, "", false
+	}
+
+	if sbts == nil {
+		c.JSON(http.StatusNotFound, gin.H{"status": false, "error": fmt.Sprintf("SBTs does not exist: %s", p.Symbol)})
+		return nil, "", false
+	}
+
+	tokens, next, err := srv.APIBackend.QueryTokens(p.GetQuery())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "error": fmt.Sprintf("%v", err)})
+		return nil, "", false
+	}
+
+	return tokens, next, true
+}
+
 // GetOwnedSBTsWrapper dispatches execution to the GetOwnedSBTs handler or GetOwnedSBT handler according to request params
 func (srv *APIService) GetOwnedSBTsWrapper(c *gin.Context) {
 	var p params.GetOwnedSBTsWrapperParams
@@ -89,7 +173,7 @@ func (srv *APIService) GetOwnedSBTsWrapper(c *gin.Context) {
 	}
 }
 
-// GetOwnedSBTs queries the SBT tokens owned by the given address
+// GetOwnedSBTs queries the SBT tokens owned by the given address page by page
 func (srv *APIService) GetOwnedSBTs(c *gin.Context) {
 	var p params.GetOwnedSBTsParams
 	if err := c.ShouldBindUri(&p); err != nil {
@@ -97,18 +181,23 @@ func (srv *APIService) GetOwnedSBTs(c *gin.Context) {
 		return
 	}
 
+	if err := c.ShouldBindQuery(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "error": fmt.Sprintf("invalid params: %v", err)})
+		return
+	}
+
 	if err := p.Validate(c, srv.GetNetParams()); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"status": false, "error": fmt.Sprintf("invalid params: %v", err)})
 		return
 	}
 
-	sbts, err := srv.APIBackend.GetOwnedSBTs(p.Address)
+	sbts, next, err := srv.APIBackend.QueryOwnedSBTs(p.GetQuery())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "error": fmt.Sprintf("%v", err)})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"status": true, "result": sbts})
+	c.JSON(http.StatusOK, gin.H{"status": true, "result": sbts, "next": next})
 }
 
 // GetOwnedSBT queries the specified SBT token owned by the given address
@@ -139,6 +228,59 @@ func (srv *APIService) GetOwnedSBT(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": true, "result": sbt})
 }
 
+// GetTx queries the protocol operations and results of the given reveal tx, or of all the reveal txs spending the given commit tx
+func (srv *APIService) GetTx(c *gin.Context) {
+	var p params.GetTxParams
+	if err := c.ShouldBindUri(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "error": fmt.Sprintf("invalid params: %v", err)})
+		return
+	}
+
+	if err := p.Validate(c, srv.GetNetParams()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "error": fmt.Sprintf("invalid params: %v", err)})
+		return
+	}
+
+	records, err := srv.APIBackend.GetTx(p.GetTxHash())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "error": fmt.Sprintf("%v", err)})
+		return
+	}
+
+	if len(records) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"status": false, "error": fmt.Sprintf("no protocol operations found in tx: %s", p.TxId)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "result": records})
+}
+
+// Decode decodes the protocol operations from the given raw tx or PSBT and simulates them against the current state.
+// Nothing is persisted
+func (srv *APIService) Decode(c *gin.Context) {
+	var p params.DecodeParams
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "error": fmt.Sprintf("invalid params: %v", err)})
+		return
+	}
+
+	if err := p.Validate(c, srv.GetNetParams()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "error": fmt.Sprintf("invalid params: %v", err)})
+		return
+	}
+
+	// validated
+	tx, _ := p.GetTx()
+
+	result, err := srv.APIBackend.Decode(tx)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"status": false, "error": fmt.Sprintf("%v", err)})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"status": true, "result": result})
+}
+
 // Status returns the current status of the indexer
 func (srv *APIService) Status(c *gin.Context) {
 	res, err := srv.APIBackend.GetStatus()
@@ -149,3 +291,136 @@ func (srv *APIService) Status(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"status": true, "result": res})
 }
+
+// SubscribeEvents streams the events matching the given filter as Server-Sent Events.
+// The events since `from` or the Last-Event-ID header are replayed before the live events
+func (srv *APIService) SubscribeEvents(c *gin.Context) {
+	var p params.SubscribeEventsParams
+	if err := c.ShouldBindQuery(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "error": fmt.Sprintf("invalid params: %v", err)})
+		return
+	}
+
+	if err := p.Validate(c, srv.GetNetParams()); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"status": false, "error": fmt.Sprintf("invalid params: %v", err)})
+		return
+	}
+
+	// validated
+	cursor, _ := p.GetCursor()
+
+	if lastEventId := c.GetHeader("Last-Event-ID"); len(lastEventId) > 0 {
+		lastCursor, err := types.ParseEventCursor(lastEventId)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"status": false, "error": fmt.Sprintf("invalid Last-Event-ID: %v", err)})
+			return
+		}
+
+		nextCursor := lastCursor.Next()
+		cursor = &nextCursor
+	}
+
+	filter := p.GetFilter()
+
+	// subscribe before replaying so that no event is missed in between
+	sub := srv.APIBackend.SubscribeEvents(filter)
+	defer func() { sub.Unsubscribe() }()
+
+	// the first event to catch up from if falling behind before any event is sent
+	resumeCursor := cursor
+	if resumeCursor == nil {
+		lastBlockHeight, err := srv.APIBackend.GetLastBlockHeight()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"status": false, "error": fmt.Sprintf("%v", err)})
+			return
+		}
+
+		resumeCursor = &types.EventCursor{BlockHeight: lastBlockHeight + 1}
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+
+	var lastSent *types.EventCursor
+
+	// replay sends the stored events from the given cursor, returning false on failure
+	replay := func(cursor types.EventCursor) bool {
+		for {
+			events, err := srv.APIBackend.GetEvents(cursor, filter, EVENT_REPLAY_BATCH_SIZE)
+			if err != nil {
+				c.SSEvent("error", fmt.Sprintf("%v", err))
+				return false
+			}
+
+			for _, event := range events {
+				if lastSent != nil && !lastSent.Before(event.Cursor()) {
+					continue
+				}
+
+				writeEvent(c, event)
+
+				sent := event.Cursor()
+				lastSent = &sent
+			}
+
+			c.Writer.Flush()
+
+			if len(events) < EVENT_REPLAY_BATCH_SIZE {
+				return true
+			}
+
+			cursor = events[len(events)-1].Cursor().Next()
+		}
+	}
+
+	if cursor != nil && !replay(*cursor) {
+		return
+	}
+
+	ticker := time.NewTicker(EVENT_KEEPALIVE_INTERVAL)
+	defer ticker.Stop()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+
+		case event, ok := <-sub.Events():
+			if !ok {
+				// fallen behind, catch up from the store where the events are persisted before published
+				sub = srv.APIBackend.SubscribeEvents(filter)
+
+				if lastSent != nil {
+					return replay(lastSent.Next())
+				}
+
+				return replay(*resumeCursor)
+			}
+
+			if lastSent != nil && !lastSent.Before(event.Cursor()) {
+				// already replayed
+				return true
+			}
+
+			writeEvent(c, event)
+
+			sent := event.Cursor()
+			lastSent = &sent
+
+			return true
+
+		case <-ticker.C:
+			_, err := w.Write([]byte(": keep-alive\n\n"))
+			return err == nil
+		}
+	})
+}
+
+// writeEvent writes the given event to the event stream
+func writeEvent(c *gin.Context, event *types.Event) {
+	c.Render(-1, sse.Event{
+		Id:    event.Cursor().String(),
+		Event: string(event.Type),
+		Data:  event,
+	})
+}
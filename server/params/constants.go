@@ -0,0 +1,11 @@
+package params
+
+const (
+	// Pagination orders
+	ORDER_ASC  = "asc"
+	ORDER_DESC = "desc"
+
+	// Mint status filters
+	MINT_OPEN   = "open"
+	MINT_CLOSED = "closed"
+)
@@ -1,28 +1,163 @@
 package params
 
 import (
+	"encoding/hex"
 	"fmt"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 
 	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
 
+	"btc-sbt/decoder"
 	"btc-sbt/protocol"
+	"btc-sbt/types"
 )
 
+var _ Validator = (*GetAllSBTsParams)(nil)
 var _ Validator = (*GetSBTsParams)(nil)
 var _ Validator = (*GetSBTParams)(nil)
+var _ Validator = (*GetTokensParams)(nil)
 var _ Validator = (*GetOwnedSBTsWrapperParams)(nil)
 var _ Validator = (*GetOwnedSBTsParams)(nil)
 var _ Validator = (*GetOwnedSBTParams)(nil)
+var _ Validator = (*GetTxParams)(nil)
+var _ Validator = (*DecodeParams)(nil)
+var _ Validator = (*SubscribeEventsParams)(nil)
+
+// PaginationParams represents the common pagination params
+type PaginationParams struct {
+	Cursor string `json:"cursor" form:"cursor"`
+	Limit  int    `json:"limit" form:"limit"`
+	Order  string `json:"order" form:"order"` // asc or desc, default to asc
+}
+
+// Validate validates the pagination params
+func (p *PaginationParams) Validate() error {
+	if p.Limit < 0 || p.Limit > types.MAX_PAGE_LIMIT {
+		return fmt.Errorf("invalid limit, the limit must be between [0,%d]: %d", types.MAX_PAGE_LIMIT, p.Limit)
+	}
+
+	if _, err := hex.DecodeString(p.Cursor); err != nil {
+		return fmt.Errorf("invalid cursor: %s", p.Cursor)
+	}
+
+	if len(p.Order) > 0 && p.Order != ORDER_ASC && p.Order != ORDER_DESC {
+		return fmt.Errorf("invalid order, only %s or %s allowed: %s", ORDER_ASC, ORDER_DESC, p.Order)
+	}
+
+	return nil
+}
+
+// GetPagination returns the pagination from the params
+func (p *PaginationParams) GetPagination() types.Pagination {
+	return types.Pagination{
+		Cursor:  p.Cursor,
+		Limit:   p.Limit,
+		Reverse: p.Order == ORDER_DESC,
+	}
+}
+
+// ValidateHeight validates the block height at which the state is queried
+func ValidateHeight(height int64) error {
+	if height < 0 {
+		return fmt.Errorf("invalid block height: %d", height)
+	}
+
+	return nil
+}
+
+// GetAllSBTsParams represents the params for the GetAllSBTs handler
+type GetAllSBTsParams struct {
+	PaginationParams
+
+	Sort   string `json:"sort" form:"sort"`     // symbol, seq, height or supply
+	Issuer string `json:"issuer" form:"issuer"` // issuer address
+	Auth   string `json:"auth" form:"auth"`     // true if the authority signature is required on mint, false otherwise
+	Mint   string `json:"mint" form:"mint"`     // open or closed
+	Prefix string `json:"prefix" form:"prefix"` // symbol prefix
+	Height int64  `json:"height" form:"height"` // block height at which the SBTs are queried, default to the latest
+}
+
+// Validate implements the Validator interface
+func (p *GetAllSBTsParams) Validate(c *gin.Context, netParams *chaincfg.Params) error {
+	if err := p.PaginationParams.Validate(); err != nil {
+		return err
+	}
+
+	if len(p.Sort) > 0 && !types.SortKey(p.Sort).IsValid() {
+		return fmt.Errorf("invalid sort key: %s", p.Sort)
+	}
+
+	if err := ValidateHeight(p.Height); err != nil {
+		return err
+	}
+
+	if p.Height > 0 && types.SortKey(p.Sort) == types.SORT_BY_SUPPLY {
+		return fmt.Errorf("sorting by supply is only supported for the latest state")
+	}
+
+	if len(p.Issuer) > 0 {
+		if err := protocol.ValidateAddress(p.Issuer, netParams); err != nil {
+			return err
+		}
+	}
+
+	if len(p.Auth) > 0 {
+		if _, err := strconv.ParseBool(p.Auth); err != nil {
+			return fmt.Errorf("invalid auth filter: %s", p.Auth)
+		}
+	}
+
+	if len(p.Mint) > 0 && p.Mint != MINT_OPEN && p.Mint != MINT_CLOSED {
+		return fmt.Errorf("invalid mint filter, only %s or %s allowed: %s", MINT_OPEN, MINT_CLOSED, p.Mint)
+	}
+
+	if len(p.Prefix) > protocol.MAX_SYMBOL_LEN {
+		return fmt.Errorf("invalid symbol prefix: %s", p.Prefix)
+	}
+
+	return nil
+}
+
+// GetQuery returns the SBTs query from the params.
+// Assume that the params are validated
+func (p *GetAllSBTsParams) GetQuery() *types.SBTsQuery {
+	query := &types.SBTsQuery{
+		Pagination:   p.GetPagination(),
+		SortBy:       types.SortKey(p.Sort),
+		Issuer:       p.Issuer,
+		SymbolPrefix: p.Prefix,
+		BlockHeight:  p.Height,
+	}
+
+	if len(p.Auth) > 0 {
+		requireAuthority, _ := strconv.ParseBool(p.Auth)
+		query.RequireAuthority = &requireAuthority
+	}
+
+	if len(p.Mint) > 0 {
+		mintOpen := p.Mint == MINT_OPEN
+		query.MintOpen = &mintOpen
+	}
+
+	return query
+}
 
 // GetSBTsParams represents the params for the GetSBTs handler
 type GetSBTsParams struct {
 	Symbol string `json:"symbol" uri:"symbol"`
+	Height int64  `json:"height" form:"height"` // block height at which the SBTs is queried, default to the latest
 }
 
 // Validate implements the Validator interface
 func (p *GetSBTsParams) Validate(c *gin.Context, netParams *chaincfg.Params) error {
+	if err := ValidateHeight(p.Height); err != nil {
+		return err
+	}
+
 	return protocol.ValidateSymbol(p.Symbol)
 }
 
@@ -30,6 +165,7 @@ func (p *GetSBTsParams) Validate(c *gin.Context, netParams *chaincfg.Params) err
 type GetSBTParams struct {
 	Symbol string `json:"symbol" form:"symbol"`
 	Id     uint64 `json:"id" form:"id"`
+	Height int64  `json:"height" form:"height"` // block height at which the SBT is queried, default to the latest
 }
 
 // Validate implements the Validator interface
@@ -38,6 +174,10 @@ func (p *GetSBTParams) Validate(c *gin.Context, netParams *chaincfg.Params) erro
 		return err
 	}
 
+	if err := ValidateHeight(p.Height); err != nil {
+		return err
+	}
+
 	id := c.Query("id")
 	if len(id) == 0 {
 		return fmt.Errorf("token id missing")
@@ -46,6 +186,30 @@ func (p *GetSBTParams) Validate(c *gin.Context, netParams *chaincfg.Params) erro
 	return nil
 }
 
+// GetTokensParams represents the params for the GetTokens and GetHolders handlers
+type GetTokensParams struct {
+	PaginationParams
+
+	Symbol string `json:"symbol" uri:"symbol"`
+}
+
+// Validate implements the Validator interface
+func (p *GetTokensParams) Validate(c *gin.Context, netParams *chaincfg.Params) error {
+	if err := p.PaginationParams.Validate(); err != nil {
+		return err
+	}
+
+	return protocol.ValidateSymbol(p.Symbol)
+}
+
+// GetQuery returns the tokens query from the params
+func (p *GetTokensParams) GetQuery() *types.TokensQuery {
+	return &types.TokensQuery{
+		Pagination: p.GetPagination(),
+		Symbol:     p.Symbol,
+	}
+}
+
 // GetOwnedSBTsWrapperParams represents the params for the GetOwnedSBTsWrapper handler
 type GetOwnedSBTsWrapperParams struct {
 	Symbol string `json:"symbol" form:"symbol"`
@@ -64,14 +228,40 @@ func (p *GetOwnedSBTsWrapperParams) SymbolExists() bool {
 
 // GetOwnedSBTsParams represents the params for the GetOwnedSBTs handler
 type GetOwnedSBTsParams struct {
+	PaginationParams
+
 	Address string `json:"address" uri:"address"`
+	Prefix  string `json:"prefix" form:"prefix"` // symbol prefix
+	Height  int64  `json:"height" form:"height"` // block height at which the SBT tokens are queried, default to the latest
 }
 
 // Validate implements the Validator interface
 func (p *GetOwnedSBTsParams) Validate(c *gin.Context, netParams *chaincfg.Params) error {
+	if err := p.PaginationParams.Validate(); err != nil {
+		return err
+	}
+
+	if err := ValidateHeight(p.Height); err != nil {
+		return err
+	}
+
+	if len(p.Prefix) > protocol.MAX_SYMBOL_LEN {
+		return fmt.Errorf("invalid symbol prefix: %s", p.Prefix)
+	}
+
 	return protocol.ValidateAddress(p.Address, netParams)
 }
 
+// GetQuery returns the owned SBTs query from the params
+func (p *GetOwnedSBTsParams) GetQuery() *types.OwnedSBTsQuery {
+	return &types.OwnedSBTsQuery{
+		Pagination:   p.GetPagination(),
+		Owner:        p.Address,
+		SymbolPrefix: p.Prefix,
+		BlockHeight:  p.Height,
+	}
+}
+
 // GetOwnedSBTParams represents the params for the GetOwnedSBT handler
 type GetOwnedSBTParams struct {
 	Address string `json:"address" uri:"address"`
@@ -86,3 +276,101 @@ func (p *GetOwnedSBTParams) Validate(c *gin.Context, netParams *chaincfg.Params)
 
 	return protocol.ValidateSymbol(p.Symbol)
 }
+
+// GetTxParams represents the params for the GetTx handler
+type GetTxParams struct {
+	TxId string `json:"txid" uri:"txid"`
+}
+
+// Validate implements the Validator interface
+func (p *GetTxParams) Validate(c *gin.Context, netParams *chaincfg.Params) error {
+	if _, err := chainhash.NewHashFromStr(p.TxId); err != nil || len(p.TxId) != chainhash.MaxHashStringSize {
+		return fmt.Errorf("invalid txid: %s", p.TxId)
+	}
+
+	return nil
+}
+
+// GetTxHash returns the tx hash from the params.
+// Assume that the params are validated
+func (p *GetTxParams) GetTxHash() *chainhash.Hash {
+	hash, _ := chainhash.NewHashFromStr(p.TxId)
+	return hash
+}
+
+// DecodeParams represents the params for the Decode handler
+type DecodeParams struct {
+	Data string `json:"data"` // raw tx or PSBT, encoded in hex or base64
+}
+
+// Validate implements the Validator interface
+func (p *DecodeParams) Validate(c *gin.Context, netParams *chaincfg.Params) error {
+	_, err := p.GetTx()
+	return err
+}
+
+// GetTx returns the tx decoded from the params
+func (p *DecodeParams) GetTx() (*wire.MsgTx, error) {
+	return decoder.DecodeTxData(p.Data)
+}
+
+// SubscribeEventsParams represents the params for the SubscribeEvents handler
+type SubscribeEventsParams struct {
+	Address string `json:"address" form:"address"`
+	Symbol  string `json:"symbol" form:"symbol"`
+	From    string `json:"from" form:"from"` // block height or event cursor to resume from
+}
+
+// Validate implements the Validator interface
+func (p *SubscribeEventsParams) Validate(c *gin.Context, netParams *chaincfg.Params) error {
+	if len(p.Address) > 0 {
+		if err := protocol.ValidateAddress(p.Address, netParams); err != nil {
+			return err
+		}
+	}
+
+	if len(p.Symbol) > 0 {
+		if err := protocol.ValidateSymbol(p.Symbol); err != nil {
+			return err
+		}
+	}
+
+	if len(p.From) > 0 {
+		if _, err := p.GetCursor(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetFilter returns the event filter from the params
+func (p *SubscribeEventsParams) GetFilter() *types.EventFilter {
+	return &types.EventFilter{
+		Address: p.Address,
+		Symbol:  p.Symbol,
+	}
+}
+
+// GetCursor returns the event cursor to resume from.
+// `from` is either a block height or a cursor in the form of `<block height>-<index>`
+func (p *SubscribeEventsParams) GetCursor() (*types.EventCursor, error) {
+	if len(p.From) == 0 {
+		return nil, nil
+	}
+
+	if height, err := strconv.ParseInt(p.From, 10, 64); err == nil {
+		if height < 0 {
+			return nil, fmt.Errorf("invalid block height: %d", height)
+		}
+
+		return &types.EventCursor{BlockHeight: height}, nil
+	}
+
+	cursor, err := types.ParseEventCursor(p.From)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cursor, nil
+}
@@ -1,6 +1,7 @@
 package basics
 
 import (
+	"encoding/hex"
 	"fmt"
 
 	"github.com/btcsuite/btcd/btcec/v2/schnorr"
@@ -94,6 +95,9 @@ func GetAddress(key *secp256k1.PrivateKey, addrType AddressType, netParams *chai
 
 	case PubKeyHash:
 		return GetPubKeyHashAddress(key, netParams)
+
+	case ScriptHash:
+		return GetNestedSegWitAddress(key, netParams)
 	}
 
 	return nil, fmt.Errorf("unsupported address type: %s", addrType)
@@ -104,6 +108,16 @@ func GetPubKeyHashAddress(key *secp256k1.PrivateKey, netParams *chaincfg.Params)
 	return btcutil.NewAddressPubKeyHash(btcutil.Hash160(key.PubKey().SerializeCompressed()), netParams)
 }
 
+// GetNestedSegWitAddress gets the p2sh-p2wpkh address from the given private key
+func GetNestedSegWitAddress(key *secp256k1.PrivateKey, netParams *chaincfg.Params) (*btcutil.AddressScriptHash, error) {
+	redeemScript, err := GetRedeemScriptForNestedSegWit(hex.EncodeToString(key.PubKey().SerializeCompressed()), netParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return btcutil.NewAddressScriptHash(redeemScript, netParams)
+}
+
 // GetWitnessPubKeyHashAddress gets the witness public key hash address from the given private key
 func GetWitnessPubKeyHashAddress(key *secp256k1.PrivateKey, netParams *chaincfg.Params) (*btcutil.AddressWitnessPubKeyHash, error) {
 	return btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(key.PubKey().SerializeCompressed()), netParams)
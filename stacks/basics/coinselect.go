@@ -0,0 +1,550 @@
+package basics
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// CoinSelectionStrategy represents the strategy by which the payment utxos are selected
+type CoinSelectionStrategy uint8
+
+const (
+	LargestFirst   CoinSelectionStrategy = iota // largest first greedily
+	BranchAndBound                              // changeless match by branch and bound, falling back to knapsack
+	Knapsack                                    // subset closest to the target with change by random approximation
+	SmallestFirst                               // smallest first to consolidate the small utxos
+	LeastWaste                                  // the least waste among the strategies above
+)
+
+const (
+	// maximum tries of the branch and bound search
+	BNB_MAX_TRIES = 100000
+
+	// iterations of the knapsack approximation
+	KNAPSACK_ITERATIONS = 1000
+
+	// default fee rate in sat/vB at which the utxos are expected to be spent in the long term
+	DefaultLongTermFeeRate = float64(10)
+)
+
+// String implements fmt.Stringer
+func (s CoinSelectionStrategy) String() string {
+	switch s {
+	case LargestFirst:
+		return "largest-first"
+
+	case BranchAndBound:
+		return "bnb"
+
+	case Knapsack:
+		return "knapsack"
+
+	case SmallestFirst:
+		return "smallest-first"
+
+	case LeastWaste:
+		return "least-waste"
+
+	default:
+		return ""
+	}
+}
+
+// ParseCoinSelectionStrategy parses the coin selection strategy from the given name
+func ParseCoinSelectionStrategy(name string) (CoinSelectionStrategy, error) {
+	for _, s := range []CoinSelectionStrategy{LargestFirst, BranchAndBound, Knapsack, SmallestFirst, LeastWaste} {
+		if s.String() == name {
+			return s, nil
+		}
+	}
+
+	return 0, fmt.Errorf("invalid coin selection strategy: only largest-first, bnb, knapsack, smallest-first or least-waste allowed, %s given", name)
+}
+
+// CoinSelection defines the options by which the payment utxos are selected
+type CoinSelection struct {
+	Strategy CoinSelectionStrategy // selection strategy
+
+	LongTermFeeRate float64 // fee rate in sat/vB at which the utxos are expected to be spent in the long term, for the waste metric
+
+	LockedOutPoints    []wire.OutPoint // outpoints never spent
+	ExcludeUnconfirmed bool            // indicates if the utxos known to be unconfirmed are excluded
+
+	Rand *rand.Rand // source of the randomness of the knapsack selection
+}
+
+// NewCoinSelection creates a new CoinSelection instance with the randomness seeded by the current time
+func NewCoinSelection(strategy CoinSelectionStrategy, longTermFeeRate float64) *CoinSelection {
+	if longTermFeeRate <= 0 {
+		longTermFeeRate = DefaultLongTermFeeRate
+	}
+
+	return &CoinSelection{
+		Strategy:        strategy,
+		LongTermFeeRate: longTermFeeRate,
+		Rand:            rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// CoinSelectionResult defines the payment utxos selected along with the waste
+type CoinSelectionResult struct {
+	Strategy CoinSelectionStrategy
+	Utxos    []*UTXO
+	Waste    int64
+	Change   bool // indicates if the change output is expected
+}
+
+// coin defines the payment utxo candidate along with the effective value at the fee rate
+type coin struct {
+	utxo           *UTXO
+	effectiveValue int64 // value minus the fee for the input
+	fee            int64 // fee for the input at the fee rate
+	longTermFee    int64 // fee for the input at the long term fee rate
+}
+
+// AddPaymentUtxosToTx adds the payment utxos selected by the strategy to the tx.
+// No payment utxo is added if the given utxos already spent by the tx are sufficient.
+// The largest first selection applies if the coin selection is nil
+func (cs *CoinSelection) AddPaymentUtxosToTx(tx *wire.MsgTx, utxos []*UTXO, inOutdiff int64, paymentUtxos []*UTXO, changeOut *wire.TxOut, feeRate float64, netParams *chaincfg.Params) ([]*UTXO, error) {
+	if cs == nil {
+		return AddPaymentUtxosToTx(tx, utxos, inOutdiff, paymentUtxos, changeOut, feeRate, netParams)
+	}
+
+	utxos = append([]*UTXO{}, utxos...)
+
+	if len(utxos) > 0 && settleTx(tx, utxos, inOutdiff, changeOut, feeRate, netParams) {
+		return []*UTXO{}, nil
+	}
+
+	selectedPaymentUtxos := make([]*UTXO, 0)
+	paymentValue := int64(0)
+
+	add := func(utxo *UTXO) {
+		AddUtxoToTx(tx, utxo)
+
+		utxos = append(utxos, utxo)
+		selectedPaymentUtxos = append(selectedPaymentUtxos, utxo)
+
+		paymentValue += utxo.Value
+	}
+
+	// the fee without change to be paid by the payment utxos
+	target := GetFee(GetTxVirtualSize(tx, utxos, false), feeRate) - inOutdiff
+
+	candidates := cs.Filter(paymentUtxos)
+
+	result, err := cs.Select(candidates, target, changeOut, feeRate, netParams)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, utxo := range result.Utxos {
+		add(utxo)
+	}
+
+	if settleTx(tx, utxos, paymentValue+inOutdiff, changeOut, feeRate, netParams) {
+		return selectedPaymentUtxos, nil
+	}
+
+	// the input fees are estimated separately, so the remaining candidates may be required by rounding
+	remaining := excludeUtxos(candidates, result.Utxos)
+
+	sort.Slice(remaining, func(i, j int) bool {
+		return remaining[i].Value > remaining[j].Value
+	})
+
+	for _, utxo := range remaining {
+		add(utxo)
+
+		if settleTx(tx, utxos, paymentValue+inOutdiff, changeOut, feeRate, netParams) {
+			return selectedPaymentUtxos, nil
+		}
+	}
+
+	return nil, fmt.Errorf("insufficient utxos")
+}
+
+// Filter excludes the locked utxos and the unconfirmed ones if specified
+func (cs *CoinSelection) Filter(utxos []*UTXO) []*UTXO {
+	excluded := make(map[wire.OutPoint]bool)
+
+	for _, outPoint := range cs.LockedOutPoints {
+		excluded[outPoint] = true
+	}
+
+	filtered := make([]*UTXO, 0, len(utxos))
+
+	for _, utxo := range utxos {
+		if excluded[*utxo.GetOutPoint()] || (cs.ExcludeUnconfirmed && utxo.Unconfirmed) {
+			continue
+		}
+
+		filtered = append(filtered, utxo)
+	}
+
+	return filtered
+}
+
+// Select selects the utxos whose total effective value covers the target by the strategy.
+// The target is the fee without change to be paid by the selected utxos in addition to the fees for their inputs
+func (cs *CoinSelection) Select(utxos []*UTXO, target int64, changeOut *wire.TxOut, feeRate float64, netParams *chaincfg.Params) (*CoinSelectionResult, error) {
+	coins := make([]*coin, 0, len(utxos))
+
+	for _, utxo := range utxos {
+		inputVSize := GetInputVirtualSize(utxo)
+
+		c := &coin{
+			utxo:        utxo,
+			fee:         int64(math.Ceil(inputVSize * feeRate)),
+			longTermFee: int64(math.Ceil(inputVSize * cs.LongTermFeeRate)),
+		}
+
+		c.effectiveValue = utxo.Value - c.fee
+
+		// skip the uneconomical utxos
+		if c.effectiveValue > 0 {
+			coins = append(coins, c)
+		}
+	}
+
+	// the cost to create the change now and spend it later
+	changeFee := GetFee(int64(changeOut.SerializeSize()), feeRate)
+	changeSpendFee := int64(math.Ceil(GetInputVirtualSize(&UTXO{PkScript: changeOut.PkScript}) * cs.LongTermFeeRate))
+
+	costOfChange := changeFee + changeSpendFee
+	minChange := changeFee + GetDustLimit(changeOut, netParams)
+
+	var strategies []CoinSelectionStrategy
+	if cs.Strategy == LeastWaste {
+		strategies = []CoinSelectionStrategy{BranchAndBound, Knapsack, SmallestFirst, LargestFirst}
+	} else {
+		strategies = []CoinSelectionStrategy{cs.Strategy}
+	}
+
+	var best *CoinSelectionResult
+
+	for _, strategy := range strategies {
+		var selected []*coin
+
+		switch strategy {
+		case BranchAndBound:
+			selected = selectBnB(coins, target, costOfChange)
+			if selected == nil {
+				selected = selectKnapsack(cs.getRand(), coins, target, minChange)
+			}
+
+		case Knapsack:
+			selected = selectKnapsack(cs.getRand(), coins, target, minChange)
+
+		case SmallestFirst:
+			selected = selectInOrder(coins, target, func(a, b *coin) bool { return a.effectiveValue < b.effectiveValue })
+
+		default:
+			selected = selectInOrder(coins, target, func(a, b *coin) bool { return a.effectiveValue > b.effectiveValue })
+		}
+
+		if selected == nil {
+			continue
+		}
+
+		result := newCoinSelectionResult(strategy, selected, target, costOfChange, minChange)
+		if best == nil || result.Waste < best.Waste {
+			best = result
+		}
+	}
+
+	if best == nil {
+		return nil, fmt.Errorf("insufficient utxos")
+	}
+
+	return best, nil
+}
+
+// getRand gets the source of the randomness, seeded by the current time if not set
+func (cs *CoinSelection) getRand() *rand.Rand {
+	if cs.Rand == nil {
+		cs.Rand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+
+	return cs.Rand
+}
+
+// newCoinSelectionResult creates the result of the selected coins, scored by the waste metric:
+// the input fees above the long term ones, plus the cost of change if any, otherwise the excess dropped to the fee
+func newCoinSelectionResult(strategy CoinSelectionStrategy, selected []*coin, target int64, costOfChange int64, minChange int64) *CoinSelectionResult {
+	result := &CoinSelectionResult{
+		Strategy: strategy,
+		Utxos:    make([]*UTXO, 0, len(selected)),
+	}
+
+	effectiveValue := int64(0)
+
+	for _, c := range selected {
+		result.Utxos = append(result.Utxos, c.utxo)
+		result.Waste += c.fee - c.longTermFee
+
+		effectiveValue += c.effectiveValue
+	}
+
+	excess := effectiveValue - target
+
+	if excess >= minChange {
+		result.Change = true
+		result.Waste += costOfChange
+	} else {
+		result.Waste += excess
+	}
+
+	return result
+}
+
+// selectInOrder selects the coins in the given order until the target is covered
+func selectInOrder(coins []*coin, target int64, less func(a, b *coin) bool) []*coin {
+	sorted := append([]*coin{}, coins...)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return less(sorted[i], sorted[j])
+	})
+
+	total := int64(0)
+
+	for i, c := range sorted {
+		total += c.effectiveValue
+		if total >= target {
+			return sorted[:i+1]
+		}
+	}
+
+	return nil
+}
+
+// selectBnB searches the changeless selection whose effective value is within [target, target + cost of change] by branch and bound,
+// minimizing the waste. Returns nil if not found
+func selectBnB(coins []*coin, target int64, costOfChange int64) []*coin {
+	pool := append([]*coin{}, coins...)
+
+	sort.SliceStable(pool, func(i, j int) bool {
+		return pool[i].effectiveValue > pool[j].effectiveValue
+	})
+
+	available := int64(0)
+	for _, c := range pool {
+		available += c.effectiveValue
+	}
+
+	if available < target {
+		return nil
+	}
+
+	selection := make([]bool, 0, len(pool))
+	value := int64(0)
+	waste := int64(0)
+
+	var best []bool
+	bestWaste := int64(math.MaxInt64)
+
+	// the waste grows with more inputs if the fee rate is above the long term one
+	feeAboveLongTerm := len(pool) > 0 && pool[0].fee > pool[0].longTermFee
+
+	for tries := 0; tries < BNB_MAX_TRIES; tries++ {
+		backtrack := false
+
+		if value+available < target || value > target+costOfChange || (feeAboveLongTerm && waste > bestWaste) {
+			backtrack = true
+		} else if value >= target {
+			if waste+value-target <= bestWaste {
+				best = append([]bool{}, selection...)
+				bestWaste = waste + value - target
+			}
+
+			backtrack = true
+		}
+
+		if backtrack {
+			// walk back to the last included coin, restoring the omitted ones
+			for len(selection) > 0 && !selection[len(selection)-1] {
+				available += pool[len(selection)-1].effectiveValue
+				selection = selection[:len(selection)-1]
+			}
+
+			if len(selection) == 0 {
+				break
+			}
+
+			// omit the last included coin
+			last := pool[len(selection)-1]
+
+			selection[len(selection)-1] = false
+			value -= last.effectiveValue
+			waste -= last.fee - last.longTermFee
+
+			continue
+		}
+
+		next := pool[len(selection)]
+		available -= next.effectiveValue
+
+		// omit the coin equivalent to the previous omitted one, which leads to the explored selections
+		if len(selection) > 0 && !selection[len(selection)-1] {
+			prev := pool[len(selection)-1]
+			if next.effectiveValue == prev.effectiveValue && next.fee == prev.fee {
+				selection = append(selection, false)
+				continue
+			}
+		}
+
+		selection = append(selection, true)
+		value += next.effectiveValue
+		waste += next.fee - next.longTermFee
+	}
+
+	if best == nil {
+		return nil
+	}
+
+	selected := make([]*coin, 0)
+	for i, included := range best {
+		if included {
+			selected = append(selected, pool[i])
+		}
+	}
+
+	return selected
+}
+
+// selectKnapsack selects the coins by the knapsack approximation which prefers the exact match,
+// otherwise the subset closest to the target with the minimum change, falling back to the smallest coin covering it
+func selectKnapsack(rnd *rand.Rand, coins []*coin, target int64, minChange int64) []*coin {
+	pool := append([]*coin{}, coins...)
+
+	rnd.Shuffle(len(pool), func(i, j int) {
+		pool[i], pool[j] = pool[j], pool[i]
+	})
+
+	targetWithChange := target + minChange
+
+	var lowestLarger *coin
+	smaller := make([]*coin, 0)
+	totalSmaller := int64(0)
+
+	for _, c := range pool {
+		if c.effectiveValue == target {
+			return []*coin{c}
+		}
+
+		if c.effectiveValue < targetWithChange {
+			smaller = append(smaller, c)
+			totalSmaller += c.effectiveValue
+		} else if lowestLarger == nil || c.effectiveValue < lowestLarger.effectiveValue {
+			lowestLarger = c
+		}
+	}
+
+	if totalSmaller == target || totalSmaller == targetWithChange {
+		return smaller
+	}
+
+	if totalSmaller < target {
+		if lowestLarger == nil {
+			return nil
+		}
+
+		return []*coin{lowestLarger}
+	}
+
+	sort.SliceStable(smaller, func(i, j int) bool {
+		return smaller[i].effectiveValue > smaller[j].effectiveValue
+	})
+
+	// aim at the target with change if reachable, otherwise the changeless target
+	aim := targetWithChange
+	if totalSmaller < targetWithChange {
+		aim = target
+	}
+
+	best, bestValue := approximateBestSubset(rnd, smaller, totalSmaller, aim)
+
+	if lowestLarger != nil && (bestValue < targetWithChange && bestValue != target || lowestLarger.effectiveValue <= bestValue) {
+		return []*coin{lowestLarger}
+	}
+
+	return best
+}
+
+// approximateBestSubset approximates the subset of the coins whose total effective value is the closest to the target from above
+// by random inclusion over iterations
+func approximateBestSubset(rnd *rand.Rand, coins []*coin, total int64, target int64) ([]*coin, int64) {
+	best := make([]bool, len(coins))
+	for i := range best {
+		best[i] = true
+	}
+
+	bestValue := total
+
+	included := make([]bool, len(coins))
+
+	for rep := 0; rep < KNAPSACK_ITERATIONS && bestValue != target; rep++ {
+		for i := range included {
+			included[i] = false
+		}
+
+		value := int64(0)
+		reached := false
+
+		for pass := 0; pass < 2 && !reached; pass++ {
+			for i, c := range coins {
+				// include randomly in the first pass and the rest in the second pass
+				if pass == 0 && rnd.Intn(2) == 0 || pass == 1 && included[i] {
+					continue
+				}
+
+				value += c.effectiveValue
+				included[i] = true
+
+				if value >= target {
+					reached = true
+
+					if value < bestValue {
+						bestValue = value
+						copy(best, included)
+					}
+
+					value -= c.effectiveValue
+					included[i] = false
+				}
+			}
+		}
+	}
+
+	subset := make([]*coin, 0)
+	for i, c := range coins {
+		if best[i] {
+			subset = append(subset, c)
+		}
+	}
+
+	return subset, bestValue
+}
+
+// excludeUtxos excludes the given utxos from the utxo set
+func excludeUtxos(utxos []*UTXO, excluded []*UTXO) []*UTXO {
+	set := make(map[wire.OutPoint]bool)
+	for _, utxo := range excluded {
+		set[*utxo.GetOutPoint()] = true
+	}
+
+	remaining := make([]*UTXO, 0, len(utxos))
+	for _, utxo := range utxos {
+		if !set[*utxo.GetOutPoint()] {
+			remaining = append(remaining, utxo)
+		}
+	}
+
+	return remaining
+}
@@ -0,0 +1,250 @@
+package basics
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// newTestUtxo creates the p2tr utxo of the given value with a distinct outpoint
+func newTestUtxo(index uint32, value int64) *UTXO {
+	pkScript := append([]byte{txscript.OP_1, txscript.OP_DATA_32}, make([]byte, 32)...)
+
+	return NewUTXO(&chainhash.Hash{}, index, value, pkScript)
+}
+
+// newTestCoins creates the coins of the given effective values with the fee above the long term one
+func newTestCoins(values ...int64) []*coin {
+	coins := make([]*coin, len(values))
+	for i, value := range values {
+		coins[i] = &coin{
+			utxo:           newTestUtxo(uint32(i), value+10),
+			effectiveValue: value,
+			fee:            10,
+			longTermFee:    5,
+		}
+	}
+
+	return coins
+}
+
+func sumEffectiveValues(coins []*coin) int64 {
+	total := int64(0)
+	for _, c := range coins {
+		total += c.effectiveValue
+	}
+
+	return total
+}
+
+func TestSelectBnB(t *testing.T) {
+	tests := []struct {
+		name         string
+		values       []int64
+		target       int64
+		costOfChange int64
+		want         int64 // total effective value selected, 0 if none
+	}{
+		{"exact match", []int64{1000, 2000, 3000, 5000}, 6000, 0, 6000},
+		{"single exact match", []int64{1000, 2000, 5000}, 5000, 0, 5000},
+		{"within the cost of change", []int64{4000, 6000}, 5000, 1500, 6000},
+		{"beyond the cost of change", []int64{4000, 7000}, 5000, 1000, 0},
+		{"insufficient funds", []int64{1000, 2000}, 5000, 1000, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected := selectBnB(newTestCoins(tt.values...), tt.target, tt.costOfChange)
+
+			if got := sumEffectiveValues(selected); got != tt.want {
+				t.Fatalf("got %d; want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectBnBLeastInputs(t *testing.T) {
+	// both {5000} and {2000, 3000} match, the fewer inputs waste less above the long term fee rate
+	selected := selectBnB(newTestCoins(2000, 3000, 5000), 5000, 0)
+
+	if len(selected) != 1 || selected[0].effectiveValue != 5000 {
+		t.Fatalf("got %d coins of %d; want the single coin of 5000", len(selected), sumEffectiveValues(selected))
+	}
+}
+
+func TestSelectKnapsack(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    []int64
+		target    int64
+		minChange int64
+		want      int64
+	}{
+		{"single exact match", []int64{1000, 3000, 5000}, 3000, 500, 3000},
+		{"smaller ones matching", []int64{1000, 2000, 10000}, 3000, 500, 3000},
+		{"lowest larger", []int64{1000, 8000, 6000}, 3000, 500, 6000},
+		{"subset with change", []int64{1000, 1500, 2000, 2500}, 3000, 500, 3500},
+		{"insufficient funds", []int64{1000, 1500}, 3000, 500, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selected := selectKnapsack(rand.New(rand.NewSource(1)), newTestCoins(tt.values...), tt.target, tt.minChange)
+
+			if got := sumEffectiveValues(selected); got != tt.want {
+				t.Fatalf("got %d; want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectKnapsackDeterministic(t *testing.T) {
+	coins := newTestCoins(1100, 1300, 1700, 1900, 2300, 2900, 3100, 3700)
+
+	first := selectKnapsack(rand.New(rand.NewSource(7)), coins, 6000, 500)
+	second := selectKnapsack(rand.New(rand.NewSource(7)), coins, 6000, 500)
+
+	if len(first) != len(second) {
+		t.Fatalf("got %d and %d coins; want the same selection", len(first), len(second))
+	}
+
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("coin %d: got %d and %d; want the same selection", i, first[i].effectiveValue, second[i].effectiveValue)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	netParams := &chaincfg.MainNetParams
+	feeRate := float64(2)
+
+	changeOut := wire.NewTxOut(0, newTestUtxo(0, 0).PkScript)
+	changeFee := GetFee(int64(changeOut.SerializeSize()), feeRate)
+	inputFee := int64(math.Ceil(GetInputVirtualSize(newTestUtxo(0, 0)) * feeRate))
+
+	minChange := changeFee + GetDustLimit(changeOut, netParams)
+	costOfChange := changeFee + inputFee
+
+	target := int64(5000)
+
+	tests := []struct {
+		name       string
+		strategy   CoinSelectionStrategy
+		values     []int64
+		wantErr    bool
+		wantValues []int64
+		wantChange bool
+		wantWaste  int64
+		wantBy     CoinSelectionStrategy
+	}{
+		{
+			name:       "no change below the minimum change",
+			strategy:   LargestFirst,
+			values:     []int64{target + inputFee + minChange - 1},
+			wantValues: []int64{target + inputFee + minChange - 1},
+			wantWaste:  minChange - 1,
+			wantBy:     LargestFirst,
+		},
+		{
+			name:       "change at the minimum change",
+			strategy:   LargestFirst,
+			values:     []int64{target + inputFee + minChange},
+			wantValues: []int64{target + inputFee + minChange},
+			wantChange: true,
+			wantWaste:  costOfChange,
+			wantBy:     LargestFirst,
+		},
+		{
+			name:     "insufficient funds",
+			strategy: LargestFirst,
+			values:   []int64{target + inputFee - 1},
+			wantErr:  true,
+		},
+		{
+			name:     "uneconomical utxos skipped",
+			strategy: LeastWaste,
+			values:   []int64{inputFee, inputFee, inputFee},
+			wantErr:  true,
+		},
+		{
+			name:       "least waste preferring changeless",
+			strategy:   LeastWaste,
+			values:     []int64{10 * target, target + inputFee},
+			wantValues: []int64{target + inputFee},
+			wantBy:     BranchAndBound,
+		},
+		{
+			name:       "least waste tie broken by order",
+			strategy:   LeastWaste,
+			values:     []int64{target + inputFee},
+			wantValues: []int64{target + inputFee},
+			wantBy:     BranchAndBound,
+		},
+		{
+			name:       "smallest first consolidating",
+			strategy:   SmallestFirst,
+			values:     []int64{10 * target, target/2 + inputFee, target/2 + inputFee},
+			wantValues: []int64{target/2 + inputFee, target/2 + inputFee},
+			wantBy:     SmallestFirst,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			utxos := make([]*UTXO, len(tt.values))
+			for i, value := range tt.values {
+				utxos[i] = newTestUtxo(uint32(i), value)
+			}
+
+			cs := NewCoinSelection(tt.strategy, feeRate)
+			cs.Rand = rand.New(rand.NewSource(1))
+
+			result, err := cs.Select(utxos, target, changeOut, feeRate, netParams)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("got %d utxos; want error", len(result.Utxos))
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("failed to select: %v", err)
+			}
+
+			if result.Strategy != tt.wantBy || result.Change != tt.wantChange || result.Waste != tt.wantWaste {
+				t.Fatalf("got %s, change %v, waste %d; want %s, change %v, waste %d",
+					result.Strategy, result.Change, result.Waste, tt.wantBy, tt.wantChange, tt.wantWaste)
+			}
+
+			if len(result.Utxos) != len(tt.wantValues) {
+				t.Fatalf("got %d utxos; want %d", len(result.Utxos), len(tt.wantValues))
+			}
+
+			for i, utxo := range result.Utxos {
+				if utxo.Value != tt.wantValues[i] {
+					t.Fatalf("utxo %d: got %d; want %d", i, utxo.Value, tt.wantValues[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSelectUtxosFromUtxos(t *testing.T) {
+	utxos := []*UTXO{newTestUtxo(0, 1000), newTestUtxo(1, 5000), newTestUtxo(2, 3000)}
+
+	selected, total, err := SelectUtxosFromUtxos(utxos, 7000)
+	if err != nil || total != 8000 || len(selected) != 2 || selected[0].Value != 5000 || selected[1].Value != 3000 {
+		t.Fatalf("got %v, %d, %v; want 5000 and 3000 selected", UTXOs(selected), total, err)
+	}
+
+	if _, _, err := SelectUtxosFromUtxos(utxos, 10000); err == nil {
+		t.Fatalf("insufficient: got nil; want error")
+	}
+}
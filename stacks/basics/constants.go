@@ -37,3 +37,8 @@ var (
 	// null output script with padding
 	PADDED_NULL_OUTPUT_SCRIPT = append(NULL_OUTPUT_SCRIPT, MIN_PADDING_SCRIPT[:]...)
 )
+
+const (
+	// sequence of the inputs signaling replaceability by BIP125
+	RBFSequence = wire.MaxTxInSequenceNum - 2
+)
@@ -61,3 +61,12 @@ func IsDust(txOut *wire.TxOut, netParams *chaincfg.Params) bool {
 
 	return mempool.IsDust(txOut, btcutil.Amount(MIN_RELAY_TX_FEE))
 }
+
+// GetDustLimit gets the minimum value of the given output not to be dust
+func GetDustLimit(txOut *wire.TxOut, netParams *chaincfg.Params) int64 {
+	if netParams.RelayNonStdTxs || txscript.IsUnspendable(txOut.PkScript) {
+		return 0
+	}
+
+	return mempool.GetDustThreshold(txOut) * MIN_RELAY_TX_FEE / 1000
+}
@@ -2,9 +2,12 @@ package basics
 
 import (
 	"bytes"
+	"encoding/hex"
 	"fmt"
+	"math"
 	"sort"
 
+	"github.com/btcsuite/btcd/blockchain"
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/mempool"
@@ -13,7 +16,7 @@ import (
 )
 
 // GetTxVirtualSize gets the virtual size of the given tx.
-// Assume that tx.TxIn corresponds to the given utxos if the tx is unsigned
+// Assume that tx.TxIn corresponds to the given utxos if the tx is unsigned, in which case the inputs already carrying the witness are kept as is
 func GetTxVirtualSize(tx *wire.MsgTx, utxos []*UTXO, signed bool) int64 {
 	if signed {
 		return mempool.GetTxVirtualSize(btcutil.NewTx(tx))
@@ -22,31 +25,55 @@ func GetTxVirtualSize(tx *wire.MsgTx, utxos []*UTXO, signed bool) int64 {
 	newTx := tx.Copy()
 
 	for i, txIn := range newTx.TxIn {
-		var dummySigScript []byte
-		var dummyWitness []byte
+		if len(txIn.Witness) > 0 {
+			continue
+		}
 
-		switch txscript.GetScriptClass(utxos[i].PkScript) {
-		case txscript.WitnessV1TaprootTy:
-			dummyWitness = make([]byte, P2TRWitnessSize)
+		setDummySignature(txIn, utxos[i].PkScript)
+	}
 
-		case txscript.WitnessV0PubKeyHashTy:
-			dummyWitness = make([]byte, P2WPKHWitnessSize)
+	return mempool.GetTxVirtualSize(btcutil.NewTx(newTx))
+}
 
-		case txscript.ScriptHashTy:
-			dummySigScript = make([]byte, NestedSegWitSigScriptSize)
-			dummyWitness = make([]byte, P2WPKHWitnessSize)
+// GetInputVirtualSize gets the virtual size of the signed input spending the given utxo, excluding the segwit marker and flag
+func GetInputVirtualSize(utxo *UTXO) float64 {
+	txIn := wire.NewTxIn(utxo.GetOutPoint(), nil, nil)
+	setDummySignature(txIn, utxo.PkScript)
 
-		case txscript.PubKeyHashTy:
-			dummySigScript = make([]byte, P2PKHSigScriptSize)
+	weight := txIn.SerializeSize()*blockchain.WitnessScaleFactor + txIn.Witness.SerializeSize()
 
-		default:
-		}
+	return float64(weight) / blockchain.WitnessScaleFactor
+}
 
-		txIn.SignatureScript = dummySigScript
-		txIn.Witness = wire.TxWitness{dummyWitness}
+// setDummySignature sets the dummy signature script and witness of the max size to the input spending the given pk script
+func setDummySignature(txIn *wire.TxIn, pkScript []byte) {
+	var dummySigScript []byte
+	var dummyWitness []byte
+
+	switch txscript.GetScriptClass(pkScript) {
+	case txscript.WitnessV1TaprootTy:
+		dummyWitness = make([]byte, P2TRWitnessSize)
+
+	case txscript.WitnessV0PubKeyHashTy:
+		dummyWitness = make([]byte, P2WPKHWitnessSize)
+
+	case txscript.ScriptHashTy:
+		dummySigScript = make([]byte, NestedSegWitSigScriptSize)
+		dummyWitness = make([]byte, P2WPKHWitnessSize)
+
+	case txscript.PubKeyHashTy:
+		dummySigScript = make([]byte, P2PKHSigScriptSize)
+
+	default:
 	}
 
-	return mempool.GetTxVirtualSize(btcutil.NewTx(newTx))
+	txIn.SignatureScript = dummySigScript
+	txIn.Witness = wire.TxWitness{dummyWitness}
+}
+
+// GetFee gets the fee in satoshis for the given virtual size at the fee rate in sat/vB, rounded up
+func GetFee(vsize int64, feeRate float64) int64 {
+	return int64(math.Ceil(float64(vsize) * feeRate))
 }
 
 // SerializeTx serializes the given tx
@@ -59,6 +86,31 @@ func SerializeTx(tx *wire.MsgTx) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// EncodeTx encodes the given tx to hex
+func EncodeTx(tx *wire.MsgTx) (string, error) {
+	bz, err := SerializeTx(tx)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(bz), nil
+}
+
+// DecodeTx decodes the given hex encoded tx
+func DecodeTx(txHex string) (*wire.MsgTx, error) {
+	bz, err := hex.DecodeString(txHex)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := new(wire.MsgTx)
+	if err := tx.Deserialize(bytes.NewReader(bz)); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
 // GetTotalOutputValue gets the total value of the given tx outs
 func GetTotalOutputValue(txOuts []*wire.TxOut) int64 {
 	var totalValue int64
@@ -71,7 +123,8 @@ func GetTotalOutputValue(txOuts []*wire.TxOut) int64 {
 }
 
 // BuildTransaction builds an unsigned tx from the given params.
-func BuildTransaction(utxos []*UTXO, txOuts []*wire.TxOut, paymentUtxos []*UTXO, changeAddress btcutil.Address, feeRate int64, netParams *chaincfg.Params) (*wire.MsgTx, []*UTXO, error) {
+// The payment utxos are selected by the given coin selection, largest first if nil
+func BuildTransaction(utxos []*UTXO, txOuts []*wire.TxOut, paymentUtxos []*UTXO, changeAddress btcutil.Address, feeRate float64, coinSelection *CoinSelection, netParams *chaincfg.Params) (*wire.MsgTx, []*UTXO, error) {
 	tx := wire.NewMsgTx(TxVersion)
 
 	inAmount := int64(0)
@@ -94,7 +147,7 @@ func BuildTransaction(utxos []*UTXO, txOuts []*wire.TxOut, paymentUtxos []*UTXO,
 
 	changeOut := wire.NewTxOut(0, changePkScript)
 
-	selectedPaymentUtxos, err := AddPaymentUtxosToTx(tx, utxos, inAmount-outAmount, paymentUtxos, changeOut, feeRate, netParams)
+	selectedPaymentUtxos, err := coinSelection.AddPaymentUtxosToTx(tx, utxos, inAmount-outAmount, paymentUtxos, changeOut, feeRate, netParams)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -106,48 +159,67 @@ func BuildTransaction(utxos []*UTXO, txOuts []*wire.TxOut, paymentUtxos []*UTXO,
 	return tx, selectedPaymentUtxos, nil
 }
 
-// AddPaymentUtxosToTx adds the payment utxos to the tx
-func AddPaymentUtxosToTx(tx *wire.MsgTx, utxos []*UTXO, inOutdiff int64, paymentUtxos []*UTXO, changeOut *wire.TxOut, feeRate int64, netParams *chaincfg.Params) ([]*UTXO, error) {
+// AddPaymentUtxosToTx adds the payment utxos to the tx, largest first.
+// No payment utxo is added if the given utxos already spent by the tx are sufficient
+func AddPaymentUtxosToTx(tx *wire.MsgTx, utxos []*UTXO, inOutdiff int64, paymentUtxos []*UTXO, changeOut *wire.TxOut, feeRate float64, netParams *chaincfg.Params) ([]*UTXO, error) {
 	selectedPaymentUtxos := make([]*UTXO, 0)
 	paymentValue := int64(0)
 
+	utxos = append([]*UTXO{}, utxos...)
+
+	if len(utxos) > 0 && settleTx(tx, utxos, inOutdiff, changeOut, feeRate, netParams) {
+		return selectedPaymentUtxos, nil
+	}
+
 	sort.Slice(paymentUtxos, func(i, j int) bool {
 		return paymentUtxos[i].Value > paymentUtxos[j].Value
 	})
 
 	for _, utxo := range paymentUtxos {
 		AddUtxoToTx(tx, utxo)
-		tx.AddTxOut(changeOut)
 
 		utxos = append(utxos, utxo)
 		selectedPaymentUtxos = append(selectedPaymentUtxos, utxo)
 
 		paymentValue += utxo.Value
-		fee := GetTxVirtualSize(tx, utxos, false) * feeRate
-
-		changeValue := paymentValue + inOutdiff - fee
-		if changeValue > 0 {
-			tx.TxOut[len(tx.TxOut)-1].Value = changeValue
-			if IsDust(tx.TxOut[len(tx.TxOut)-1], netParams) {
-				tx.TxOut = tx.TxOut[0 : len(tx.TxOut)-1]
-			}
 
+		if settleTx(tx, utxos, paymentValue+inOutdiff, changeOut, feeRate, netParams) {
 			return selectedPaymentUtxos, nil
-		} else {
-			tx.TxOut = tx.TxOut[0 : len(tx.TxOut)-1]
+		}
+	}
+
+	return nil, fmt.Errorf("insufficient utxos")
+}
+
+// settleTx adds the change output if any and returns true if the fee is paid, false otherwise.
+// The balance is the total input value minus the output value excluding the change
+func settleTx(tx *wire.MsgTx, utxos []*UTXO, balance int64, changeOut *wire.TxOut, feeRate float64, netParams *chaincfg.Params) bool {
+	tx.AddTxOut(changeOut)
 
-			if changeValue == 0 {
-				return selectedPaymentUtxos, nil
-			}
+	fee := GetFee(GetTxVirtualSize(tx, utxos, false), feeRate)
 
-			if changeValue < 0 {
-				feeWithoutChange := GetTxVirtualSize(tx, utxos, false) * feeRate
-				if paymentValue+inOutdiff-feeWithoutChange >= 0 {
-					return selectedPaymentUtxos, nil
-				}
-			}
+	changeValue := balance - fee
+	if changeValue > 0 {
+		tx.TxOut[len(tx.TxOut)-1].Value = changeValue
+		if IsDust(tx.TxOut[len(tx.TxOut)-1], netParams) {
+			tx.TxOut = tx.TxOut[0 : len(tx.TxOut)-1]
 		}
+
+		return true
 	}
 
-	return nil, fmt.Errorf("insufficient utxos")
+	tx.TxOut = tx.TxOut[0 : len(tx.TxOut)-1]
+
+	// a tx without outputs is invalid
+	if len(tx.TxOut) == 0 {
+		return false
+	}
+
+	if changeValue == 0 {
+		return true
+	}
+
+	feeWithoutChange := GetFee(GetTxVirtualSize(tx, utxos, false), feeRate)
+
+	return balance-feeWithoutChange >= 0
 }
@@ -5,9 +5,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/tidwall/gjson"
 
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
 )
@@ -18,6 +21,8 @@ type UTXO struct {
 	Index    uint32         `json:"index"`
 	Value    int64          `json:"value"`
 	PkScript []byte         `json:"pkScript"`
+
+	Unconfirmed bool `json:"unconfirmed,omitempty"` // known to be unconfirmed by the provider
 }
 
 // NewUTXO creates a new UTXO instance
@@ -67,6 +72,7 @@ func (u *UTXO) UnmarshalJSON(data []byte) error {
 	u.Index = uint32(json.Get("index").Uint())
 	u.Value = json.Get("value").Int()
 	u.PkScript = pkScript
+	u.Unconfirmed = json.Get("unconfirmed").Bool()
 
 	return nil
 }
@@ -95,11 +101,12 @@ func (us UTXOs) String() string {
 	return string(bz)
 }
 
-// AddUtxoToTx adds the given utxo to the specified tx
+// AddUtxoToTx adds the given utxo to the specified tx, signaling RBF
 func AddUtxoToTx(tx *wire.MsgTx, utxo *UTXO) {
 	txIn := new(wire.TxIn)
 
 	txIn.PreviousOutPoint = *utxo.GetOutPoint()
+	txIn.Sequence = RBFSequence
 
 	tx.AddTxIn(txIn)
 }
@@ -111,23 +118,35 @@ func AddUtxosToTx(tx *wire.MsgTx, utxos []*UTXO) {
 	}
 }
 
-// SelectUtxosFromUtxos selects sufficient utxos with total output values not less than `requiredValue` from the given utxo set
+// SelectUtxosFromUtxos selects sufficient utxos with total output values not less than `requiredValue` from the given utxo set.
+// The utxos are selected largest first, regardless of the fees for their inputs.
+//
+// Deprecated: use CoinSelection.Select, which accounts for the input fees and the change
 func SelectUtxosFromUtxos(utxos []*UTXO, requiredValue int64) ([]*UTXO, int64, error) {
-	selectedUtxos := make([]*UTXO, 0)
-	totalValue := int64(0)
+	result, err := (&CoinSelection{Strategy: LargestFirst}).Select(utxos, requiredValue, wire.NewTxOut(0, nil), 0, &chaincfg.MainNetParams)
+	if err != nil {
+		return nil, 0, err
+	}
 
-	for _, utxo := range utxos {
-		selectedUtxos = append(selectedUtxos, utxo)
+	return result.Utxos, UTXOs(result.Utxos).TotalValue(), nil
+}
 
-		totalValue += utxo.Value
-		if totalValue >= requiredValue {
-			break
-		}
+// ParseOutPoint parses the outpoint in the form of txid:vout
+func ParseOutPoint(s string) (*wire.OutPoint, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("invalid outpoint: %s, txid:vout expected", s)
 	}
 
-	if totalValue >= requiredValue {
-		return selectedUtxos, totalValue, nil
+	hash, err := chainhash.NewHashFromStr(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid outpoint: %s, invalid txid", s)
+	}
+
+	index, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid outpoint: %s, invalid vout", s)
 	}
 
-	return nil, 0, fmt.Errorf("insufficient utxos")
+	return wire.NewOutPoint(hash, uint32(index)), nil
 }
@@ -0,0 +1,328 @@
+package broadcaster
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/stacks/basics"
+)
+
+// Mode represents how the txs are submitted to the node
+type Mode string
+
+const (
+	MODE_PACKAGE    Mode = "package"    // submitted as a package by submitpackage
+	MODE_SEQUENTIAL Mode = "sequential" // sent one by one by sendrawtransaction
+)
+
+// reject reasons of testmempoolaccept for the txs already accepted by the node
+var acceptedRejectReasons = []string{"txn-already-in-mempool", "txn-already-known", "txn-same-nonwitness-data-in-mempool"}
+
+// Rejection defines the reason for which the node rejects the tx
+type Rejection struct {
+	TxID   string
+	Reason string
+}
+
+// RejectError defines the error of the txs rejected by the node
+type RejectError struct {
+	Rejections []*Rejection
+}
+
+// Error implements error
+func (e *RejectError) Error() string {
+	reasons := make([]string, 0, len(e.Rejections))
+	for _, rejection := range e.Rejections {
+		reasons = append(reasons, fmt.Sprintf("%s: %s", rejection.TxID, rejection.Reason))
+	}
+
+	return fmt.Sprintf("txs rejected by the node: %s", strings.Join(reasons, "; "))
+}
+
+// Broadcaster broadcasts the dependent txs, i.e. the commit tx and the reveal txs, as a whole.
+// The txs are checked together by testmempoolaccept before anything is sent, so that no tx is broadcast if any is rejected
+type Broadcaster struct {
+	rpcClient *rpcclient.Client
+}
+
+// NewBroadcaster creates a Broadcaster instance
+func NewBroadcaster(rpcClient *rpcclient.Client) *Broadcaster {
+	return &Broadcaster{
+		rpcClient: rpcClient,
+	}
+}
+
+// Broadcast checks the given txs in order by testmempoolaccept, and then submits them if all accepted.
+// Returns the mode by which the txs are submitted, or *RejectError with the per-tx rejection reasons
+func (b *Broadcaster) Broadcast(txs []*wire.MsgTx) (Mode, error) {
+	if err := b.TestAccept(txs); err != nil {
+		return "", err
+	}
+
+	return b.Submit(txs)
+}
+
+// Submit submits the given txs as a package by submitpackage if available without testing,
+// or sends them one by one in order if the node does not support submitpackage or the txs are not a child with its parents.
+// Returns the mode by which the txs are submitted
+func (b *Broadcaster) Submit(txs []*wire.MsgTx) (Mode, error) {
+	if len(txs) == 0 {
+		return "", fmt.Errorf("no txs to broadcast")
+	}
+
+	if len(txs) > 1 && isChildWithParents(txs) {
+		err := b.submitPackage(txs)
+		if err == nil {
+			return MODE_PACKAGE, nil
+		}
+
+		if _, ok := err.(*RejectError); ok {
+			return "", err
+		}
+
+		// fall through to send the txs one by one, e.g. submitpackage not supported by the node
+	}
+
+	for _, tx := range txs {
+		if err := b.sendRawTx(tx); err != nil {
+			return "", fmt.Errorf("failed to broadcast tx %s: %v", tx.TxHash(), err)
+		}
+	}
+
+	return MODE_SEQUENTIAL, nil
+}
+
+// TestAccept checks if the given txs in order are accepted by the node as a package through testmempoolaccept.
+// If the node does not support testing the packages, the txs which spend no other given txs are tested individually.
+// Returns *RejectError with the per-tx rejection reasons if any tx is rejected
+func (b *Broadcaster) TestAccept(txs []*wire.MsgTx) error {
+	if len(txs) == 0 {
+		return fmt.Errorf("no txs to test")
+	}
+
+	results, err := b.testMempoolAccept(txs)
+	if err != nil && len(txs) > 1 && isPackageUnsupported(err) {
+		return b.testAcceptIndividually(txs)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to test mempool accept: %v", err)
+	}
+
+	return checkTestAcceptResults(results)
+}
+
+// testAcceptIndividually tests the txs which spend no other given txs one by one
+func (b *Broadcaster) testAcceptIndividually(txs []*wire.MsgTx) error {
+	txHashes := make(map[chainhash.Hash]bool, len(txs))
+	for _, tx := range txs {
+		txHashes[tx.TxHash()] = true
+	}
+
+	for _, tx := range txs {
+		if spendsAny(tx, txHashes) {
+			continue
+		}
+
+		results, err := b.testMempoolAccept([]*wire.MsgTx{tx})
+		if err != nil {
+			return fmt.Errorf("failed to test mempool accept: %v", err)
+		}
+
+		if err := checkTestAcceptResults(results); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// testMempoolAccept calls testmempoolaccept with the given txs
+func (b *Broadcaster) testMempoolAccept(txs []*wire.MsgTx) ([]*TestMempoolAcceptResult, error) {
+	rawTxs, err := encodeTxs(txs)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.rpcClient.RawRequest("testmempoolaccept", []json.RawMessage{rawTxs})
+	if err != nil {
+		return nil, err
+	}
+
+	var results []*TestMempoolAcceptResult
+	if err := json.Unmarshal(resp, &results); err != nil {
+		return nil, fmt.Errorf("invalid response, err: %v", err)
+	}
+
+	if len(results) != len(txs) {
+		return nil, fmt.Errorf("invalid response: %d results for %d txs", len(results), len(txs))
+	}
+
+	return results, nil
+}
+
+// submitPackage calls submitpackage with the given txs
+func (b *Broadcaster) submitPackage(txs []*wire.MsgTx) error {
+	rawTxs, err := encodeTxs(txs)
+	if err != nil {
+		return err
+	}
+
+	resp, err := b.rpcClient.RawRequest("submitpackage", []json.RawMessage{rawTxs})
+	if err != nil {
+		return err
+	}
+
+	var result SubmitPackageResult
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return fmt.Errorf("invalid response, err: %v", err)
+	}
+
+	rejections := make([]*Rejection, 0)
+
+	for _, tx := range txs {
+		txResult, ok := result.TxResults[tx.WitnessHash().String()]
+		if !ok {
+			rejections = append(rejections, &Rejection{TxID: tx.TxHash().String(), Reason: "missing in the package result"})
+			continue
+		}
+
+		if len(txResult.Error) > 0 {
+			rejections = append(rejections, &Rejection{TxID: tx.TxHash().String(), Reason: txResult.Error})
+		}
+	}
+
+	if len(rejections) > 0 {
+		return &RejectError{Rejections: rejections}
+	}
+
+	// package_msg is only returned by the newer nodes
+	if len(result.PackageMsg) > 0 && result.PackageMsg != "success" {
+		return &RejectError{Rejections: []*Rejection{{TxID: txs[len(txs)-1].TxHash().String(), Reason: result.PackageMsg}}}
+	}
+
+	return nil
+}
+
+// sendRawTx sends the given tx unless known by the node
+func (b *Broadcaster) sendRawTx(tx *wire.MsgTx) error {
+	txHash := tx.TxHash()
+
+	if _, err := b.rpcClient.GetRawTransaction(&txHash); err == nil {
+		return nil
+	}
+
+	_, err := b.rpcClient.SendRawTransaction(tx, false)
+	if rpcErr, ok := err.(*btcjson.RPCError); ok && rpcErr.Code == btcjson.ErrRPCVerifyAlreadyInChain {
+		return nil
+	}
+
+	return err
+}
+
+// checkTestAcceptResults checks the testmempoolaccept results, returning *RejectError if any tx is rejected
+func checkTestAcceptResults(results []*TestMempoolAcceptResult) error {
+	rejections := make([]*Rejection, 0)
+
+	for _, result := range results {
+		if result.Allowed != nil && *result.Allowed {
+			continue
+		}
+
+		reason := result.RejectReason
+		if len(reason) == 0 {
+			reason = result.PackageError
+		}
+
+		if len(reason) == 0 {
+			reason = "not allowed"
+		}
+
+		if isAcceptedRejectReason(reason) {
+			continue
+		}
+
+		rejections = append(rejections, &Rejection{TxID: result.TxID, Reason: reason})
+	}
+
+	if len(rejections) > 0 {
+		return &RejectError{Rejections: rejections}
+	}
+
+	return nil
+}
+
+// isChildWithParents checks if the last tx spends all the other txs, which spend none of the given txs.
+// submitpackage only accepts the package of this topology
+func isChildWithParents(txs []*wire.MsgTx) bool {
+	parents := make(map[chainhash.Hash]bool, len(txs)-1)
+	for _, tx := range txs[:len(txs)-1] {
+		parents[tx.TxHash()] = true
+	}
+
+	for _, tx := range txs[:len(txs)-1] {
+		if spendsAny(tx, parents) {
+			return false
+		}
+	}
+
+	spent := make(map[chainhash.Hash]bool, len(parents))
+	for _, txIn := range txs[len(txs)-1].TxIn {
+		if parents[txIn.PreviousOutPoint.Hash] {
+			spent[txIn.PreviousOutPoint.Hash] = true
+		}
+	}
+
+	return len(spent) == len(parents)
+}
+
+// spendsAny checks if the given tx spends any of the given txs
+func spendsAny(tx *wire.MsgTx, txHashes map[chainhash.Hash]bool) bool {
+	for _, txIn := range tx.TxIn {
+		if txHashes[txIn.PreviousOutPoint.Hash] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isPackageUnsupported checks if the error indicates that testmempoolaccept only accepts one tx, i.e. the older nodes
+func isPackageUnsupported(err error) bool {
+	rpcErr, ok := err.(*btcjson.RPCError)
+
+	return ok && rpcErr.Code == btcjson.ErrRPCInvalidParameter && strings.Contains(rpcErr.Message, "exactly one")
+}
+
+// isAcceptedRejectReason checks if the reject reason indicates that the tx is already accepted by the node
+func isAcceptedRejectReason(reason string) bool {
+	for _, accepted := range acceptedRejectReasons {
+		if reason == accepted {
+			return true
+		}
+	}
+
+	return false
+}
+
+// encodeTxs encodes the given txs to the JSON array of the raw txs
+func encodeTxs(txs []*wire.MsgTx) (json.RawMessage, error) {
+	rawTxs := make([]string, 0, len(txs))
+
+	for _, tx := range txs {
+		rawTx, err := basics.EncodeTx(tx)
+		if err != nil {
+			return nil, err
+		}
+
+		rawTxs = append(rawTxs, rawTx)
+	}
+
+	return json.Marshal(rawTxs)
+}
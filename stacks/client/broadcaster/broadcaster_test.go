@@ -0,0 +1,316 @@
+package broadcaster_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/devnet"
+	"btc-sbt/logger"
+	"btc-sbt/stacks/basics"
+	"btc-sbt/stacks/client/broadcaster"
+)
+
+// testNode serves the devnet node, recording the rpc methods called.
+// The legacy node rejects testmempoolaccept with more than one tx and does not know submitpackage, as bitcoind before v28 does
+type testNode struct {
+	*devnet.Node
+
+	legacy bool
+
+	mu      sync.Mutex
+	methods []string
+}
+
+func (n *testNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+
+	if err := json.Unmarshal(body, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n.mu.Lock()
+	n.methods = append(n.methods, req.Method)
+	n.mu.Unlock()
+
+	if n.legacy {
+		var rawTxs []string
+		if req.Method == "testmempoolaccept" && len(req.Params) > 0 && json.Unmarshal(req.Params[0], &rawTxs) == nil && len(rawTxs) > 1 {
+			writeTestRPCError(w, req.ID, -8, "Array must contain exactly one raw transaction for now")
+			return
+		}
+
+		if req.Method == "submitpackage" {
+			writeTestRPCError(w, req.ID, -32601, "Method not found")
+			return
+		}
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	n.Node.Router.ServeHTTP(w, r)
+}
+
+// called returns the number of the calls of the given method
+func (n *testNode) called(method string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	count := 0
+	for _, m := range n.methods {
+		if m == method {
+			count++
+		}
+	}
+
+	return count
+}
+
+func writeTestRPCError(w http.ResponseWriter, id json.RawMessage, code int, message string) {
+	w.WriteHeader(http.StatusInternalServerError)
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"result": nil,
+		"error":  map[string]interface{}{"code": code, "message": message},
+		"id":     id,
+	})
+}
+
+// testWallet holds the key whose matured coinbase output funds the test txs
+type testWallet struct {
+	key      *btcec.PrivateKey
+	pkScript []byte
+	coinbase *wire.MsgTx
+}
+
+// newTestBroadcaster starts the devnet node with the matured coinbase of the wallet, returning the broadcaster connected to it
+func newTestBroadcaster(t *testing.T, legacy bool) (*testNode, *broadcaster.Broadcaster, *testWallet) {
+	node := &testNode{Node: devnet.NewNode("user", "pass", 1, logger.Logger), legacy: legacy}
+
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+
+	rpcClient, err := rpcclient.New(&rpcclient.ConnConfig{
+		Host:         strings.TrimPrefix(server.URL, "http://"),
+		User:         "user",
+		Pass:         "pass",
+		HTTPPostMode: true,
+		DisableTLS:   true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to create the rpc client: %v", err)
+	}
+
+	t.Cleanup(rpcClient.Shutdown)
+
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate the key: %v", err)
+	}
+
+	addr, err := basics.GetAddress(key, basics.Taproot, node.NetParams)
+	if err != nil {
+		t.Fatalf("failed to get the address: %v", err)
+	}
+
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("failed to get the script: %v", err)
+	}
+
+	if _, err := node.Mine(101, addr); err != nil {
+		t.Fatalf("failed to mine: %v", err)
+	}
+
+	wallet := &testWallet{key: key, pkScript: pkScript, coinbase: node.Chain.GetBlock(1).Transactions[0]}
+
+	return node, broadcaster.NewBroadcaster(rpcClient), wallet
+}
+
+// spend builds the tx spending the given outputs of the parent tx to the wallet, one output of the given values each
+func (w *testWallet) spend(t *testing.T, parent *wire.MsgTx, indexes []uint32, values ...int64) *wire.MsgTx {
+	tx := wire.NewMsgTx(basics.TxVersion)
+	parentHash := parent.TxHash()
+
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for _, idx := range indexes {
+		outPoint := wire.NewOutPoint(&parentHash, idx)
+
+		tx.AddTxIn(wire.NewTxIn(outPoint, nil, nil))
+		prevOutFetcher.AddPrevOut(*outPoint, parent.TxOut[idx])
+	}
+
+	for _, value := range values {
+		tx.AddTxOut(wire.NewTxOut(value, w.pkScript))
+	}
+
+	sigHashes := txscript.NewTxSigHashes(tx, prevOutFetcher)
+
+	for i, idx := range indexes {
+		witness, err := txscript.TaprootWitnessSignature(tx, sigHashes, i, parent.TxOut[idx].Value, w.pkScript, txscript.SigHashDefault, w.key)
+		if err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+
+		tx.TxIn[i].Witness = witness
+	}
+
+	return tx
+}
+
+// newTestCommit builds the commit tx splitting the coinbase into two outputs of 1 BTC each
+func (w *testWallet) newTestCommit(t *testing.T) *wire.MsgTx {
+	return w.spend(t, w.coinbase, []uint32{0}, btcutil.SatoshiPerBitcoin, btcutil.SatoshiPerBitcoin)
+}
+
+func assertInMempool(t *testing.T, node *testNode, txs ...*wire.MsgTx) {
+	t.Helper()
+
+	if node.Mempool.Size() != len(txs) {
+		t.Fatalf("got %d txs in the mempool; want %d", node.Mempool.Size(), len(txs))
+	}
+
+	for _, tx := range txs {
+		txHash := tx.TxHash()
+		if node.Mempool.Get(&txHash) == nil {
+			t.Fatalf("tx %s not in the mempool", txHash)
+		}
+	}
+}
+
+func TestBroadcastMode(t *testing.T) {
+	tests := []struct {
+		name       string
+		legacy     bool
+		reveals    int
+		wantMode   broadcaster.Mode
+		wantTests  int // testmempoolaccept calls
+		wantSubmit int // submitpackage calls
+		wantSends  int // sendrawtransaction calls
+	}{
+		{"child with parent", false, 1, broadcaster.MODE_PACKAGE, 1, 1, 0},
+		{"multiple reveals", false, 2, broadcaster.MODE_SEQUENTIAL, 1, 0, 3},
+		// the package test falls back to the commit tx alone, and submitpackage to the txs sent one by one
+		{"legacy child with parent", true, 1, broadcaster.MODE_SEQUENTIAL, 2, 1, 2},
+		{"legacy multiple reveals", true, 2, broadcaster.MODE_SEQUENTIAL, 2, 0, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node, b, wallet := newTestBroadcaster(t, test.legacy)
+
+			commitTx := wallet.newTestCommit(t)
+			txs := []*wire.MsgTx{commitTx}
+
+			for idx := 0; idx < test.reveals; idx++ {
+				txs = append(txs, wallet.spend(t, commitTx, []uint32{uint32(idx)}, btcutil.SatoshiPerBitcoin-1000))
+			}
+
+			mode, err := b.Broadcast(txs)
+			if err != nil || mode != test.wantMode {
+				t.Fatalf("got %s, %v; want %s", mode, err, test.wantMode)
+			}
+
+			if tests, submits, sends := node.called("testmempoolaccept"), node.called("submitpackage"), node.called("sendrawtransaction"); tests != test.wantTests || submits != test.wantSubmit || sends != test.wantSends {
+				t.Fatalf("got %d tests, %d package submits, %d sends; want %d, %d, %d", tests, submits, sends, test.wantTests, test.wantSubmit, test.wantSends)
+			}
+
+			assertInMempool(t, node, txs...)
+
+			// broadcasting again succeeds as the txs are already known
+			if _, err := b.Broadcast(txs); err != nil {
+				t.Fatalf("again: got %v; want nil", err)
+			}
+
+			assertInMempool(t, node, txs...)
+		})
+	}
+}
+
+func TestBroadcastRejected(t *testing.T) {
+	node, b, wallet := newTestBroadcaster(t, false)
+
+	commitTx := wallet.newTestCommit(t)
+
+	// the first reveal pays no fee, while the second one spends more than the commit output
+	reveals := []*wire.MsgTx{
+		wallet.spend(t, commitTx, []uint32{0}, btcutil.SatoshiPerBitcoin),
+		wallet.spend(t, commitTx, []uint32{1}, btcutil.SatoshiPerBitcoin+1),
+	}
+
+	for _, txs := range [][]*wire.MsgTx{{commitTx, reveals[0]}, {commitTx, reveals[0], reveals[1]}} {
+		_, err := b.Broadcast(txs)
+
+		var rejectErr *broadcaster.RejectError
+		if !errors.As(err, &rejectErr) {
+			t.Fatalf("got %v; want *RejectError", err)
+		}
+
+		// the commit tx is accepted, thus only the reveals are reported
+		if len(rejectErr.Rejections) != len(txs)-1 {
+			t.Fatalf("got %d rejections; want %d", len(rejectErr.Rejections), len(txs)-1)
+		}
+
+		wantReasons := []string{"min relay fee not met", "bad-txns-in-belowout"}
+
+		for idx, rejection := range rejectErr.Rejections {
+			if rejection.TxID != reveals[idx].TxHash().String() || !strings.Contains(rejection.Reason, wantReasons[idx]) {
+				t.Fatalf("rejection %d: got %+v; want %s rejected for %s", idx, rejection, reveals[idx].TxHash(), wantReasons[idx])
+			}
+		}
+	}
+
+	// nothing is sent if any tx is rejected
+	if submits, sends := node.called("submitpackage"), node.called("sendrawtransaction"); submits != 0 || sends != 0 {
+		t.Fatalf("got %d package submits and %d sends; want none", submits, sends)
+	}
+
+	assertInMempool(t, node)
+}
+
+func TestBroadcastRejectedLegacy(t *testing.T) {
+	node, b, wallet := newTestBroadcaster(t, true)
+
+	// the commit tx spending the immature coinbase is rejected by the individual test
+	commitTx := wallet.spend(t, node.Chain.GetBlock(101).Transactions[0], []uint32{0}, btcutil.SatoshiPerBitcoin)
+	revealTx := wallet.spend(t, commitTx, []uint32{0}, btcutil.SatoshiPerBitcoin-1000)
+
+	_, err := b.Broadcast([]*wire.MsgTx{commitTx, revealTx})
+
+	var rejectErr *broadcaster.RejectError
+	if !errors.As(err, &rejectErr) || len(rejectErr.Rejections) != 1 {
+		t.Fatalf("got %v; want the commit tx rejected", err)
+	}
+
+	if rejection := rejectErr.Rejections[0]; rejection.TxID != commitTx.TxHash().String() || !strings.Contains(rejection.Reason, "premature-spend-of-coinbase") {
+		t.Fatalf("got %+v; want %s rejected for the immature coinbase", rejection, commitTx.TxHash())
+	}
+
+	if submits, sends := node.called("submitpackage"), node.called("sendrawtransaction"); submits != 0 || sends != 0 {
+		t.Fatalf("got %d package submits and %d sends; want none", submits, sends)
+	}
+
+	assertInMempool(t, node)
+}
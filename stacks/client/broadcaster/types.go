@@ -0,0 +1,32 @@
+package broadcaster
+
+// TestMempoolAcceptResult defines the result of testmempoolaccept for each tx
+type TestMempoolAcceptResult struct {
+	TxID         string                 `json:"txid"`
+	WTxID        string                 `json:"wtxid"`
+	PackageError string                 `json:"package-error"`
+	Allowed      *bool                  `json:"allowed"`
+	VSize        int64                  `json:"vsize"`
+	Fees         *TestMempoolAcceptFees `json:"fees"`
+	RejectReason string                 `json:"reject-reason"`
+}
+
+// TestMempoolAcceptFees defines the fees of the testmempoolaccept result, in BTC
+type TestMempoolAcceptFees struct {
+	Base float64 `json:"base"`
+}
+
+// SubmitPackageResult defines the result of submitpackage
+type SubmitPackageResult struct {
+	PackageMsg           string                           `json:"package_msg"`
+	TxResults            map[string]SubmitPackageTxResult `json:"tx-results"`
+	ReplacedTransactions []string                         `json:"replaced-transactions"`
+}
+
+// SubmitPackageTxResult defines the result of submitpackage for each tx, keyed by the wtxid
+type SubmitPackageTxResult struct {
+	TxID       string `json:"txid"`
+	OtherWTxID string `json:"other-wtxid"`
+	VSize      int64  `json:"vsize"`
+	Error      string `json:"error"`
+}
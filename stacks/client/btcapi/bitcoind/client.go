@@ -0,0 +1,127 @@
+package bitcoind
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/rpcclient"
+
+	"btc-sbt/stacks/basics"
+	"btc-sbt/stacks/client/btcapi"
+)
+
+var _ btcapi.UTXOProvider = (*Client)(nil)
+
+// max confirmations for listunspent
+const MAX_CONFIRMATIONS = 9999999
+
+// Client defines the bitcoind client which provides the utxos from the node
+type Client struct {
+	RPCClient *rpcclient.Client
+
+	Scan bool // scantxoutset instead of listunspent of the node wallet
+}
+
+// NewClient creates a bitcoind client instance
+func NewClient(rpcClient *rpcclient.Client, scan bool) *Client {
+	return &Client{
+		RPCClient: rpcClient,
+		Scan:      scan,
+	}
+}
+
+// GetUTXOs gets the utxos of the given address.
+// listunspent requires the address to be watched by the node wallet and includes the unconfirmed utxos,
+// while scantxoutset requires no wallet but only includes the confirmed utxos
+func (c *Client) GetUTXOs(address btcutil.Address) ([]*basics.UTXO, error) {
+	if c.Scan {
+		return c.scanTxOutSet(address)
+	}
+
+	return c.listUnspent(address)
+}
+
+// listUnspent gets the utxos of the given address by listunspent
+func (c *Client) listUnspent(address btcutil.Address) ([]*basics.UTXO, error) {
+	results, err := c.RPCClient.ListUnspentMinMaxAddresses(0, MAX_CONFIRMATIONS, []btcutil.Address{address})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unspent, err: %v", err)
+	}
+
+	utxos := make([]*basics.UTXO, 0, len(results))
+
+	for _, result := range results {
+		if !result.Spendable {
+			continue
+		}
+
+		utxo, err := newUTXO(result.TxID, result.Vout, result.Amount, result.ScriptPubKey)
+		if err != nil {
+			return nil, err
+		}
+
+		utxo.Unconfirmed = result.Confirmations == 0
+
+		utxos = append(utxos, utxo)
+	}
+
+	return utxos, nil
+}
+
+// scanTxOutSet gets the utxos of the given address by scantxoutset
+func (c *Client) scanTxOutSet(address btcutil.Address) ([]*basics.UTXO, error) {
+	descriptors, err := json.Marshal([]string{fmt.Sprintf("addr(%s)", address.EncodeAddress())})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.RPCClient.RawRequest("scantxoutset", []json.RawMessage{json.RawMessage(`"start"`), descriptors})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan utxo set, err: %v", err)
+	}
+
+	var result ScanTxOutSetResult
+	if err := json.Unmarshal(resp, &result); err != nil {
+		return nil, fmt.Errorf("failed to scan utxo set: invalid response, err: %v", err)
+	}
+
+	if !result.Success {
+		return nil, fmt.Errorf("failed to scan utxo set: scan aborted")
+	}
+
+	utxos := make([]*basics.UTXO, 0, len(result.Unspents))
+
+	for _, unspent := range result.Unspents {
+		utxo, err := newUTXO(unspent.TxID, unspent.Vout, unspent.Amount, unspent.ScriptPubKey)
+		if err != nil {
+			return nil, err
+		}
+
+		utxos = append(utxos, utxo)
+	}
+
+	return utxos, nil
+}
+
+// newUTXO creates the utxo from the rpc result, of which the amount is in BTC
+func newUTXO(txID string, vout uint32, amount float64, scriptPubKey string) (*basics.UTXO, error) {
+	hash, err := chainhash.NewHashFromStr(txID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response: invalid tx hash")
+	}
+
+	value, err := btcutil.NewAmount(amount)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response: invalid amount")
+	}
+
+	pkScript, err := hex.DecodeString(scriptPubKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid response: invalid pk script")
+	}
+
+	return basics.NewUTXO(hash, vout, int64(value), pkScript), nil
+}
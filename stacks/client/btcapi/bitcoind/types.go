@@ -0,0 +1,20 @@
+package bitcoind
+
+// ScanTxOutSetResult defines the result of scantxoutset
+type ScanTxOutSetResult struct {
+	Success     bool                  `json:"success"`
+	Height      int64                 `json:"height"`
+	BestBlock   string                `json:"bestblock"`
+	Unspents    []ScanTxOutSetUnspent `json:"unspents"`
+	TotalAmount float64               `json:"total_amount"`
+}
+
+// ScanTxOutSetUnspent defines the unspent output of the scantxoutset result
+type ScanTxOutSetUnspent struct {
+	TxID         string  `json:"txid"`
+	Vout         uint32  `json:"vout"`
+	ScriptPubKey string  `json:"scriptPubKey"`
+	Descriptor   string  `json:"desc"`
+	Amount       float64 `json:"amount"`
+	Height       int64   `json:"height"`
+}
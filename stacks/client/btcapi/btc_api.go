@@ -4,6 +4,8 @@ import (
 	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/stacks/basics"
 )
 
 type UnspentOutput struct {
@@ -16,3 +18,8 @@ type BTCAPIClient interface {
 	BroadcastTx(tx *wire.MsgTx) (*chainhash.Hash, error)
 	ListUnspent(address btcutil.Address) ([]*UnspentOutput, error)
 }
+
+// UTXOProvider defines the provider of the spendable utxos of the address
+type UTXOProvider interface {
+	GetUTXOs(address btcutil.Address) ([]*basics.UTXO, error)
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 
+	"btc-sbt/stacks/basics"
 	"btc-sbt/stacks/client/btcapi"
 )
 
@@ -29,17 +30,12 @@ type UTXO struct {
 type UTXOs []UTXO
 
 func (c *Client) ListUnspent(address btcutil.Address) ([]*btcapi.UnspentOutput, error) {
-	statusCode, resp, err := c.BaseClient.Request(http.MethodGet, fmt.Sprintf("%s/address/%s/utxo", c.MempoolAPI, address.EncodeAddress()), c.BaseClient.GetBaseOptions())
+	utxos, err := c.getUTXOs(address)
 	if err != nil {
 		return nil, err
 	}
 
-	if statusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to query utxos, status code: %d, response: %s", statusCode, string(resp))
-	}
-
-	var utxos UTXOs
-	err = json.Unmarshal(resp, &utxos)
+	pkScript, err := txscript.PayToAddrScript(address)
 	if err != nil {
 		return nil, err
 	}
@@ -51,11 +47,6 @@ func (c *Client) ListUnspent(address btcutil.Address) ([]*btcapi.UnspentOutput,
 			return nil, err
 		}
 
-		pkScript, err := txscript.PayToAddrScript(address)
-		if err != nil {
-			return nil, err
-		}
-
 		unspentOutputs = append(unspentOutputs, &btcapi.UnspentOutput{
 			Outpoint: wire.NewOutPoint(txHash, uint32(utxo.Vout)),
 			Output:   wire.NewTxOut(utxo.Value, pkScript),
@@ -64,3 +55,51 @@ func (c *Client) ListUnspent(address btcutil.Address) ([]*btcapi.UnspentOutput,
 
 	return unspentOutputs, nil
 }
+
+// GetUTXOs gets the utxos of the given address, including the unconfirmed ones
+func (c *Client) GetUTXOs(address btcutil.Address) ([]*basics.UTXO, error) {
+	utxos, err := c.getUTXOs(address)
+	if err != nil {
+		return nil, err
+	}
+
+	pkScript, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*basics.UTXO, 0, len(utxos))
+	for _, utxo := range utxos {
+		txHash, err := chainhash.NewHashFromStr(utxo.Txid)
+		if err != nil {
+			return nil, err
+		}
+
+		u := basics.NewUTXO(txHash, uint32(utxo.Vout), utxo.Value, pkScript)
+		u.Unconfirmed = !utxo.Status.Confirmed
+
+		result = append(result, u)
+	}
+
+	return result, nil
+}
+
+// getUTXOs queries the utxos of the given address
+func (c *Client) getUTXOs(address btcutil.Address) (UTXOs, error) {
+	statusCode, resp, err := c.BaseClient.Request(http.MethodGet, fmt.Sprintf("%s/address/%s/utxo", c.MempoolAPI, address.EncodeAddress()), c.BaseClient.GetBaseOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to query utxos, status code: %d, response: %s", statusCode, string(resp))
+	}
+
+	var utxos UTXOs
+	err = json.Unmarshal(resp, &utxos)
+	if err != nil {
+		return nil, err
+	}
+
+	return utxos, nil
+}
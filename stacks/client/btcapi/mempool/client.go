@@ -1,16 +1,12 @@
 package mempool
 
 import (
-	"log"
-
-	"github.com/btcsuite/btcd/chaincfg"
-	"github.com/btcsuite/btcd/wire"
-
 	"btc-sbt/stacks/client/base"
 	"btc-sbt/stacks/client/btcapi"
 )
 
 var _ btcapi.BTCAPIClient = (*Client)(nil)
+var _ btcapi.UTXOProvider = (*Client)(nil)
 
 // Client defines the mempool client
 type Client struct {
@@ -19,20 +15,8 @@ type Client struct {
 	MempoolAPI string
 }
 
-// NewClient creates a mempool client instance
-func NewClient(netParams *chaincfg.Params, baseClient *base.Client) *Client {
-	mempoolAPI := ""
-
-	if netParams.Net == wire.MainNet {
-		mempoolAPI = "https://mempool.space/api"
-	} else if netParams.Net == wire.TestNet3 {
-		mempoolAPI = "https://mempool.space/testnet/api"
-	} else if netParams.Net == chaincfg.SigNetParams.Net {
-		mempoolAPI = "https://mempool.space/signet/api"
-	} else {
-		log.Fatal("mempool don't support other netParams")
-	}
-
+// NewClient creates a mempool client instance with the given esplora compatible api
+func NewClient(mempoolAPI string, baseClient *base.Client) *Client {
 	return &Client{
 		BaseClient: baseClient,
 		MempoolAPI: mempoolAPI,
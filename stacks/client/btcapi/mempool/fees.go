@@ -8,11 +8,11 @@ import (
 
 // Fees defines the fees struct
 type Fees struct {
-	FastestFee  int64 `json:"fastestFee"`
-	HalfHourFee int64 `json:"halfHourFee"`
-	HourFee     int64 `json:"hourFee"`
-	EconomyFee  int64 `json:"economyFee"`
-	MinimumFee  int64 `json:"minimumFee"`
+	FastestFee  float64 `json:"fastestFee"`
+	HalfHourFee float64 `json:"halfHourFee"`
+	HourFee     float64 `json:"hourFee"`
+	EconomyFee  float64 `json:"economyFee"`
+	MinimumFee  float64 `json:"minimumFee"`
 }
 
 // GetFees gets the recommended fees
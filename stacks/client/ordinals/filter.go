@@ -0,0 +1,50 @@
+package ordinals
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+
+	"btc-sbt/stacks/basics"
+	"btc-sbt/stacks/client/btcapi"
+)
+
+var _ btcapi.UTXOProvider = (*UTXOFilter)(nil)
+
+// UTXOFilter wraps the utxo provider to exclude the utxos carrying inscriptions, so that they are never spent as the fee
+type UTXOFilter struct {
+	Provider btcapi.UTXOProvider
+	Client   *Client
+}
+
+// NewUTXOFilter creates a UTXOFilter instance
+func NewUTXOFilter(provider btcapi.UTXOProvider, client *Client) *UTXOFilter {
+	return &UTXOFilter{
+		Provider: provider,
+		Client:   client,
+	}
+}
+
+// GetUTXOs gets the utxos of the given address from the provider, excluding the ones carrying inscriptions.
+// Fails if any utxo can not be checked
+func (f *UTXOFilter) GetUTXOs(address btcutil.Address) ([]*basics.UTXO, error) {
+	utxos, err := f.Provider.GetUTXOs(address)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]*basics.UTXO, 0, len(utxos))
+
+	for _, utxo := range utxos {
+		inscriptions, err := f.Client.GetInscriptionsByOutput(utxo.GetOutPoint())
+		if err != nil {
+			return nil, fmt.Errorf("failed to check inscriptions of utxo %s: %v", utxo.GetOutPoint(), err)
+		}
+
+		if len(inscriptions) == 0 {
+			filtered = append(filtered, utxo)
+		}
+	}
+
+	return filtered, nil
+}
@@ -0,0 +1,120 @@
+package ordinals
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+
+	"btc-sbt/stacks/basics"
+	"btc-sbt/stacks/client/base"
+)
+
+// testProvider provides the fixed utxos or error
+type testProvider struct {
+	utxos []*basics.UTXO
+	err   error
+}
+
+func (p *testProvider) GetUTXOs(address btcutil.Address) ([]*basics.UTXO, error) {
+	return p.utxos, p.err
+}
+
+// newTestOrdServer serves the inscriptions by output, responding with the given status code for the unlisted outputs
+func newTestOrdServer(t *testing.T, inscribed map[string]string, statusCode int) *Client {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		output := strings.TrimPrefix(r.URL.Path, "/output/")
+
+		if id, ok := inscribed[output]; ok {
+			w.Write([]byte(`{"inscriptions":["` + id + `"]}`))
+			return
+		}
+
+		w.WriteHeader(statusCode)
+
+		if statusCode == http.StatusOK {
+			w.Write([]byte(`{"inscriptions":[]}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return NewClient(server.URL, base.NewClient(1, 0))
+}
+
+func TestUTXOFilter(t *testing.T) {
+	txHash := chainhash.HashH([]byte("tx"))
+	utxos := []*basics.UTXO{
+		basics.NewUTXO(&txHash, 0, 1000, nil),
+		basics.NewUTXO(&txHash, 1, 2000, nil),
+		basics.NewUTXO(&txHash, 2, 3000, nil),
+	}
+
+	inscribed := map[string]string{utxos[1].GetOutPoint().String(): txHash.String() + "i0"}
+
+	tests := []struct {
+		statusCode int   // status code of the unlisted outputs
+		want       []int // indexes of the utxos kept, nil if error expected
+	}{
+		{http.StatusOK, []int{0, 2}},
+		{http.StatusNotFound, []int{0, 2}},
+		{http.StatusInternalServerError, nil},
+		{http.StatusTooManyRequests, nil},
+	}
+
+	for _, test := range tests {
+		filter := NewUTXOFilter(&testProvider{utxos: utxos}, newTestOrdServer(t, inscribed, test.statusCode))
+
+		got, err := filter.GetUTXOs(nil)
+		if test.want == nil {
+			if err == nil {
+				t.Errorf("status %d: got %d utxos; want error", test.statusCode, len(got))
+			}
+
+			continue
+		}
+
+		if err != nil || len(got) != len(test.want) {
+			t.Errorf("status %d: got %d utxos, %v; want %d", test.statusCode, len(got), err, len(test.want))
+			continue
+		}
+
+		for idx, utxoIdx := range test.want {
+			if got[idx] != utxos[utxoIdx] {
+				t.Errorf("status %d: got utxo %s; want %s", test.statusCode, got[idx].GetOutPoint(), utxos[utxoIdx].GetOutPoint())
+			}
+		}
+	}
+}
+
+func TestUTXOFilterFailClosed(t *testing.T) {
+	txHash := chainhash.HashH([]byte("tx"))
+	utxos := []*basics.UTXO{basics.NewUTXO(&txHash, 0, 1000, nil)}
+
+	// unreachable ord server
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	filter := NewUTXOFilter(&testProvider{utxos: utxos}, NewClient(server.URL, base.NewClient(1, 0)))
+
+	if got, err := filter.GetUTXOs(nil); err == nil {
+		t.Fatalf("unreachable: got %d utxos; want error", len(got))
+	}
+
+	// malformed response
+	filter.Client = newTestOrdServer(t, map[string]string{utxos[0].GetOutPoint().String(): `"]}`}, http.StatusOK)
+
+	if got, err := filter.GetUTXOs(nil); err == nil {
+		t.Fatalf("malformed: got %d utxos; want error", len(got))
+	}
+
+	// provider error
+	filter.Provider = &testProvider{err: errors.New("provider down")}
+
+	if _, err := filter.GetUTXOs(nil); err == nil || !strings.Contains(err.Error(), "provider down") {
+		t.Fatalf("provider: got %v; want the provider error", err)
+	}
+}
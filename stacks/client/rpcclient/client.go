@@ -3,6 +3,7 @@ package rpcclient
 import (
 	"fmt"
 
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/rpcclient"
 	"github.com/btcsuite/btcd/wire"
 )
@@ -61,3 +62,13 @@ func (c *Client) GetBlock(height int64) (*wire.MsgBlock, error) {
 
 	return c.inner.GetBlock(hash)
 }
+
+// GetRawTransaction gets the tx by the given hash
+func (c *Client) GetRawTransaction(hash *chainhash.Hash) (*wire.MsgTx, error) {
+	tx, err := c.inner.GetRawTransaction(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	return tx.MsgTx(), nil
+}
@@ -0,0 +1,325 @@
+package sbtnode
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/stacks/basics"
+	"btc-sbt/stacks/client/base"
+	"btc-sbt/types"
+)
+
+// Client defines the client of the btc-sbt node api
+type Client struct {
+	BaseClient *base.Client
+
+	API string
+}
+
+// NewClient creates a btc-sbt node client instance
+func NewClient(api string, baseClient *base.Client) *Client {
+	return &Client{
+		BaseClient: baseClient,
+		API:        strings.TrimSuffix(api, "/"),
+	}
+}
+
+// Decode decodes the protocol operations from the given tx and simulates them against the current state of the node
+func (c *Client) Decode(tx *wire.MsgTx) (*types.DecodeResult, error) {
+	rawTx, err := basics.EncodeTx(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(map[string]string{"data": rawTx})
+	if err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/api/decode", c.API)
+
+	opts := c.BaseClient.GetBaseOptions()
+	opts.Body = body
+	opts.IsJSON = true
+
+	statusCode, resp, err := c.BaseClient.Request(http.MethodPost, url, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the tx, err: %v", err)
+	}
+
+	var r Response
+	if err := json.Unmarshal(resp, &r); err != nil {
+		return nil, fmt.Errorf("failed to decode the tx: invalid response, status code: %d, err: %v", statusCode, err)
+	}
+
+	if statusCode != http.StatusOK || !r.Status {
+		return nil, fmt.Errorf("failed to decode the tx, status code: %d, error: %s", statusCode, r.Error)
+	}
+
+	var result types.DecodeResult
+	if err := json.Unmarshal(r.Result, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode the tx: invalid response, err: %v", err)
+	}
+
+	return &result, nil
+}
+
+// GetTx gets the protocol operations and results of the given reveal tx, or of all the reveal txs spending the given commit tx, indexed by the node.
+// Returns nil if no protocol operations indexed for the tx
+func (c *Client) GetTx(txHash *chainhash.Hash) ([]*types.TxRecord, error) {
+	url := fmt.Sprintf("%s/api/tx/%s", c.API, txHash)
+
+	// not found is expected until the tx is indexed
+	opts := c.BaseClient.GetBaseOptions()
+	opts.Attempts = 1
+
+	statusCode, resp, err := c.BaseClient.Request(http.MethodGet, url, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the tx, err: %v", err)
+	}
+
+	switch statusCode {
+	case http.StatusOK:
+		var r Response
+		if err := json.Unmarshal(resp, &r); err != nil {
+			return nil, fmt.Errorf("failed to query the tx: invalid response, err: %v", err)
+		}
+
+		var records []*types.TxRecord
+		if err := json.Unmarshal(r.Result, &records); err != nil {
+			return nil, fmt.Errorf("failed to query the tx: invalid response, err: %v", err)
+		}
+
+		return records, nil
+
+	case http.StatusNotFound:
+		return nil, nil
+
+	default:
+		return nil, fmt.Errorf("failed to query the tx, status code: %d, response: %s", statusCode, string(resp))
+	}
+}
+
+// GetLastBlockHeight gets the height of the last block indexed by the node
+func (c *Client) GetLastBlockHeight() (int64, error) {
+	url := fmt.Sprintf("%s/api/status", c.API)
+
+	statusCode, resp, err := c.BaseClient.Request(http.MethodGet, url, c.BaseClient.GetBaseOptions())
+	if err != nil {
+		return 0, fmt.Errorf("failed to query the status, err: %v", err)
+	}
+
+	if statusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to query the status, status code: %d, response: %s", statusCode, string(resp))
+	}
+
+	var r Response
+	if err := json.Unmarshal(resp, &r); err != nil {
+		return 0, fmt.Errorf("failed to query the status: invalid response, err: %v", err)
+	}
+
+	var height int64
+	if err := json.Unmarshal(r.Result, &height); err != nil {
+		return 0, fmt.Errorf("failed to query the status: invalid response, err: %v", err)
+	}
+
+	return height, nil
+}
+
+// QuerySBTs queries the SBTs page by the given query from the node.
+// Returns the SBTs along with the cursor for the next page
+func (c *Client) QuerySBTs(query *types.SBTsQuery) ([]*types.SBTs, string, error) {
+	params := getPaginationParams(&query.Pagination)
+
+	setParam(params, "sort", string(query.SortBy))
+	setParam(params, "issuer", query.Issuer)
+	setParam(params, "prefix", query.SymbolPrefix)
+
+	if query.RequireAuthority != nil {
+		params.Set("auth", strconv.FormatBool(*query.RequireAuthority))
+	}
+
+	if query.MintOpen != nil {
+		if *query.MintOpen {
+			params.Set("mint", "open")
+		} else {
+			params.Set("mint", "closed")
+		}
+	}
+
+	setHeightParam(params, query.BlockHeight)
+
+	collections := make([]*types.SBTs, 0)
+
+	next, err := c.queryPage("/api/collections", params, &collections)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to query the SBTs, %v", err)
+	}
+
+	return collections, next, nil
+}
+
+// GetSBTsAt gets the SBTs by the given symbol at the given block height from the node, 0 for the latest.
+// Returns nil if the SBTs does not exist
+func (c *Client) GetSBTsAt(symbol string, blockHeight int64) (*types.SBTs, error) {
+	params := url.Values{}
+	setHeightParam(params, blockHeight)
+
+	var sbts types.SBTs
+
+	found, err := c.queryOne(fmt.Sprintf("/api/collections/%s", url.PathEscape(symbol)), params, &sbts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the SBTs, %v", err)
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	return &sbts, nil
+}
+
+// GetSBTAt gets the SBT token by the given symbol and token id at the given block height from the node, 0 for the latest.
+// Returns nil if the SBT does not exist
+func (c *Client) GetSBTAt(symbol string, id uint64, blockHeight int64) (*types.SBT, error) {
+	params := url.Values{}
+	params.Set("symbol", symbol)
+	params.Set("id", strconv.FormatUint(id, 10))
+	setHeightParam(params, blockHeight)
+
+	var sbt types.SBT
+
+	found, err := c.queryOne("/api/sbts", params, &sbt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query the SBT, %v", err)
+	}
+
+	if !found {
+		return nil, nil
+	}
+
+	return &sbt, nil
+}
+
+// QueryOwnedSBTs queries the page of the SBT tokens owned by the given owner from the node.
+// Returns the SBT tokens along with the cursor for the next page
+func (c *Client) QueryOwnedSBTs(query *types.OwnedSBTsQuery) ([]*types.CompactSBT, string, error) {
+	params := getPaginationParams(&query.Pagination)
+
+	setParam(params, "prefix", query.SymbolPrefix)
+	setHeightParam(params, query.BlockHeight)
+
+	sbts := make([]*types.CompactSBT, 0)
+
+	next, err := c.queryPage(fmt.Sprintf("/api/sbts/address/%s", url.PathEscape(query.Owner)), params, &sbts)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to query the owned SBTs, %v", err)
+	}
+
+	return sbts, next, nil
+}
+
+// queryOne requests the given api path with the params and decodes the result.
+// Returns false if not found
+func (c *Client) queryOne(path string, params url.Values, result any) (bool, error) {
+	// not found is expected
+	opts := c.BaseClient.GetBaseOptions()
+	opts.Attempts = 1
+
+	statusCode, r, err := c.get(path, params, opts)
+	if err != nil {
+		return false, err
+	}
+
+	if statusCode == http.StatusNotFound {
+		return false, nil
+	}
+
+	if err := json.Unmarshal(r.Result, result); err != nil {
+		return false, fmt.Errorf("invalid response, err: %v", err)
+	}
+
+	return true, nil
+}
+
+// queryPage requests the given paginated api path with the params and decodes the result.
+// Returns the cursor for the next page
+func (c *Client) queryPage(path string, params url.Values, result any) (string, error) {
+	statusCode, r, err := c.get(path, params, c.BaseClient.GetBaseOptions())
+	if err != nil {
+		return "", err
+	}
+
+	if statusCode == http.StatusNotFound {
+		return "", fmt.Errorf("status code: %d, error: %s", statusCode, r.Error)
+	}
+
+	if err := json.Unmarshal(r.Result, result); err != nil {
+		return "", fmt.Errorf("invalid response, err: %v", err)
+	}
+
+	return r.Next, nil
+}
+
+// get requests the given api path with the params.
+// The response is returned if succeeded or not found, otherwise the error
+func (c *Client) get(path string, params url.Values, opts *base.RequestOptions) (int, *Response, error) {
+	endpoint := c.API + path
+	if len(params) > 0 {
+		endpoint = fmt.Sprintf("%s?%s", endpoint, params.Encode())
+	}
+
+	statusCode, resp, err := c.BaseClient.Request(http.MethodGet, endpoint, opts)
+	if err != nil {
+		return statusCode, nil, fmt.Errorf("err: %v", err)
+	}
+
+	var r Response
+	if err := json.Unmarshal(resp, &r); err != nil {
+		return statusCode, nil, fmt.Errorf("invalid response, status code: %d, err: %v", statusCode, err)
+	}
+
+	if (statusCode != http.StatusOK || !r.Status) && statusCode != http.StatusNotFound {
+		return statusCode, nil, fmt.Errorf("status code: %d, error: %s", statusCode, r.Error)
+	}
+
+	return statusCode, &r, nil
+}
+
+// getPaginationParams gets the query params from the given pagination
+func getPaginationParams(pagination *types.Pagination) url.Values {
+	params := url.Values{}
+
+	setParam(params, "cursor", pagination.Cursor)
+
+	if pagination.Limit > 0 {
+		params.Set("limit", strconv.Itoa(pagination.Limit))
+	}
+
+	if pagination.Reverse {
+		params.Set("order", "desc")
+	}
+
+	return params
+}
+
+// setHeightParam sets the block height param if given
+func setHeightParam(params url.Values, blockHeight int64) {
+	if blockHeight > 0 {
+		params.Set("height", strconv.FormatInt(blockHeight, 10))
+	}
+}
+
+// setParam sets the param if not empty
+func setParam(params url.Values, key string, value string) {
+	if len(value) > 0 {
+		params.Set(key, value)
+	}
+}
@@ -0,0 +1,11 @@
+package sbtnode
+
+import "encoding/json"
+
+// Response defines the common response of the btc-sbt node api
+type Response struct {
+	Status bool            `json:"status"`
+	Result json.RawMessage `json:"result"`
+	Next   string          `json:"next"` // cursor for the next page of the paginated queries
+	Error  string          `json:"error"`
+}
@@ -4,10 +4,15 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/btcsuite/btcd/btcutil"
+
 	"btc-sbt/stacks/basics"
 	"btc-sbt/stacks/client/base"
+	"btc-sbt/stacks/client/btcapi"
 )
 
+var _ btcapi.UTXOProvider = (*Client)(nil)
+
 // Client defines the unisat client
 type Client struct {
 	BaseClient *base.Client
@@ -51,3 +56,8 @@ func (c *Client) GetBTCUtxos(address string) ([]*basics.UTXO, error) {
 
 	return utxos, nil
 }
+
+// GetUTXOs gets the non-inscription utxos of the given address
+func (c *Client) GetUTXOs(address btcutil.Address) ([]*basics.UTXO, error) {
+	return c.GetBTCUtxos(address.EncodeAddress())
+}
@@ -12,6 +12,9 @@ import (
 
 const UNISAT_WALLET_CLIENT = "UniSat Wallet"
 
+// height of the unconfirmed utxos returned by unisat
+const UNISAT_UNCONFIRMED_HEIGHT = 4194303
+
 // UTXO represents the UTXO struct for unisat api
 type UTXO struct {
 	TxId        string `json:"txid"`
@@ -45,8 +48,10 @@ func (r GetBTCUtxosResponse) GetUtxos() ([]*basics.UTXO, error) {
 				return nil, fmt.Errorf("invalid response: invalid pk script")
 			}
 
-			utxo := basics.NewUTXO(hash, utxo.Vout, utxo.Value, pkScript)
-			utxos = append(utxos, utxo)
+			u := basics.NewUTXO(hash, utxo.Vout, utxo.Value, pkScript)
+			u.Unconfirmed = utxo.Height >= UNISAT_UNCONFIRMED_HEIGHT
+
+			utxos = append(utxos, u)
 		}
 
 		return utxos, nil
@@ -26,3 +26,41 @@ func SignWitnessTransaction(key *secp256k1.PrivateKey, tx *wire.MsgTx, utxos []*
 
 	return nil
 }
+
+func SignWitnessInput(key *secp256k1.PrivateKey, tx *wire.MsgTx, utxos []*basics.UTXO, idx int, hashType txscript.SigHashType) (wire.TxWitness, error) {
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(nil)
+
+	for i, utxo := range utxos {
+		prevOutFetcher.AddPrevOut(tx.TxIn[i].PreviousOutPoint, utxo.GetOutput())
+	}
+
+	return txscript.WitnessSignature(tx, txscript.NewTxSigHashes(tx, prevOutFetcher), idx, utxos[idx].Value, utxos[idx].PkScript, hashType, key, true)
+}
+
+// SignNestedWitnessInput signs the p2sh-p2wpkh input at the given index.
+// Returns the signature script pushing the redeem script, along with the witness
+func SignNestedWitnessInput(key *secp256k1.PrivateKey, tx *wire.MsgTx, utxos []*basics.UTXO, idx int, redeemScript []byte, hashType txscript.SigHashType) ([]byte, wire.TxWitness, error) {
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(nil)
+
+	for i, utxo := range utxos {
+		prevOutFetcher.AddPrevOut(tx.TxIn[i].PreviousOutPoint, utxo.GetOutput())
+	}
+
+	sigScript, err := txscript.NewScriptBuilder().AddData(redeemScript).Script()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// the witness program in the redeem script is signed in place of the p2sh pk script
+	witness, err := txscript.WitnessSignature(tx, txscript.NewTxSigHashes(tx, prevOutFetcher), idx, utxos[idx].Value, redeemScript, hashType, key, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return sigScript, witness, nil
+}
+
+// SignLegacyInput signs the p2pkh input at the given index with the legacy sighash, returning the signature script
+func SignLegacyInput(key *secp256k1.PrivateKey, tx *wire.MsgTx, utxos []*basics.UTXO, idx int, hashType txscript.SigHashType) ([]byte, error) {
+	return txscript.SignatureScript(tx, idx, utxos[idx].PkScript, hashType, key, true)
+}
@@ -126,3 +126,20 @@ func SignTapscript(key *secp256k1.PrivateKey, tx *wire.MsgTx, utxos []*basics.UT
 
 	return signature, nil
 }
+
+// SignTaprootKeyPath signs the input at the given index through the key path of the output committing to the script tree of the given root hash,
+// i.e. with the internal key tweaked by the root hash
+func SignTaprootKeyPath(key *secp256k1.PrivateKey, tx *wire.MsgTx, utxos []*basics.UTXO, idx int, tapScriptRootHash []byte, hashType txscript.SigHashType) ([]byte, error) {
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(nil)
+
+	for i, utxo := range utxos {
+		prevOutFetcher.AddPrevOut(tx.TxIn[i].PreviousOutPoint, utxo.GetOutput())
+	}
+
+	signature, err := txscript.RawTxInTaprootSignature(tx, txscript.NewTxSigHashes(tx, prevOutFetcher), idx, utxos[idx].Value, utxos[idx].PkScript, tapScriptRootHash, hashType, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return signature, nil
+}
@@ -0,0 +1,70 @@
+package inscriber
+
+import (
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/stacks/basics"
+)
+
+const (
+	// Maximum number of the reveal txs funded by one commit tx,
+	// bounded by the default mempool descendant limit of 25 txs including the commit tx
+	MAX_BATCH_REVEALS = 24
+)
+
+// InscribeResult defines the broadcast commit/reveal pair
+type InscribeResult struct {
+	CommitTxHash   chainhash.Hash // hash of the commit tx shared by the batch
+	CommitOutIndex uint32         // index of the commit tx output spent by the reveal tx
+	RevealTxHash   chainhash.Hash // hash of the reveal tx
+	RevealFee      int64          // fee paid by the reveal tx
+	RevealVSize    int64          // virtual size of the signed reveal tx
+}
+
+// SignReveals signs the reveal txs against the given signed commit tx, returning a result per commit/reveal pair in order
+func SignReveals(commitTx *wire.MsgTx, reveals []*Reveal) ([]*InscribeResult, error) {
+	commitTxHash := commitTx.TxHash()
+
+	results := make([]*InscribeResult, 0, len(reveals))
+
+	for _, reveal := range reveals {
+		if err := reveal.MatchCommitTx(commitTx); err != nil {
+			return nil, err
+		}
+
+		if err := reveal.Sign(commitTxHash); err != nil {
+			return nil, err
+		}
+
+		results = append(results, &InscribeResult{
+			CommitTxHash:   commitTxHash,
+			CommitOutIndex: reveal.CommitOutIndex,
+			RevealTxHash:   reveal.Tx.TxHash(),
+			RevealFee:      reveal.CommitTxOut.Value - basics.GetTotalOutputValue(reveal.Tx.TxOut),
+			RevealVSize:    basics.GetTxVirtualSize(reveal.Tx, nil, true),
+		})
+	}
+
+	return results, nil
+}
+
+// BroadcastBatch signs the reveal txs against the given signed commit tx and broadcasts the commit tx followed by the reveal txs in order.
+// The txs are checked together by the node before anything is sent, and submitted as a package if possible
+func (i *Inscriber) BroadcastBatch(commitTx *wire.MsgTx, reveals []*Reveal) ([]*InscribeResult, error) {
+	results, err := SignReveals(commitTx, reveals)
+	if err != nil {
+		return nil, err
+	}
+
+	txs := []*wire.MsgTx{commitTx}
+	for _, reveal := range reveals {
+		txs = append(txs, reveal.Tx)
+	}
+
+	if _, err := i.broadcaster.Broadcast(txs); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
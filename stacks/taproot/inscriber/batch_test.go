@@ -0,0 +1,121 @@
+package inscriber
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/stacks/basics"
+)
+
+func TestBuildBatch(t *testing.T) {
+	netParams := &chaincfg.RegressionNetParams
+	feeRate := float64(3)
+
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate the key: %v", err)
+	}
+
+	addr, err := basics.GetAddress(key, basics.Taproot, netParams)
+	if err != nil {
+		t.Fatalf("failed to get the address: %v", err)
+	}
+
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("failed to get the script: %v", err)
+	}
+
+	fundingTxHash := chainhash.HashH([]byte("funding"))
+	utxos := []*basics.UTXO{basics.NewUTXO(&fundingTxHash, 0, 1000000, pkScript)}
+
+	// envelopes of different sizes, the last one paying an output besides the null output
+	envelopes := [][]byte{newTestEnvelope(t, "a"), newTestEnvelope(t, strings.Repeat("b", 200)), newTestEnvelope(t, strings.Repeat("c", 80))}
+	revealTxOuts := [][]*wire.TxOut{
+		{wire.NewTxOut(0, basics.PADDED_NULL_OUTPUT_SCRIPT)},
+		{wire.NewTxOut(0, basics.PADDED_NULL_OUTPUT_SCRIPT)},
+		{wire.NewTxOut(0, basics.PADDED_NULL_OUTPUT_SCRIPT), wire.NewTxOut(1000, pkScript)},
+	}
+
+	i := NewInscriber(nil, netParams)
+
+	inscription, err := i.BuildBatch(addr, utxos, envelopes, revealTxOuts, feeRate)
+	if err != nil {
+		t.Fatalf("failed to build the batch: %v", err)
+	}
+
+	if len(inscription.Reveals) != len(envelopes) {
+		t.Fatalf("got %d reveals; want %d", len(inscription.Reveals), len(envelopes))
+	}
+
+	// the commit outputs precede the change, each funding the reveal of the same index
+	for idx, reveal := range inscription.Reveals {
+		if reveal.CommitOutIndex != uint32(idx) {
+			t.Fatalf("reveal %d: got commit output %d; want %d", idx, reveal.CommitOutIndex, idx)
+		}
+
+		out := inscription.CommitTx.TxOut[idx]
+		if out.Value != reveal.CommitTxOut.Value || !bytes.Equal(out.PkScript, reveal.CommitTxOut.PkScript) {
+			t.Fatalf("reveal %d: got commit output %v; want %v", idx, out, reveal.CommitTxOut)
+		}
+	}
+
+	if err := i.SignCommitTx(key, addr, inscription); err != nil {
+		t.Fatalf("failed to sign the commit tx: %v", err)
+	}
+
+	verifyTestInputs(t, inscription.CommitTx, []*wire.TxOut{utxos[0].GetOutput()})
+
+	results, err := SignReveals(inscription.CommitTx, inscription.Reveals)
+	if err != nil {
+		t.Fatalf("failed to sign the reveal txs: %v", err)
+	}
+
+	commitTxHash := inscription.CommitTx.TxHash()
+
+	for idx, reveal := range inscription.Reveals {
+		revealTx := reveal.Tx
+
+		if outPoint := revealTx.TxIn[0].PreviousOutPoint; outPoint.Hash != commitTxHash || outPoint.Index != uint32(idx) {
+			t.Fatalf("reveal %d: got input %s; want %s:%d", idx, outPoint, commitTxHash, idx)
+		}
+
+		verifyTestInputs(t, revealTx, []*wire.TxOut{inscription.CommitTx.TxOut[idx]})
+
+		// each reveal pays the fee rate for its own size, as signed
+		vsize := basics.GetTxVirtualSize(revealTx, nil, true)
+		fee := inscription.CommitTx.TxOut[idx].Value - basics.GetTotalOutputValue(revealTx.TxOut)
+
+		if fee != vsize*int64(feeRate) || results[idx].RevealFee != fee || results[idx].RevealVSize != vsize {
+			t.Fatalf("reveal %d: got fee %d, result %+v; want %d for vsize %d", idx, fee, results[idx], vsize*int64(feeRate), vsize)
+		}
+	}
+}
+
+func TestBuildBatchInvalid(t *testing.T) {
+	envelope := newTestEnvelope(t, "a")
+	txOuts := []*wire.TxOut{wire.NewTxOut(0, basics.PADDED_NULL_OUTPUT_SCRIPT)}
+
+	tests := []struct {
+		name         string
+		envelopes    [][]byte
+		revealTxOuts [][]*wire.TxOut
+	}{
+		{"empty", nil, nil},
+		{"mismatched", [][]byte{envelope, envelope}, [][]*wire.TxOut{txOuts}},
+		{"too many", make([][]byte, MAX_BATCH_REVEALS+1), make([][]*wire.TxOut, MAX_BATCH_REVEALS+1)},
+	}
+
+	for _, test := range tests {
+		if _, err := NewInscriber(nil, &chaincfg.RegressionNetParams).BuildBatch(nil, nil, test.envelopes, test.revealTxOuts, 1); err == nil {
+			t.Fatalf("%s: got nil; want error", test.name)
+		}
+	}
+}
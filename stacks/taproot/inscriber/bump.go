@@ -0,0 +1,112 @@
+package inscriber
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+
+	"btc-sbt/stacks/basics"
+)
+
+// RebuildCommit rebuilds the commit tx at the given fee rate to replace the previous one by RBF.
+// All the utxos spent by the previous commit tx are spent again along with the funding utxos if needed.
+// The commit outputs keep the same tapscripts with the values raised for the reveal txs to pay the same fee rate,
+// and the reveal txs are restored to spend the commit outputs only, to be signed against the rebuilt commit tx
+func (i *Inscriber) RebuildCommit(commitAddress btcutil.Address, commitUtxos []*basics.UTXO, reveals []*Reveal, fundingUtxos []*basics.UTXO, feeRate float64) (*Inscription, error) {
+	changePkScript, err := txscript.PayToAddrScript(commitAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	rebuiltReveals := make([]*Reveal, 0, len(reveals))
+	commitTxOuts := make([]*wire.TxOut, 0, len(reveals))
+
+	for idx, reveal := range reveals {
+		if reveal.CommitOutIndex != uint32(idx) {
+			return nil, fmt.Errorf("reveal %d spends commit output %d, commit outputs in order expected", idx, reveal.CommitOutIndex)
+		}
+
+		revealIn := reveal.Tx.TxIn[0]
+
+		tx := wire.NewMsgTx(basics.TxVersion)
+		tx.AddTxIn(&wire.TxIn{
+			Witness:  wire.TxWitness{revealIn.Witness[0], revealIn.Witness[1], revealIn.Witness[2]},
+			Sequence: revealIn.Sequence,
+		})
+
+		for _, out := range reveal.Tx.TxOut {
+			if !bytes.Equal(out.PkScript, changePkScript) {
+				tx.AddTxOut(out)
+			}
+		}
+
+		commitTxOut := wire.NewTxOut(basics.GetFee(basics.GetTxVirtualSize(tx, nil, true), feeRate)+basics.GetTotalOutputValue(tx.TxOut), reveal.CommitTxOut.PkScript)
+		if dustLimit := basics.GetDustLimit(commitTxOut, i.netParams); commitTxOut.Value < dustLimit {
+			commitTxOut.Value = dustLimit
+		}
+
+		rebuiltReveals = append(rebuiltReveals, &Reveal{
+			Tx:             tx,
+			Key:            reveal.Key,
+			CommitTxOut:    commitTxOut,
+			CommitOutIndex: reveal.CommitOutIndex,
+		})
+
+		commitTxOuts = append(commitTxOuts, commitTxOut)
+	}
+
+	commitTx, selectedUtxos, err := basics.BuildTransaction(commitUtxos, commitTxOuts, fundingUtxos, commitAddress, feeRate, i.coinSelection, i.netParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rebuild commit tx: %v", err)
+	}
+
+	return &Inscription{
+		CommitTx:    commitTx,
+		CommitUtxos: append(append([]*basics.UTXO{}, commitUtxos...), selectedUtxos...),
+		Reveals:     rebuiltReveals,
+	}, nil
+}
+
+// BuildCPFPTx builds the child tx spending the given output of the parent tx to the funding address,
+// paying the fee for the parent and the child to reach the given fee rate as a package.
+// Returns the unsigned child tx along with the utxos spent by it
+func (i *Inscriber) BuildCPFPTx(parentTx *wire.MsgTx, parentFee int64, outIndex uint32, fundingAddress btcutil.Address, fundingUtxos []*basics.UTXO, feeRate float64) (*wire.MsgTx, []*basics.UTXO, error) {
+	parentTxHash := parentTx.TxHash()
+	parentOut := parentTx.TxOut[outIndex]
+
+	utxos := []*basics.UTXO{basics.NewUTXO(&parentTxHash, outIndex, parentOut.Value, parentOut.PkScript)}
+
+	changePkScript, err := txscript.PayToAddrScript(fundingAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx := wire.NewMsgTx(basics.TxVersion)
+	basics.AddUtxoToTx(tx, utxos[0])
+
+	// the child pays the shortfall of the parent fee
+	parentShortfall := basics.GetFee(basics.GetTxVirtualSize(parentTx, nil, true), feeRate) - parentFee
+	if parentShortfall < 0 {
+		parentShortfall = 0
+	}
+
+	selectedUtxos, err := i.coinSelection.AddPaymentUtxosToTx(tx, utxos, parentOut.Value-parentShortfall, fundingUtxos, wire.NewTxOut(0, changePkScript), feeRate, i.netParams)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build child tx: %v", err)
+	}
+
+	return tx, append(utxos, selectedUtxos...), nil
+}
+
+// SignFundingTx signs all the inputs of the given tx with the key of the funding address
+func (i *Inscriber) SignFundingTx(key *secp256k1.PrivateKey, fundingAddress btcutil.Address, tx *wire.MsgTx, utxos []*basics.UTXO) error {
+	if err := i.signCommitTx(key, fundingAddress, tx, utxos); err != nil {
+		return fmt.Errorf("failed to sign tx: %v", err)
+	}
+
+	return nil
+}
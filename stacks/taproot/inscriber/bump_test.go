@@ -0,0 +1,136 @@
+package inscriber
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/stacks/basics"
+)
+
+func newTestFundingAddress(t *testing.T) (btcutil.Address, []byte) {
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate the key: %v", err)
+	}
+
+	addr, err := basics.GetAddress(key, basics.Taproot, &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("failed to get the address: %v", err)
+	}
+
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("failed to get the script: %v", err)
+	}
+
+	return addr, pkScript
+}
+
+func TestBuildCPFPTx(t *testing.T) {
+	i := NewInscriber(nil, &chaincfg.RegressionNetParams)
+
+	addr, pkScript := newTestFundingAddress(t)
+
+	// the parent paying 1 sat/vB with the change to the funding address
+	prevTxHash := chainhash.HashH([]byte("prev"))
+
+	parentTx := wire.NewMsgTx(basics.TxVersion)
+	parentTx.AddTxIn(&wire.TxIn{PreviousOutPoint: *wire.NewOutPoint(&prevTxHash, 0), Witness: wire.TxWitness{make([]byte, 64)}, Sequence: basics.RBFSequence})
+	parentTx.AddTxOut(wire.NewTxOut(0, basics.PADDED_NULL_OUTPUT_SCRIPT))
+	parentTx.AddTxOut(wire.NewTxOut(600, pkScript))
+
+	parentVSize := basics.GetTxVirtualSize(parentTx, nil, true)
+	parentFee := parentVSize
+
+	fundingTxHash := chainhash.HashH([]byte("funding"))
+	fundingUtxos := []*basics.UTXO{basics.NewUTXO(&fundingTxHash, 0, 100000, pkScript)}
+
+	parentTxHash := parentTx.TxHash()
+
+	for _, feeRate := range []float64{5, 20, 100} {
+		tx, utxos, err := i.BuildCPFPTx(parentTx, parentFee, 1, addr, fundingUtxos, feeRate)
+		if err != nil {
+			t.Fatalf("%v sat/vB: failed to build the child: %v", feeRate, err)
+		}
+
+		if tx.TxIn[0].PreviousOutPoint != *wire.NewOutPoint(&parentTxHash, 1) {
+			t.Fatalf("%v sat/vB: the child does not spend the parent change", feeRate)
+		}
+
+		childFee := basics.UTXOs(utxos).TotalValue() - basics.GetTotalOutputValue(tx.TxOut)
+		childVSize := basics.GetTxVirtualSize(tx, utxos, false)
+
+		// the package reaches the fee rate, overpaying by the rounding of the sizes only
+		packageFee := parentFee + childFee
+		if minFee := basics.GetFee(parentVSize+childVSize, feeRate); packageFee < minFee || packageFee > minFee+int64(2*feeRate)+2 {
+			t.Errorf("%v sat/vB: got package fee %d; want %d", feeRate, packageFee, minFee)
+		}
+	}
+
+	// the parent already paying the fee rate is not paid again
+	tx, utxos, err := i.BuildCPFPTx(parentTx, basics.GetFee(parentVSize, 10), 1, addr, fundingUtxos, 5)
+	if err != nil {
+		t.Fatalf("failed to build the child: %v", err)
+	}
+
+	childFee := basics.UTXOs(utxos).TotalValue() - basics.GetTotalOutputValue(tx.TxOut)
+	if childVSize := basics.GetTxVirtualSize(tx, utxos, false); childFee < basics.GetFee(childVSize, 5) || childFee > basics.GetFee(childVSize, 5)+12 {
+		t.Errorf("got child fee %d; want its own fee at 5 sat/vB", childFee)
+	}
+}
+
+func TestRebuildCommit(t *testing.T) {
+	i := NewInscriber(nil, &chaincfg.RegressionNetParams)
+
+	addr, pkScript := newTestFundingAddress(t)
+
+	envelope, err := txscript.NewScriptBuilder().AddOp(txscript.OP_FALSE).AddOp(txscript.OP_IF).AddData([]byte("ord")).AddOp(txscript.OP_ENDIF).Script()
+	if err != nil {
+		t.Fatalf("failed to build the envelope: %v", err)
+	}
+
+	reveal, err := i.buildReveal(envelope, []*wire.TxOut{wire.NewTxOut(0, basics.PADDED_NULL_OUTPUT_SCRIPT)}, 0, 2)
+	if err != nil {
+		t.Fatalf("failed to build the reveal tx: %v", err)
+	}
+
+	commitTxHash := chainhash.HashH([]byte("commit"))
+	commitUtxos := []*basics.UTXO{basics.NewUTXO(&commitTxHash, 0, 50000, pkScript)}
+
+	inscription, err := i.RebuildCommit(addr, commitUtxos, []*Reveal{reveal}, nil, 20)
+	if err != nil {
+		t.Fatalf("failed to rebuild the commit tx: %v", err)
+	}
+
+	// the commit output is raised for the reveal tx to pay the new fee rate
+	rebuilt := inscription.Reveals[0]
+	if revealFee := rebuilt.CommitTxOut.Value - basics.GetTotalOutputValue(rebuilt.Tx.TxOut); revealFee != basics.GetFee(basics.GetTxVirtualSize(rebuilt.Tx, nil, true), 20) {
+		t.Errorf("got reveal fee %d; want the fee at 20 sat/vB", revealFee)
+	}
+
+	if rebuilt.CommitTxOut.Value <= reveal.CommitTxOut.Value {
+		t.Errorf("got commit output %d; want above %d", rebuilt.CommitTxOut.Value, reveal.CommitTxOut.Value)
+	}
+
+	// the previous inputs are spent again so that the rebuilt tx replaces the previous one
+	if inscription.CommitTx.TxIn[0].PreviousOutPoint != *commitUtxos[0].GetOutPoint() {
+		t.Errorf("the rebuilt commit tx does not spend the previous input")
+	}
+
+	commitVSize := basics.GetTxVirtualSize(inscription.CommitTx, inscription.CommitUtxos, false)
+	if commitFee := inscription.CommitFee(); commitFee < basics.GetFee(commitVSize, 20) {
+		t.Errorf("got commit fee %d; want at least %d", commitFee, basics.GetFee(commitVSize, 20))
+	}
+
+	// the reveal txs spend the commit outputs in order
+	reveal.CommitOutIndex = 1
+	if _, err := i.RebuildCommit(addr, commitUtxos, []*Reveal{reveal}, nil, 20); err == nil {
+		t.Errorf("commit output out of order: got nil; want error")
+	}
+}
@@ -1,6 +1,8 @@
 package inscriber
 
 import (
+	"bytes"
+	"encoding/hex"
 	"fmt"
 
 	"github.com/btcsuite/btcd/btcutil"
@@ -12,6 +14,7 @@ import (
 	"github.com/decred/dcrd/dcrec/secp256k1/v4"
 
 	"btc-sbt/stacks/basics"
+	"btc-sbt/stacks/client/broadcaster"
 	"btc-sbt/stacks/signer"
 	"btc-sbt/stacks/taproot"
 )
@@ -21,84 +24,160 @@ type Inscriber struct {
 	rpcClient *rpcclient.Client
 
 	netParams *chaincfg.Params
+
+	coinSelection *basics.CoinSelection
+
+	broadcaster *broadcaster.Broadcaster
 }
 
 // NewInscriber creates an Inscriber instance
 func NewInscriber(rpcClient *rpcclient.Client, netParams *chaincfg.Params) *Inscriber {
 	return &Inscriber{
-		rpcClient,
-		netParams,
+		rpcClient:   rpcClient,
+		netParams:   netParams,
+		broadcaster: broadcaster.NewBroadcaster(rpcClient),
 	}
 }
 
+// SetCoinSelection sets the coin selection by which the funding utxos are selected; largest first if not set
+func (i *Inscriber) SetCoinSelection(coinSelection *basics.CoinSelection) *Inscriber {
+	i.coinSelection = coinSelection
+
+	return i
+}
+
+// SetBroadcaster sets the broadcaster by which the txs are broadcast; one on the rpc client of the inscriber if not set
+func (i *Inscriber) SetBroadcaster(broadcaster *broadcaster.Broadcaster) *Inscriber {
+	i.broadcaster = broadcaster
+
+	return i
+}
+
 // Inscribe performs the inscribing process which consists of two phases named commit and reveal
-func (i *Inscriber) Inscribe(commitKey *secp256k1.PrivateKey, commitAddress btcutil.Address, commitUtxos []*basics.UTXO, envelope []byte, revealTxOuts []*wire.TxOut, feeRate int64) (*chainhash.Hash, *chainhash.Hash, error) {
-	commitOutWIF, commitOutAddress, err := taproot.GenerateTapscriptCommitOutAddress(envelope, i.netParams)
+func (i *Inscriber) Inscribe(commitKey *secp256k1.PrivateKey, commitAddress btcutil.Address, commitUtxos []*basics.UTXO, envelope []byte, revealTxOuts []*wire.TxOut, feeRate float64) (*chainhash.Hash, *chainhash.Hash, error) {
+	inscription, err := i.Build(commitAddress, commitUtxos, envelope, revealTxOuts, feeRate)
 	if err != nil {
 		return nil, nil, err
 	}
 
-	commitOutPkScript, err := txscript.PayToAddrScript(commitOutAddress)
-	if err != nil {
+	if err := i.SignCommitTx(commitKey, commitAddress, inscription); err != nil {
 		return nil, nil, err
 	}
 
-	script, err := taproot.BuildTapscript(commitOutWIF.PrivKey.PubKey(), envelope)
-	if err != nil {
-		return nil, nil, err
+	return i.Broadcast(inscription.CommitTx, inscription.Reveals[0])
+}
+
+// Build builds the unsigned commit tx and the reveal tx to be signed once the commit tx is signed
+func (i *Inscriber) Build(commitAddress btcutil.Address, commitUtxos []*basics.UTXO, envelope []byte, revealTxOuts []*wire.TxOut, feeRate float64) (*Inscription, error) {
+	return i.BuildBatch(commitAddress, commitUtxos, [][]byte{envelope}, [][]*wire.TxOut{revealTxOuts}, feeRate)
+}
+
+// BuildBatch builds the unsigned commit tx funding a reveal tx per envelope, along with the reveal txs to be signed once the commit tx is signed.
+// The commit tx output at index i is spent by the reveal tx of the envelope i, whose outputs are specified by revealTxOuts[i]
+func (i *Inscriber) BuildBatch(commitAddress btcutil.Address, commitUtxos []*basics.UTXO, envelopes [][]byte, revealTxOuts [][]*wire.TxOut, feeRate float64) (*Inscription, error) {
+	if len(envelopes) == 0 || len(envelopes) != len(revealTxOuts) {
+		return nil, fmt.Errorf("failed to build commit tx: %d envelopes with %d reveal outputs", len(envelopes), len(revealTxOuts))
 	}
 
-	revealTx, err := i.buildDummyRevealTx(commitOutWIF.PrivKey.PubKey(), script, revealTxOuts)
-	if err != nil {
-		return nil, nil, err
+	if len(envelopes) > MAX_BATCH_REVEALS {
+		return nil, fmt.Errorf("failed to build commit tx: %d envelopes exceed the maximum of %d per commit tx", len(envelopes), MAX_BATCH_REVEALS)
 	}
 
-	commitOutValue := basics.GetTxVirtualSize(revealTx, nil, true)*feeRate + basics.GetTotalOutputValue(revealTxOuts)
-	commitTx, err := i.buildCommitTx(commitKey, commitAddress, commitUtxos, commitOutValue, commitOutPkScript, feeRate)
+	reveals := make([]*Reveal, 0, len(envelopes))
+	commitTxOuts := make([]*wire.TxOut, 0, len(envelopes))
+
+	for idx, envelope := range envelopes {
+		reveal, err := i.buildReveal(envelope, revealTxOuts[idx], uint32(idx), feeRate)
+		if err != nil {
+			return nil, err
+		}
+
+		reveals = append(reveals, reveal)
+		commitTxOuts = append(commitTxOuts, reveal.CommitTxOut)
+	}
+
+	commitTx, utxos, err := i.buildCommitTx(commitAddress, commitUtxos, commitTxOuts, feeRate)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	commitTxHash := commitTx.TxHash()
-	commitTxOutPoint := wire.NewOutPoint(&commitTxHash, 0)
-	commitTxOut := wire.NewTxOut(commitOutValue, commitOutPkScript)
+	return &Inscription{
+		CommitTx:    commitTx,
+		CommitUtxos: utxos,
+		Reveals:     reveals,
+	}, nil
+}
 
-	if err := i.populateDummyRevealTx(commitOutWIF.PrivKey, revealTx, *commitTxOutPoint, commitTxOut); err != nil {
-		return nil, nil, err
+// SignCommitTx signs the commit tx of the given inscription with the key of the commit address
+func (i *Inscriber) SignCommitTx(commitKey *secp256k1.PrivateKey, commitAddress btcutil.Address, inscription *Inscription) error {
+	if err := i.signCommitTx(commitKey, commitAddress, inscription.CommitTx, inscription.CommitUtxos); err != nil {
+		return fmt.Errorf("failed to sign commit tx: %v", err)
 	}
 
-	_, err = i.rpcClient.SendRawTransaction(commitTx, false)
+	return nil
+}
+
+// Broadcast signs the reveal tx against the given signed commit tx and broadcasts both
+func (i *Inscriber) Broadcast(commitTx *wire.MsgTx, reveal *Reveal) (*chainhash.Hash, *chainhash.Hash, error) {
+	results, err := i.BroadcastBatch(commitTx, []*Reveal{reveal})
 	if err != nil {
 		return nil, nil, err
 	}
 
-	revealTxHash, err := i.rpcClient.SendRawTransaction(revealTx, false)
+	return &results[0].CommitTxHash, &results[0].RevealTxHash, nil
+}
+
+// buildReveal builds the reveal tx with the dummy signature for the given envelope.
+// The value of the commit tx output covers the reveal tx outputs and the reveal fee, and is raised to the dust limit if below
+func (i *Inscriber) buildReveal(envelope []byte, revealTxOuts []*wire.TxOut, commitOutIndex uint32, feeRate float64) (*Reveal, error) {
+	commitOutWIF, commitOutAddress, err := taproot.GenerateTapscriptCommitOutAddress(envelope, i.netParams)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	return &commitTxHash, revealTxHash, nil
-}
+	commitOutPkScript, err := txscript.PayToAddrScript(commitOutAddress)
+	if err != nil {
+		return nil, err
+	}
 
-func (i *Inscriber) buildCommitTx(key *secp256k1.PrivateKey, commitAddress btcutil.Address, utxos []*basics.UTXO, outValue int64, outPkScript []byte, feeRate int64) (*wire.MsgTx, error) {
-	commitOut := wire.NewTxOut(outValue, outPkScript)
+	script, err := taproot.BuildTapscript(commitOutWIF.PrivKey.PubKey(), envelope)
+	if err != nil {
+		return nil, err
+	}
 
-	tx, utxos, err := basics.BuildTransaction(nil, []*wire.TxOut{commitOut}, utxos, commitAddress, feeRate, i.netParams)
+	revealTx, err := i.buildDummyRevealTx(commitOutWIF.PrivKey.PubKey(), script, revealTxOuts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build commit tx: %v", err)
+		return nil, err
 	}
 
-	if err := i.signCommitTx(key, commitAddress, tx, utxos); err != nil {
-		return nil, fmt.Errorf("failed to build commit tx: %v", err)
+	commitOutValue := basics.GetFee(basics.GetTxVirtualSize(revealTx, nil, true), feeRate) + basics.GetTotalOutputValue(revealTxOuts)
+
+	commitTxOut := wire.NewTxOut(commitOutValue, commitOutPkScript)
+	if dustLimit := basics.GetDustLimit(commitTxOut, i.netParams); commitOutValue < dustLimit {
+		commitTxOut.Value = dustLimit
 	}
 
-	return tx, nil
+	return &Reveal{
+		Tx:             revealTx,
+		Key:            commitOutWIF,
+		CommitTxOut:    commitTxOut,
+		CommitOutIndex: commitOutIndex,
+	}, nil
+}
+
+func (i *Inscriber) buildCommitTx(commitAddress btcutil.Address, utxos []*basics.UTXO, commitOuts []*wire.TxOut, feeRate float64) (*wire.MsgTx, []*basics.UTXO, error) {
+	tx, utxos, err := basics.BuildTransaction(nil, commitOuts, utxos, commitAddress, feeRate, i.coinSelection, i.netParams)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build commit tx: %v", err)
+	}
+
+	return tx, utxos, nil
 }
 
 func (i *Inscriber) buildDummyRevealTx(pubKey *secp256k1.PublicKey, script []byte, txOuts []*wire.TxOut) (*wire.MsgTx, error) {
 	tx := wire.NewMsgTx(basics.TxVersion)
 
-	tx.AddTxIn(&wire.TxIn{})
+	tx.AddTxIn(&wire.TxIn{Sequence: basics.RBFSequence})
 
 	if len(txOuts) == 0 {
 		txOuts = append(txOuts, wire.NewTxOut(0, basics.PADDED_NULL_OUTPUT_SCRIPT))
@@ -122,38 +201,93 @@ func (i *Inscriber) buildDummyRevealTx(pubKey *secp256k1.PublicKey, script []byt
 	return tx, nil
 }
 
-func (i *Inscriber) populateDummyRevealTx(key *secp256k1.PrivateKey, revealTx *wire.MsgTx, commitTxOutPoint wire.OutPoint, commitTxOut *wire.TxOut) error {
-	revealTx.TxIn[0].PreviousOutPoint = commitTxOutPoint
-
-	if err := i.signRevealTx(key, revealTx, commitTxOut); err != nil {
-		return fmt.Errorf("failed to populate reveal tx: %v", err)
+func (i *Inscriber) signCommitTx(key *secp256k1.PrivateKey, addr btcutil.Address, tx *wire.MsgTx, utxos []*basics.UTXO) error {
+	for idx := range tx.TxIn {
+		if err := i.signInput(key, addr, tx, utxos, idx); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func (i *Inscriber) signCommitTx(key *secp256k1.PrivateKey, addr btcutil.Address, tx *wire.MsgTx, utxos []*basics.UTXO) error {
-	switch addr.(type) {
+// signInput signs the input at the given index spending the utxo of the address with the key
+func (i *Inscriber) signInput(key *secp256k1.PrivateKey, addr btcutil.Address, tx *wire.MsgTx, utxos []*basics.UTXO, idx int) error {
+	txIn := tx.TxIn[idx]
+
+	switch addr := addr.(type) {
 	case *btcutil.AddressTaproot:
-		return taproot.SignTaprootTransaction(key, tx, utxos, txscript.SigHashDefault)
+		witness, err := taproot.SignTaproot(key, tx, utxos, idx, txscript.SigHashDefault)
+		if err != nil {
+			return err
+		}
+
+		txIn.Witness = witness
 
 	case *btcutil.AddressWitnessPubKeyHash:
-		return signer.SignWitnessTransaction(key, tx, utxos, txscript.SigHashAll)
+		witness, err := signer.SignWitnessInput(key, tx, utxos, idx, txscript.SigHashAll)
+		if err != nil {
+			return err
+		}
+
+		txIn.Witness = witness
+
+	case *btcutil.AddressScriptHash:
+		redeemScript, err := basics.GetRedeemScriptForNestedSegWit(hex.EncodeToString(key.PubKey().SerializeCompressed()), i.netParams)
+		if err != nil {
+			return err
+		}
+
+		if !bytes.Equal(btcutil.Hash160(redeemScript), addr.ScriptAddress()) {
+			return fmt.Errorf("address %s is not the nested segwit address of the key", addr)
+		}
+
+		sigScript, witness, err := signer.SignNestedWitnessInput(key, tx, utxos, idx, redeemScript, txscript.SigHashAll)
+		if err != nil {
+			return err
+		}
+
+		txIn.SignatureScript = sigScript
+		txIn.Witness = witness
+
+	case *btcutil.AddressPubKeyHash:
+		if err := i.verifyPrevOutput(txIn.PreviousOutPoint, utxos[idx]); err != nil {
+			return err
+		}
+
+		sigScript, err := signer.SignLegacyInput(key, tx, utxos, idx, txscript.SigHashAll)
+		if err != nil {
+			return err
+		}
+
+		txIn.SignatureScript = sigScript
+		txIn.Witness = nil
+
+	default:
+		return fmt.Errorf("unsupported address type: %T, only Taproot, Native Segwit, Nested Segwit and Legacy supported", addr)
 	}
 
-	return fmt.Errorf("unsupported address type: %t, only Taproot and Native Segwit supported", addr)
+	return nil
 }
 
-func (i *Inscriber) signRevealTx(key *secp256k1.PrivateKey, tx *wire.MsgTx, commitTxOut *wire.TxOut) error {
-	revealScript := tx.TxIn[0].Witness[1]
-	utxo := &basics.UTXO{Value: commitTxOut.Value, PkScript: commitTxOut.PkScript}
-
-	signature, err := taproot.SignTapscript(key, tx, []*basics.UTXO{utxo}, 0, revealScript, txscript.SigHashDefault)
+// verifyPrevOutput checks the utxo against the output of the previous tx fetched from the node.
+// The legacy sighash does not commit to the value of the spent output, thus the value reported by the utxo provider is verified
+// to avoid the fee being miscomputed without failing the signature
+func (i *Inscriber) verifyPrevOutput(outPoint wire.OutPoint, utxo *basics.UTXO) error {
+	prevTx, err := i.rpcClient.GetRawTransaction(&outPoint.Hash)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to get the previous tx %s: %v", outPoint.Hash, err)
 	}
 
-	tx.TxIn[0].Witness[0] = signature
+	prevTxOuts := prevTx.MsgTx().TxOut
+	if int(outPoint.Index) >= len(prevTxOuts) {
+		return fmt.Errorf("previous tx %s has no output %d", outPoint.Hash, outPoint.Index)
+	}
+
+	prevOut := prevTxOuts[outPoint.Index]
+	if prevOut.Value != utxo.Value || !bytes.Equal(prevOut.PkScript, utxo.PkScript) {
+		return fmt.Errorf("utxo %s does not match the previous tx output", outPoint)
+	}
 
 	return nil
 }
@@ -0,0 +1,140 @@
+package inscriber
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/devnet"
+	"btc-sbt/logger"
+	"btc-sbt/stacks/basics"
+)
+
+// newTestDevnetInscriber starts the devnet node, returning the inscriber connected to it
+func newTestDevnetInscriber(t *testing.T) (*devnet.Node, *Inscriber) {
+	node := devnet.NewNode("user", "pass", 1, logger.Logger)
+
+	server := httptest.NewServer(node.Router)
+	t.Cleanup(server.Close)
+
+	rpcClient, err := rpcclient.New(&rpcclient.ConnConfig{
+		Host:         strings.TrimPrefix(server.URL, "http://"),
+		User:         "user",
+		Pass:         "pass",
+		HTTPPostMode: true,
+		DisableTLS:   true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to create the rpc client: %v", err)
+	}
+
+	t.Cleanup(rpcClient.Shutdown)
+
+	return node, NewInscriber(rpcClient, node.NetParams)
+}
+
+func TestSignCommitTx(t *testing.T) {
+	for _, addrType := range []basics.AddressType{basics.Taproot, basics.WitnessPubKeyHash, basics.ScriptHash, basics.PubKeyHash} {
+		t.Run(addrType.String(), func(t *testing.T) {
+			node, i := newTestDevnetInscriber(t)
+
+			key, err := btcec.NewPrivateKey()
+			if err != nil {
+				t.Fatalf("failed to generate the key: %v", err)
+			}
+
+			addr, err := basics.GetAddress(key, addrType, node.NetParams)
+			if err != nil {
+				t.Fatalf("failed to get the address: %v", err)
+			}
+
+			if _, err := node.Mine(2, addr); err != nil {
+				t.Fatalf("failed to mine: %v", err)
+			}
+
+			// the coinbase outputs known by the node, as required to verify the legacy inputs
+			utxos := make([]*basics.UTXO, 0, 2)
+			for height := int64(1); height <= 2; height++ {
+				coinbase := node.Chain.GetBlock(height).Transactions[0]
+				coinbaseHash := coinbase.TxHash()
+
+				utxos = append(utxos, basics.NewUTXO(&coinbaseHash, 0, coinbase.TxOut[0].Value, coinbase.TxOut[0].PkScript))
+			}
+
+			inscription, err := i.Build(addr, utxos, newTestEnvelope(t, "signed"), []*wire.TxOut{wire.NewTxOut(0, basics.PADDED_NULL_OUTPUT_SCRIPT)}, 2)
+			if err != nil {
+				t.Fatalf("failed to build the inscription: %v", err)
+			}
+
+			other, err := btcec.NewPrivateKey()
+			if err != nil {
+				t.Fatalf("failed to generate the key: %v", err)
+			}
+
+			if addrType == basics.ScriptHash {
+				if err := i.SignCommitTx(other, addr, inscription); err == nil || !strings.Contains(err.Error(), "not the nested segwit address") {
+					t.Fatalf("another key: got %v; want the address mismatch", err)
+				}
+			}
+
+			if err := i.SignCommitTx(key, addr, inscription); err != nil {
+				t.Fatalf("failed to sign: %v", err)
+			}
+
+			prevOuts := make([]*wire.TxOut, len(inscription.CommitUtxos))
+			for idx, utxo := range inscription.CommitUtxos {
+				prevOuts[idx] = utxo.GetOutput()
+			}
+
+			verifyTestInputs(t, inscription.CommitTx, prevOuts)
+
+			// the estimated size covers the actual signatures
+			if vsize := basics.GetTxVirtualSize(inscription.CommitTx, nil, true); inscription.CommitFee() < vsize*2 {
+				t.Fatalf("got fee %d for vsize %d; want at least 2 sat/vB", inscription.CommitFee(), vsize)
+			}
+		})
+	}
+}
+
+func TestSignLegacyInputVerifiesPrevOutput(t *testing.T) {
+	node, i := newTestDevnetInscriber(t)
+
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate the key: %v", err)
+	}
+
+	addr, err := basics.GetAddress(key, basics.PubKeyHash, node.NetParams)
+	if err != nil {
+		t.Fatalf("failed to get the address: %v", err)
+	}
+
+	if _, err := node.Mine(1, addr); err != nil {
+		t.Fatalf("failed to mine: %v", err)
+	}
+
+	coinbase := node.Chain.GetBlock(1).Transactions[0]
+	coinbaseHash := coinbase.TxHash()
+
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("failed to get the script: %v", err)
+	}
+
+	// the value overstated by the utxo provider is not committed to by the legacy sighash
+	utxos := []*basics.UTXO{basics.NewUTXO(&coinbaseHash, 0, coinbase.TxOut[0].Value+100000, pkScript)}
+
+	inscription, err := i.Build(addr, utxos, newTestEnvelope(t, "signed"), []*wire.TxOut{wire.NewTxOut(0, basics.PADDED_NULL_OUTPUT_SCRIPT)}, 2)
+	if err != nil {
+		t.Fatalf("failed to build the inscription: %v", err)
+	}
+
+	if err := i.SignCommitTx(key, addr, inscription); err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Fatalf("got %v; want the previous output mismatch", err)
+	}
+}
@@ -0,0 +1,154 @@
+package inscriber
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/stacks/basics"
+	"btc-sbt/stacks/taproot"
+)
+
+// Inscription defines the unsigned commit tx and the prepared reveal txs funded by it
+type Inscription struct {
+	CommitTx    *wire.MsgTx    // unsigned commit tx
+	CommitUtxos []*basics.UTXO // utxos spent by the commit tx
+
+	Reveals []*Reveal // prepared reveal txs
+}
+
+// CommitFee returns the fee of the commit tx
+func (i *Inscription) CommitFee() int64 {
+	return basics.UTXOs(i.CommitUtxos).TotalValue() - basics.GetTotalOutputValue(i.CommitTx.TxOut)
+}
+
+// RevealFee returns the total fee of the reveal txs
+func (i *Inscription) RevealFee() int64 {
+	fee := int64(0)
+	for _, reveal := range i.Reveals {
+		fee += reveal.CommitTxOut.Value - basics.GetTotalOutputValue(reveal.Tx.TxOut)
+	}
+
+	return fee
+}
+
+// DisableRBF sets the sequence of the inputs of the unsigned commit tx and the reveal txs to opt out of RBF
+func (i *Inscription) DisableRBF() {
+	for _, txIn := range i.CommitTx.TxIn {
+		txIn.Sequence = wire.MaxTxInSequenceNum
+	}
+
+	for _, reveal := range i.Reveals {
+		reveal.Tx.TxIn[0].Sequence = wire.MaxTxInSequenceNum
+	}
+}
+
+// Reveal defines the prepared reveal tx along with the ephemeral key material.
+// The reveal tx spends the commit tx output at CommitOutIndex through the tapscript path
+type Reveal struct {
+	Tx             *wire.MsgTx  // reveal tx with the dummy signature
+	Key            *btcutil.WIF // ephemeral key which the tapscript commits to
+	CommitTxOut    *wire.TxOut  // commit tx output spent by the reveal tx
+	CommitOutIndex uint32       // index of the commit tx output
+}
+
+// revealJSON is the JSON representation of the Reveal
+type revealJSON struct {
+	Tx                string `json:"tx"`
+	Key               string `json:"key"`
+	CommitOutValue    int64  `json:"commit_out_value"`
+	CommitOutPkScript string `json:"commit_out_pk_script"`
+	CommitOutIndex    uint32 `json:"commit_out_index,omitempty"`
+}
+
+// Sign points the reveal tx to the given commit tx and signs it with the ephemeral key
+func (r *Reveal) Sign(commitTxHash chainhash.Hash) error {
+	r.Tx.TxIn[0].PreviousOutPoint = *wire.NewOutPoint(&commitTxHash, r.CommitOutIndex)
+
+	revealScript := r.Tx.TxIn[0].Witness[1]
+	utxo := &basics.UTXO{Value: r.CommitTxOut.Value, PkScript: r.CommitTxOut.PkScript}
+
+	signature, err := taproot.SignTapscript(r.Key.PrivKey, r.Tx, []*basics.UTXO{utxo}, 0, revealScript, txscript.SigHashDefault)
+	if err != nil {
+		return fmt.Errorf("failed to sign reveal tx: %v", err)
+	}
+
+	r.Tx.TxIn[0].Witness[0] = signature
+
+	return nil
+}
+
+// MatchCommitTx checks if the given commit tx funds the reveal tx
+func (r *Reveal) MatchCommitTx(commitTx *wire.MsgTx) error {
+	if len(commitTx.TxOut) <= int(r.CommitOutIndex) {
+		return fmt.Errorf("commit tx has no output %d", r.CommitOutIndex)
+	}
+
+	out := commitTx.TxOut[r.CommitOutIndex]
+	if out.Value != r.CommitTxOut.Value || !bytes.Equal(out.PkScript, r.CommitTxOut.PkScript) {
+		return fmt.Errorf("commit tx does not match the reveal tx")
+	}
+
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (r *Reveal) MarshalJSON() ([]byte, error) {
+	txBytes, err := basics.SerializeTx(r.Tx)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(revealJSON{
+		Tx:                hex.EncodeToString(txBytes),
+		Key:               r.Key.String(),
+		CommitOutValue:    r.CommitTxOut.Value,
+		CommitOutPkScript: hex.EncodeToString(r.CommitTxOut.PkScript),
+		CommitOutIndex:    r.CommitOutIndex,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (r *Reveal) UnmarshalJSON(data []byte) error {
+	var raw revealJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	txBytes, err := hex.DecodeString(raw.Tx)
+	if err != nil {
+		return fmt.Errorf("invalid reveal tx: %v", err)
+	}
+
+	tx := new(wire.MsgTx)
+	if err := tx.Deserialize(bytes.NewReader(txBytes)); err != nil {
+		return fmt.Errorf("invalid reveal tx: %v", err)
+	}
+
+	if len(tx.TxIn) != 1 || len(tx.TxIn[0].Witness) != 3 {
+		return fmt.Errorf("invalid reveal tx: tapscript witness required")
+	}
+
+	key, err := btcutil.DecodeWIF(raw.Key)
+	if err != nil {
+		return fmt.Errorf("invalid reveal key: %v", err)
+	}
+
+	pkScript, err := hex.DecodeString(raw.CommitOutPkScript)
+	if err != nil {
+		return fmt.Errorf("invalid commit out pk script: %v", err)
+	}
+
+	r.Tx = tx
+	r.Key = key
+	r.CommitTxOut = wire.NewTxOut(raw.CommitOutValue, pkScript)
+	r.CommitOutIndex = raw.CommitOutIndex
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package inscriber
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/stacks/basics"
+	stackspsbt "btc-sbt/stacks/psbt"
+)
+
+// BuildCommitPsbt creates the psbt for the unsigned commit tx of the given inscription.
+// `pubKey` is the hex encoded public key of the commit address, which is required for taproot and nested segwit inputs
+func (i *Inscriber) BuildCommitPsbt(inscription *Inscription, commitAddress btcutil.Address, pubKey string) (*psbt.Packet, error) {
+	p, err := psbt.NewFromUnsignedTx(inscription.CommitTx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build commit psbt: %v", err)
+	}
+
+	sigHashType := txscript.SigHashAll
+	if basics.IsTaprootAddress(commitAddress) {
+		sigHashType = txscript.SigHashDefault
+	}
+
+	for idx, utxo := range inscription.CommitUtxos {
+		var rawTx *wire.MsgTx
+
+		if !basics.IsSegWitAddress(commitAddress) {
+			tx, err := i.rpcClient.GetRawTransaction(&utxo.Hash)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get the previous tx %s: %v", utxo.Hash, err)
+			}
+
+			rawTx = tx.MsgTx()
+		}
+
+		if err := stackspsbt.AddInputToPsbt(p, idx, utxo, rawTx, commitAddress, pubKey, sigHashType, i.netParams); err != nil {
+			return nil, fmt.Errorf("failed to build commit psbt: %v", err)
+		}
+	}
+
+	return p, nil
+}
+
+// FinalizeCommitPsbt finalizes the signed commit psbt and extracts the commit tx for the given reveal tx
+func FinalizeCommitPsbt(p *psbt.Packet, reveal *Reveal) (*wire.MsgTx, error) {
+	if err := psbt.MaybeFinalizeAll(p); err != nil {
+		return nil, fmt.Errorf("failed to finalize commit psbt: %v", err)
+	}
+
+	commitTx, err := psbt.Extract(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract commit tx: %v", err)
+	}
+
+	if err := reveal.MatchCommitTx(commitTx); err != nil {
+		return nil, err
+	}
+
+	return commitTx, nil
+}
@@ -0,0 +1,163 @@
+package inscriber
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/psbt"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/stacks/basics"
+)
+
+// newTestEnvelope builds the envelope script carrying the given payload
+func newTestEnvelope(t *testing.T, payload string) []byte {
+	envelope, err := txscript.NewScriptBuilder().AddOp(txscript.OP_FALSE).AddOp(txscript.OP_IF).AddData([]byte(payload)).AddOp(txscript.OP_ENDIF).Script()
+	if err != nil {
+		t.Fatalf("failed to build the envelope: %v", err)
+	}
+
+	return envelope
+}
+
+// newTestInscription builds the inscription funded by a fake utxo of the given address
+func newTestInscription(t *testing.T, addr btcutil.Address, payload string) *Inscription {
+	pkScript, err := txscript.PayToAddrScript(addr)
+	if err != nil {
+		t.Fatalf("failed to get the script: %v", err)
+	}
+
+	fundingTxHash := chainhash.HashH([]byte("funding"))
+	utxos := []*basics.UTXO{basics.NewUTXO(&fundingTxHash, 0, 1000000, pkScript)}
+
+	inscription, err := NewInscriber(nil, &chaincfg.RegressionNetParams).Build(addr, utxos, newTestEnvelope(t, payload), []*wire.TxOut{wire.NewTxOut(0, basics.PADDED_NULL_OUTPUT_SCRIPT)}, 2)
+	if err != nil {
+		t.Fatalf("failed to build the inscription: %v", err)
+	}
+
+	return inscription
+}
+
+// signTestPsbt signs the segwit inputs of the psbt by the given key as an external signer would
+func signTestPsbt(t *testing.T, p *psbt.Packet, key *btcec.PrivateKey, taproot bool) {
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for idx, in := range p.Inputs {
+		prevOutFetcher.AddPrevOut(p.UnsignedTx.TxIn[idx].PreviousOutPoint, in.WitnessUtxo)
+	}
+
+	sigHashes := txscript.NewTxSigHashes(p.UnsignedTx, prevOutFetcher)
+
+	for idx, in := range p.Inputs {
+		if taproot {
+			sig, err := txscript.RawTxInTaprootSignature(p.UnsignedTx, sigHashes, idx, in.WitnessUtxo.Value, in.WitnessUtxo.PkScript, nil, in.SighashType, key)
+			if err != nil {
+				t.Fatalf("input %d: failed to sign: %v", idx, err)
+			}
+
+			p.Inputs[idx].TaprootKeySpendSig = sig
+
+			continue
+		}
+
+		sig, err := txscript.RawTxInWitnessSignature(p.UnsignedTx, sigHashes, idx, in.WitnessUtxo.Value, in.WitnessUtxo.PkScript, in.SighashType, key)
+		if err != nil {
+			t.Fatalf("input %d: failed to sign: %v", idx, err)
+		}
+
+		p.Inputs[idx].PartialSigs = []*psbt.PartialSig{{PubKey: key.PubKey().SerializeCompressed(), Signature: sig}}
+	}
+}
+
+// verifyTestInputs verifies the scripts of all the inputs of the tx spending the given outputs
+func verifyTestInputs(t *testing.T, tx *wire.MsgTx, prevOuts []*wire.TxOut) {
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for idx, prevOut := range prevOuts {
+		prevOutFetcher.AddPrevOut(tx.TxIn[idx].PreviousOutPoint, prevOut)
+	}
+
+	sigHashes := txscript.NewTxSigHashes(tx, prevOutFetcher)
+
+	for idx, prevOut := range prevOuts {
+		engine, err := txscript.NewEngine(prevOut.PkScript, tx, idx, txscript.StandardVerifyFlags, nil, sigHashes, prevOut.Value, prevOutFetcher)
+		if err != nil {
+			t.Fatalf("input %d: failed to create the engine: %v", idx, err)
+		}
+
+		if err := engine.Execute(); err != nil {
+			t.Fatalf("input %d: invalid witness: %v", idx, err)
+		}
+	}
+}
+
+func TestFinalizeCommitPsbt(t *testing.T) {
+	netParams := &chaincfg.RegressionNetParams
+
+	for _, addrType := range []basics.AddressType{basics.Taproot, basics.WitnessPubKeyHash} {
+		t.Run(addrType.String(), func(t *testing.T) {
+			key, err := btcec.NewPrivateKey()
+			if err != nil {
+				t.Fatalf("failed to generate the key: %v", err)
+			}
+
+			addr, err := basics.GetAddress(key, addrType, netParams)
+			if err != nil {
+				t.Fatalf("failed to get the address: %v", err)
+			}
+
+			inscription := newTestInscription(t, addr, "first")
+			pubKey := hex.EncodeToString(key.PubKey().SerializeCompressed())
+
+			p, err := NewInscriber(nil, netParams).BuildCommitPsbt(inscription, addr, pubKey)
+			if err != nil {
+				t.Fatalf("failed to build the psbt: %v", err)
+			}
+
+			// round trip as exported and imported by the external signer
+			encoded, err := p.B64Encode()
+			if err != nil {
+				t.Fatalf("failed to encode the psbt: %v", err)
+			}
+
+			p, err = psbt.NewFromRawBytes(strings.NewReader(encoded), true)
+			if err != nil {
+				t.Fatalf("failed to decode the psbt: %v", err)
+			}
+
+			if _, err := FinalizeCommitPsbt(p, inscription.Reveals[0]); err == nil {
+				t.Fatalf("unsigned: got nil; want error")
+			}
+
+			signTestPsbt(t, p, key, addrType == basics.Taproot)
+
+			other := newTestInscription(t, addr, "second")
+			if _, err := FinalizeCommitPsbt(p, other.Reveals[0]); err == nil || !strings.Contains(err.Error(), "does not match") {
+				t.Fatalf("another reveal: got %v; want the mismatch error", err)
+			}
+
+			commitTx, err := FinalizeCommitPsbt(p, inscription.Reveals[0])
+			if err != nil {
+				t.Fatalf("failed to finalize: %v", err)
+			}
+
+			verifyTestInputs(t, commitTx, []*wire.TxOut{inscription.CommitUtxos[0].GetOutput()})
+
+			results, err := SignReveals(commitTx, inscription.Reveals)
+			if err != nil {
+				t.Fatalf("failed to sign the reveal tx: %v", err)
+			}
+
+			if results[0].CommitTxHash != commitTx.TxHash() {
+				t.Fatalf("got commit tx %s; want %s", results[0].CommitTxHash, commitTx.TxHash())
+			}
+
+			revealTx := inscription.Reveals[0].Tx
+			verifyTestInputs(t, revealTx, []*wire.TxOut{commitTx.TxOut[revealTx.TxIn[0].PreviousOutPoint.Index]})
+		})
+	}
+}
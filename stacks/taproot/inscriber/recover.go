@@ -0,0 +1,125 @@
+package inscriber
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+
+	"btc-sbt/stacks/basics"
+	"btc-sbt/stacks/taproot"
+)
+
+// FundReveal rebuilds the signed reveal tx with the funding utxos appended to pay the given fee rate.
+// The reveal outputs are kept in order and the change to the funding address is appended, replacing the previous change if any.
+// The rebuilt tx signals RBF and spends the same commit output, so that it replaces the previous reveal tx.
+// Returns the unsigned tx along with the utxos spent by it
+func (i *Inscriber) FundReveal(reveal *Reveal, fundingAddress btcutil.Address, fundingUtxos []*basics.UTXO, feeRate float64) (*wire.MsgTx, []*basics.UTXO, error) {
+	changePkScript, err := txscript.PayToAddrScript(fundingAddress)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	revealIn := reveal.Tx.TxIn[0]
+
+	tx := wire.NewMsgTx(basics.TxVersion)
+	tx.AddTxIn(&wire.TxIn{
+		PreviousOutPoint: revealIn.PreviousOutPoint,
+		Witness:          wire.TxWitness{revealIn.Witness[0], revealIn.Witness[1], revealIn.Witness[2]},
+		Sequence:         basics.RBFSequence,
+	})
+
+	for _, out := range reveal.Tx.TxOut {
+		if !bytes.Equal(out.PkScript, changePkScript) {
+			tx.AddTxOut(out)
+		}
+	}
+
+	utxos := []*basics.UTXO{
+		basics.NewUTXO(&revealIn.PreviousOutPoint.Hash, revealIn.PreviousOutPoint.Index, reveal.CommitTxOut.Value, reveal.CommitTxOut.PkScript),
+	}
+
+	inOutDiff := reveal.CommitTxOut.Value - basics.GetTotalOutputValue(tx.TxOut)
+
+	selectedUtxos, err := i.coinSelection.AddPaymentUtxosToTx(tx, utxos, inOutDiff, fundingUtxos, wire.NewTxOut(0, changePkScript), feeRate, i.netParams)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fund reveal tx: %v", err)
+	}
+
+	return tx, append(utxos, selectedUtxos...), nil
+}
+
+// SignFundedReveal signs the reveal tx rebuilt by FundReveal.
+// The commit output is signed with the ephemeral key through the script path and the funding utxos with the funding key
+func (i *Inscriber) SignFundedReveal(reveal *Reveal, tx *wire.MsgTx, utxos []*basics.UTXO, fundingKey *secp256k1.PrivateKey, fundingAddress btcutil.Address) error {
+	for idx := 1; idx < len(tx.TxIn); idx++ {
+		if err := i.signInput(fundingKey, fundingAddress, tx, utxos, idx); err != nil {
+			return fmt.Errorf("failed to sign reveal tx: %v", err)
+		}
+	}
+
+	signature, err := taproot.SignTapscript(reveal.Key.PrivKey, tx, utxos, 0, tx.TxIn[0].Witness[1], txscript.SigHashDefault)
+	if err != nil {
+		return fmt.Errorf("failed to sign reveal tx: %v", err)
+	}
+
+	tx.TxIn[0].Witness[0] = signature
+
+	return nil
+}
+
+// BuildSweepTx builds the signed tx sweeping the commit outputs of the given reveals to the address through the key path.
+// The journaled ephemeral keys are tweaked by the envelope tapscripts, so that the envelopes are neither revealed nor executed.
+// The key path is taken rather than the script path, since spending through the tapscript would put the envelope in the witness,
+// which the indexer parses as the op the sweep is meant to abandon; the key path signature is also smaller than the tapscript and control block
+func (i *Inscriber) BuildSweepTx(reveals []*Reveal, toAddress btcutil.Address, feeRate float64) (*wire.MsgTx, error) {
+	if len(reveals) == 0 {
+		return nil, fmt.Errorf("no commit outputs to sweep")
+	}
+
+	pkScript, err := txscript.PayToAddrScript(toAddress)
+	if err != nil {
+		return nil, err
+	}
+
+	tx := wire.NewMsgTx(basics.TxVersion)
+	utxos := make([]*basics.UTXO, 0, len(reveals))
+
+	for _, reveal := range reveals {
+		revealIn := reveal.Tx.TxIn[0]
+
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: revealIn.PreviousOutPoint,
+			Witness:          wire.TxWitness{make([]byte, schnorr.SignatureSize)},
+			Sequence:         basics.RBFSequence,
+		})
+
+		utxos = append(utxos, basics.NewUTXO(&revealIn.PreviousOutPoint.Hash, revealIn.PreviousOutPoint.Index, reveal.CommitTxOut.Value, reveal.CommitTxOut.PkScript))
+	}
+
+	tx.AddTxOut(wire.NewTxOut(0, pkScript))
+
+	fee := basics.GetFee(basics.GetTxVirtualSize(tx, nil, true), feeRate)
+
+	tx.TxOut[0].Value = basics.UTXOs(utxos).TotalValue() - fee
+	if tx.TxOut[0].Value <= 0 || basics.IsDust(tx.TxOut[0], i.netParams) {
+		return nil, fmt.Errorf("commit outputs of %d sats insufficient to pay the sweep fee of %d sats", basics.UTXOs(utxos).TotalValue(), fee)
+	}
+
+	for idx, reveal := range reveals {
+		tapHash := txscript.NewBaseTapLeaf(reveal.Tx.TxIn[0].Witness[1]).TapHash()
+
+		signature, err := taproot.SignTaprootKeyPath(reveal.Key.PrivKey, tx, utxos, idx, tapHash[:], txscript.SigHashDefault)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign sweep tx: %v", err)
+		}
+
+		tx.TxIn[idx].Witness[0] = signature
+	}
+
+	return tx, nil
+}
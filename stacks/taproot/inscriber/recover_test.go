@@ -0,0 +1,88 @@
+package inscriber
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/stacks/basics"
+)
+
+func TestBuildSweepTx(t *testing.T) {
+	netParams := &chaincfg.RegressionNetParams
+	i := NewInscriber(nil, netParams)
+
+	envelope, err := txscript.NewScriptBuilder().AddOp(txscript.OP_FALSE).AddOp(txscript.OP_IF).AddData([]byte("ord")).AddOp(txscript.OP_ENDIF).Script()
+	if err != nil {
+		t.Fatalf("failed to build the envelope: %v", err)
+	}
+
+	commitTx := wire.NewMsgTx(basics.TxVersion)
+	reveals := make([]*Reveal, 0, 2)
+
+	for idx := 0; idx < 2; idx++ {
+		reveal, err := i.buildReveal(envelope, []*wire.TxOut{wire.NewTxOut(0, basics.PADDED_NULL_OUTPUT_SCRIPT)}, uint32(idx), 10)
+		if err != nil {
+			t.Fatalf("failed to build the reveal tx: %v", err)
+		}
+
+		reveal.CommitTxOut.Value += 10000
+		commitTx.AddTxOut(reveal.CommitTxOut)
+
+		reveals = append(reveals, reveal)
+	}
+
+	commitTxHash := commitTx.TxHash()
+	for idx, reveal := range reveals {
+		reveal.Tx.TxIn[0].PreviousOutPoint = *wire.NewOutPoint(&commitTxHash, uint32(idx))
+	}
+
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate the key: %v", err)
+	}
+
+	to, err := basics.GetAddress(key, basics.Taproot, netParams)
+	if err != nil {
+		t.Fatalf("failed to get the address: %v", err)
+	}
+
+	tx, err := i.BuildSweepTx(reveals, to, 10)
+	if err != nil {
+		t.Fatalf("failed to build the sweep tx: %v", err)
+	}
+
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(nil)
+	for idx, reveal := range reveals {
+		prevOutFetcher.AddPrevOut(tx.TxIn[idx].PreviousOutPoint, reveal.CommitTxOut)
+	}
+
+	sigHashes := txscript.NewTxSigHashes(tx, prevOutFetcher)
+
+	for idx, reveal := range reveals {
+		// spent through the key path, leaving the envelope unrevealed
+		if len(tx.TxIn[idx].Witness) != 1 {
+			t.Fatalf("input %d: got %d witness items; want the signature only", idx, len(tx.TxIn[idx].Witness))
+		}
+
+		engine, err := txscript.NewEngine(reveal.CommitTxOut.PkScript, tx, idx, txscript.StandardVerifyFlags, nil, sigHashes, reveal.CommitTxOut.Value, prevOutFetcher)
+		if err != nil {
+			t.Fatalf("input %d: failed to create the engine: %v", idx, err)
+		}
+
+		if err := engine.Execute(); err != nil {
+			t.Fatalf("input %d: invalid signature: %v", idx, err)
+		}
+	}
+
+	if fee := basics.GetTotalOutputValue(commitTx.TxOut) - tx.TxOut[0].Value; fee <= 0 {
+		t.Fatalf("got fee %d; want positive", fee)
+	}
+
+	if _, err := i.BuildSweepTx(nil, to, 10); err == nil {
+		t.Fatalf("sweeping nothing: got nil; want error")
+	}
+}
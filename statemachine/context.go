@@ -3,6 +3,9 @@ package statemachine
 import (
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
+
+	"btc-sbt/protocol"
+	"btc-sbt/types"
 )
 
 // Context represents the context for handling the protocol operations
@@ -12,6 +15,9 @@ type Context struct {
 	TxIndex             int            // tx index
 	Tx                  *wire.MsgTx    // tx
 	OperationOutAddress string         // operation output address, i.e. issuer address if there exists the issue operation
+
+	Events  []*types.Event    // events emitted during handling
+	Results []*types.OpResult // operation results
 }
 
 // NewContext creates a new Context instance
@@ -24,3 +30,15 @@ func NewContext(blockHeight int64, blockHash chainhash.Hash, txIndex int, tx *wi
 		OperationOutAddress: opOutAddr,
 	}
 }
+
+// EmitEvent appends the given event to the context
+func (ctx *Context) EmitEvent(event *types.Event) {
+	event.BlockHash = ctx.BlockHash.String()
+
+	ctx.Events = append(ctx.Events, event)
+}
+
+// AddResult appends the result of the given operation to the context
+func (ctx *Context) AddResult(op protocol.Operation, err error) {
+	ctx.Results = append(ctx.Results, types.NewOpResult(op, err))
+}
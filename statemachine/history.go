@@ -0,0 +1,117 @@
+package statemachine
+
+import (
+	"fmt"
+	"sort"
+
+	"btc-sbt/types"
+)
+
+// The state is append only, i.e. the SBTs and SBT tokens are never modified or removed once created and the token ids
+// increase in the order of minting. So the state at a past block height is derived by the block heights of the records
+
+// CheckBlockHeight checks if the state at the given block height is available, i.e. the block is indexed
+func (sm *StateMachine) CheckBlockHeight(blockHeight int64) error {
+	if blockHeight < 0 {
+		return fmt.Errorf("invalid block height: %d", blockHeight)
+	}
+
+	lastBlockHeight, err := sm.GetLastBlockHeight()
+	if err != nil {
+		return err
+	}
+
+	if blockHeight > lastBlockHeight {
+		return fmt.Errorf("block %d not indexed yet, last indexed block: %d", blockHeight, lastBlockHeight)
+	}
+
+	return nil
+}
+
+// GetSBTsAt queries the SBTs by the given symbol at the given block height.
+// The latest state is queried if the block height is 0
+func (sm *StateMachine) GetSBTsAt(symbol string, blockHeight int64) (*types.SBTs, error) {
+	if blockHeight == 0 {
+		return sm.GetSBTs(symbol)
+	}
+
+	if err := sm.CheckBlockHeight(blockHeight); err != nil {
+		return nil, err
+	}
+
+	sbts, err := sm.GetSBTs(symbol)
+	if err != nil || sbts == nil {
+		return nil, err
+	}
+
+	return sm.rewindSBTs(sbts, blockHeight)
+}
+
+// GetSBTAt queries the SBT token by the given symbol and token id at the given block height.
+// The latest state is queried if the block height is 0
+func (sm *StateMachine) GetSBTAt(symbol string, id uint64, blockHeight int64) (*types.SBT, error) {
+	if blockHeight == 0 {
+		return sm.GetSBT(symbol, id)
+	}
+
+	if err := sm.CheckBlockHeight(blockHeight); err != nil {
+		return nil, err
+	}
+
+	sbt, err := sm.GetSBT(symbol, id)
+	if err != nil || sbt == nil || sbt.BlockHeight > blockHeight {
+		return nil, err
+	}
+
+	return sbt, nil
+}
+
+// rewindSBTs returns the given SBTs as of the given block height.
+// Returns nil if the SBTs is issued after the block height
+func (sm *StateMachine) rewindSBTs(sbts *types.SBTs, blockHeight int64) (*types.SBTs, error) {
+	if sbts.BlockHeight > blockHeight {
+		return nil, nil
+	}
+
+	supply, err := sm.getSBTsSupplyAt(sbts.Symbol, sbts.TotalSupply, blockHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	sbts.TotalSupply = supply
+
+	return sbts, nil
+}
+
+// getSBTsSupplyAt gets the supply of the given SBTs at the given block height,
+// i.e. the number of the tokens minted no later than the block height
+func (sm *StateMachine) getSBTsSupplyAt(symbol string, supply uint64, blockHeight int64) (uint64, error) {
+	var err error
+
+	// the token ids are in the order of minting, so search for the first token minted after the block height
+	n := sort.Search(int(supply), func(id int) bool {
+		if err != nil {
+			return true
+		}
+
+		var sbt *types.SBT
+
+		sbt, err = sm.GetSBT(symbol, uint64(id))
+		if err != nil {
+			return true
+		}
+
+		if sbt == nil {
+			err = fmt.Errorf("SBT missing, symbol: %s, id: %d", symbol, id)
+			return true
+		}
+
+		return sbt.BlockHeight > blockHeight
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return uint64(n), nil
+}
@@ -0,0 +1,133 @@
+package statemachine
+
+import (
+	"strings"
+	"testing"
+
+	"btc-sbt/types"
+)
+
+// newTestHistory sets the SBTs issued at block 10 along with the tokens minted at the given block heights, indexed up to block 30
+func newTestHistory(t *testing.T, symbol string, mintHeights []int64) *StateMachine {
+	sm := newTestStateMachine(t)
+
+	if err := sm.SetSBTs(types.NewSBTs(symbol, 1, 100, "", 0, "", "issuer", 10, 0, "", 0)); err != nil {
+		t.Fatalf("failed to set the SBTs: %v", err)
+	}
+
+	for id, height := range mintHeights {
+		if err := sm.SetSBT(types.NewSBT(symbol, uint64(id), "owner", "", height, 1, "")); err != nil {
+			t.Fatalf("failed to set the SBT: %v", err)
+		}
+	}
+
+	if err := sm.SetSBTsSupply(symbol, uint64(len(mintHeights))); err != nil {
+		t.Fatalf("failed to set the supply: %v", err)
+	}
+
+	if err := sm.SetLastBlockHeight(30); err != nil {
+		t.Fatalf("failed to set the last block height: %v", err)
+	}
+
+	return sm
+}
+
+func TestGetSBTsAt(t *testing.T) {
+	sm := newTestHistory(t, "abc", []int64{12, 12, 15, 20, 20, 20})
+
+	tests := []struct {
+		height int64
+		exists bool
+		supply uint64
+	}{
+		{0, true, 6},
+		{9, false, 0},
+		{10, true, 0},
+		{11, true, 0},
+		{12, true, 2},
+		{14, true, 2},
+		{15, true, 3},
+		{19, true, 3},
+		{20, true, 6},
+		{30, true, 6},
+	}
+
+	for _, tt := range tests {
+		sbts, err := sm.GetSBTsAt("abc", tt.height)
+		if err != nil {
+			t.Fatalf("block %d: failed to query: %v", tt.height, err)
+		}
+
+		if (sbts != nil) != tt.exists {
+			t.Fatalf("block %d: got %+v; want exists %v", tt.height, sbts, tt.exists)
+		}
+
+		if sbts != nil && sbts.TotalSupply != tt.supply {
+			t.Fatalf("block %d: got supply %d; want %d", tt.height, sbts.TotalSupply, tt.supply)
+		}
+	}
+
+	for _, height := range []int64{-1, 31} {
+		if _, err := sm.GetSBTsAt("abc", height); err == nil {
+			t.Fatalf("block %d: got nil; want error", height)
+		}
+	}
+
+	if sbts, err := sm.GetSBTsAt("xyz", 20); err != nil || sbts != nil {
+		t.Fatalf("nonexistent symbol: got %+v, %v; want nil", sbts, err)
+	}
+}
+
+func TestGetSBTsAtNoTokens(t *testing.T) {
+	sm := newTestHistory(t, "abc", nil)
+
+	if sbts, err := sm.GetSBTsAt("abc", 20); err != nil || sbts == nil || sbts.TotalSupply != 0 {
+		t.Fatalf("got %+v, %v; want the SBTs of no supply", sbts, err)
+	}
+}
+
+func TestGetSBTsAtTokenMissing(t *testing.T) {
+	sm := newTestHistory(t, "abc", []int64{12, 15})
+
+	// the supply beyond the stored tokens
+	if err := sm.SetSBTsSupply("abc", 3); err != nil {
+		t.Fatalf("failed to set the supply: %v", err)
+	}
+
+	if _, err := sm.GetSBTsAt("abc", 20); err == nil || !strings.Contains(err.Error(), "SBT missing") {
+		t.Fatalf("got %v; want the missing SBT error", err)
+	}
+}
+
+func TestGetSBTAt(t *testing.T) {
+	sm := newTestHistory(t, "abc", []int64{12, 15})
+
+	tests := []struct {
+		id     uint64
+		height int64
+		exists bool
+	}{
+		{0, 0, true},
+		{0, 11, false},
+		{0, 12, true},
+		{1, 14, false},
+		{1, 15, true},
+		{2, 0, false},
+		{2, 30, false},
+	}
+
+	for _, tt := range tests {
+		sbt, err := sm.GetSBTAt("abc", tt.id, tt.height)
+		if err != nil {
+			t.Fatalf("token %d at block %d: failed to query: %v", tt.id, tt.height, err)
+		}
+
+		if (sbt != nil) != tt.exists {
+			t.Fatalf("token %d at block %d: got %+v; want exists %v", tt.id, tt.height, sbt, tt.exists)
+		}
+	}
+
+	if _, err := sm.GetSBTAt("abc", 0, 31); err == nil {
+		t.Fatalf("block not indexed: got nil; want error")
+	}
+}
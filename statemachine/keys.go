@@ -18,6 +18,16 @@ var (
 
 	INDEXER_STATUS_LAST_BLOCK_HEIGHT_KEY = []byte{0x06}
 	INDEXER_STATUS_LAST_BLOCK_HASH_KEY   = []byte{0x07}
+
+	EVENT_KEY_PREFIX = []byte{0x08}
+
+	SBTS_SEQUENCE_INDEX_KEY_PREFIX = []byte{0x09}
+	SBTS_SUPPLY_INDEX_KEY_PREFIX   = []byte{0x0a}
+
+	STORE_VERSION_KEY = []byte{0x0b}
+
+	TX_KEY_PREFIX        = []byte{0x0c}
+	COMMIT_TX_KEY_PREFIX = []byte{0x0d}
 )
 
 // GetSBTsKeyPrefix gets the key prefix for iteration over all the SBTs
@@ -30,12 +40,31 @@ func GetSBTsKey(symbol string) []byte {
 	return append(SBTS_KEY_PREFIX, []byte(strings.ToLower(symbol))...)
 }
 
+// GetSBTsKeyPrefixBySymbolPrefix gets the key prefix for iteration over the SBTs of which the symbol starts with the given prefix
+func GetSBTsKeyPrefixBySymbolPrefix(symbolPrefix string) []byte {
+	return append(SBTS_KEY_PREFIX, []byte(strings.ToLower(symbolPrefix))...)
+}
+
 // GetSBTKey gets the store key for the SBT token by the given symbol and id
 func GetSBTKey(symbol string, id uint64) []byte {
 	idBz := make([]byte, 8)
 	binary.BigEndian.PutUint64(idBz, id)
 
-	return append(append(SBT_KEY_PREFIX, []byte(strings.ToLower(symbol))...), idBz...)
+	return append(GetSBTKeyPrefix(symbol), idBz...)
+}
+
+// GetSBTKeyPrefix gets the key prefix for iteration over the SBT tokens of the given symbol.
+// The symbol is terminated by the separator so that the prefix is not shared with the symbols starting with the given symbol
+func GetSBTKeyPrefix(symbol string) []byte {
+	prefix := append(SBT_KEY_PREFIX, []byte(strings.ToLower(symbol))...)
+	prefix = append(prefix, KEY_SEPARATOR)
+
+	return prefix
+}
+
+// GetSBTKeyPrefixAll gets the key prefix for iteration over all the SBT tokens
+func GetSBTKeyPrefixAll() []byte {
+	return SBT_KEY_PREFIX
 }
 
 // GetSBTsSequenceKey gets the store key for the current SBTs sequence
@@ -48,6 +77,32 @@ func GetSBTsSupplyKey(symbol string) []byte {
 	return append(SBTS_SUPPLY_KEY_PREFIX, []byte(strings.ToLower(symbol))...)
 }
 
+// GetSBTsSequenceIndexKeyPrefix gets the key prefix for iteration over the SBTs ordered by the sequence
+func GetSBTsSequenceIndexKeyPrefix() []byte {
+	return SBTS_SEQUENCE_INDEX_KEY_PREFIX
+}
+
+// GetSBTsSequenceIndexKey gets the index key for the SBTs by the given sequence
+func GetSBTsSequenceIndexKey(sequence uint64) []byte {
+	seqBz := make([]byte, 8)
+	binary.BigEndian.PutUint64(seqBz, sequence)
+
+	return append(SBTS_SEQUENCE_INDEX_KEY_PREFIX, seqBz...)
+}
+
+// GetSBTsSupplyIndexKeyPrefix gets the key prefix for iteration over the SBTs ordered by the current supply
+func GetSBTsSupplyIndexKeyPrefix() []byte {
+	return SBTS_SUPPLY_INDEX_KEY_PREFIX
+}
+
+// GetSBTsSupplyIndexKey gets the index key for the given SBTs by the current supply
+func GetSBTsSupplyIndexKey(symbol string, supply uint64) []byte {
+	supplyBz := make([]byte, 8)
+	binary.BigEndian.PutUint64(supplyBz, supply)
+
+	return append(append(SBTS_SUPPLY_INDEX_KEY_PREFIX, supplyBz...), []byte(strings.ToLower(symbol))...)
+}
+
 // GetOwnerSBTKey gets the store key for the SBT token owned by the given owner
 func GetOwnerSBTKey(owner string, symbol string) []byte {
 	prefix := GetOwnerSBTKeyPrefix(strings.ToLower(owner))
@@ -63,6 +118,12 @@ func GetOwnerSBTKeyPrefix(owner string) []byte {
 	return prefix
 }
 
+// GetOwnerSBTKeyPrefixBySymbolPrefix gets the key prefix for iteration over the SBT tokens owned by the given owner,
+// of which the symbol starts with the given prefix
+func GetOwnerSBTKeyPrefixBySymbolPrefix(owner string, symbolPrefix string) []byte {
+	return append(GetOwnerSBTKeyPrefix(owner), []byte(strings.ToLower(symbolPrefix))...)
+}
+
 // GetIndexerLastBlockHeightKey gets the store key for the last block height of the indexer
 func GetIndexerLastBlockHeightKey() []byte {
 	return INDEXER_STATUS_LAST_BLOCK_HEIGHT_KEY
@@ -72,3 +133,37 @@ func GetIndexerLastBlockHeightKey() []byte {
 func GetIndexerLastBlockHashKey() []byte {
 	return INDEXER_STATUS_LAST_BLOCK_HASH_KEY
 }
+
+// GetEventKeyPrefix gets the key prefix for iteration over the events
+func GetEventKeyPrefix() []byte {
+	return EVENT_KEY_PREFIX
+}
+
+// GetEventKey gets the store key for the event by the given block height and event index
+func GetEventKey(blockHeight int64, index uint32) []byte {
+	bz := make([]byte, 12)
+	binary.BigEndian.PutUint64(bz[0:8], uint64(blockHeight))
+	binary.BigEndian.PutUint32(bz[8:12], index)
+
+	return append(EVENT_KEY_PREFIX, bz...)
+}
+
+// GetStoreVersionKey gets the store key for the version of the store layout
+func GetStoreVersionKey() []byte {
+	return STORE_VERSION_KEY
+}
+
+// GetTxKey gets the store key for the tx record by the given tx hash
+func GetTxKey(txHash []byte) []byte {
+	return append(TX_KEY_PREFIX, txHash...)
+}
+
+// GetCommitTxKey gets the index key for the given reveal tx spending the given commit tx
+func GetCommitTxKey(commitTxHash []byte, revealTxHash []byte) []byte {
+	return append(GetCommitTxKeyPrefix(commitTxHash), revealTxHash...)
+}
+
+// GetCommitTxKeyPrefix gets the key prefix for iteration over the reveal txs spending the given commit tx
+func GetCommitTxKeyPrefix(commitTxHash []byte) []byte {
+	return append(COMMIT_TX_KEY_PREFIX, commitTxHash...)
+}
@@ -1,42 +1,15 @@
 package statemachine
 
 import (
+	"fmt"
+	"sort"
+
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 
 	"btc-sbt/store"
 	"btc-sbt/types"
 )
 
-// GetAllSBTs queries all the SBTs from the store
-func (sm *StateMachine) GetAllSBTs() ([]*types.SBTs, error) {
-	iter, err := sm.Store.Iterator(GetSBTsKeyPrefix())
-	if err != nil {
-		return nil, err
-	}
-
-	defer iter.Close()
-
-	collections := make([]*types.SBTs, 0)
-
-	for iter.First(); iter.Valid(); iter.Next() {
-		var sbts types.SBTs
-		if err := sbts.Unmarshal(iter.Value()); err != nil {
-			return nil, err
-		}
-
-		supply, err := sm.GetSBTsSupply(sbts.Symbol)
-		if err != nil {
-			return nil, err
-		}
-
-		sbts.TotalSupply = supply
-
-		collections = append(collections, &sbts)
-	}
-
-	return collections, nil
-}
-
 // GetSBTs queries the SBTs by the given symbol from the store
 func (sm *StateMachine) GetSBTs(symbol string) (*types.SBTs, error) {
 	key := GetSBTsKey(symbol)
@@ -110,29 +83,6 @@ func (sm *StateMachine) GetSBTsSupply(symbol string) (uint64, error) {
 	return supply, nil
 }
 
-// GetOwnedSBTs queries the SBT tokens owned by the given owner from the store
-func (sm *StateMachine) GetOwnedSBTs(owner string) ([]*types.CompactSBT, error) {
-	iter, err := sm.Store.Iterator(GetOwnerSBTKeyPrefix(owner))
-	if err != nil {
-		return nil, err
-	}
-
-	defer iter.Close()
-
-	sbts := make([]*types.CompactSBT, 0)
-
-	for iter.First(); iter.Valid(); iter.Next() {
-		var sbt types.CompactSBT
-		if err := sbt.Unmarshal(iter.Value()); err != nil {
-			return nil, err
-		}
-
-		sbts = append(sbts, &sbt)
-	}
-
-	return sbts, nil
-}
-
 // GetOwnedSBT queries the specified SBT token owned by the given owner from the store
 func (sm *StateMachine) GetOwnedSBT(owner string, symbol string) (*types.CompactSBT, error) {
 	key := GetOwnerSBTKey(owner, symbol)
@@ -196,3 +146,259 @@ func (sm *StateMachine) SBTExists(symbol string, id uint64) (bool, error) {
 func (sm *StateMachine) HasOwnedSBT(address string, symbol string) (bool, error) {
 	return sm.Store.Exist(GetOwnerSBTKey(address, symbol))
 }
+
+// GetEvents queries at most `limit` events matching the given filter, starting from the given cursor
+func (sm *StateMachine) GetEvents(cursor types.EventCursor, filter *types.EventFilter, limit int) ([]*types.Event, error) {
+	iter, err := sm.Store.Iterator(GetEventKeyPrefix())
+	if err != nil {
+		return nil, err
+	}
+
+	defer iter.Close()
+
+	events := make([]*types.Event, 0)
+
+	for iter.SeekGE(GetEventKey(cursor.BlockHeight, cursor.Index)); iter.Valid() && len(events) < limit; iter.Next() {
+		var event types.Event
+		if err := event.Unmarshal(iter.Value()); err != nil {
+			return nil, err
+		}
+
+		if filter.Match(&event) {
+			events = append(events, &event)
+		}
+	}
+
+	return events, nil
+}
+
+// QuerySBTs queries the SBTs page by the given query.
+// Returns the SBTs along with the cursor for the next page
+func (sm *StateMachine) QuerySBTs(query *types.SBTsQuery) ([]*types.SBTs, string, error) {
+	blockHeight, err := sm.GetLastBlockHeight()
+	if err != nil {
+		return nil, "", err
+	}
+
+	if query.BlockHeight > 0 {
+		if query.SortBy == types.SORT_BY_SUPPLY {
+			return nil, "", fmt.Errorf("sorting by supply is only supported for the latest state")
+		}
+
+		if err := sm.CheckBlockHeight(query.BlockHeight); err != nil {
+			return nil, "", err
+		}
+
+		blockHeight = query.BlockHeight
+	}
+
+	collections := make([]*types.SBTs, 0)
+
+	accept := func(sbts *types.SBTs) (bool, error) {
+		if query.BlockHeight > 0 {
+			var err error
+
+			sbts, err = sm.rewindSBTs(sbts, blockHeight)
+			if err != nil || sbts == nil {
+				return false, err
+			}
+		}
+
+		if query.Match(sbts, blockHeight) {
+			collections = append(collections, sbts)
+			return true, nil
+		}
+
+		return false, nil
+	}
+
+	var next string
+
+	switch query.SortBy {
+	case types.SORT_BY_SEQUENCE, types.SORT_BY_HEIGHT:
+		// the sequence increases in the order of issuing
+		next, err = sm.paginate(GetSBTsSequenceIndexKeyPrefix(), &query.Pagination, func(key []byte, value []byte) (bool, error) {
+			sbts, err := sm.GetSBTs(string(value))
+			if err != nil || sbts == nil {
+				return false, err
+			}
+
+			return accept(sbts)
+		})
+
+	case types.SORT_BY_SUPPLY:
+		next, err = sm.paginate(GetSBTsSupplyIndexKeyPrefix(), &query.Pagination, func(key []byte, value []byte) (bool, error) {
+			sbts, err := sm.GetSBTs(string(value))
+			if err != nil || sbts == nil {
+				return false, err
+			}
+
+			return accept(sbts)
+		})
+
+	default:
+		next, err = sm.paginate(GetSBTsKeyPrefixBySymbolPrefix(query.SymbolPrefix), &query.Pagination, func(key []byte, value []byte) (bool, error) {
+			var sbts types.SBTs
+			if err := sbts.Unmarshal(value); err != nil {
+				return false, err
+			}
+
+			supply, err := sm.GetSBTsSupply(sbts.Symbol)
+			if err != nil {
+				return false, err
+			}
+
+			sbts.TotalSupply = supply
+
+			return accept(&sbts)
+		})
+	}
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return collections, next, nil
+}
+
+// QueryOwnedSBTs queries the page of the SBT tokens owned by the given owner.
+// Returns the SBT tokens along with the cursor for the next page
+func (sm *StateMachine) QueryOwnedSBTs(query *types.OwnedSBTsQuery) ([]*types.CompactSBT, string, error) {
+	sbts := make([]*types.CompactSBT, 0)
+
+	if query.BlockHeight > 0 {
+		if err := sm.CheckBlockHeight(query.BlockHeight); err != nil {
+			return nil, "", err
+		}
+	}
+
+	next, err := sm.paginate(GetOwnerSBTKeyPrefixBySymbolPrefix(query.Owner, query.SymbolPrefix), &query.Pagination, func(key []byte, value []byte) (bool, error) {
+		var sbt types.CompactSBT
+		if err := sbt.Unmarshal(value); err != nil {
+			return false, err
+		}
+
+		if query.BlockHeight > 0 {
+			// the compact SBT does not keep the mint block height
+			minted, err := sm.GetSBTAt(sbt.Symbol, sbt.Id, query.BlockHeight)
+			if err != nil || minted == nil {
+				return false, err
+			}
+		}
+
+		sbts = append(sbts, &sbt)
+
+		return true, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return sbts, next, nil
+}
+
+// QueryTokens queries the page of the SBT tokens of the given symbol, ordered by the token id.
+// Returns the SBT tokens along with the cursor for the next page
+func (sm *StateMachine) QueryTokens(query *types.TokensQuery) ([]*types.SBT, string, error) {
+	sbts := make([]*types.SBT, 0)
+
+	next, err := sm.paginate(GetSBTKeyPrefix(query.Symbol), &query.Pagination, func(key []byte, value []byte) (bool, error) {
+		var sbt types.SBT
+		if err := sbt.Unmarshal(value); err != nil {
+			return false, err
+		}
+
+		sbts = append(sbts, &sbt)
+
+		return true, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+
+	return sbts, next, nil
+}
+
+// GetTxRecord queries the tx record by the given reveal tx hash from the store
+func (sm *StateMachine) GetTxRecord(txHash *chainhash.Hash) (*types.TxRecord, error) {
+	bz, err := sm.Store.Get(GetTxKey(txHash[:]))
+	if err != nil && !store.IsNotFoundErr(err) {
+		return nil, err
+	}
+
+	if len(bz) == 0 {
+		return nil, nil
+	}
+
+	var record types.TxRecord
+	if err := record.Unmarshal(bz); err != nil {
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// GetTxRecords queries the tx record of the given reveal tx, or the records of all the reveal txs spending the given commit tx.
+// The records are sorted in the order of indexing
+func (sm *StateMachine) GetTxRecords(txHash *chainhash.Hash) ([]*types.TxRecord, error) {
+	record, err := sm.GetTxRecord(txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if record != nil {
+		return []*types.TxRecord{record}, nil
+	}
+
+	iter, err := sm.Store.Iterator(GetCommitTxKeyPrefix(txHash[:]))
+	if err != nil {
+		return nil, err
+	}
+
+	defer iter.Close()
+
+	records := make([]*types.TxRecord, 0)
+
+	for iter.First(); iter.Valid(); iter.Next() {
+		revealTxHash, err := chainhash.NewHash(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+
+		record, err := sm.GetTxRecord(revealTxHash)
+		if err != nil {
+			return nil, err
+		}
+
+		if record != nil {
+			records = append(records, record)
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].BlockHeight != records[j].BlockHeight {
+			return records[i].BlockHeight < records[j].BlockHeight
+		}
+
+		return records[i].TransactionIndex < records[j].TransactionIndex
+	})
+
+	return records, nil
+}
+
+// GetTx queries the tx record of the given reveal tx, or the records of all the reveal txs spending the given commit tx,
+// along with the resulting SBTs or SBT of each applied operation
+func (sm *StateMachine) GetTx(txHash *chainhash.Hash) ([]*types.TxRecord, error) {
+	records, err := sm.GetTxRecords(txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, record := range records {
+		if err := sm.ResolveOpResults(record.Results); err != nil {
+			return nil, err
+		}
+	}
+
+	return records, nil
+}
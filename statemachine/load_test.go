@@ -0,0 +1,201 @@
+package statemachine
+
+import (
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+
+	"btc-sbt/types"
+)
+
+func TestQueryTokensExactSymbol(t *testing.T) {
+	sm := newTestStateMachine(t)
+
+	for _, sbt := range []*types.SBT{
+		types.NewSBT("ab", 0, "owner0", "", 1, 1, ""),
+		types.NewSBT("ab", 1, "owner1", "", 1, 2, ""),
+		types.NewSBT("abc", 0, "owner2", "", 1, 3, ""),
+		types.NewSBT("AB1", 0, "owner3", "", 1, 4, ""),
+	} {
+		if err := sm.SetSBT(sbt); err != nil {
+			t.Fatalf("failed to set the SBT: %v", err)
+		}
+	}
+
+	tests := []struct {
+		symbol  string
+		reverse bool
+		owners  []string
+	}{
+		{"ab", false, []string{"owner0", "owner1"}},
+		{"AB", true, []string{"owner1", "owner0"}},
+		{"abc", false, []string{"owner2"}},
+		{"ab1", false, []string{"owner3"}},
+		{"a", false, []string{}},
+	}
+
+	for _, test := range tests {
+		sbts, next, err := sm.QueryTokens(&types.TokensQuery{Symbol: test.symbol, Pagination: types.Pagination{Reverse: test.reverse}})
+		if err != nil {
+			t.Fatalf("%s: failed to query: %v", test.symbol, err)
+		}
+
+		if len(next) != 0 {
+			t.Fatalf("%s: got next cursor %s; want none", test.symbol, next)
+		}
+
+		if len(sbts) != len(test.owners) {
+			t.Fatalf("%s: got %d tokens; want %d", test.symbol, len(sbts), len(test.owners))
+		}
+
+		for idx, sbt := range sbts {
+			if sbt.Owner != test.owners[idx] {
+				t.Fatalf("%s: got owner %s at %d; want %s", test.symbol, sbt.Owner, idx, test.owners[idx])
+			}
+		}
+	}
+}
+
+func TestQueryTokensPagination(t *testing.T) {
+	sm := newTestStateMachine(t)
+
+	for id := uint64(0); id < 5; id++ {
+		if err := sm.SetSBT(types.NewSBT("ab", id, "owner", "", 1, int(id), "")); err != nil {
+			t.Fatalf("failed to set the SBT: %v", err)
+		}
+	}
+
+	if err := sm.SetSBT(types.NewSBT("abc", 0, "owner", "", 1, 5, "")); err != nil {
+		t.Fatalf("failed to set the SBT: %v", err)
+	}
+
+	query := &types.TokensQuery{Symbol: "ab", Pagination: types.Pagination{Limit: 2}}
+	ids := make([]uint64, 0)
+
+	for {
+		sbts, next, err := sm.QueryTokens(query)
+		if err != nil {
+			t.Fatalf("failed to query: %v", err)
+		}
+
+		for _, sbt := range sbts {
+			ids = append(ids, sbt.Id)
+		}
+
+		if len(next) == 0 {
+			break
+		}
+
+		query.Cursor = next
+	}
+
+	if len(ids) != 5 {
+		t.Fatalf("got ids %v; want 0 to 4", ids)
+	}
+
+	for idx, id := range ids {
+		if id != uint64(idx) {
+			t.Fatalf("got ids %v; want 0 to 4", ids)
+		}
+	}
+}
+
+func TestGetTxRecordsByCommit(t *testing.T) {
+	sm := newTestStateMachine(t)
+
+	commit := chainhash.HashH([]byte("commit"))
+	reveals := []chainhash.Hash{chainhash.HashH([]byte("reveal0")), chainhash.HashH([]byte("reveal1")), chainhash.HashH([]byte("reveal2"))}
+
+	// indexed out of the hash order
+	for idx, reveal := range reveals {
+		record := types.NewTxRecord(reveal.String(), []string{commit.String()}, 10, "", len(reveals)-idx, nil)
+		if err := sm.SetTxRecord(record); err != nil {
+			t.Fatalf("failed to set the tx record: %v", err)
+		}
+	}
+
+	records, err := sm.GetTxRecords(&commit)
+	if err != nil {
+		t.Fatalf("failed to get the tx records: %v", err)
+	}
+
+	if len(records) != len(reveals) {
+		t.Fatalf("got %d records; want %d", len(records), len(reveals))
+	}
+
+	for idx, record := range records {
+		if want := reveals[len(reveals)-1-idx].String(); record.TxHash != want {
+			t.Fatalf("got reveal %s at %d; want %s", record.TxHash, idx, want)
+		}
+	}
+
+	records, err = sm.GetTxRecords(&reveals[1])
+	if err != nil || len(records) != 1 || records[0].TxHash != reveals[1].String() {
+		t.Fatalf("got %+v, %v; want the record of reveal %s", records, err, reveals[1])
+	}
+
+	unknown := chainhash.HashH([]byte("unknown"))
+
+	records, err = sm.GetTxRecords(&unknown)
+	if err != nil || len(records) != 0 {
+		t.Fatalf("got %+v, %v; want none", records, err)
+	}
+}
+
+func TestQuerySBTsIssuerCaseInsensitive(t *testing.T) {
+	sm := newTestStateMachine(t)
+
+	issuer := "bcrt1pissuer"
+
+	if err := sm.SetSBTs(types.NewSBTs("abc", 1, 100, "", 0, "", issuer, 10, 0, "", 0)); err != nil {
+		t.Fatalf("failed to set the SBTs: %v", err)
+	}
+
+	for _, query := range []string{issuer, strings.ToUpper(issuer)} {
+		collections, _, err := sm.QuerySBTs(&types.SBTsQuery{Issuer: query})
+		if err != nil || len(collections) != 1 {
+			t.Fatalf("%s: got %d collections, %v; want 1", query, len(collections), err)
+		}
+	}
+}
+
+func TestPaginateScanBounded(t *testing.T) {
+	sm := newTestStateMachine(t)
+
+	prefix := []byte{0xff}
+	total := types.MAX_PAGE_SCAN + 5
+
+	for idx := 0; idx < total; idx++ {
+		key := binary.BigEndian.AppendUint32(append([]byte{}, prefix...), uint32(idx))
+		if err := sm.Store.Set(key, nil); err != nil {
+			t.Fatalf("failed to set: %v", err)
+		}
+	}
+
+	// only the last entry matches
+	accepted := 0
+	accept := func(key []byte, value []byte) (bool, error) {
+		if binary.BigEndian.Uint32(key[len(prefix):]) == uint32(total-1) {
+			accepted++
+			return true, nil
+		}
+
+		return false, nil
+	}
+
+	pagination := &types.Pagination{Limit: 10}
+
+	next, err := sm.paginate(prefix, pagination, accept)
+	if err != nil || len(next) == 0 || accepted != 0 {
+		t.Fatalf("first page: got cursor %q, %d accepted, %v; want the cursor of the empty page", next, accepted, err)
+	}
+
+	pagination.Cursor = next
+
+	next, err = sm.paginate(prefix, pagination, accept)
+	if err != nil || len(next) != 0 || accepted != 1 {
+		t.Fatalf("second page: got cursor %q, %d accepted, %v; want the last entry", next, accepted, err)
+	}
+}
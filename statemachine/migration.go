@@ -0,0 +1,145 @@
+package statemachine
+
+import (
+	"bytes"
+	"fmt"
+
+	"btc-sbt/store"
+	"btc-sbt/types"
+)
+
+// Current version of the store layout
+const STORE_VERSION = 2
+
+// migrations defines the migrations by the target store version
+var migrations = map[uint64]func(sm *StateMachine) error{
+	1: buildSBTsIndexes,
+	2: separateSBTKeys,
+}
+
+// Migrate migrates the store to the current layout version
+func (sm *StateMachine) Migrate() error {
+	version, err := sm.GetStoreVersion()
+	if err != nil {
+		return err
+	}
+
+	for v := version + 1; v <= STORE_VERSION; v++ {
+		sm.Logger.Infof("migrating the store to version %d", v)
+
+		if err := migrations[v](sm); err != nil {
+			return err
+		}
+
+		if err := sm.SetStoreVersion(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckVersion checks if the store is of the current layout version, to which the store is migrated by the node
+func (sm *StateMachine) CheckVersion() error {
+	version, err := sm.GetStoreVersion()
+	if err != nil {
+		return err
+	}
+
+	if version < STORE_VERSION {
+		return fmt.Errorf("the store of version %d requires migration to version %d, start the node to migrate it", version, STORE_VERSION)
+	}
+
+	if version > STORE_VERSION {
+		return fmt.Errorf("the store of version %d is newer than the supported version %d", version, STORE_VERSION)
+	}
+
+	return nil
+}
+
+// GetStoreVersion queries the version of the store layout
+func (sm *StateMachine) GetStoreVersion() (uint64, error) {
+	version, err := sm.Store.GetUint64(GetStoreVersionKey())
+	if err != nil && !store.IsNotFoundErr(err) {
+		return 0, err
+	}
+
+	return version, nil
+}
+
+// SetStoreVersion sets the version of the store layout
+func (sm *StateMachine) SetStoreVersion(version uint64) error {
+	return sm.Store.SetUint64(GetStoreVersionKey(), version)
+}
+
+// buildSBTsIndexes builds the sequence and supply indexes for the existing SBTs
+func buildSBTsIndexes(sm *StateMachine) error {
+	iter, err := sm.Store.Iterator(GetSBTsKeyPrefix())
+	if err != nil {
+		return err
+	}
+
+	defer iter.Close()
+
+	for iter.First(); iter.Valid(); iter.Next() {
+		var sbts types.SBTs
+		if err := sbts.Unmarshal(iter.Value()); err != nil {
+			return err
+		}
+
+		if err := sm.setSBTsSequenceIndex(sbts.Symbol, sbts.Sequence); err != nil {
+			return err
+		}
+
+		supply, err := sm.GetSBTsSupply(sbts.Symbol)
+		if err != nil {
+			return err
+		}
+
+		if err := sm.SetSBTsSupply(sbts.Symbol, supply); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// separateSBTKeys rewrites the store keys of the SBT tokens from the layout without the separator following the symbol
+func separateSBTKeys(sm *StateMachine) error {
+	iter, err := sm.Store.Iterator(GetSBTKeyPrefixAll())
+	if err != nil {
+		return err
+	}
+
+	sbts := make([]*types.SBT, 0)
+	keys := make([][]byte, 0)
+
+	for iter.First(); iter.Valid(); iter.Next() {
+		var sbt types.SBT
+		if err := sbt.Unmarshal(iter.Value()); err != nil {
+			iter.Close()
+			return err
+		}
+
+		if !bytes.Equal(iter.Key(), GetSBTKey(sbt.Symbol, sbt.Id)) {
+			sbts = append(sbts, &sbt)
+			keys = append(keys, append([]byte(nil), iter.Key()...))
+		}
+	}
+
+	if err := iter.Close(); err != nil {
+		return err
+	}
+
+	for idx, sbt := range sbts {
+		if err := sm.Store.Delete(keys[idx]); err != nil {
+			return err
+		}
+
+		if err := sm.SetSBT(sbt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package statemachine
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+
+	"btc-sbt/logger"
+	"btc-sbt/store"
+	"btc-sbt/types"
+)
+
+func TestOpenMigrates(t *testing.T) {
+	path := t.TempDir()
+
+	sm, err := Open(path, &chaincfg.RegressionNetParams, logger.Logger)
+	if err != nil {
+		t.Fatalf("failed to open: %v", err)
+	}
+
+	version, err := sm.GetStoreVersion()
+	if err != nil || version != STORE_VERSION {
+		t.Fatalf("got version %d, %v; want %d", version, err, STORE_VERSION)
+	}
+
+	sm.Store.Close()
+
+	readOnly, err := OpenReadOnly(path, &chaincfg.RegressionNetParams, logger.Logger)
+	if err != nil {
+		t.Fatalf("failed to open read-only: %v", err)
+	}
+
+	readOnly.Store.Close()
+}
+
+func TestOpenReadOnlyUnmigrated(t *testing.T) {
+	path := t.TempDir()
+
+	s, err := store.NewStore(path)
+	if err != nil {
+		t.Fatalf("failed to open the store: %v", err)
+	}
+
+	s.Close()
+
+	if _, err := OpenReadOnly(path, &chaincfg.RegressionNetParams, logger.Logger); err == nil {
+		t.Fatalf("got nil; want the migration required error")
+	}
+}
+
+func TestSeparateSBTKeys(t *testing.T) {
+	sm := newTestStateMachine(t)
+
+	sbts := []*types.SBT{
+		types.NewSBT("ab", 0, "owner0", "", 1, 1, ""),
+		types.NewSBT("abc", 0, "owner1", "", 1, 2, ""),
+	}
+
+	// the layout without the separator
+	for _, sbt := range sbts {
+		bz, err := sbt.Marshal()
+		if err != nil {
+			t.Fatalf("failed to marshal: %v", err)
+		}
+
+		key := append(append([]byte{}, SBT_KEY_PREFIX...), []byte(sbt.Symbol)...)
+		key = append(key, 0, 0, 0, 0, 0, 0, 0, byte(sbt.Id))
+
+		if err := sm.Store.Set(key, bz); err != nil {
+			t.Fatalf("failed to set: %v", err)
+		}
+	}
+
+	if err := separateSBTKeys(sm); err != nil {
+		t.Fatalf("failed to migrate: %v", err)
+	}
+
+	for _, sbt := range sbts {
+		got, err := sm.GetSBT(sbt.Symbol, sbt.Id)
+		if err != nil || got == nil || got.Owner != sbt.Owner {
+			t.Fatalf("%s: got %+v, %v; want owner %s", sbt.Symbol, got, err, sbt.Owner)
+		}
+	}
+
+	iter, err := sm.Store.Iterator(GetSBTKeyPrefixAll())
+	if err != nil {
+		t.Fatalf("failed to iterate: %v", err)
+	}
+
+	defer iter.Close()
+
+	count := 0
+	for iter.First(); iter.Valid(); iter.Next() {
+		count++
+	}
+
+	if count != len(sbts) {
+		t.Fatalf("got %d keys; want %d", count, len(sbts))
+	}
+}
@@ -0,0 +1,101 @@
+package statemachine
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/cockroachdb/pebble"
+
+	"btc-sbt/types"
+)
+
+// paginate iterates over the entries with the given key prefix from the cursor of the pagination.
+// `accept` is called on every entry and reports if the entry is included in the page.
+// At most MAX_PAGE_SCAN entries are examined so that a selective filter does not scan the whole prefix,
+// in which case the page may hold fewer items than the limit, even none.
+// Returns the cursor for the next page, which is empty if there are no more entries
+func (sm *StateMachine) paginate(prefix []byte, pagination *types.Pagination, accept func(key []byte, value []byte) (bool, error)) (string, error) {
+	cursor, err := decodeCursor(prefix, pagination.Cursor)
+	if err != nil {
+		return "", err
+	}
+
+	iter, err := sm.Store.Iterator(prefix)
+	if err != nil {
+		return "", err
+	}
+
+	defer iter.Close()
+
+	limit := pagination.GetLimit()
+	count := 0
+	examined := 0
+
+	var lastKey []byte
+
+	for valid := seek(iter, cursor, pagination.Reverse); valid; valid = step(iter, pagination.Reverse) {
+		if count == limit || examined == types.MAX_PAGE_SCAN {
+			return hex.EncodeToString(lastKey), nil
+		}
+
+		ok, err := accept(iter.Key(), iter.Value())
+		if err != nil {
+			return "", err
+		}
+
+		if ok {
+			count++
+		}
+
+		// the next page starts after the last examined entry, accepted or not
+		examined++
+		lastKey = append(lastKey[:0], iter.Key()...)
+	}
+
+	return "", nil
+}
+
+// seek positions the iterator at the first entry after the cursor in the given direction
+func seek(iter *pebble.Iterator, cursor []byte, reverse bool) bool {
+	if cursor == nil {
+		if reverse {
+			return iter.Last()
+		}
+
+		return iter.First()
+	}
+
+	if reverse {
+		return iter.SeekLT(cursor)
+	}
+
+	if iter.SeekGE(cursor) && bytes.Equal(iter.Key(), cursor) {
+		return iter.Next()
+	}
+
+	return iter.Valid()
+}
+
+// step moves the iterator to the next entry in the given direction
+func step(iter *pebble.Iterator, reverse bool) bool {
+	if reverse {
+		return iter.Prev()
+	}
+
+	return iter.Next()
+}
+
+// decodeCursor decodes the cursor and checks if it belongs to the given key prefix
+func decodeCursor(prefix []byte, cursor string) ([]byte, error) {
+	if len(cursor) == 0 {
+		return nil, nil
+	}
+
+	key, err := hex.DecodeString(cursor)
+	if err != nil || !bytes.HasPrefix(key, prefix) {
+		return nil, fmt.Errorf("invalid cursor: %s", cursor)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,61 @@
+package statemachine
+
+import (
+	"btc-sbt/protocol"
+	"btc-sbt/types"
+)
+
+// NewOverlay creates a state machine on top of a throwaway overlay of the current store.
+// The overlay store must be closed to release the resources
+func (sm *StateMachine) NewOverlay() *StateMachine {
+	return NewStateMachine(sm.Store.NewOverlay(), sm.NetParams, sm.Logger)
+}
+
+// Simulate handles the given operations against the current state without persisting anything.
+// The results recorded in the context are resolved against the simulated state
+func (sm *StateMachine) Simulate(ctx *Context, ops []protocol.Operation) error {
+	overlay := sm.NewOverlay()
+	defer overlay.Store.Close()
+
+	if err := overlay.HandleOps(ctx, ops); err != nil {
+		return err
+	}
+
+	return overlay.ResolveOpResults(ctx.Results)
+}
+
+// ResolveOpResults populates the resulting SBTs or SBT for the applied operations
+func (sm *StateMachine) ResolveOpResults(results []*types.OpResult) error {
+	for _, result := range results {
+		if !result.Valid {
+			continue
+		}
+
+		switch result.Type {
+		case protocol.OP_ISSUE:
+			sbts, err := sm.GetSBTs(result.Symbol)
+			if err != nil {
+				return err
+			}
+
+			result.SBTs = sbts
+
+		case protocol.OP_MINT:
+			owned, err := sm.GetOwnedSBT(result.Owner, result.Symbol)
+			if err != nil {
+				return err
+			}
+
+			if owned != nil {
+				sbt, err := sm.GetSBT(result.Symbol, owned.Id)
+				if err != nil {
+					return err
+				}
+
+				result.SBT = sbt
+			}
+		}
+	}
+
+	return nil
+}
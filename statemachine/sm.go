@@ -25,3 +25,43 @@ func NewStateMachine(store *store.Store, netParams *chaincfg.Params, logger *log
 		Logger:    logger,
 	}
 }
+
+// Open opens the store at the given path and migrates it to the current layout version
+func Open(path string, netParams *chaincfg.Params, logger *logrus.Logger) (*StateMachine, error) {
+	s, err := store.NewStore(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sm := NewStateMachine(s, netParams, logger)
+
+	if err := sm.Migrate(); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return sm, nil
+}
+
+// OpenReadOnly opens the store at the given path in the read-only mode, which must be of the current layout version
+func OpenReadOnly(path string, netParams *chaincfg.Params, logger *logrus.Logger) (*StateMachine, error) {
+	s, err := store.NewReadOnlyStore(path)
+	if err != nil {
+		return nil, err
+	}
+
+	sm := NewStateMachine(s, netParams, logger)
+
+	if err := sm.CheckVersion(); err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return sm, nil
+}
+
+// NewBatch creates a state machine on top of a batch of the current store.
+// The state transition is persisted atomically by committing the batch store, which must be closed afterwards
+func (sm *StateMachine) NewBatch() *StateMachine {
+	return NewStateMachine(sm.Store.NewBatch(), sm.NetParams, sm.Logger)
+}
@@ -0,0 +1,21 @@
+package statemachine
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+
+	"btc-sbt/logger"
+)
+
+// newTestStateMachine opens a migrated state machine on a temporary store
+func newTestStateMachine(t *testing.T) *StateMachine {
+	sm, err := Open(t.TempDir(), &chaincfg.RegressionNetParams, logger.Logger)
+	if err != nil {
+		t.Fatalf("failed to open the state machine: %v", err)
+	}
+
+	t.Cleanup(func() { sm.Store.Close() })
+
+	return sm
+}
@@ -1,6 +1,8 @@
 package statemachine
 
 import (
+	"strings"
+
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 
 	"btc-sbt/types"
@@ -15,7 +17,11 @@ func (sm *StateMachine) SetSBTs(sbts *types.SBTs) error {
 
 	key := GetSBTsKey(sbts.Symbol)
 
-	return sm.Store.Set(key, bz)
+	if err := sm.Store.Set(key, bz); err != nil {
+		return err
+	}
+
+	return sm.setSBTsSequenceIndex(sbts.Symbol, sbts.Sequence)
 }
 
 // SetSBT sets the given SBT token in the store
@@ -53,9 +59,36 @@ func (sm *StateMachine) IncreaseSBTsSequence() (uint64, error) {
 
 // SetSBTsSupply sets the current supply of the given SBTs in the store
 func (sm *StateMachine) SetSBTsSupply(symbol string, supply uint64) error {
+	prevSupply, err := sm.GetSBTsSupply(symbol)
+	if err != nil {
+		return err
+	}
+
 	key := GetSBTsSupplyKey(symbol)
 
-	return sm.Store.SetUint64(key, supply)
+	if err := sm.Store.SetUint64(key, supply); err != nil {
+		return err
+	}
+
+	return sm.setSBTsSupplyIndex(symbol, prevSupply, supply)
+}
+
+// setSBTsSequenceIndex indexes the given SBTs by the sequence
+func (sm *StateMachine) setSBTsSequenceIndex(symbol string, sequence uint64) error {
+	key := GetSBTsSequenceIndexKey(sequence)
+
+	return sm.Store.Set(key, []byte(strings.ToLower(symbol)))
+}
+
+// setSBTsSupplyIndex re-indexes the given SBTs by the current supply
+func (sm *StateMachine) setSBTsSupplyIndex(symbol string, prevSupply uint64, supply uint64) error {
+	if err := sm.Store.Delete(GetSBTsSupplyIndexKey(symbol, prevSupply)); err != nil {
+		return err
+	}
+
+	key := GetSBTsSupplyIndexKey(symbol, supply)
+
+	return sm.Store.Set(key, []byte(strings.ToLower(symbol)))
 }
 
 // SetOwnerSBT sets the SBT token by the given owner in the store
@@ -83,3 +116,52 @@ func (sm *StateMachine) SetLastBlockHash(hash chainhash.Hash) error {
 
 	return sm.Store.Set(key, hash[:])
 }
+
+// SetEvents sets the given events in the store
+func (sm *StateMachine) SetEvents(events []*types.Event) error {
+	for _, event := range events {
+		bz, err := event.Marshal()
+		if err != nil {
+			return err
+		}
+
+		key := GetEventKey(event.BlockHeight, event.Index)
+
+		if err := sm.Store.Set(key, bz); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SetTxRecord sets the given tx record in the store, indexed by both the reveal tx hash and the commit tx hashes.
+// A commit tx may be spent by many reveal txs, each of which is indexed under the commit tx hash
+func (sm *StateMachine) SetTxRecord(record *types.TxRecord) error {
+	bz, err := record.Marshal()
+	if err != nil {
+		return err
+	}
+
+	txHash, err := chainhash.NewHashFromStr(record.TxHash)
+	if err != nil {
+		return err
+	}
+
+	if err := sm.Store.Set(GetTxKey(txHash[:]), bz); err != nil {
+		return err
+	}
+
+	for _, commitTxHashStr := range record.CommitTxHashes {
+		commitTxHash, err := chainhash.NewHashFromStr(commitTxHashStr)
+		if err != nil {
+			return err
+		}
+
+		if err := sm.Store.Set(GetCommitTxKey(commitTxHash[:], txHash[:]), txHash[:]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
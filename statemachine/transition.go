@@ -9,13 +9,16 @@ import (
 	"btc-sbt/types"
 )
 
-// HandleOps handles the specified protocol operations in the given context
+// HandleOps handles the specified protocol operations in the given context.
+// The result of each operation is recorded in the context
 func (sm *StateMachine) HandleOps(ctx *Context, ops []protocol.Operation) error {
 	for _, op := range ops {
 		err := sm.HandleOp(ctx, op)
 		if err != nil && IsExecutionFailedErr(err) {
 			return err
 		}
+
+		ctx.AddResult(op, err)
 	}
 
 	return nil
@@ -66,6 +69,12 @@ func (sm *StateMachine) HandleIssue(ctx *Context, op *protocol.IssueOperation) e
 		return wrapError(ExecutionFailedErr, err)
 	}
 
+	if err := sm.SetSBTsSupply(sbts.Symbol, 0); err != nil {
+		return wrapError(ExecutionFailedErr, err)
+	}
+
+	ctx.EmitEvent(types.NewIssueEvent(sbts))
+
 	return nil
 }
 
@@ -134,5 +143,7 @@ func (sm *StateMachine) HandleMint(ctx *Context, op *protocol.MintOperation) err
 		return wrapError(ExecutionFailedErr, err)
 	}
 
+	ctx.EmitEvent(types.NewMintEvent(sbt))
+
 	return nil
 }
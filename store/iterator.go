@@ -6,7 +6,7 @@ import (
 
 // Iterator gets the iterator by the given key prefix
 func (s *Store) Iterator(prefix []byte) (*pebble.Iterator, error) {
-	return s.db.NewIter(getPrefixIterOptions(prefix))
+	return s.rw.NewIter(getPrefixIterOptions(prefix))
 }
 
 // getPrefixIterOptions gets the iterator options by the given key prefix
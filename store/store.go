@@ -3,6 +3,7 @@ package store
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 
 	"github.com/sirupsen/logrus"
 
@@ -11,9 +12,16 @@ import (
 	"btc-sbt/logger"
 )
 
+// readWriter is implemented by both the db and the indexed batch
+type readWriter interface {
+	pebble.Reader
+	pebble.Writer
+}
+
 // Store defines a struct for data store
 type Store struct {
 	db *pebble.DB
+	rw readWriter // the db itself, or the indexed batch for the overlay
 }
 
 // NewStore constructs a new Store instance
@@ -25,12 +33,65 @@ func NewStore(path string) (*Store, error) {
 
 	return &Store{
 		db: db,
+		rw: db,
+	}, nil
+}
+
+// NewReadOnlyStore constructs a new Store instance in the read-only mode.
+// Note that the db can not be opened while being used by another process, e.g. the running node
+func NewReadOnlyStore(path string) (*Store, error) {
+	opts := getDefaultOptions()
+	opts.ReadOnly = true
+
+	db, err := pebble.Open(path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Store{
+		db: db,
+		rw: db,
 	}, nil
 }
 
+// NewOverlay creates a throwaway overlay on top of the store.
+// Writes to the overlay are visible to the reads from the overlay but never persisted
+func (s *Store) NewOverlay() *Store {
+	return s.NewBatch()
+}
+
+// NewBatch creates a batch on top of the store.
+// Writes to the batch are visible to the reads from the batch, and persisted atomically by Commit
+func (s *Store) NewBatch() *Store {
+	return &Store{
+		db: s.db,
+		rw: s.db.NewIndexedBatch(),
+	}
+}
+
+// Commit persists the writes of the batch atomically.
+// The batch must be closed afterwards to release the resources
+func (s *Store) Commit() error {
+	batch, ok := s.rw.(*pebble.Batch)
+	if !ok {
+		return fmt.Errorf("the store is not a batch")
+	}
+
+	return batch.Commit(pebble.Sync)
+}
+
+// Close closes the store. The writes are discarded if the store is an uncommitted batch or an overlay
+func (s *Store) Close() error {
+	if batch, ok := s.rw.(*pebble.Batch); ok {
+		return batch.Close()
+	}
+
+	return s.db.Close()
+}
+
 // Set writes the given key-value into the store
 func (s *Store) Set(key, value []byte) error {
-	return s.db.Set(key, value, pebble.Sync)
+	return s.rw.Set(key, value, pebble.Sync)
 }
 
 // SetUint64 is a convenience to store the uint64 typed value
@@ -48,14 +109,15 @@ func (s *Store) SetInt64(key []byte, value int64) error {
 
 // Get retrieves the value of the given key
 func (s *Store) Get(key []byte) ([]byte, error) {
-	value, closer, err := s.db.Get(key)
+	value, closer, err := s.rw.Get(key)
 	if err != nil {
 		return nil, err
 	}
 
 	defer closer.Close()
 
-	return value, nil
+	// the value is only valid until the closer is closed
+	return append([]byte(nil), value...), nil
 }
 
 // GetUint64 is a convenience to get the uint64 typed value
@@ -76,7 +138,7 @@ func (s *Store) GetInt64(key []byte) (int64, error) {
 
 // Delete deletes the value by the given key
 func (s *Store) Delete(key []byte) error {
-	err := s.db.Delete(key, nil)
+	err := s.rw.Delete(key, nil)
 	if err != nil {
 		return err
 	}
@@ -86,7 +148,7 @@ func (s *Store) Delete(key []byte) error {
 
 // Exist checks if the given key exists
 func (s *Store) Exist(key []byte) (bool, error) {
-	iter, err := s.db.NewIter(nil)
+	iter, err := s.rw.NewIter(nil)
 	if err != nil {
 		return false, err
 	}
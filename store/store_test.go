@@ -0,0 +1,69 @@
+package store
+
+import (
+	"testing"
+)
+
+func newTestStore(t *testing.T) *Store {
+	s, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("failed to open the store: %v", err)
+	}
+
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
+
+func TestBatchCommit(t *testing.T) {
+	s := newTestStore(t)
+
+	batch := s.NewBatch()
+	defer batch.Close()
+
+	if err := batch.Set([]byte("a"), []byte("1")); err != nil {
+		t.Fatalf("failed to set: %v", err)
+	}
+
+	if value, err := batch.Get([]byte("a")); err != nil || string(value) != "1" {
+		t.Fatalf("batch read: got %q, %v; want 1", value, err)
+	}
+
+	if _, err := s.Get([]byte("a")); !IsNotFoundErr(err) {
+		t.Fatalf("store read before commit: got %v; want not found", err)
+	}
+
+	if err := batch.Commit(); err != nil {
+		t.Fatalf("failed to commit: %v", err)
+	}
+
+	if value, err := s.Get([]byte("a")); err != nil || string(value) != "1" {
+		t.Fatalf("store read after commit: got %q, %v; want 1", value, err)
+	}
+}
+
+func TestOverlayDiscarded(t *testing.T) {
+	s := newTestStore(t)
+
+	overlay := s.NewOverlay()
+
+	if err := overlay.Set([]byte("a"), []byte("1")); err != nil {
+		t.Fatalf("failed to set: %v", err)
+	}
+
+	if err := overlay.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	if _, err := s.Get([]byte("a")); !IsNotFoundErr(err) {
+		t.Fatalf("store read: got %v; want not found", err)
+	}
+}
+
+func TestCommitNotBatch(t *testing.T) {
+	s := newTestStore(t)
+
+	if err := s.Commit(); err == nil {
+		t.Fatalf("commit on the db: got nil; want error")
+	}
+}
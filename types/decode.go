@@ -0,0 +1,18 @@
+package types
+
+// DecodedEnvelope defines the protocol envelope decoded from the tx input
+type DecodedEnvelope struct {
+	InputIndex   int    `json:"input"`     // input index
+	CommitTxHash string `json:"commit_tx"` // hash of the commit tx spent by the input
+	Payload      string `json:"payload"`   // envelope payload
+	OpCount      int    `json:"op_count"`  // number of the operations parsed from the payload
+}
+
+// DecodeResult defines the result of decoding the tx and simulating the protocol operations
+type DecodeResult struct {
+	TxHash      string             `json:"tx"`               // tx hash
+	Envelopes   []*DecodedEnvelope `json:"envelopes"`        // decoded envelopes
+	Issuer      string             `json:"issuer,omitempty"` // issuer address if the tx contains the issue operation
+	BlockHeight int64              `json:"block_height"`     // block height at which the operations are simulated
+	Results     []*OpResult        `json:"ops"`              // simulated operation results
+}
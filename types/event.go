@@ -0,0 +1,145 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// EventType represents the type of the event emitted by the indexer
+type EventType string
+
+const (
+	EVENT_ISSUE EventType = "issue" // SBTs issued
+	EVENT_MINT  EventType = "mint"  // SBT minted
+	EVENT_BLOCK EventType = "block" // block indexed
+)
+
+// Event defines the event emitted when the indexer applies a block
+type Event struct {
+	Type        EventType `json:"type"`         // event type
+	BlockHeight int64     `json:"block_height"` // block height
+	BlockHash   string    `json:"block_hash"`   // block hash
+	Index       uint32    `json:"index"`        // event index in the block
+
+	TxHash  string `json:"tx,omitempty"`      // tx hash
+	Symbol  string `json:"symbol,omitempty"`  // SBTs symbol
+	Address string `json:"address,omitempty"` // issuer address for issue, owner address for mint
+
+	SBTs *SBTs `json:"sbts,omitempty"` // issued SBTs
+	SBT  *SBT  `json:"sbt,omitempty"`  // minted SBT
+}
+
+// NewIssueEvent creates an issue event from the given SBTs
+func NewIssueEvent(sbts *SBTs) *Event {
+	return &Event{
+		Type:        EVENT_ISSUE,
+		BlockHeight: sbts.BlockHeight,
+		TxHash:      sbts.IssueTransactionHash,
+		Symbol:      sbts.Symbol,
+		Address:     sbts.Issuer,
+		SBTs:        sbts,
+	}
+}
+
+// NewMintEvent creates a mint event from the given SBT
+func NewMintEvent(sbt *SBT) *Event {
+	return &Event{
+		Type:        EVENT_MINT,
+		BlockHeight: sbt.BlockHeight,
+		TxHash:      sbt.MintTransactionHash,
+		Symbol:      sbt.Symbol,
+		Address:     sbt.Owner,
+		SBT:         sbt,
+	}
+}
+
+// NewBlockEvent creates a block event
+func NewBlockEvent(blockHeight int64, blockHash string) *Event {
+	return &Event{
+		Type:        EVENT_BLOCK,
+		BlockHeight: blockHeight,
+		BlockHash:   blockHash,
+	}
+}
+
+// Cursor returns the cursor pointing to the event
+func (e *Event) Cursor() EventCursor {
+	return EventCursor{BlockHeight: e.BlockHeight, Index: e.Index}
+}
+
+// Marshal marshals the Event
+func (e *Event) Marshal() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// Unmarshal unmarshals the given data to the Event struct
+func (e *Event) Unmarshal(data []byte) error {
+	return json.Unmarshal(data, e)
+}
+
+// EventCursor identifies the position of an event, i.e. the block height and the event index in the block
+type EventCursor struct {
+	BlockHeight int64
+	Index       uint32
+}
+
+// ParseEventCursor parses the cursor from the string form `<block height>-<index>`
+func ParseEventCursor(str string) (EventCursor, error) {
+	parts := strings.Split(str, "-")
+	if len(parts) != 2 {
+		return EventCursor{}, fmt.Errorf("invalid event cursor: %s", str)
+	}
+
+	height, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return EventCursor{}, fmt.Errorf("invalid event cursor: %s", str)
+	}
+
+	index, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return EventCursor{}, fmt.Errorf("invalid event cursor: %s", str)
+	}
+
+	return EventCursor{BlockHeight: height, Index: uint32(index)}, nil
+}
+
+// String implements fmt.Stringer
+func (c EventCursor) String() string {
+	return fmt.Sprintf("%d-%d", c.BlockHeight, c.Index)
+}
+
+// Next returns the cursor right after the current one
+func (c EventCursor) Next() EventCursor {
+	return EventCursor{BlockHeight: c.BlockHeight, Index: c.Index + 1}
+}
+
+// Before returns true if the cursor is located before the given cursor, false otherwise
+func (c EventCursor) Before(other EventCursor) bool {
+	return c.BlockHeight < other.BlockHeight || (c.BlockHeight == other.BlockHeight && c.Index < other.Index)
+}
+
+// EventFilter defines the filter for events.
+// Block events always match in order to let subscribers track the indexing progress
+type EventFilter struct {
+	Address string // issuer or owner address
+	Symbol  string // SBTs symbol
+}
+
+// Match returns true if the given event matches the filter, false otherwise
+func (f *EventFilter) Match(e *Event) bool {
+	if f == nil || e.Type == EVENT_BLOCK {
+		return true
+	}
+
+	if len(f.Address) > 0 && !strings.EqualFold(f.Address, e.Address) {
+		return false
+	}
+
+	if len(f.Symbol) > 0 && !strings.EqualFold(f.Symbol, e.Symbol) {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,110 @@
+package types
+
+import (
+	"strings"
+)
+
+const (
+	// Default number of items per page
+	DEFAULT_PAGE_LIMIT = 100
+
+	// Maximum number of items per page
+	MAX_PAGE_LIMIT = 1000
+
+	// Maximum number of entries examined per page, beyond which the page is returned short along with the cursor
+	MAX_PAGE_SCAN = 10 * MAX_PAGE_LIMIT
+)
+
+// SortKey represents the key by which the SBTs are sorted
+type SortKey string
+
+const (
+	SORT_BY_SYMBOL   SortKey = "symbol" // sort by symbol
+	SORT_BY_SEQUENCE SortKey = "seq"    // sort by sequence
+	SORT_BY_HEIGHT   SortKey = "height" // sort by issue height, equivalent to sequence
+	SORT_BY_SUPPLY   SortKey = "supply" // sort by current supply
+)
+
+// IsValid returns true if the sort key is supported, false otherwise
+func (k SortKey) IsValid() bool {
+	switch k {
+	case SORT_BY_SYMBOL, SORT_BY_SEQUENCE, SORT_BY_HEIGHT, SORT_BY_SUPPLY:
+		return true
+
+	default:
+		return false
+	}
+}
+
+// Pagination defines the cursor based pagination
+type Pagination struct {
+	Cursor  string // opaque cursor returned along with the previous page, empty for the first page
+	Limit   int    // maximum number of items per page
+	Reverse bool   // indicates if iterating in descending order
+}
+
+// GetLimit returns the page limit, defaulting to DEFAULT_PAGE_LIMIT
+func (p *Pagination) GetLimit() int {
+	if p.Limit <= 0 {
+		return DEFAULT_PAGE_LIMIT
+	}
+
+	if p.Limit > MAX_PAGE_LIMIT {
+		return MAX_PAGE_LIMIT
+	}
+
+	return p.Limit
+}
+
+// SBTsQuery defines the query for the SBTs collections
+type SBTsQuery struct {
+	Pagination
+
+	SortBy SortKey // sort key, default to symbol
+
+	Issuer           string // issuer address
+	RequireAuthority *bool  // indicates if the authority signature is required on mint
+	MintOpen         *bool  // indicates if the mint is open
+	SymbolPrefix     string // symbol prefix
+
+	BlockHeight int64 // block height at which the SBTs are queried, 0 for the latest
+}
+
+// Match returns true if the given SBTs matches the query filters, false otherwise.
+// `blockHeight` is the queried block height used to determine if the mint is open
+func (q *SBTsQuery) Match(sbts *SBTs, blockHeight int64) bool {
+	if len(q.Issuer) > 0 && !strings.EqualFold(q.Issuer, sbts.Issuer) {
+		return false
+	}
+
+	if q.RequireAuthority != nil && *q.RequireAuthority != sbts.RequireSignatureOnMint() {
+		return false
+	}
+
+	if len(q.SymbolPrefix) > 0 && !strings.HasPrefix(strings.ToLower(sbts.Symbol), strings.ToLower(q.SymbolPrefix)) {
+		return false
+	}
+
+	if q.MintOpen != nil && *q.MintOpen != sbts.IsMintOpen(blockHeight+1) {
+		return false
+	}
+
+	return true
+}
+
+// OwnedSBTsQuery defines the query for the SBT tokens owned by an address
+type OwnedSBTsQuery struct {
+	Pagination
+
+	Owner        string // owner address
+	SymbolPrefix string // symbol prefix
+
+	BlockHeight int64 // block height at which the SBT tokens are queried, 0 for the latest
+}
+
+// TokensQuery defines the query for the SBT tokens of a symbol
+type TokensQuery struct {
+	Pagination
+
+	Symbol string // symbol
+}
@@ -62,6 +62,15 @@ func (s *SBTs) RequireSignatureOnMint() bool {
 	return len(s.AuthorityPubKey) > 0
 }
 
+// IsMintOpen indicates if the SBT can be minted at the given block height
+func (s *SBTs) IsMintOpen(blockHeight int64) bool {
+	if s.EndBlockHeight > 0 && blockHeight > s.EndBlockHeight {
+		return false
+	}
+
+	return s.MaxSupply == 0 || s.TotalSupply < s.MaxSupply
+}
+
 // Marshal marshals the SBTs
 func (s *SBTs) Marshal() ([]byte, error) {
 	return json.Marshal(s)
@@ -107,6 +116,16 @@ func (s *SBT) Compact() *CompactSBT {
 	}
 }
 
+// Holder returns the holder view of the SBT
+func (s *SBT) Holder() *Holder {
+	return &Holder{
+		Owner:               s.Owner,
+		Id:                  s.Id,
+		BlockHeight:         s.BlockHeight,
+		MintTransactionHash: s.MintTransactionHash,
+	}
+}
+
 // Marshal marshals the SBT
 func (s *SBT) Marshal() ([]byte, error) {
 	return json.Marshal(s)
@@ -144,3 +163,11 @@ func (s *CompactSBT) Marshal() ([]byte, error) {
 func (s *CompactSBT) Unmarshal(data []byte) error {
 	return json.Unmarshal(data, s)
 }
+
+// Holder defines the struct of the SBT token holder
+type Holder struct {
+	Owner               string `json:"owner"`        // token owner
+	Id                  uint64 `json:"token_id"`     // token id
+	BlockHeight         int64  `json:"block_height"` // mint block height
+	MintTransactionHash string `json:"mint_tx"`      // mint tx hash
+}
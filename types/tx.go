@@ -0,0 +1,82 @@
+package types
+
+import (
+	"encoding/json"
+
+	"btc-sbt/protocol"
+)
+
+// OpResult defines the execution result of the protocol operation
+type OpResult struct {
+	Type  protocol.OpType `json:"type"`            // operation type
+	Op    json.RawMessage `json:"op"`              // operation payload
+	Valid bool            `json:"valid"`           // indicates if the operation is applied
+	Error string          `json:"error,omitempty"` // rejection reason
+
+	Symbol string `json:"symbol"`          // SBTs symbol
+	Owner  string `json:"owner,omitempty"` // token owner for mint
+
+	SBTs *SBTs `json:"sbts,omitempty"` // resulting SBTs for issue, populated on query
+	SBT  *SBT  `json:"sbt,omitempty"`  // resulting SBT for mint, populated on query
+}
+
+// NewOpResult creates an OpResult instance from the given operation and the error returned by the state machine
+func NewOpResult(op protocol.Operation, err error) *OpResult {
+	result := &OpResult{
+		Type:  op.Type(),
+		Valid: err == nil,
+	}
+
+	if bz, err := op.Marshal(); err == nil {
+		result.Op = bz
+	}
+
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	switch op := op.(type) {
+	case *protocol.IssueOperation:
+		result.Symbol = op.Symbol
+
+	case *protocol.MintOperation:
+		result.Symbol = op.Symbol
+		result.Owner = op.Owner
+	}
+
+	return result
+}
+
+// TxRecord defines the record of the tx containing the protocol operations
+type TxRecord struct {
+	TxHash         string   `json:"tx"`                   // reveal tx hash
+	CommitTxHashes []string `json:"commit_txs,omitempty"` // hashes of the commit txs spent by the envelope inputs
+
+	BlockHeight      int64  `json:"block_height"` // block height
+	BlockHash        string `json:"block_hash"`   // block hash
+	TransactionIndex int    `json:"tx_index"`     // tx index
+
+	Results []*OpResult `json:"ops"` // operation results
+}
+
+// NewTxRecord creates a new TxRecord instance
+func NewTxRecord(txHash string, commitTxHashes []string, blockHeight int64, blockHash string, txIndex int, results []*OpResult) *TxRecord {
+	return &TxRecord{
+		TxHash:           txHash,
+		CommitTxHashes:   commitTxHashes,
+		BlockHeight:      blockHeight,
+		BlockHash:        blockHash,
+		TransactionIndex: txIndex,
+		Results:          results,
+	}
+}
+
+// Marshal marshals the TxRecord
+func (r *TxRecord) Marshal() ([]byte, error) {
+	return json.Marshal(r)
+}
+
+// Unmarshal unmarshals the given data to the TxRecord struct
+func (r *TxRecord) Unmarshal(data []byte) error {
+	return json.Unmarshal(data, r)
+}